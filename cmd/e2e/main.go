@@ -31,7 +31,7 @@ func main() {
 			SSLMode:  "disable",
 		},
 		JWT: config.JWTConfig{
-			Secret: getEnv("JWT_SECRET", "test_secret"),
+			Secret: getEnv("JWT_SECRET", "e2e_test_secret_of_at_least_32_bytes"),
 			TTL:    parseDuration(getEnv("JWT_TTL", "24h")),
 		},
 	}
@@ -13,10 +13,15 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
 	"github.com/netscrawler/avito-shop/internal/handler"
+	"github.com/netscrawler/avito-shop/internal/hasher"
 	"github.com/netscrawler/avito-shop/internal/middleware"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/netscrawler/avito-shop/internal/repository/memory"
 	"github.com/netscrawler/avito-shop/internal/repository/postgres"
 	"github.com/netscrawler/avito-shop/internal/service"
+	"github.com/netscrawler/avito-shop/internal/signer"
 	"github.com/sirupsen/logrus"
 )
 
@@ -38,8 +43,26 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, fmt.Errorf("ошибка подключения к БД: %w", err)
 	}
 
+	// Загружаем ключи подписи JWT
+	keys, err := signer.NewFromConfig(cfg.JWT)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка загрузки ключей JWT: %w", err)
+	}
+
+	// Создаем хэшер паролей
+	passwords, err := hasher.NewFromConfig(cfg.Password)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка настройки хэширования паролей: %w", err)
+	}
+
 	// Создаем роутер
-	router := setupRouter(cfg, db, logger)
+	router, err := setupRouter(cfg, db, keys, passwords, logger)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка настройки роутера: %w", err)
+	}
 
 	return &App{
 		cfg:    cfg,
@@ -54,7 +77,7 @@ func (a *App) Run() error {
 	// Создаем HTTP сервер
 	server := &http.Server{
 		Addr:           ":" + a.cfg.Server.Port,
-		Handler:        a.router,
+		Handler:        middleware.ResponseControllerHandler(a.router),
 		ReadTimeout:    a.cfg.Server.ReadTimeout,
 		WriteTimeout:   a.cfg.Server.WriteTimeout,
 		MaxHeaderBytes: 1 << 20,
@@ -140,38 +163,158 @@ func setupDatabase(cfg *config.Config, logger *logrus.Logger) (*pgxpool.Pool, er
 
 	return pool, nil
 }
-func setupRouter(cfg *config.Config, db *pgxpool.Pool, logger *logrus.Logger) *gin.Engine {
+func setupRouter(cfg *config.Config, db *pgxpool.Pool, keys signer.Signer, passwords hasher.PasswordHasher, logger *logrus.Logger) (*gin.Engine, error) {
 	// Создаем репозитории
 	dbPool := postgres.NewPoolAdapter(db)
 	userRepo := postgres.NewUserRepository(dbPool)
 	merchRepo := postgres.NewMerchRepository(dbPool)
 	transRepo := postgres.NewTransactionRepository(dbPool)
+	tokenRepo := postgres.NewTokenRepository(dbPool)
+	passwordResetRepo := postgres.NewPasswordResetRepository(dbPool)
+	mfaRepo := postgres.NewMFARepository(dbPool)
+	apiKeyRepo := postgres.NewAPIKeyRepository(dbPool)
+	idempotencyRepo := postgres.NewIdempotencyRepository(dbPool)
+	cartRepo := postgres.NewCartRepository(dbPool)
+	orderRepo := postgres.NewOrderRepository(dbPool)
+	promoRepo := postgres.NewPromoCodeRepository(dbPool)
+	marketRepo := postgres.NewMarketRepository(dbPool)
+	loginAttemptRepo := setupLoginAttemptRepository(cfg, dbPool, logger)
 
 	// Создаем сервисы
-	userService := service.NewUserService(userRepo, cfg.JWT.Secret)
+	tokenService := service.NewTokenService(tokenRepo, userRepo, keys, cfg.JWT.TTL, cfg.JWT.RefreshTTL, cfg.JWT.CleanupInterval)
+	userService := service.NewUserService(userRepo, mfaRepo, tokenService, passwords, cfg.Auth)
 	transferService := service.NewTransferService(transRepo, userRepo)
-	merchService := service.NewMerchService(userRepo, merchRepo, transRepo)
+	merchService := service.NewMerchService(userRepo, merchRepo, transRepo, promoRepo)
+	passwordService := service.NewPasswordService(userRepo, passwordResetRepo, tokenService, passwords, cfg.Auth)
+	loginGuard := service.NewLoginGuard(loginAttemptRepo, cfg.Lockout)
+	mfaService := service.NewMFAService(mfaRepo, userRepo, tokenService, loginGuard, cfg.MFA)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency)
+	statementService := service.NewStatementService(transRepo)
+	cartService := service.NewCartService(cartRepo, merchRepo, merchService)
+	orderService := service.NewOrderService(orderRepo, merchService)
+	promoService := service.NewPromoCodeService(promoRepo)
+	marketService := service.NewMarketService(marketRepo, cfg.Market)
 
 	// Создаем обработчики
-	h := handler.NewHandler(userService, transferService, merchService)
+	h := handler.NewHandler(userService, transferService, merchService, loginGuard, idempotencyService)
+	authHandler := handler.NewAuthHandler(tokenService, keys)
+	passwordHandler := handler.NewPasswordHandler(passwordService, tokenService)
+	adminHandler := handler.NewAdminHandler(userService, loginGuard)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	statementHandler := handler.NewStatementHandler(statementService)
+	merchHandler := handler.NewMerchHandler(merchService)
+	cartHandler := handler.NewCartHandler(cartService, idempotencyService)
+	orderHandler := handler.NewOrderHandler(orderService)
+	promoHandler := handler.NewPromoCodeHandler(promoService)
+	marketHandler := handler.NewMarketHandler(marketService, idempotencyService)
 
 	// Настраиваем роутер
 	router := gin.New()
 
+	// Адрес клиента из X-Forwarded-For принимается только от доверенных прокси,
+	// иначе ограничение попыток входа по адресу обходится подменой заголовка
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("некорректный список доверенных прокси: %w", err)
+	}
+
 	// Добавляем middleware
 	router.Use(gin.Recovery())
 	router.Use(middleware.LoggerMiddleware(logger))
 
 	// Определяем маршруты
 	router.GET("/health", h.HealthCheck)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 	router.POST("/api/auth", h.Authenticate)
+	router.POST("/api/register", h.Register)
+	router.POST("/api/auth/refresh", authHandler.Refresh)
+	router.POST("/api/auth/mfa", mfaHandler.Verify)
+	router.POST("/api/password/reset", passwordHandler.ResetPassword)
 
 	// Группа защищенных маршрутов
 	api := router.Group("/api")
-	api.Use(middleware.JWTAuthMiddleware(cfg.JWT.Secret))
+	api.Use(middleware.JWTAuthMiddleware(keys, tokenService))
+	api.POST("/auth/logout", authHandler.Logout)
+	api.POST("/password", passwordHandler.ChangePassword)
+	api.POST("/mfa/enroll", mfaHandler.Enroll)
+	api.POST("/mfa/confirm", mfaHandler.Confirm)
 	api.GET("/info", h.GetInfo)
-	api.POST("/sendCoin", h.SendCoin)
+	api.GET("/transactions", h.GetTransactions)
+	api.GET("/transactions/export", middleware.WriteTimeout(cfg.Server.ExportWriteTimeout), statementHandler.Export)
+	if cfg.MFA.RequireForTransfers {
+		api.POST("/sendCoin", middleware.RequireMFA(), h.SendCoin)
+	} else {
+		api.POST("/sendCoin", h.SendCoin)
+	}
 	api.GET("/buy/:item", h.BuyMerch)
+	api.POST("/buy", h.BuyMerchQuantity)
+	api.POST("/gift", h.Gift)
+	api.POST("/inventory/transfer", h.TransferItem)
+	api.GET("/merch", merchHandler.List)
+	api.GET("/merch/:name", merchHandler.Get)
+	api.GET("/cart", cartHandler.List)
+	api.POST("/cart", cartHandler.Add)
+	api.DELETE("/cart/:item", cartHandler.Remove)
+	api.POST("/cart/checkout", cartHandler.Checkout)
+	api.GET("/orders", orderHandler.List)
+	api.GET("/orders/:id", orderHandler.Get)
+	api.POST("/orders/:id/cancel", orderHandler.Cancel)
+	api.GET("/market/listings", marketHandler.List)
+	api.POST("/market/listings", marketHandler.Create)
+	api.GET("/market/listings/:id", marketHandler.Get)
+	api.POST("/market/listings/:id/cancel", marketHandler.Cancel)
+	api.POST("/market/listings/:id/buy", marketHandler.Buy)
+
+	// Маршруты, доступные и пользователям, и сервисным учетным записям по API-ключу
+	machine := router.Group("/api")
+	machine.Use(middleware.AuthMiddleware(keys, tokenService, apiKeyService))
+	if cfg.MFA.RequireForAdmin {
+		// По JWT сюда допускаются только администраторы, поэтому от них требуется тот же второй фактор
+		machine.Use(middleware.RequireUserMFA())
+	}
+	machine.POST("/coins/grant", middleware.RequireScope(domain.ScopeCoinsGrant, domain.RoleAdmin), h.GrantCoins)
+	machine.GET("/users/:username/balance", middleware.RequireScope(domain.ScopeInfoRead, domain.RoleAdmin), h.GetBalance)
+
+	// Группа административных маршрутов
+	admin := router.Group("/api/admin")
+	admin.Use(middleware.JWTAuthMiddleware(keys, tokenService))
+	admin.Use(middleware.RequireRole(domain.RoleAdmin))
+	if cfg.MFA.RequireForAdmin {
+		admin.Use(middleware.RequireMFA())
+	}
+	admin.DELETE("/lockouts/:username", adminHandler.Unlock)
+	admin.PUT("/users/:username/role", adminHandler.SetRole)
+	admin.POST("/users/:username/password-reset", passwordHandler.IssueResetToken)
+	admin.POST("/api-keys", apiKeyHandler.Create)
+	admin.GET("/api-keys", apiKeyHandler.List)
+	admin.DELETE("/api-keys/:id", apiKeyHandler.Revoke)
+	admin.GET("/transactions/export", middleware.WriteTimeout(cfg.Server.ExportWriteTimeout), statementHandler.ExportAll)
+	admin.POST("/merch", merchHandler.Create)
+	admin.PATCH("/merch/:name", merchHandler.Update)
+	admin.DELETE("/merch/:name", merchHandler.Retire)
+	admin.POST("/merch/:name/restock", merchHandler.Restock)
+	admin.POST("/merch/:name/price-schedules", merchHandler.SchedulePrice)
+	admin.DELETE("/merch/:name/price-schedules/:id", merchHandler.CancelPriceSchedule)
+	admin.GET("/merch/:name/prices", merchHandler.PriceHistory)
+	admin.GET("/orders", orderHandler.AdminList)
+	admin.GET("/orders/:id", orderHandler.AdminGet)
+	admin.PUT("/orders/:id/status", orderHandler.UpdateStatus)
+	admin.POST("/promo-codes", promoHandler.Create)
+	admin.GET("/promo-codes", promoHandler.List)
+	admin.DELETE("/promo-codes/:code", promoHandler.Deactivate)
 
-	return router
+	return router, nil
+}
+
+// setupLoginAttemptRepository выбирает хранилище счетчиков неудачных попыток входа.
+// PostgreSQL нужен, когда запущено несколько реплик сервиса.
+func setupLoginAttemptRepository(cfg *config.Config, db postgres.DBPool, logger *logrus.Logger) repository.LoginAttemptRepository {
+	if cfg.Lockout.Store == "postgres" {
+		return postgres.NewLoginAttemptRepository(db)
+	}
+	if cfg.Lockout.Store != "memory" {
+		logger.Warnf("Неизвестное хранилище блокировок %q, используется память процесса", cfg.Lockout.Store)
+	}
+	return memory.NewLoginAttemptRepository(cfg.Lockout.ResetAfter)
 }
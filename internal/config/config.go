@@ -4,19 +4,29 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	JWT         JWTConfig
+	Auth        AuthConfig
+	Lockout     LockoutConfig
+	Password    PasswordConfig
+	MFA         MFAConfig
+	Idempotency IdempotencyConfig
+	Market      MarketConfig
 }
 
 type ServerConfig struct {
-	Port         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
+	Port               string
+	ReadTimeout        time.Duration
+	WriteTimeout       time.Duration
+	ExportWriteTimeout time.Duration
+	TrustedProxies     []string
 }
 
 type DatabaseConfig struct {
@@ -29,16 +39,63 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret string
-	TTL    time.Duration
+	Secret          string
+	KeysDir         string
+	ActiveKeyId     string
+	TTL             time.Duration
+	RefreshTTL      time.Duration
+	CleanupInterval time.Duration
+}
+
+type AuthConfig struct {
+	AutoRegister      bool
+	InitialCoins      uint64
+	PasswordMinLength int
+	PasswordResetTTL  time.Duration
+}
+
+type LockoutConfig struct {
+	Store           string
+	FreeAttempts    int
+	MaxFailures     int
+	IPMaxFailures   int
+	BaseDelay       time.Duration
+	Duration        time.Duration
+	ResetAfter      time.Duration
+	CleanupInterval time.Duration
+}
+
+type PasswordConfig struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+type MFAConfig struct {
+	Issuer              string
+	RequireForTransfers bool
+	RequireForAdmin     bool
+}
+
+type IdempotencyConfig struct {
+	TTL             time.Duration
+	CleanupInterval time.Duration
+}
+
+type MarketConfig struct {
+	FeePercent uint64
 }
 
 func New() (*Config, error) {
 	return &Config{
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			ReadTimeout:  getEnvAsDuration("SERVER_READ_TIMEOUT", 5*time.Second),
-			WriteTimeout: getEnvAsDuration("SERVER_WRITE_TIMEOUT", 5*time.Second),
+			Port:               getEnv("SERVER_PORT", "8080"),
+			ReadTimeout:        getEnvAsDuration("SERVER_READ_TIMEOUT", 5*time.Second),
+			WriteTimeout:       getEnvAsDuration("SERVER_WRITE_TIMEOUT", 5*time.Second),
+			ExportWriteTimeout: getEnvAsDuration("SERVER_EXPORT_WRITE_TIMEOUT", 10*time.Minute),
+			TrustedProxies:     getEnvAsSlice("SERVER_TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DATABASE_HOST", "localhost"),
@@ -50,8 +107,47 @@ func New() (*Config, error) {
 		},
 
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-256-bit-secret"),
-			TTL:    getEnvAsDuration("JWT_TTL", 24*time.Hour),
+			Secret:          getEnv("JWT_SECRET", "your-256-bit-secret-change-me-in-prod"),
+			KeysDir:         getEnv("JWT_KEYS_DIR", ""),
+			ActiveKeyId:     getEnv("JWT_ACTIVE_KEY_ID", ""),
+			TTL:             getEnvAsDuration("JWT_TTL", 15*time.Minute),
+			RefreshTTL:      getEnvAsDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
+			CleanupInterval: getEnvAsDuration("JWT_CLEANUP_INTERVAL", time.Hour),
+		},
+		Auth: AuthConfig{
+			AutoRegister:      getEnvAsBool("AUTH_AUTO_REGISTER", true),
+			InitialCoins:      getEnvAsUint("AUTH_INITIAL_COINS", 1000),
+			PasswordMinLength: getEnvAsInt("AUTH_PASSWORD_MIN_LENGTH", 8),
+			PasswordResetTTL:  getEnvAsDuration("AUTH_PASSWORD_RESET_TTL", time.Hour),
+		},
+		Lockout: LockoutConfig{
+			Store:           getEnv("LOCKOUT_STORE", "memory"),
+			FreeAttempts:    getEnvAsInt("LOCKOUT_FREE_ATTEMPTS", 3),
+			MaxFailures:     getEnvAsInt("LOCKOUT_MAX_FAILURES", 10),
+			IPMaxFailures:   getEnvAsInt("LOCKOUT_IP_MAX_FAILURES", 50),
+			BaseDelay:       getEnvAsDuration("LOCKOUT_BASE_DELAY", time.Second),
+			Duration:        getEnvAsDuration("LOCKOUT_DURATION", 15*time.Minute),
+			ResetAfter:      getEnvAsDuration("LOCKOUT_RESET_AFTER", time.Hour),
+			CleanupInterval: getEnvAsDuration("LOCKOUT_CLEANUP_INTERVAL", time.Hour),
+		},
+		Password: PasswordConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:        getEnvAsInt("PASSWORD_BCRYPT_COST", 10),
+			Argon2Memory:      uint32(getEnvAsUint("PASSWORD_ARGON2_MEMORY", 64*1024)),
+			Argon2Iterations:  uint32(getEnvAsUint("PASSWORD_ARGON2_ITERATIONS", 3)),
+			Argon2Parallelism: uint8(getEnvAsUint("PASSWORD_ARGON2_PARALLELISM", 2)),
+		},
+		MFA: MFAConfig{
+			Issuer:              getEnv("MFA_ISSUER", "Avito Shop"),
+			RequireForTransfers: getEnvAsBool("MFA_REQUIRE_FOR_TRANSFERS", false),
+			RequireForAdmin:     getEnvAsBool("MFA_REQUIRE_FOR_ADMIN", false),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:             getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+			CleanupInterval: getEnvAsDuration("IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+		},
+		Market: MarketConfig{
+			FeePercent: getEnvAsUint("MARKET_FEE_PERCENT", 0),
 		},
 	}, nil
 }
@@ -85,3 +181,45 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsUint(key string, defaultValue uint64) uint64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return defaultValue
+	}
+
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -19,6 +19,8 @@ func TestNew(t *testing.T) {
 		assert.Equal(t, "8080", cfg.Server.Port)
 		assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout)
 		assert.Equal(t, 5*time.Second, cfg.Server.WriteTimeout)
+		assert.Equal(t, 10*time.Minute, cfg.Server.ExportWriteTimeout)
+		assert.Empty(t, cfg.Server.TrustedProxies)
 	})
 
 	t.Run("загрузка конфигурации из переменных окружения", func(t *testing.T) {
@@ -104,6 +106,16 @@ func TestServerConfig(t *testing.T) {
 		assert.Equal(t, 15*time.Second, cfg.Server.WriteTimeout)
 	})
 
+	t.Run("список доверенных прокси", func(t *testing.T) {
+		os.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.1, 192.168.0.0/16,")
+		defer os.Unsetenv("SERVER_TRUSTED_PROXIES")
+
+		cfg, err := New()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"10.0.0.1", "192.168.0.0/16"}, cfg.Server.TrustedProxies)
+	})
+
 	t.Run("некорректный формат таймаута", func(t *testing.T) {
 		os.Setenv("SERVER_READ_TIMEOUT", "invalid")
 		defer os.Unsetenv("SERVER_READ_TIMEOUT")
@@ -117,7 +129,7 @@ func TestServerConfig(t *testing.T) {
 
 func TestJWTConfig(t *testing.T) {
 	t.Run("проверка секрета JWT", func(t *testing.T) {
-		defaultSecret := "your-256-bit-secret"
+		defaultSecret := "your-256-bit-secret-change-me-in-prod"
 		os.Setenv("JWT_SECRET", "")
 		defer os.Unsetenv("JWT_SECRET")
 
@@ -136,4 +148,165 @@ func TestJWTConfig(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, customSecret, cfg.JWT.Secret)
 	})
+
+	t.Run("время жизни и каталог ключей JWT", func(t *testing.T) {
+		os.Setenv("JWT_TTL", "10m")
+		os.Setenv("JWT_KEYS_DIR", "/etc/avito-shop/keys")
+		os.Setenv("JWT_ACTIVE_KEY_ID", "2024-06")
+		defer func() {
+			os.Unsetenv("JWT_TTL")
+			os.Unsetenv("JWT_KEYS_DIR")
+			os.Unsetenv("JWT_ACTIVE_KEY_ID")
+		}()
+
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Minute, cfg.JWT.TTL)
+		assert.Equal(t, "/etc/avito-shop/keys", cfg.JWT.KeysDir)
+		assert.Equal(t, "2024-06", cfg.JWT.ActiveKeyId)
+		assert.Equal(t, time.Hour, cfg.JWT.CleanupInterval)
+	})
+}
+
+func TestAuthConfig(t *testing.T) {
+	t.Run("значения по умолчанию", func(t *testing.T) {
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.True(t, cfg.Auth.AutoRegister)
+		assert.Equal(t, uint64(1000), cfg.Auth.InitialCoins)
+		assert.Equal(t, 8, cfg.Auth.PasswordMinLength)
+		assert.Equal(t, time.Hour, cfg.Auth.PasswordResetTTL)
+	})
+
+	t.Run("строгий вход и начальный баланс", func(t *testing.T) {
+		os.Setenv("AUTH_AUTO_REGISTER", "false")
+		os.Setenv("AUTH_INITIAL_COINS", "500")
+		os.Setenv("AUTH_PASSWORD_MIN_LENGTH", "not-a-number")
+		defer func() {
+			os.Unsetenv("AUTH_AUTO_REGISTER")
+			os.Unsetenv("AUTH_INITIAL_COINS")
+			os.Unsetenv("AUTH_PASSWORD_MIN_LENGTH")
+		}()
+
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.False(t, cfg.Auth.AutoRegister)
+		assert.Equal(t, uint64(500), cfg.Auth.InitialCoins)
+		// При некорректном значении используется значение по умолчанию
+		assert.Equal(t, 8, cfg.Auth.PasswordMinLength)
+	})
+}
+
+func TestLockoutConfig(t *testing.T) {
+	t.Run("значения по умолчанию", func(t *testing.T) {
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, "memory", cfg.Lockout.Store)
+		assert.Equal(t, 3, cfg.Lockout.FreeAttempts)
+		assert.Equal(t, 10, cfg.Lockout.MaxFailures)
+		assert.Equal(t, 15*time.Minute, cfg.Lockout.Duration)
+		assert.Equal(t, time.Hour, cfg.Lockout.CleanupInterval)
+	})
+
+	t.Run("хранилище в PostgreSQL", func(t *testing.T) {
+		os.Setenv("LOCKOUT_STORE", "postgres")
+		os.Setenv("LOCKOUT_DURATION", "1h")
+		defer func() {
+			os.Unsetenv("LOCKOUT_STORE")
+			os.Unsetenv("LOCKOUT_DURATION")
+		}()
+
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, "postgres", cfg.Lockout.Store)
+		assert.Equal(t, time.Hour, cfg.Lockout.Duration)
+	})
+}
+
+func TestPasswordConfig(t *testing.T) {
+	t.Run("значения по умолчанию", func(t *testing.T) {
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, "bcrypt", cfg.Password.Algorithm)
+		assert.Equal(t, 10, cfg.Password.BcryptCost)
+		assert.Equal(t, uint32(64*1024), cfg.Password.Argon2Memory)
+	})
+
+	t.Run("argon2id", func(t *testing.T) {
+		os.Setenv("PASSWORD_HASH_ALGORITHM", "argon2id")
+		os.Setenv("PASSWORD_ARGON2_ITERATIONS", "4")
+		defer func() {
+			os.Unsetenv("PASSWORD_HASH_ALGORITHM")
+			os.Unsetenv("PASSWORD_ARGON2_ITERATIONS")
+		}()
+
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, "argon2id", cfg.Password.Algorithm)
+		assert.Equal(t, uint32(4), cfg.Password.Argon2Iterations)
+	})
+}
+
+func TestMFAConfig(t *testing.T) {
+	t.Run("значения по умолчанию", func(t *testing.T) {
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, "Avito Shop", cfg.MFA.Issuer)
+		assert.False(t, cfg.MFA.RequireForTransfers)
+		assert.False(t, cfg.MFA.RequireForAdmin)
+	})
+
+	t.Run("обязательный второй фактор", func(t *testing.T) {
+		os.Setenv("MFA_REQUIRE_FOR_TRANSFERS", "true")
+		os.Setenv("MFA_REQUIRE_FOR_ADMIN", "true")
+		defer func() {
+			os.Unsetenv("MFA_REQUIRE_FOR_TRANSFERS")
+			os.Unsetenv("MFA_REQUIRE_FOR_ADMIN")
+		}()
+
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.True(t, cfg.MFA.RequireForTransfers)
+		assert.True(t, cfg.MFA.RequireForAdmin)
+	})
+}
+
+func TestIdempotencyConfig(t *testing.T) {
+	t.Run("значения по умолчанию", func(t *testing.T) {
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, cfg.Idempotency.TTL)
+		assert.Equal(t, time.Hour, cfg.Idempotency.CleanupInterval)
+	})
+
+	t.Run("переопределение через окружение", func(t *testing.T) {
+		os.Setenv("IDEMPOTENCY_TTL", "2h")
+		os.Setenv("IDEMPOTENCY_CLEANUP_INTERVAL", "10m")
+		defer func() {
+			os.Unsetenv("IDEMPOTENCY_TTL")
+			os.Unsetenv("IDEMPOTENCY_CLEANUP_INTERVAL")
+		}()
+
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, cfg.Idempotency.TTL)
+		assert.Equal(t, 10*time.Minute, cfg.Idempotency.CleanupInterval)
+	})
+}
+
+func TestMarketConfig(t *testing.T) {
+	t.Run("значения по умолчанию", func(t *testing.T) {
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), cfg.Market.FeePercent)
+	})
+
+	t.Run("переопределение через окружение", func(t *testing.T) {
+		os.Setenv("MARKET_FEE_PERCENT", "5")
+		defer os.Unsetenv("MARKET_FEE_PERCENT")
+
+		cfg, err := New()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), cfg.Market.FeePercent)
+	})
 }
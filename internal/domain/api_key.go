@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Области доступа API-ключей
+const (
+	ScopeCoinsGrant = "coins:grant" // Начисление монет сотрудникам
+	ScopeInfoRead   = "info:read"   // Чтение балансов сотрудников
+)
+
+// APIKeyPrefix отличает API-ключ от JWT в заголовке Authorization
+const APIKeyPrefix = "ak_"
+
+// ValidateScopes проверяет, что все области доступа известны
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: список пуст", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeCoinsGrant, ScopeInfoRead:
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+	return nil
+}
+
+// APIKey представляет API-ключ сервисной учетной записи
+type APIKey struct {
+	Id         int64      // Идентификатор ключа
+	Account    string     // Сервисная учетная запись, от имени которой действует ключ
+	Name       string     // Описание ключа
+	Prefix     string     // Открытая часть ключа для поиска и отображения
+	KeyHash    string     // SHA-256 хэш полного значения ключа
+	Scopes     []string   // Разрешенные области доступа
+	ExpiresAt  *time.Time // Время истечения, nil для бессрочного ключа
+	LastUsedAt *time.Time // Время последнего использования
+	CreatedAt  time.Time  // Время выпуска ключа
+	RevokedAt  *time.Time // Время отзыва, nil если ключ активен
+}
+
+// IsActive проверяет, что ключ не отозван и не истек на момент now
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// HasScope проверяет, разрешена ли ключу область доступа
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
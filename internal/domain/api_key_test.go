@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScopes(t *testing.T) {
+	t.Run("известные области", func(t *testing.T) {
+		require.NoError(t, ValidateScopes([]string{ScopeCoinsGrant, ScopeInfoRead}))
+	})
+
+	t.Run("неизвестная область", func(t *testing.T) {
+		require.ErrorIs(t, ValidateScopes([]string{"coins:burn"}), ErrInvalidScope)
+	})
+
+	t.Run("пустой список", func(t *testing.T) {
+		require.ErrorIs(t, ValidateScopes(nil), ErrInvalidScope)
+	})
+}
+
+func TestAPIKeyIsActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	require.True(t, (&APIKey{}).IsActive(now))
+	require.True(t, (&APIKey{ExpiresAt: &future}).IsActive(now))
+	require.False(t, (&APIKey{ExpiresAt: &past}).IsActive(now))
+	require.False(t, (&APIKey{RevokedAt: &past}).IsActive(now))
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	key := &APIKey{Scopes: []string{ScopeInfoRead}}
+
+	require.True(t, key.HasScope(ScopeInfoRead))
+	require.False(t, key.HasScope(ScopeCoinsGrant))
+}
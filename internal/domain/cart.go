@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxCartItems ограничивает количество разных товаров в корзине
+const MaxCartItems = 50
+
+// CartItem представляет позицию корзины
+type CartItem struct {
+	ItemName  string    // Название товара
+	Quantity  uint64    // Количество единиц
+	UnitPrice uint64    // Цена единицы на момент добавления в корзину
+	AddedAt   time.Time // Время добавления
+}
+
+// Cart представляет корзину пользователя
+type Cart struct {
+	Username string
+	Items    []*CartItem
+}
+
+// Total возвращает стоимость корзины по зафиксированным ценам
+func (c *Cart) Total() (uint64, error) {
+	purchases := make([]*Purchase, 0, len(c.Items))
+	for _, item := range c.Items {
+		purchases = append(purchases, &Purchase{Username: c.Username, ItemName: item.ItemName, Quantity: item.Quantity, UnitPrice: item.UnitPrice})
+	}
+	return totalOf(purchases)
+}
+
+// totalOf суммирует стоимость покупок с проверкой переполнения
+func totalOf(purchases []*Purchase) (uint64, error) {
+	var total uint64
+	for _, p := range purchases {
+		amount, err := p.Total()
+		if err != nil {
+			return 0, err
+		}
+		if total > math.MaxInt64-amount {
+			return 0, fmt.Errorf("%w: стоимость заказа слишком велика", ErrInvalidQuantity)
+		}
+		total += amount
+	}
+	return total, nil
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCart_Total(t *testing.T) {
+	cart := &Cart{Username: "buyer", Items: []*CartItem{
+		{ItemName: "cup", Quantity: 3, UnitPrice: 20},
+		{ItemName: "pen", Quantity: 2, UnitPrice: 10},
+	}}
+
+	total, err := cart.Total()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(80), total)
+}
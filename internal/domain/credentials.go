@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 32
+	// bcrypt учитывает только первые 72 байта пароля
+	maxPasswordLength = 72
+)
+
+// reservedUsernames содержит имена, занятые системой
+var reservedUsernames = map[string]struct{}{
+	"shop":   {},
+	"system": {},
+	"admin":  {},
+}
+
+// ValidateUsername проверяет формат имени пользователя: 3–32 символа из латинских букв,
+// цифр, '_', '-' и '.', начинается с буквы или цифры
+func ValidateUsername(username string) error {
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return fmt.Errorf("%w: длина должна быть от %d до %d символов", ErrInvalidUsername, minUsernameLength, maxUsernameLength)
+	}
+
+	for i, r := range username {
+		isAlnum := r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+		if i == 0 && !isAlnum {
+			return fmt.Errorf("%w: должно начинаться с буквы или цифры", ErrInvalidUsername)
+		}
+		if !isAlnum && r != '_' && r != '-' && r != '.' {
+			return fmt.Errorf("%w: недопустимый символ %q", ErrInvalidUsername, r)
+		}
+	}
+
+	return CheckReservedUsername(username)
+}
+
+// CheckReservedUsername проверяет, что имя не занято системой. Регистер не учитывается,
+// чтобы пользователь не мог выдать себя за системную учетную запись.
+func CheckReservedUsername(username string) error {
+	if _, reserved := reservedUsernames[strings.ToLower(username)]; reserved {
+		return fmt.Errorf("%w: имя зарезервировано", ErrInvalidUsername)
+	}
+
+	return nil
+}
+
+// PasswordPolicy описывает требования к сложности пароля
+type PasswordPolicy struct {
+	MinLength     int  // Минимальная длина пароля
+	RequireLetter bool // Пароль должен содержать букву
+	RequireDigit  bool // Пароль должен содержать цифру
+}
+
+// NewPasswordPolicy создает политику паролей с обязательными буквой и цифрой
+func NewPasswordPolicy(minLength int) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:     minLength,
+		RequireLetter: true,
+		RequireDigit:  true,
+	}
+}
+
+// Validate проверяет пароль на соответствие политике
+func (p PasswordPolicy) Validate(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("%w: минимальная длина %d символов", ErrWeakPassword, p.MinLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("%w: максимальная длина %d байт", ErrWeakPassword, maxPasswordLength)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+
+	if p.RequireLetter && !hasLetter {
+		return fmt.Errorf("%w: пароль должен содержать букву", ErrWeakPassword)
+	}
+	if p.RequireDigit && !hasDigit {
+		return fmt.Errorf("%w: пароль должен содержать цифру", ErrWeakPassword)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		wantErr  bool
+	}{
+		{name: "корректное имя", username: "test_user", wantErr: false},
+		{name: "имя с точкой и дефисом", username: "ivan.petrov-2", wantErr: false},
+		{name: "слишком короткое имя", username: "ab", wantErr: true},
+		{name: "недопустимый символ", username: "user name", wantErr: true},
+		{name: "начинается с подчеркивания", username: "_user", wantErr: true},
+		{name: "кириллица", username: "пользователь", wantErr: true},
+		{name: "зарезервированное имя", username: "SHOP", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUsername(tt.username)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidUsername)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := NewPasswordPolicy(8)
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "надежный пароль", password: "testpass123", wantErr: false},
+		{name: "слишком короткий пароль", password: "abc1", wantErr: true},
+		{name: "без цифр", password: "testpassword", wantErr: true},
+		{name: "без букв", password: "1234567890", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrWeakPassword)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
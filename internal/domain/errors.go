@@ -12,5 +12,66 @@ var (
 	ErrInvalidAmount      = errors.New("неверная сумма перевода")
 	ErrTransactionFailed  = errors.New("ошибка выполнения транзакции")
 	ErrMerchNotFound      = errors.New("товар не найден")
+	ErrMerchUnavailable   = errors.New("товар недоступен для покупки")
+	ErrMerchAlreadyExists = errors.New("товар уже существует")
+	ErrInvalidMerch       = errors.New("недопустимые параметры товара")
+	ErrOutOfStock         = errors.New("товар закончился")
+	ErrStockUnlimited     = errors.New("количество товара не ограничено")
+	ErrInvalidQuantity    = errors.New("недопустимое количество товара")
+	ErrCartEmpty          = errors.New("корзина пуста")
+	ErrCartItemNotFound   = errors.New("товара нет в корзине")
+	ErrCartFull           = errors.New("в корзине слишком много товаров")
+	ErrPriceChanged       = errors.New("цена товара изменилась")
+	ErrOrderNotFound      = errors.New("заказ не найден")
+	ErrInvalidOrderStatus = errors.New("неизвестный статус заказа")
+	ErrOrderTransition    = errors.New("недопустимое изменение статуса заказа")
+	ErrInventoryShortage  = errors.New("недостаточно товара в инвентаре")
+	ErrInvalidPromoCode   = errors.New("некорректный промокод")
+	ErrPromoCodeNotFound  = errors.New("промокод не найден")
+	ErrPromoCodeExists    = errors.New("промокод уже существует")
+	ErrPromoNotApplicable = errors.New("промокод неприменим")
+	ErrPromoCodeExhausted = errors.New("промокод больше нельзя использовать")
 	ErrEmptyUserHistory   = errors.New("user history is empty")
+	ErrInvalidToken       = errors.New("недействительный токен")
+	ErrTokenReused        = errors.New("повторное использование refresh-токена")
+	ErrInvalidUsername    = errors.New("недопустимое имя пользователя")
+	ErrWeakPassword       = errors.New("пароль не соответствует требованиям")
+	ErrAccountLocked      = errors.New("вход временно заблокирован")
+	ErrInvalidRole        = errors.New("неизвестная роль")
+	ErrMFANotEnrolled     = errors.New("двухфакторная аутентификация не подключена")
+	ErrMFAAlreadyEnabled  = errors.New("двухфакторная аутентификация уже подключена")
+	ErrInvalidMFACode     = errors.New("неверный код подтверждения")
+	ErrInvalidScope       = errors.New("неизвестная область доступа")
+	ErrAPIKeyNotFound     = errors.New("API-ключ не найден")
+	ErrInvalidAPIKey      = errors.New("недействительный API-ключ")
+	ErrNotServiceAccount  = errors.New("пользователь не является сервисной учетной записью")
+	ErrIdempotencyKeyUsed = errors.New("ключ идемпотентности уже использован")
+	ErrIdempotencyReused  = errors.New("ключ идемпотентности использован с другим запросом")
+	ErrInvalidCursor      = errors.New("недействительный курсор")
+	ErrInvalidFilter      = errors.New("недопустимые параметры фильтра")
+)
+
+// Ошибки расписаний цен
+var (
+	ErrInvalidPriceSchedule  = errors.New("некорректное расписание цены")
+	ErrPriceScheduleNotFound = errors.New("расписание цены не найдено")
+)
+
+// Ошибки подарков
+var (
+	ErrInvalidGift = errors.New("некорректный подарок")
+)
+
+// Ошибки передачи товаров
+var (
+	ErrInvalidItemTransfer = errors.New("некорректная передача товара")
+	ErrItemNotTransferable = errors.New("товар нельзя передать")
+)
+
+// Ошибки маркетплейса
+var (
+	ErrInvalidListing  = errors.New("некорректный лот")
+	ErrListingNotFound = errors.New("лот не найден")
+	ErrListingClosed   = errors.New("лот уже продан или снят")
+	ErrOwnListing      = errors.New("нельзя купить собственный лот")
 )
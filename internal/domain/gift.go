@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxGiftMessageLength ограничивает длину сообщения к подарку в символах
+const MaxGiftMessageLength = 500
+
+// Gift описывает покупку товара в подарок: оплачивает отправитель, товар получает получатель
+type Gift struct {
+	Purchase         // Покупка от имени отправителя; Username — отправитель подарка
+	Recipient string // Получатель подарка
+	Message   string // Сообщение получателю; пусто — без сообщения
+}
+
+// NewGift создает подарок из quantity единиц товара
+func NewGift(sender, recipient string, merch *Merch, quantity uint64, message string) *Gift {
+	return &Gift{
+		Purchase:  *NewPurchase(sender, merch, quantity),
+		Recipient: recipient,
+		Message:   message,
+	}
+}
+
+// Validate проверяет получателя, количество и сообщение подарка
+func (g *Gift) Validate() error {
+	if g.Recipient == "" {
+		return fmt.Errorf("%w: не указан получатель", ErrInvalidGift)
+	}
+	if g.Recipient == g.Username {
+		return fmt.Errorf("%w: нельзя подарить товар самому себе", ErrInvalidGift)
+	}
+	if utf8.RuneCountInString(g.Message) > MaxGiftMessageLength {
+		return fmt.Errorf("%w: сообщение не должно быть длиннее %d символов", ErrInvalidGift, MaxGiftMessageLength)
+	}
+	return ValidatePurchaseQuantity(g.Quantity)
+}
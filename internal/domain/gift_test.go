@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGift_Validate(t *testing.T) {
+	merch := &Merch{Name: "cup", Price: 20}
+
+	tests := []struct {
+		name    string
+		gift    *Gift
+		wantErr error
+	}{
+		{name: "подарок с сообщением", gift: NewGift("alice", "bob", merch, 1, "С днем рождения!")},
+		{name: "сообщение максимальной длины", gift: NewGift("alice", "bob", merch, 1, strings.Repeat("я", MaxGiftMessageLength))},
+		{name: "без получателя", gift: NewGift("alice", "", merch, 1, ""), wantErr: ErrInvalidGift},
+		{name: "подарок самому себе", gift: NewGift("alice", "alice", merch, 1, ""), wantErr: ErrInvalidGift},
+		{name: "слишком длинное сообщение", gift: NewGift("alice", "bob", merch, 1, strings.Repeat("я", MaxGiftMessageLength+1)), wantErr: ErrInvalidGift},
+		{name: "нулевое количество", gift: NewGift("alice", "bob", merch, 0, ""), wantErr: ErrInvalidQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.gift.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord хранит результат запроса с заголовком Idempotency-Key.
+// Повтор запроса с тем же ключом получает сохраненный ответ без повторного списания.
+type IdempotencyRecord struct {
+	Key         string    // Значение заголовка Idempotency-Key
+	Username    string    // Пользователь, в пространстве которого действует ключ
+	RequestHash string    // SHA-256 хэш метода, пути и тела запроса
+	StatusCode  int       // HTTP-статус сохраненного ответа
+	Response    []byte    // Тело сохраненного ответа
+	CreatedAt   time.Time // Время первого запроса
+	ExpiresAt   time.Time // Время, после которого запись удаляется
+
+	// Render формирует тело ответа по результату, который становится известен только внутри транзакции,
+	// например по созданному заказу. Если не задан, сохраняется Response.
+	Render func(result interface{}) ([]byte, error)
+}
+
+// NewIdempotencyRecord создает запись для ответа, который будет сохранен вместе с изменением баланса
+func NewIdempotencyRecord(username, key, requestHash string, ttl time.Duration) *IdempotencyRecord {
+	now := time.Now()
+	return &IdempotencyRecord{
+		Key:         key,
+		Username:    username,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+}
+
+// Matches проверяет, что запись создана для запроса с тем же содержимым
+func (r *IdempotencyRecord) Matches(requestHash string) bool {
+	return r.RequestHash == requestHash
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "fmt"
+
+// ItemTransfer описывает передачу единиц купленного товара из инвентаря одного пользователя другому
+type ItemTransfer struct {
+	From     string // Владелец товара
+	To       string // Получатель товара
+	ItemName string
+	Quantity uint64
+}
+
+// NewItemTransfer создает передачу quantity единиц товара
+func NewItemTransfer(from, to, itemName string, quantity uint64) *ItemTransfer {
+	return &ItemTransfer{
+		From:     from,
+		To:       to,
+		ItemName: itemName,
+		Quantity: quantity,
+	}
+}
+
+// Validate проверяет получателя, товар и количество передаваемых единиц
+func (t *ItemTransfer) Validate() error {
+	if t.To == "" {
+		return fmt.Errorf("%w: не указан получатель", ErrInvalidItemTransfer)
+	}
+	if t.To == t.From {
+		return fmt.Errorf("%w: нельзя передать товар самому себе", ErrInvalidItemTransfer)
+	}
+	if t.ItemName == "" {
+		return fmt.Errorf("%w: не указан товар", ErrInvalidItemTransfer)
+	}
+	return ValidatePurchaseQuantity(t.Quantity)
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItemTransfer_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer *ItemTransfer
+		wantErr  error
+	}{
+		{name: "передача нескольких единиц", transfer: NewItemTransfer("alice", "bob", "cup", 3)},
+		{name: "без получателя", transfer: NewItemTransfer("alice", "", "cup", 1), wantErr: ErrInvalidItemTransfer},
+		{name: "передача самому себе", transfer: NewItemTransfer("alice", "alice", "cup", 1), wantErr: ErrInvalidItemTransfer},
+		{name: "без товара", transfer: NewItemTransfer("alice", "bob", "", 1), wantErr: ErrInvalidItemTransfer},
+		{name: "нулевое количество", transfer: NewItemTransfer("alice", "bob", "cup", 0), wantErr: ErrInvalidQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.transfer.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMerch_CanBeTransferred(t *testing.T) {
+	retiredAt := time.Now()
+
+	assert.True(t, (&Merch{Name: "cup", Transferable: true}).CanBeTransferred())
+	assert.False(t, (&Merch{Name: "badge"}).CanBeTransferred(), "непередаваемый товар")
+	assert.False(t, (&Merch{Name: "cup", Transferable: true, RetiredAt: &retiredAt}).CanBeTransferred(), "снятый с продажи товар")
+}
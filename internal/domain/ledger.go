@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LedgerDirection определяет направление движения монет для пользователя
+type LedgerDirection string
+
+const (
+	// LedgerDebit представляет списание монет у пользователя
+	LedgerDebit LedgerDirection = "DEBIT"
+	// LedgerCredit представляет зачисление монет пользователю
+	LedgerCredit LedgerDirection = "CREDIT"
+)
+
+const (
+	// DefaultLedgerPageSize количество записей на странице истории по умолчанию
+	DefaultLedgerPageSize = 50
+	// MaxLedgerPageSize максимальное количество записей на странице истории
+	MaxLedgerPageSize = 100
+)
+
+// LedgerEntry представляет запись в истории операций пользователя
+type LedgerEntry struct {
+	TransactionId int64           // Идентификатор транзакции
+	Username      string          // Владелец истории
+	Counterparty  string          // Второй участник транзакции
+	Direction     LedgerDirection // Списание или зачисление
+	Amount        uint64          // Сумма транзакции
+	Type          TransactionType // Тип транзакции
+	ItemName      string          // Купленный товар для покупок
+	Quantity      uint64          // Количество купленных единиц для покупок
+	Message       string          // Сообщение к подарку
+	Timestamp     time.Time       // Время транзакции
+}
+
+// IsPurchase проверяет, что запись отражает покупку товара пользователем
+func (e *LedgerEntry) IsPurchase() bool {
+	return e.Type == TransactionTypePurchase && e.Direction == LedgerDebit
+}
+
+// SignedAmount возвращает изменение баланса пользователя: зачисления положительные, списания отрицательные
+func (e *LedgerEntry) SignedAmount() int64 {
+	if e.Direction == LedgerCredit {
+		return int64(e.Amount)
+	}
+	return -int64(e.Amount)
+}
+
+// Cursor возвращает позицию записи для запроса следующей страницы
+func (e *LedgerEntry) Cursor() *LedgerCursor {
+	return &LedgerCursor{Timestamp: e.Timestamp, TransactionId: e.TransactionId}
+}
+
+// LedgerCursor указывает на последнюю запись предыдущей страницы.
+// Записи упорядочены по (timestamp, id), поэтому страницы не смещаются при появлении новых операций.
+type LedgerCursor struct {
+	Timestamp     time.Time
+	TransactionId int64
+}
+
+// Encode возвращает непрозрачное строковое представление курсора
+func (c *LedgerCursor) Encode() string {
+	raw := strconv.FormatInt(c.Timestamp.UnixNano(), 10) + ":" + strconv.FormatInt(c.TransactionId, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseLedgerCursor восстанавливает курсор из строки, полученной от Encode
+func ParseLedgerCursor(s string) (*LedgerCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &LedgerCursor{Timestamp: time.Unix(0, nanos).UTC(), TransactionId: id}, nil
+}
+
+// LedgerFilter задает условия выборки истории операций пользователя.
+// Пустые поля не ограничивают выборку, Limit = 0 возвращает все записи.
+type LedgerFilter struct {
+	Username     string
+	Direction    LedgerDirection
+	Counterparty string
+	Type         TransactionType
+	MinAmount    uint64
+	MaxAmount    uint64
+	From         time.Time
+	To           time.Time
+	After        *LedgerCursor
+	Limit        int
+}
+
+// Validate проверяет согласованность параметров фильтра
+func (f *LedgerFilter) Validate() error {
+	switch f.Direction {
+	case "", LedgerDebit, LedgerCredit:
+	default:
+		return ErrInvalidFilter
+	}
+
+	switch f.Type {
+	case "", TransactionTypeTransfer, TransactionTypePurchase, TransactionTypeGrant, TransactionTypeRefund, TransactionTypeGift, TransactionTypeItemTransfer,
+		TransactionTypeMarketSale, TransactionTypeMarketFee:
+	default:
+		return ErrInvalidFilter
+	}
+
+	if f.MaxAmount > 0 && f.MinAmount > f.MaxAmount {
+		return ErrInvalidFilter
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.From.After(f.To) {
+		return ErrInvalidFilter
+	}
+	if f.Limit < 0 {
+		return ErrInvalidFilter
+	}
+
+	return nil
+}
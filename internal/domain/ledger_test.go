@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerEntryIsPurchase(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry LedgerEntry
+		want  bool
+	}{
+		{
+			name:  "покупка товара",
+			entry: LedgerEntry{Type: TransactionTypePurchase, Direction: LedgerDebit},
+			want:  true,
+		},
+		{
+			name:  "отправленный перевод",
+			entry: LedgerEntry{Type: TransactionTypeTransfer, Direction: LedgerDebit},
+			want:  false,
+		},
+		{
+			name:  "начисление",
+			entry: LedgerEntry{Type: TransactionTypeGrant, Direction: LedgerCredit},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.entry.IsPurchase())
+		})
+	}
+}
+
+func TestLedgerCursor(t *testing.T) {
+	t.Run("кодирование и разбор", func(t *testing.T) {
+		cursor := &LedgerCursor{Timestamp: time.Date(2025, 3, 1, 10, 30, 0, 123456000, time.UTC), TransactionId: 77}
+
+		parsed, err := ParseLedgerCursor(cursor.Encode())
+
+		require.NoError(t, err)
+		assert.True(t, cursor.Timestamp.Equal(parsed.Timestamp))
+		assert.Equal(t, int64(77), parsed.TransactionId)
+	})
+
+	t.Run("недействительный курсор", func(t *testing.T) {
+		for _, raw := range []string{"", "!!!", "bm9wZQ", "YTpi"} {
+			_, err := ParseLedgerCursor(raw)
+			assert.ErrorIs(t, err, ErrInvalidCursor, raw)
+		}
+	})
+}
+
+func TestLedgerFilterValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		filter  LedgerFilter
+		wantErr bool
+	}{
+		{name: "пустой фильтр", filter: LedgerFilter{Username: "user"}},
+		{name: "все параметры", filter: LedgerFilter{Direction: LedgerCredit, Type: TransactionTypeGrant, MinAmount: 1, MaxAmount: 10, From: now.Add(-time.Hour), To: now}},
+		{name: "подарки", filter: LedgerFilter{Type: TransactionTypeGift}},
+		{name: "неизвестное направление", filter: LedgerFilter{Direction: "SIDEWAYS"}, wantErr: true},
+		{name: "неизвестный тип", filter: LedgerFilter{Type: "BONUS"}, wantErr: true},
+		{name: "минимальная сумма больше максимальной", filter: LedgerFilter{MinAmount: 10, MaxAmount: 1}, wantErr: true},
+		{name: "начало периода позже конца", filter: LedgerFilter{From: now, To: now.Add(-time.Hour)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginAttempts описывает счетчик неудачных попыток входа по ключу (имя пользователя или IP)
+type LoginAttempts struct {
+	Key         string    // Ключ счетчика
+	Failures    int       // Количество неудачных попыток подряд
+	LastFailure time.Time // Время последней неудачной попытки
+	LockedUntil time.Time // Время окончания блокировки, нулевое если блокировки нет
+}
+
+// IsLocked проверяет, заблокирован ли вход на момент now
+func (a *LoginAttempts) IsLocked(now time.Time) bool {
+	return now.Before(a.LockedUntil)
+}
+
+// LockoutPolicy определяет экспоненциальную задержку и блокировку после неудачных попыток
+type LockoutPolicy struct {
+	FreeAttempts    int           // Количество попыток без задержки
+	MaxFailures     int           // Количество попыток до полной блокировки
+	BaseDelay       time.Duration // Начальная задержка, удваивается с каждой попыткой
+	LockoutDuration time.Duration // Длительность полной блокировки
+	ResetAfter      time.Duration // Через сколько после последней ошибки счетчик сбрасывается
+}
+
+// LockDuration возвращает время блокировки после указанного числа неудачных попыток
+func (p LockoutPolicy) LockDuration(failures int) time.Duration {
+	if failures >= p.MaxFailures {
+		return p.LockoutDuration
+	}
+	if failures < p.FreeAttempts {
+		return 0
+	}
+
+	shift := failures - p.FreeAttempts
+	if shift > 30 {
+		return p.LockoutDuration
+	}
+
+	delay := p.BaseDelay << shift
+	if delay <= 0 || delay > p.LockoutDuration {
+		return p.LockoutDuration
+	}
+	return delay
+}
+
+// LockoutError сообщает о блокировке входа и времени до ее снятия
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("%s: повторите через %s", ErrAccountLocked, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrAccountLocked
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutPolicyLockDuration(t *testing.T) {
+	policy := LockoutPolicy{
+		FreeAttempts:    3,
+		MaxFailures:     10,
+		BaseDelay:       time.Second,
+		LockoutDuration: 15 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{name: "первые попытки без задержки", failures: 2, want: 0},
+		{name: "начало задержки", failures: 3, want: time.Second},
+		{name: "экспоненциальный рост", failures: 6, want: 8 * time.Second},
+		{name: "полная блокировка", failures: 10, want: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.LockDuration(tt.failures))
+		})
+	}
+}
+
+func TestLockoutError(t *testing.T) {
+	err := error(&LockoutError{RetryAfter: 30 * time.Second})
+
+	assert.True(t, errors.Is(err, ErrAccountLocked))
+
+	var lockoutErr *LockoutError
+	assert.True(t, errors.As(err, &lockoutErr))
+	assert.Equal(t, 30*time.Second, lockoutErr.RetryAfter)
+}
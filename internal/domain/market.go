@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ListingStatus определяет состояние лота на маркетплейсе
+type ListingStatus string
+
+const (
+	ListingStatusActive    ListingStatus = "active"    // Лот выставлен, товар удерживается у маркетплейса
+	ListingStatusSold      ListingStatus = "sold"      // Лот куплен, товар передан покупателю
+	ListingStatusCancelled ListingStatus = "cancelled" // Лот снят продавцом, товар возвращен в инвентарь
+)
+
+const (
+	// DefaultListingPageSize количество лотов в списке по умолчанию
+	DefaultListingPageSize = 50
+	// MaxListingPageSize максимальное количество лотов в списке
+	MaxListingPageSize = 100
+	// MaxListingPrice ограничивает цену лота, чтобы комиссия вычислялась без переполнения
+	MaxListingPrice = 1_000_000_000
+	// MaxMarketFeePercent максимальная комиссия маркетплейса в процентах
+	MaxMarketFeePercent = 100
+)
+
+// ParseListingStatus проверяет, что строка является известным состоянием лота
+func ParseListingStatus(status string) (ListingStatus, error) {
+	switch ListingStatus(status) {
+	case ListingStatusActive, ListingStatusSold, ListingStatusCancelled:
+		return ListingStatus(status), nil
+	default:
+		return "", fmt.Errorf("%w: неизвестное состояние лота %q", ErrInvalidListing, status)
+	}
+}
+
+// Listing представляет лот маркетплейса: quantity единиц товара из инвентаря продавца за price монет.
+// Пока лот активен, товар списан из инвентаря продавца и не может быть продан или передан повторно.
+type Listing struct {
+	Id        int64
+	Seller    string
+	ItemName  string
+	Quantity  uint64
+	Price     uint64 // Цена всего лота
+	Status    ListingStatus
+	Buyer     string // Пустой, пока лот не куплен
+	Fee       uint64 // Комиссия маркетплейса, удержанная из выручки продавца
+	CreatedAt time.Time
+	ClosedAt  *time.Time // Время продажи или снятия лота
+}
+
+// NewListing создает активный лот
+func NewListing(seller, itemName string, quantity, price uint64, now time.Time) *Listing {
+	return &Listing{
+		Seller:    seller,
+		ItemName:  itemName,
+		Quantity:  quantity,
+		Price:     price,
+		Status:    ListingStatusActive,
+		CreatedAt: now,
+	}
+}
+
+// Validate проверяет товар, количество и цену лота
+func (l *Listing) Validate() error {
+	if err := ValidateMerchName(l.ItemName); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidListing, err)
+	}
+	if l.Price == 0 || l.Price > MaxListingPrice {
+		return fmt.Errorf("%w: цена должна быть от 1 до %d", ErrInvalidListing, MaxListingPrice)
+	}
+	return ValidatePurchaseQuantity(l.Quantity)
+}
+
+// IsActive проверяет, что лот можно купить или снять
+func (l *Listing) IsActive() bool {
+	return l.Status == ListingStatusActive
+}
+
+// SellerProceeds возвращает сумму, которую продавец получает после вычета комиссии
+func (l *Listing) SellerProceeds() uint64 {
+	return l.Price - l.Fee
+}
+
+// MarketFee вычисляет комиссию маркетплейса с цены лота, округляя вниз
+func MarketFee(price, feePercent uint64) uint64 {
+	return price * feePercent / 100
+}
+
+// ListingFilter задает условия выборки лотов. Пустые поля не ограничивают выборку.
+type ListingFilter struct {
+	ItemName string
+	Seller   string
+	Status   ListingStatus
+	MinPrice uint64
+	MaxPrice uint64
+	Limit    int
+}
+
+// Validate проверяет параметры фильтра
+func (f *ListingFilter) Validate() error {
+	if f.Status != "" {
+		if _, err := ParseListingStatus(string(f.Status)); err != nil {
+			return ErrInvalidFilter
+		}
+	}
+	if f.MaxPrice != 0 && f.MinPrice > f.MaxPrice {
+		return ErrInvalidFilter
+	}
+	if f.Limit < 0 {
+		return ErrInvalidFilter
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListing_Validate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		listing *Listing
+		wantErr error
+	}{
+		{name: "корректный лот", listing: NewListing("alice", "cup", 2, 100, now)},
+		{name: "нулевая цена", listing: NewListing("alice", "cup", 2, 0, now), wantErr: ErrInvalidListing},
+		{name: "слишком высокая цена", listing: NewListing("alice", "cup", 2, MaxListingPrice+1, now), wantErr: ErrInvalidListing},
+		{name: "недопустимое название", listing: NewListing("alice", "Cup", 2, 100, now), wantErr: ErrInvalidListing},
+		{name: "нулевое количество", listing: NewListing("alice", "cup", 0, 100, now), wantErr: ErrInvalidQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.listing.Validate()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, tt.listing.IsActive())
+			}
+		})
+	}
+}
+
+func TestMarketFee(t *testing.T) {
+	assert.Equal(t, uint64(0), MarketFee(100, 0))
+	assert.Equal(t, uint64(5), MarketFee(100, 5))
+	assert.Equal(t, uint64(0), MarketFee(19, 5), "комиссия округляется вниз")
+	assert.Equal(t, uint64(MaxListingPrice), MarketFee(MaxListingPrice, MaxMarketFeePercent))
+
+	listing := &Listing{Price: 100, Fee: MarketFee(100, 10)}
+	assert.Equal(t, uint64(90), listing.SellerProceeds())
+}
+
+func TestListingFilter_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  ListingFilter
+		wantErr bool
+	}{
+		{name: "пустой фильтр", filter: ListingFilter{}},
+		{name: "активные лоты в диапазоне цен", filter: ListingFilter{Status: ListingStatusActive, MinPrice: 10, MaxPrice: 100}},
+		{name: "только минимальная цена", filter: ListingFilter{MinPrice: 10}},
+		{name: "неизвестное состояние", filter: ListingFilter{Status: "lost"}, wantErr: true},
+		{name: "минимальная цена больше максимальной", filter: ListingFilter{MinPrice: 100, MaxPrice: 10}, wantErr: true},
+		{name: "отрицательный размер страницы", filter: ListingFilter{Limit: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
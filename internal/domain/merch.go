@@ -1,9 +1,37 @@
 package domain
 
+import (
+	"fmt"
+	"math"
+	"time"
+	"unicode"
+)
+
+const (
+	maxMerchNameLength     = 64
+	maxMerchCategoryLength = 64
+	// DefaultMerchCategory присваивается товару без категории
+	DefaultMerchCategory = "other"
+	// MaxMerchPrice ограничивает цену товара, чтобы она помещалась в колонки INT с ценами
+	MaxMerchPrice = math.MaxInt32
+	// MaxRestockQuantity ограничивает пополнение склада за один запрос, чтобы остаток помещался в колонку INT
+	MaxRestockQuantity = 1_000_000
+)
+
 type Merch struct {
-	Id    int
-	Name  string
-	Price uint64
+	Id          int
+	Name        string
+	Price       uint64
+	Description string
+	Category    string
+	Available   bool
+	RetiredAt   *time.Time // Время снятия с продажи; снятые товары остаются в базе для истории покупок
+	Stock       *uint64    // Остаток на складе; nil — количество не ограничено
+	// Можно ли передавать купленный товар другим пользователям
+	Transferable bool
+	// Действующие и запланированные расписания цены; Price хранит базовую цену
+	PriceSchedules []*PriceSchedule
+	RegularPrice   uint64 // Базовая цена; заполняется PricedAt, когда Price содержит действующую цену
 }
 
 func NewMerch(name string, price uint64) *Merch {
@@ -12,3 +40,111 @@ func NewMerch(name string, price uint64) *Merch {
 		Price: price,
 	}
 }
+
+// IsRetired проверяет, снят ли товар с продажи
+func (m *Merch) IsRetired() bool {
+	return m.RetiredAt != nil
+}
+
+// CanBePurchased проверяет, что товар доступен и не снят с продажи
+func (m *Merch) CanBePurchased() bool {
+	return m.Available && !m.IsRetired()
+}
+
+// CanBeTransferred проверяет, что купленный товар можно передать другому пользователю.
+// Снятые с продажи товары не передаются.
+func (m *Merch) CanBeTransferred() bool {
+	return m.Transferable && !m.IsRetired()
+}
+
+// IsLimited проверяет, ограничено ли количество товара
+func (m *Merch) IsLimited() bool {
+	return m.Stock != nil
+}
+
+// InStock проверяет, остался ли товар на складе
+func (m *Merch) InStock() bool {
+	return m.Stock == nil || *m.Stock > 0
+}
+
+// Validate проверяет название, цену и категорию товара
+func (m *Merch) Validate() error {
+	if err := ValidateMerchName(m.Name); err != nil {
+		return err
+	}
+	if err := ValidateMerchPrice(m.Price); err != nil {
+		return err
+	}
+	if m.Stock != nil && *m.Stock > MaxRestockQuantity {
+		return fmt.Errorf("%w: остаток не должен превышать %d", ErrInvalidMerch, MaxRestockQuantity)
+	}
+	return ValidateMerchCategory(m.Category)
+}
+
+// ValidateMerchName проверяет, что название товара состоит из строчных латинских букв, цифр и '-'
+// и подходит для использования в URL
+func ValidateMerchName(name string) error {
+	if name == "" || len(name) > maxMerchNameLength {
+		return fmt.Errorf("%w: длина названия должна быть от 1 до %d символов", ErrInvalidMerch, maxMerchNameLength)
+	}
+	for i, r := range name {
+		isAlnum := r < unicode.MaxASCII && (unicode.IsLower(r) || unicode.IsDigit(r))
+		if i == 0 && !isAlnum {
+			return fmt.Errorf("%w: название должно начинаться с буквы или цифры", ErrInvalidMerch)
+		}
+		if !isAlnum && r != '-' {
+			return fmt.Errorf("%w: недопустимый символ %q в названии", ErrInvalidMerch, r)
+		}
+	}
+	return nil
+}
+
+// ValidateMerchPrice проверяет, что цена товара положительна и не превышает MaxMerchPrice
+func ValidateMerchPrice(price uint64) error {
+	if price == 0 || price > MaxMerchPrice {
+		return fmt.Errorf("%w: цена должна быть от 1 до %d", ErrInvalidMerch, MaxMerchPrice)
+	}
+	return nil
+}
+
+// ValidateRestockQuantity проверяет количество товара, добавляемого на склад
+func ValidateRestockQuantity(quantity uint64) error {
+	if quantity == 0 || quantity > MaxRestockQuantity {
+		return fmt.Errorf("%w: количество должно быть от 1 до %d", ErrInvalidMerch, MaxRestockQuantity)
+	}
+	return nil
+}
+
+// ValidateMerchCategory проверяет длину категории товара
+func ValidateMerchCategory(category string) error {
+	if category == "" || len(category) > maxMerchCategoryLength {
+		return fmt.Errorf("%w: длина категории должна быть от 1 до %d символов", ErrInvalidMerch, maxMerchCategoryLength)
+	}
+	return nil
+}
+
+// MerchUpdate содержит изменяемые поля товара. Поля со значением nil не меняются.
+type MerchUpdate struct {
+	Price       *uint64
+	Description *string
+	Category    *string
+	Available   *bool
+	// Можно ли передавать купленный товар другим пользователям
+	Transferable *bool
+}
+
+// Validate проверяет новые значения полей товара
+func (u *MerchUpdate) Validate() error {
+	if u.Price == nil && u.Description == nil && u.Category == nil && u.Available == nil && u.Transferable == nil {
+		return fmt.Errorf("%w: не указаны изменяемые поля", ErrInvalidMerch)
+	}
+	if u.Price != nil {
+		if err := ValidateMerchPrice(*u.Price); err != nil {
+			return err
+		}
+	}
+	if u.Category != nil {
+		return ValidateMerchCategory(*u.Category)
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package domain
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -40,3 +42,78 @@ func TestNewMerch(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMerchName(t *testing.T) {
+	tests := []struct {
+		name      string
+		merchName string
+		wantErr   bool
+	}{
+		{name: "корректное название", merchName: "t-shirt"},
+		{name: "название с цифрами", merchName: "cup2"},
+		{name: "пустое название", merchName: "", wantErr: true},
+		{name: "слишком длинное название", merchName: strings.Repeat("a", maxMerchNameLength+1), wantErr: true},
+		{name: "заглавные буквы", merchName: "Hoody", wantErr: true},
+		{name: "начинается с дефиса", merchName: "-pen", wantErr: true},
+		{name: "пробел в названии", merchName: "red pen", wantErr: true},
+		{name: "кириллица", merchName: "ручка", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMerchName(tt.merchName)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidMerch)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMerch_Validate(t *testing.T) {
+	assert.NoError(t, (&Merch{Name: "cup", Price: MaxMerchPrice, Category: DefaultMerchCategory}).Validate())
+	assert.ErrorIs(t, (&Merch{Name: "cup", Price: 0, Category: DefaultMerchCategory}).Validate(), ErrInvalidMerch)
+	assert.ErrorIs(t, (&Merch{Name: "cup", Price: MaxMerchPrice + 1, Category: DefaultMerchCategory}).Validate(), ErrInvalidMerch)
+}
+
+func TestMerchUpdate_Validate(t *testing.T) {
+	price := uint64(50)
+	zero := uint64(0)
+	maxPrice, tooExpensive := uint64(MaxMerchPrice), uint64(MaxMerchPrice+1)
+	empty := ""
+	available := false
+
+	assert.NoError(t, (&MerchUpdate{Price: &price}).Validate())
+	assert.NoError(t, (&MerchUpdate{Available: &available}).Validate())
+	assert.ErrorIs(t, (&MerchUpdate{}).Validate(), ErrInvalidMerch)
+	assert.NoError(t, (&MerchUpdate{Price: &maxPrice}).Validate())
+	assert.ErrorIs(t, (&MerchUpdate{Price: &zero}).Validate(), ErrInvalidMerch)
+	assert.ErrorIs(t, (&MerchUpdate{Price: &tooExpensive}).Validate(), ErrInvalidMerch)
+	assert.ErrorIs(t, (&MerchUpdate{Category: &empty}).Validate(), ErrInvalidMerch)
+}
+
+func TestMerch_CanBePurchased(t *testing.T) {
+	retiredAt := time.Now()
+
+	assert.True(t, (&Merch{Available: true}).CanBePurchased())
+	assert.False(t, (&Merch{Available: false}).CanBePurchased())
+	assert.False(t, (&Merch{Available: true, RetiredAt: &retiredAt}).CanBePurchased())
+}
+
+func TestMerch_InStock(t *testing.T) {
+	zero, one := uint64(0), uint64(1)
+
+	assert.True(t, (&Merch{}).InStock())
+	assert.True(t, (&Merch{Stock: &one}).InStock())
+	assert.False(t, (&Merch{Stock: &zero}).InStock())
+	assert.False(t, (&Merch{}).IsLimited())
+	assert.True(t, (&Merch{Stock: &zero}).IsLimited())
+}
+
+func TestValidateRestockQuantity(t *testing.T) {
+	assert.NoError(t, ValidateRestockQuantity(1))
+	assert.NoError(t, ValidateRestockQuantity(MaxRestockQuantity))
+	assert.ErrorIs(t, ValidateRestockQuantity(0), ErrInvalidMerch)
+	assert.ErrorIs(t, ValidateRestockQuantity(MaxRestockQuantity+1), ErrInvalidMerch)
+}
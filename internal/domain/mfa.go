@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// Методы аутентификации для claim amr (RFC 8176)
+const (
+	AMRPassword = "pwd" // Вход по паролю
+	AMRMFA      = "mfa" // Пройден второй фактор
+)
+
+// MFAFactor описывает подключенный пользователем TOTP-аутентификатор
+type MFAFactor struct {
+	Username     string     // Владелец аутентификатора
+	Secret       string     // Секрет TOTP в кодировке base32
+	Enabled      bool       // Подключение подтверждено кодом
+	LastUsedStep *int64     // Последний использованный временной шаг, защищает от повторного использования кода
+	CreatedAt    time.Time  // Время начала подключения
+	ConfirmedAt  *time.Time // Время подтверждения подключения
+}
+
+// MFAEnrollment содержит данные для добавления секрета в приложение-аутентификатор
+type MFAEnrollment struct {
+	Secret string // Секрет в кодировке base32 для ручного ввода
+	URI    string // otpauth-ссылка для QR-кода
+}
+
+// HasAMR проверяет, содержит ли список методов аутентификации указанный метод
+func HasAMR(amr []string, method string) bool {
+	for _, m := range amr {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// OrderStatus определяет этап выдачи заказа
+type OrderStatus string
+
+const (
+	OrderStatusPlaced         OrderStatus = "placed"           // Заказ оплачен и ожидает сборки
+	OrderStatusReadyForPickup OrderStatus = "ready_for_pickup" // Товар собран и ждет покупателя
+	OrderStatusDelivered      OrderStatus = "delivered"        // Товар выдан покупателю
+	OrderStatusCancelled      OrderStatus = "cancelled"        // Заказ отменен
+)
+
+const (
+	// DefaultOrderPageSize количество заказов в списке по умолчанию
+	DefaultOrderPageSize = 50
+	// MaxOrderPageSize максимальное количество заказов в списке
+	MaxOrderPageSize = 100
+)
+
+// orderTransitions перечисляет допустимые переходы между статусами заказа.
+// Выданный и отмененный заказы больше не меняются.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPlaced:         {OrderStatusReadyForPickup, OrderStatusCancelled},
+	OrderStatusReadyForPickup: {OrderStatusDelivered, OrderStatusCancelled},
+}
+
+// ParseOrderStatus проверяет, что строка является известным статусом заказа
+func ParseOrderStatus(status string) (OrderStatus, error) {
+	switch OrderStatus(status) {
+	case OrderStatusPlaced, OrderStatusReadyForPickup, OrderStatusDelivered, OrderStatusCancelled:
+		return OrderStatus(status), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidOrderStatus, status)
+	}
+}
+
+// CanTransitionTo проверяет, что заказ можно перевести из текущего статуса в next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Order представляет оплаченный заказ
+type Order struct {
+	Id        int64
+	Username  string
+	Recipient string // Получатель подарка; пусто — товар выдается покупателю
+	Total     uint64
+	Discount  uint64 // Скидка по промокоду, уже учтенная в Total
+	Status    OrderStatus
+	Items     []*OrderItem
+	History   []*OrderStatusChange // Заполняется только при запросе одного заказа
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OrderItem представляет позицию заказа
+type OrderItem struct {
+	ItemName  string
+	Quantity  uint64
+	UnitPrice uint64
+}
+
+// OrderStatusChange представляет запись журнала изменений статуса заказа
+type OrderStatusChange struct {
+	OrderId   int64
+	From      OrderStatus // Пустой для записи о создании заказа
+	To        OrderStatus
+	ChangedBy string
+	Comment   string
+	ChangedAt time.Time
+}
+
+// OrderFilter задает условия выборки заказов. Пустые поля не ограничивают выборку.
+type OrderFilter struct {
+	Username string
+	Status   OrderStatus
+	Limit    int
+}
+
+// Validate проверяет параметры фильтра
+func (f *OrderFilter) Validate() error {
+	if f.Status != "" {
+		if _, err := ParseOrderStatus(string(f.Status)); err != nil {
+			return ErrInvalidFilter
+		}
+	}
+	if f.Limit < 0 {
+		return ErrInvalidFilter
+	}
+	return nil
+}
+
+// NewOrder создает заказ из позиций корзины и вычисляет его стоимость
+func NewOrder(username string, items []*CartItem, now time.Time) (*Order, error) {
+	if len(items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	order := newPlacedOrder(username, now)
+	for _, item := range items {
+		order.Items = append(order.Items, &OrderItem{
+			ItemName:  item.ItemName,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+
+	total, err := totalOf(order.Purchases())
+	if err != nil {
+		return nil, err
+	}
+	order.Total = total
+	return order, nil
+}
+
+// NewPurchaseOrder создает заказ из одной покупки
+func NewPurchaseOrder(purchase *Purchase, now time.Time) (*Order, error) {
+	total, err := purchase.Total()
+	if err != nil {
+		return nil, err
+	}
+
+	order := newPlacedOrder(purchase.Username, now)
+	order.Total = total
+	order.Discount = purchase.Discount
+	order.Items = []*OrderItem{{
+		ItemName:  purchase.ItemName,
+		Quantity:  purchase.Quantity,
+		UnitPrice: purchase.UnitPrice,
+	}}
+	return order, nil
+}
+
+// NewGiftOrder создает заказ из подарка: оплачивает отправитель, товар выдается получателю
+func NewGiftOrder(gift *Gift, now time.Time) (*Order, error) {
+	order, err := NewPurchaseOrder(&gift.Purchase, now)
+	if err != nil {
+		return nil, err
+	}
+	order.Recipient = gift.Recipient
+	return order, nil
+}
+
+// Holder возвращает пользователя, которому выдается товар заказа
+func (o *Order) Holder() string {
+	if o.Recipient != "" {
+		return o.Recipient
+	}
+	return o.Username
+}
+
+func newPlacedOrder(username string, now time.Time) *Order {
+	return &Order{
+		Username:  username,
+		Status:    OrderStatusPlaced,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Purchases возвращает покупки, соответствующие позициям заказа
+func (o *Order) Purchases() []*Purchase {
+	purchases := make([]*Purchase, 0, len(o.Items))
+	for _, item := range o.Items {
+		purchases = append(purchases, &Purchase{Username: o.Username, ItemName: item.ItemName, Quantity: item.Quantity, UnitPrice: item.UnitPrice})
+	}
+	return purchases
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOrder(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("стоимость заказа", func(t *testing.T) {
+		order, err := NewOrder("buyer", []*CartItem{
+			{ItemName: "cup", Quantity: 2, UnitPrice: 20},
+			{ItemName: "pen", Quantity: 1, UnitPrice: 10},
+		}, now)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(50), order.Total)
+		assert.Equal(t, now, order.CreatedAt)
+		require.Len(t, order.Purchases(), 2)
+		assert.Equal(t, &Purchase{Username: "buyer", ItemName: "cup", Quantity: 2, UnitPrice: 20}, order.Purchases()[0])
+	})
+
+	t.Run("пустая корзина", func(t *testing.T) {
+		_, err := NewOrder("buyer", nil, now)
+		assert.ErrorIs(t, err, ErrCartEmpty)
+	})
+
+	t.Run("переполнение стоимости", func(t *testing.T) {
+		_, err := NewOrder("buyer", []*CartItem{
+			{ItemName: "cup", Quantity: 1, UnitPrice: math.MaxInt64},
+			{ItemName: "pen", Quantity: 1, UnitPrice: 1},
+		}, now)
+		assert.ErrorIs(t, err, ErrInvalidQuantity)
+	})
+}
+
+func TestNewPurchaseOrder(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	order, err := NewPurchaseOrder(&Purchase{Username: "buyer", ItemName: "cup", Quantity: 3, UnitPrice: 20}, now)
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusPlaced, order.Status)
+	assert.Equal(t, uint64(60), order.Total)
+	assert.Equal(t, []*OrderItem{{ItemName: "cup", Quantity: 3, UnitPrice: 20}}, order.Items)
+}
+
+func TestNewGiftOrder(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	gift := &Gift{Purchase: Purchase{Username: "alice", ItemName: "cup", Quantity: 2, UnitPrice: 20}, Recipient: "bob"}
+
+	order, err := NewGiftOrder(gift, now)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", order.Username)
+	assert.Equal(t, uint64(40), order.Total)
+	assert.Equal(t, "bob", order.Holder())
+
+	purchase, err := NewPurchaseOrder(&gift.Purchase, now)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", purchase.Holder())
+}
+
+func TestOrderStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name string
+		from OrderStatus
+		to   OrderStatus
+		want bool
+	}{
+		{name: "оплаченный заказ собран", from: OrderStatusPlaced, to: OrderStatusReadyForPickup, want: true},
+		{name: "оплаченный заказ отменен", from: OrderStatusPlaced, to: OrderStatusCancelled, want: true},
+		{name: "собранный заказ выдан", from: OrderStatusReadyForPickup, to: OrderStatusDelivered, want: true},
+		{name: "собранный заказ отменен", from: OrderStatusReadyForPickup, to: OrderStatusCancelled, want: true},
+		{name: "выдача без сборки", from: OrderStatusPlaced, to: OrderStatusDelivered},
+		{name: "возврат к предыдущему статусу", from: OrderStatusReadyForPickup, to: OrderStatusPlaced},
+		{name: "выданный заказ не отменяется", from: OrderStatusDelivered, to: OrderStatusCancelled},
+		{name: "отмененный заказ не меняется", from: OrderStatusCancelled, to: OrderStatusReadyForPickup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	status, err := ParseOrderStatus("ready_for_pickup")
+	require.NoError(t, err)
+	assert.Equal(t, OrderStatusReadyForPickup, status)
+
+	_, err = ParseOrderStatus("lost")
+	assert.ErrorIs(t, err, ErrInvalidOrderStatus)
+}
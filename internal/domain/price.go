@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// PriceSchedule описывает запланированную цену товара, например временную распродажу.
+// Пока расписание действует, оно заменяет базовую цену товара.
+type PriceSchedule struct {
+	Id            int64
+	ItemName      string
+	Price         uint64
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time // Окончание действия; nil — действует до отмены
+	Priority      int        // При пересечении расписаний применяется расписание с большим приоритетом
+	CreatedBy     string
+	CreatedAt     time.Time
+	CancelledAt   *time.Time // Время отмены; отмененное расписание остается в истории цен
+}
+
+// Validate проверяет параметры расписания. Расписание не может начинаться в прошлом,
+// чтобы не менять цену, по которой уже совершены покупки.
+func (s *PriceSchedule) Validate(now time.Time) error {
+	if err := ValidateMerchName(s.ItemName); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPriceSchedule, err)
+	}
+	if s.Price == 0 || s.Price > MaxMerchPrice {
+		return fmt.Errorf("%w: цена должна быть от 1 до %d", ErrInvalidPriceSchedule, MaxMerchPrice)
+	}
+	if s.EffectiveFrom.Before(now) {
+		return fmt.Errorf("%w: начало действия не может быть в прошлом", ErrInvalidPriceSchedule)
+	}
+	if s.EffectiveTo != nil && !s.EffectiveTo.After(s.EffectiveFrom) {
+		return fmt.Errorf("%w: окончание действия должно быть позже начала", ErrInvalidPriceSchedule)
+	}
+	return nil
+}
+
+// ActiveAt проверяет, действует ли расписание в момент at
+func (s *PriceSchedule) ActiveAt(at time.Time) bool {
+	if at.Before(s.EffectiveFrom) {
+		return false
+	}
+	if s.EffectiveTo != nil && !at.Before(*s.EffectiveTo) {
+		return false
+	}
+	return s.CancelledAt == nil || at.Before(*s.CancelledAt)
+}
+
+// outranks проверяет, важнее ли расписание другого: сначала по приоритету,
+// затем более позднее начало действия, затем более позднее создание
+func (s *PriceSchedule) outranks(other *PriceSchedule) bool {
+	if s.Priority != other.Priority {
+		return s.Priority > other.Priority
+	}
+	if !s.EffectiveFrom.Equal(other.EffectiveFrom) {
+		return s.EffectiveFrom.After(other.EffectiveFrom)
+	}
+	return s.Id > other.Id
+}
+
+// EffectivePrice возвращает цену в момент at: цену самого важного из действующих расписаний
+// или базовую цену, если ни одно расписание не действует
+func EffectivePrice(base uint64, schedules []*PriceSchedule, at time.Time) uint64 {
+	var best *PriceSchedule
+	for _, s := range schedules {
+		if s.ActiveAt(at) && (best == nil || s.outranks(best)) {
+			best = s
+		}
+	}
+	if best == nil {
+		return base
+	}
+	return best.Price
+}
+
+// PriceAt возвращает цену товара в момент at с учетом расписаний
+func (m *Merch) PriceAt(at time.Time) uint64 {
+	return EffectivePrice(m.Price, m.PriceSchedules, at)
+}
+
+// PricedAt возвращает копию товара с ценой, действующей в момент at.
+// Базовая цена сохраняется в RegularPrice.
+func (m *Merch) PricedAt(at time.Time) *Merch {
+	priced := *m
+	priced.RegularPrice = m.Price
+	priced.Price = m.PriceAt(at)
+	return &priced
+}
+
+// PriceChange фиксирует изменение базовой цены товара
+type PriceChange struct {
+	Price     uint64
+	ChangedAt time.Time
+}
+
+// PriceHistory содержит изменения базовой цены и все расписания товара,
+// включая завершенные и отмененные, чтобы восстановить цену на любой момент
+type PriceHistory struct {
+	ItemName  string
+	Changes   []*PriceChange // Упорядочены по времени изменения
+	Schedules []*PriceSchedule
+}
+
+// PriceAt возвращает цену, действовавшую в момент at. Второе значение равно false,
+// если на этот момент базовая цена неизвестна.
+func (h *PriceHistory) PriceAt(at time.Time) (uint64, bool) {
+	var base *PriceChange
+	for _, c := range h.Changes {
+		if c.ChangedAt.After(at) {
+			break
+		}
+		base = c
+	}
+	if base == nil {
+		return 0, false
+	}
+	return EffectivePrice(base.Price, h.Schedules, at), true
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceSchedule_Validate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := now.Add(time.Hour)
+	beforeStart := now.Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		schedule PriceSchedule
+		wantErr  bool
+	}{
+		{name: "распродажа на час", schedule: PriceSchedule{ItemName: "cup", Price: 15, EffectiveFrom: now, EffectiveTo: &end}},
+		{name: "бессрочная цена", schedule: PriceSchedule{ItemName: "cup", Price: 25, EffectiveFrom: now.Add(time.Hour)}},
+		{name: "нулевая цена", schedule: PriceSchedule{ItemName: "cup", EffectiveFrom: now}, wantErr: true},
+		{name: "максимальная цена", schedule: PriceSchedule{ItemName: "cup", Price: MaxMerchPrice, EffectiveFrom: now}},
+		{name: "цена больше максимальной", schedule: PriceSchedule{ItemName: "cup", Price: MaxMerchPrice + 1, EffectiveFrom: now}, wantErr: true},
+		{name: "начало в прошлом", schedule: PriceSchedule{ItemName: "cup", Price: 15, EffectiveFrom: beforeStart}, wantErr: true},
+		{name: "окончание раньше начала", schedule: PriceSchedule{ItemName: "cup", Price: 15, EffectiveFrom: end, EffectiveTo: &now}, wantErr: true},
+		{name: "недопустимое название", schedule: PriceSchedule{ItemName: "Cup", Price: 15, EffectiveFrom: now}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate(now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPriceSchedule)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEffectivePrice(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	saleEnd := start.Add(48 * time.Hour)
+	cancelledAt := start.Add(12 * time.Hour)
+
+	sale := &PriceSchedule{Id: 1, Price: 15, EffectiveFrom: start, EffectiveTo: &saleEnd}
+	flash := &PriceSchedule{Id: 2, Price: 10, EffectiveFrom: start.Add(6 * time.Hour), EffectiveTo: &saleEnd, Priority: 1, CancelledAt: &cancelledAt}
+	raise := &PriceSchedule{Id: 3, Price: 30, EffectiveFrom: start.Add(24 * time.Hour)}
+	schedules := []*PriceSchedule{sale, flash, raise}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want uint64
+	}{
+		{name: "до начала расписаний", at: start.Add(-time.Second), want: 20},
+		{name: "распродажа", at: start, want: 15},
+		{name: "больший приоритет", at: start.Add(6 * time.Hour), want: 10},
+		{name: "отмененное расписание не действует", at: cancelledAt, want: 15},
+		{name: "при равном приоритете действует более позднее", at: start.Add(24 * time.Hour), want: 30},
+		{name: "после окончания распродажи", at: saleEnd, want: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EffectivePrice(20, schedules, tt.at))
+		})
+	}
+}
+
+func TestMerch_PricedAt(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	item := &Merch{Name: "cup", Price: 20, PriceSchedules: []*PriceSchedule{{Id: 1, Price: 15, EffectiveFrom: now}}}
+
+	priced := item.PricedAt(now)
+	assert.Equal(t, uint64(15), priced.Price)
+	assert.Equal(t, uint64(20), priced.RegularPrice)
+	assert.Equal(t, uint64(20), item.Price, "исходный товар не меняется")
+}
+
+func TestPriceHistory_PriceAt(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	raised := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	saleEnd := raised.Add(time.Hour)
+	history := &PriceHistory{
+		ItemName: "cup",
+		Changes:  []*PriceChange{{Price: 20, ChangedAt: created}, {Price: 25, ChangedAt: raised}},
+		Schedules: []*PriceSchedule{
+			{Id: 1, Price: 18, EffectiveFrom: raised, EffectiveTo: &saleEnd},
+		},
+	}
+
+	_, ok := history.PriceAt(created.Add(-time.Second))
+	assert.False(t, ok)
+
+	price, ok := history.PriceAt(created.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(20), price)
+
+	price, _ = history.PriceAt(raised)
+	assert.Equal(t, uint64(18), price)
+
+	price, _ = history.PriceAt(saleEnd)
+	assert.Equal(t, uint64(25), price)
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const maxPromoCodeLength = 32
+
+// PromoDiscountType определяет, как вычисляется скидка по промокоду
+type PromoDiscountType string
+
+const (
+	PromoDiscountPercent PromoDiscountType = "percent" // Процент от стоимости покупки
+	PromoDiscountFixed   PromoDiscountType = "fixed"   // Фиксированная сумма в монетах
+)
+
+// PromoCode описывает промокод на скидку при покупке товара
+type PromoCode struct {
+	Code           string
+	DiscountType   PromoDiscountType
+	DiscountValue  uint64     // Процент скидки или сумма в монетах
+	ItemName       *string    // Товар, на который действует код; nil — любой товар
+	Category       *string    // Категория товаров, на которую действует код; nil — любая категория
+	ValidFrom      *time.Time // Начало действия; nil — действует сразу
+	ValidUntil     *time.Time // Окончание действия; nil — бессрочный
+	MaxRedemptions *uint64    // Общий лимит использований; nil — без ограничения
+	MaxPerUser     *uint64    // Лимит использований одним пользователем; nil — без ограничения
+	Redemptions    uint64     // Сколько раз код уже использован
+	CreatedAt      time.Time
+}
+
+// NormalizePromoCode приводит промокод к каноническому виду, чтобы регистр при вводе не имел значения
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate проверяет параметры промокода
+func (p *PromoCode) Validate() error {
+	if p.Code == "" || len(p.Code) > maxPromoCodeLength {
+		return fmt.Errorf("%w: длина кода должна быть от 1 до %d символов", ErrInvalidPromoCode, maxPromoCodeLength)
+	}
+	for _, r := range p.Code {
+		if r >= unicode.MaxASCII || !(unicode.IsUpper(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			return fmt.Errorf("%w: недопустимый символ %q в коде", ErrInvalidPromoCode, r)
+		}
+	}
+
+	switch p.DiscountType {
+	case PromoDiscountPercent:
+		if p.DiscountValue == 0 || p.DiscountValue > 100 {
+			return fmt.Errorf("%w: процент скидки должен быть от 1 до 100", ErrInvalidPromoCode)
+		}
+	case PromoDiscountFixed:
+		if p.DiscountValue == 0 {
+			return fmt.Errorf("%w: сумма скидки должна быть положительной", ErrInvalidPromoCode)
+		}
+	default:
+		return fmt.Errorf("%w: неизвестный тип скидки %q", ErrInvalidPromoCode, p.DiscountType)
+	}
+
+	if p.ItemName != nil {
+		if err := ValidateMerchName(*p.ItemName); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPromoCode, err)
+		}
+	}
+	if p.Category != nil {
+		if err := ValidateMerchCategory(*p.Category); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPromoCode, err)
+		}
+	}
+	if p.ValidFrom != nil && p.ValidUntil != nil && !p.ValidUntil.After(*p.ValidFrom) {
+		return fmt.Errorf("%w: окончание действия должно быть позже начала", ErrInvalidPromoCode)
+	}
+	if p.MaxRedemptions != nil && *p.MaxRedemptions == 0 {
+		return fmt.Errorf("%w: общий лимит использований должен быть положительным", ErrInvalidPromoCode)
+	}
+	if p.MaxPerUser != nil && *p.MaxPerUser == 0 {
+		return fmt.Errorf("%w: лимит использований на пользователя должен быть положительным", ErrInvalidPromoCode)
+	}
+	return nil
+}
+
+// ActiveAt проверяет, действует ли промокод в момент now
+func (p *PromoCode) ActiveAt(now time.Time) bool {
+	if p.ValidFrom != nil && now.Before(*p.ValidFrom) {
+		return false
+	}
+	return p.ValidUntil == nil || now.Before(*p.ValidUntil)
+}
+
+// AppliesTo проверяет, распространяется ли промокод на товар
+func (p *PromoCode) AppliesTo(merch *Merch) bool {
+	if p.ItemName != nil && *p.ItemName != merch.Name {
+		return false
+	}
+	return p.Category == nil || *p.Category == merch.Category
+}
+
+// Discount возвращает скидку для покупки стоимостью subtotal. Скидка не превышает стоимость покупки,
+// процентная скидка округляется вниз.
+func (p *PromoCode) Discount(subtotal uint64) uint64 {
+	if p.DiscountType == PromoDiscountPercent {
+		// Делим по частям, чтобы произведение не переполнилось
+		return subtotal/100*p.DiscountValue + subtotal%100*p.DiscountValue/100
+	}
+	if p.DiscountValue > subtotal {
+		return subtotal
+	}
+	return p.DiscountValue
+}
+
+// CheckRedeemable проверяет, что промокод действует и его лимиты не исчерпаны.
+// userRedemptions — сколько раз код уже использовал покупатель.
+func (p *PromoCode) CheckRedeemable(now time.Time, userRedemptions uint64) error {
+	if !p.ActiveAt(now) {
+		return fmt.Errorf("%w: срок действия промокода истек или еще не начался", ErrPromoNotApplicable)
+	}
+	if p.MaxRedemptions != nil && p.Redemptions >= *p.MaxRedemptions {
+		return fmt.Errorf("%w: лимит использований исчерпан", ErrPromoCodeExhausted)
+	}
+	if p.MaxPerUser != nil && userRedemptions >= *p.MaxPerUser {
+		return fmt.Errorf("%w: вы уже использовали этот промокод", ErrPromoCodeExhausted)
+	}
+	return nil
+}
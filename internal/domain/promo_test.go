@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePromoCode(t *testing.T) {
+	assert.Equal(t, "HOODIES20", NormalizePromoCode("  hoodies20 "))
+}
+
+func TestPromoCode_Validate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(24 * time.Hour)
+	item := "hoody"
+	zero := uint64(0)
+
+	tests := []struct {
+		name    string
+		promo   PromoCode
+		wantErr bool
+	}{
+		{name: "процентная скидка", promo: PromoCode{Code: "HOODIES20", DiscountType: PromoDiscountPercent, DiscountValue: 20, ItemName: &item}},
+		{name: "фиксированная скидка", promo: PromoCode{Code: "FIRST-10", DiscountType: PromoDiscountFixed, DiscountValue: 10, ValidFrom: &now, ValidUntil: &later}},
+		{name: "пустой код", promo: PromoCode{DiscountType: PromoDiscountFixed, DiscountValue: 10}, wantErr: true},
+		{name: "строчные буквы в коде", promo: PromoCode{Code: "first", DiscountType: PromoDiscountFixed, DiscountValue: 10}, wantErr: true},
+		{name: "процент больше 100", promo: PromoCode{Code: "ALL", DiscountType: PromoDiscountPercent, DiscountValue: 101}, wantErr: true},
+		{name: "неизвестный тип скидки", promo: PromoCode{Code: "GIFT", DiscountType: "gift", DiscountValue: 1}, wantErr: true},
+		{name: "окончание раньше начала", promo: PromoCode{Code: "LATE", DiscountType: PromoDiscountFixed, DiscountValue: 10, ValidFrom: &later, ValidUntil: &now}, wantErr: true},
+		{name: "нулевой лимит", promo: PromoCode{Code: "NONE", DiscountType: PromoDiscountFixed, DiscountValue: 10, MaxPerUser: &zero}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.promo.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPromoCode)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPromoCode_Discount(t *testing.T) {
+	percent := &PromoCode{DiscountType: PromoDiscountPercent, DiscountValue: 15}
+	assert.Equal(t, uint64(45), percent.Discount(300))
+	assert.Equal(t, uint64(1), percent.Discount(10), "скидка округляется вниз")
+
+	fixed := &PromoCode{DiscountType: PromoDiscountFixed, DiscountValue: 10}
+	assert.Equal(t, uint64(10), fixed.Discount(80))
+	assert.Equal(t, uint64(5), fixed.Discount(5), "скидка не превышает стоимость")
+}
+
+func TestPromoCode_AppliesTo(t *testing.T) {
+	hoody := &Merch{Name: "hoody", Category: "clothes"}
+	cup := &Merch{Name: "cup", Category: "tableware"}
+	item, category := "hoody", "clothes"
+
+	assert.True(t, (&PromoCode{}).AppliesTo(cup))
+	assert.True(t, (&PromoCode{ItemName: &item}).AppliesTo(hoody))
+	assert.False(t, (&PromoCode{ItemName: &item}).AppliesTo(cup))
+	assert.True(t, (&PromoCode{Category: &category}).AppliesTo(hoody))
+	assert.False(t, (&PromoCode{Category: &category}).AppliesTo(cup))
+}
+
+func TestPromoCode_CheckRedeemable(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	one, hundred := uint64(1), uint64(100)
+
+	t.Run("код действует", func(t *testing.T) {
+		promo := &PromoCode{MaxRedemptions: &hundred, MaxPerUser: &one, Redemptions: 99}
+		assert.NoError(t, promo.CheckRedeemable(now, 0))
+	})
+
+	t.Run("срок действия истек", func(t *testing.T) {
+		promo := &PromoCode{ValidUntil: &past}
+		assert.ErrorIs(t, promo.CheckRedeemable(now, 0), ErrPromoNotApplicable)
+	})
+
+	t.Run("общий лимит исчерпан", func(t *testing.T) {
+		promo := &PromoCode{MaxRedemptions: &hundred, Redemptions: 100}
+		assert.ErrorIs(t, promo.CheckRedeemable(now, 0), ErrPromoCodeExhausted)
+	})
+
+	t.Run("пользователь уже использовал код", func(t *testing.T) {
+		promo := &PromoCode{MaxPerUser: &one, Redemptions: 5}
+		assert.ErrorIs(t, promo.CheckRedeemable(now, 1), ErrPromoCodeExhausted)
+	})
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// MaxPurchaseQuantity ограничивает количество единиц товара в одной покупке
+const MaxPurchaseQuantity = 1000
+
+// Purchase описывает покупку нескольких единиц одного товара
+type Purchase struct {
+	Username  string // Покупатель
+	ItemName  string // Название товара
+	Quantity  uint64 // Количество единиц
+	UnitPrice uint64 // Цена одной единицы
+	PromoCode string // Примененный промокод; пусто — покупка без скидки
+	Discount  uint64 // Скидка по промокоду на всю покупку
+}
+
+// NewPurchase создает покупку одной или нескольких единиц товара
+func NewPurchase(username string, merch *Merch, quantity uint64) *Purchase {
+	return &Purchase{
+		Username:  username,
+		ItemName:  merch.Name,
+		Quantity:  quantity,
+		UnitPrice: merch.Price,
+	}
+}
+
+// ValidatePurchaseQuantity проверяет количество единиц товара в покупке
+func ValidatePurchaseQuantity(quantity uint64) error {
+	if quantity == 0 || quantity > MaxPurchaseQuantity {
+		return fmt.Errorf("%w: количество должно быть от 1 до %d", ErrInvalidQuantity, MaxPurchaseQuantity)
+	}
+	return nil
+}
+
+// Subtotal возвращает стоимость покупки без скидки. Возвращает ошибку, если стоимость не помещается в баланс пользователя.
+func (p *Purchase) Subtotal() (uint64, error) {
+	hi, total := bits.Mul64(p.UnitPrice, p.Quantity)
+	if hi != 0 || total > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: стоимость покупки слишком велика", ErrInvalidQuantity)
+	}
+	return total, nil
+}
+
+// Total возвращает стоимость покупки с учетом скидки по промокоду
+func (p *Purchase) Total() (uint64, error) {
+	subtotal, err := p.Subtotal()
+	if err != nil {
+		return 0, err
+	}
+	if p.Discount > subtotal {
+		return 0, nil
+	}
+	return subtotal - p.Discount, nil
+}
+
+// ApplyPromo применяет промокод к покупке товара merch и вычисляет скидку.
+// Лимиты использований проверяются при выполнении покупки под блокировкой промокода.
+func (p *Purchase) ApplyPromo(promo *PromoCode, merch *Merch, now time.Time) error {
+	if !promo.ActiveAt(now) {
+		return fmt.Errorf("%w: срок действия промокода истек или еще не начался", ErrPromoNotApplicable)
+	}
+	if !promo.AppliesTo(merch) {
+		return fmt.Errorf("%w: промокод не действует на товар %s", ErrPromoNotApplicable, merch.Name)
+	}
+
+	subtotal, err := p.Subtotal()
+	if err != nil {
+		return err
+	}
+	p.PromoCode = promo.Code
+	p.Discount = promo.Discount(subtotal)
+	return nil
+}
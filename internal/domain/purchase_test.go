@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurchase_Total(t *testing.T) {
+	tests := []struct {
+		name      string
+		unitPrice uint64
+		quantity  uint64
+		want      uint64
+		wantErr   bool
+	}{
+		{name: "одна единица", unitPrice: 80, quantity: 1, want: 80},
+		{name: "несколько единиц", unitPrice: 80, quantity: 3, want: 240},
+		{name: "переполнение uint64", unitPrice: math.MaxUint64, quantity: 2, wantErr: true},
+		{name: "стоимость больше максимального баланса", unitPrice: math.MaxInt64/2 + 1, quantity: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Purchase{Username: "buyer", ItemName: "t-shirt", Quantity: tt.quantity, UnitPrice: tt.unitPrice}
+			got, err := p.Total()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidQuantity)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidatePurchaseQuantity(t *testing.T) {
+	assert.NoError(t, ValidatePurchaseQuantity(1))
+	assert.NoError(t, ValidatePurchaseQuantity(MaxPurchaseQuantity))
+	assert.ErrorIs(t, ValidatePurchaseQuantity(0), ErrInvalidQuantity)
+	assert.ErrorIs(t, ValidatePurchaseQuantity(MaxPurchaseQuantity+1), ErrInvalidQuantity)
+}
+
+func TestPurchase_ApplyPromo(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	hoody := &Merch{Name: "hoody", Price: 300, Category: "clothes"}
+	category := "clothes"
+
+	t.Run("скидка учитывается в стоимости", func(t *testing.T) {
+		p := NewPurchase("buyer", hoody, 2)
+		promo := &PromoCode{Code: "HOODIES20", DiscountType: PromoDiscountPercent, DiscountValue: 20, Category: &category}
+
+		require.NoError(t, p.ApplyPromo(promo, hoody, now))
+		assert.Equal(t, "HOODIES20", p.PromoCode)
+		assert.Equal(t, uint64(120), p.Discount)
+
+		total, err := p.Total()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(480), total)
+	})
+
+	t.Run("код не действует на товар", func(t *testing.T) {
+		cup := &Merch{Name: "cup", Price: 20, Category: "tableware"}
+		p := NewPurchase("buyer", cup, 1)
+		promo := &PromoCode{Code: "HOODIES20", DiscountType: PromoDiscountPercent, DiscountValue: 20, Category: &category}
+
+		assert.ErrorIs(t, p.ApplyPromo(promo, cup, now), ErrPromoNotApplicable)
+		assert.Zero(t, p.Discount)
+	})
+
+	t.Run("код еще не начал действовать", func(t *testing.T) {
+		tomorrow := now.Add(24 * time.Hour)
+		p := NewPurchase("buyer", hoody, 1)
+		promo := &PromoCode{Code: "LATER", DiscountType: PromoDiscountFixed, DiscountValue: 10, ValidFrom: &tomorrow}
+
+		assert.ErrorIs(t, p.ApplyPromo(promo, hoody, now), ErrPromoNotApplicable)
+	})
+}
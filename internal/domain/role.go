@@ -0,0 +1,23 @@
+package domain
+
+import "fmt"
+
+// Role определяет набор прав пользователя
+type Role string
+
+const (
+	RoleEmployee Role = "employee" // Обычный сотрудник
+	RoleAdmin    Role = "admin"    // Администратор магазина
+	RoleService  Role = "service"  // Сервисная учетная запись, входит только по API-ключу
+)
+
+// ParseRole проверяет, что строка является ролью, которую можно назначить сотруднику.
+// Роль service назначается только при создании сервисной учетной записи.
+func ParseRole(role string) (Role, error) {
+	switch Role(role) {
+	case RoleEmployee, RoleAdmin:
+		return Role(role), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRole(t *testing.T) {
+	t.Run("известные роли", func(t *testing.T) {
+		role, err := ParseRole("admin")
+		require.NoError(t, err)
+		require.Equal(t, RoleAdmin, role)
+
+		role, err = ParseRole("employee")
+		require.NoError(t, err)
+		require.Equal(t, RoleEmployee, role)
+	})
+
+	t.Run("неизвестная роль", func(t *testing.T) {
+		_, err := ParseRole("superuser")
+		require.ErrorIs(t, err, ErrInvalidRole)
+	})
+
+	t.Run("роль сервисной учетной записи не назначается", func(t *testing.T) {
+		_, err := ParseRole("service")
+		require.ErrorIs(t, err, ErrInvalidRole)
+	})
+}
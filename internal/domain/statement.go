@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// StatementFormat определяет формат выгрузки выписки
+type StatementFormat string
+
+const (
+	// StatementFormatCSV выгружает выписку в CSV
+	StatementFormatCSV StatementFormat = "csv"
+	// StatementFormatJSONL выгружает выписку в JSON Lines
+	StatementFormatJSONL StatementFormat = "jsonl"
+)
+
+// StatementFilter задает период выписки и пользователя. Пустой Username означает всех сотрудников.
+// Период включает From и не включает To.
+type StatementFilter struct {
+	Username string
+	From     time.Time
+	To       time.Time
+}
+
+// Validate проверяет, что период выписки задан корректно
+func (f *StatementFilter) Validate() error {
+	if f.From.IsZero() || f.To.IsZero() || !f.From.Before(f.To) {
+		return ErrInvalidFilter
+	}
+	return nil
+}
+
+// StatementRow представляет строку выборки для выписки.
+// Балансы на начало и конец периода повторяются во всех строках пользователя,
+// Entry равен nil, если за период у пользователя не было операций.
+type StatementRow struct {
+	Username       string
+	OpeningBalance int64
+	ClosingBalance int64
+	Entry          *LedgerEntry
+}
+
+// ParseStatementFormat возвращает формат выписки по его названию. Пустое значение означает CSV.
+func ParseStatementFormat(s string) (StatementFormat, error) {
+	switch StatementFormat(s) {
+	case "", StatementFormatCSV:
+		return StatementFormatCSV, nil
+	case StatementFormatJSONL:
+		return StatementFormatJSONL, nil
+	default:
+		return "", ErrInvalidFilter
+	}
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// RefreshToken представляет refresh-токен, сохраненный в базе данных
+type RefreshToken struct {
+	Id        int64      // Идентификатор токена
+	TokenHash string     // SHA-256 хэш значения токена
+	Username  string     // Владелец токена
+	FamilyId  string     // Идентификатор семейства токенов, полученных ротацией
+	ExpiresAt time.Time  // Время истечения токена
+	CreatedAt time.Time  // Время выпуска токена
+	RevokedAt *time.Time // Время отзыва токена, nil если токен активен
+	Amr       []string   // Методы аутентификации, которыми открыто семейство
+}
+
+// NewRefreshToken создает новый refresh-токен
+func NewRefreshToken(tokenHash, username, familyId string, expiresAt time.Time) *RefreshToken {
+	return &RefreshToken{
+		TokenHash: tokenHash,
+		Username:  username,
+		FamilyId:  familyId,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+		Amr:       []string{AMRPassword},
+	}
+}
+
+// IsRevoked проверяет, был ли токен отозван
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired проверяет, истек ли срок действия токена на момент now
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// MFAPendingTokenType - значение claim typ, отличающее токен ожидания второго фактора от access-токена
+const MFAPendingTokenType = "mfa_pending"
+
+// TokenPair содержит пару access и refresh токенов.
+// Если у пользователя подключен второй фактор, вместо пары выдается только MFAToken.
+type TokenPair struct {
+	AccessToken  string // Короткоживущий JWT
+	RefreshToken string // Непрозрачный refresh-токен
+	MFAToken     string // Токен ожидания второго фактора
+}
+
+// PasswordResetToken представляет одноразовый токен сброса пароля, выданный администратором
+type PasswordResetToken struct {
+	TokenHash string     // SHA-256 хэш значения токена
+	Username  string     // Пользователь, пароль которого можно сбросить
+	ExpiresAt time.Time  // Время истечения токена
+	CreatedAt time.Time  // Время выпуска токена
+	UsedAt    *time.Time // Время использования токена, nil если токен не использован
+}
+
+// NewPasswordResetToken создает новый токен сброса пароля
+func NewPasswordResetToken(tokenHash, username string, expiresAt time.Time) *PasswordResetToken {
+	return &PasswordResetToken{
+		TokenHash: tokenHash,
+		Username:  username,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
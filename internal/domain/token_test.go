@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenState(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		token       *RefreshToken
+		wantRevoked bool
+		wantExpired bool
+	}{
+		{
+			name:        "активный токен",
+			token:       &RefreshToken{ExpiresAt: now.Add(time.Hour)},
+			wantRevoked: false,
+			wantExpired: false,
+		},
+		{
+			name:        "истекший токен",
+			token:       &RefreshToken{ExpiresAt: now.Add(-time.Hour)},
+			wantRevoked: false,
+			wantExpired: true,
+		},
+		{
+			name:        "отозванный токен",
+			token:       &RefreshToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt},
+			wantRevoked: true,
+			wantExpired: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantRevoked, tt.token.IsRevoked())
+			assert.Equal(t, tt.wantExpired, tt.token.IsExpired(now))
+		})
+	}
+}
@@ -10,14 +10,31 @@ const (
 	TransactionTypePurchase TransactionType = "PURCHASE"
 	// TransactionTypeTransfer представляет перевод монет между пользователями
 	TransactionTypeTransfer TransactionType = "TRANSFER"
+	// TransactionTypeGrant представляет начисление монет сервисной учетной записью или администратором
+	TransactionTypeGrant TransactionType = "GRANT"
+	// TransactionTypeRefund представляет возврат монет за отмененный заказ
+	TransactionTypeRefund TransactionType = "REFUND"
+	// TransactionTypeGift представляет покупку товара в подарок другому пользователю
+	TransactionTypeGift TransactionType = "GIFT"
+	// TransactionTypeItemTransfer представляет передачу купленного товара другому пользователю
+	TransactionTypeItemTransfer TransactionType = "ITEM_TRANSFER"
+	// TransactionTypeMarketSale представляет оплату лота маркетплейса покупателем продавцу
+	TransactionTypeMarketSale TransactionType = "MARKET_SALE"
+	// TransactionTypeMarketFee представляет комиссию маркетплейса, которую продавец платит магазину
+	TransactionTypeMarketFee TransactionType = "MARKET_FEE"
 )
 
+// ShopAccount — системная учетная запись магазина: получатель оплаты покупок, отправитель возвратов и начислений
+const ShopAccount = "SHOP"
+
 // Transaction представляет транзакцию в системе
 type Transaction struct {
 	SenderName   string          // Имя отправителя
 	ReceiverName string          // Имя получателя
 	Amount       uint64          // Сумма транзакции
 	Type         TransactionType // Тип транзакции
+	ItemName     string          // Купленный товар для покупок
+	Quantity     uint64          // Количество купленных единиц для покупок
 	Timestamp    time.Time       // Время транзакции
 }
 
@@ -12,6 +12,7 @@ type User struct {
 	Username  string          // Имя пользователя
 	Password  []byte          // Хэш пароля
 	Coins     uint64          // Количество монет
+	Role      Role            // Роль пользователя
 	Inventory []UserInventory // Инвентарь пользователя
 }
 
@@ -24,6 +25,7 @@ func NewUser(username string, password []byte, coins uint64) *User {
 		Username: username,
 		Password: password,
 		Coins:    coins,
+		Role:     RoleEmployee,
 	}
 }
 
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// AdminHandler обрабатывает административные запросы
+type AdminHandler struct {
+	userService service.UserService
+	loginGuard  service.LoginGuard
+}
+
+// NewAdminHandler создает новый экземпляр административного обработчика
+func NewAdminHandler(userService service.UserService, loginGuard service.LoginGuard) *AdminHandler {
+	return &AdminHandler{
+		userService: userService,
+		loginGuard:  loginGuard,
+	}
+}
+
+// Unlock снимает блокировку входа с пользователя
+func (h *AdminHandler) Unlock(c *gin.Context) {
+	username := c.Param("username")
+	if username == "" {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Не указан пользователь")
+		return
+	}
+
+	if err := h.loginGuard.UnlockUser(c.Request.Context(), username); err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка снятия блокировки")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// SetRole назначает пользователю роль
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	var req model.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	role, err := domain.ParseRole(req.Role)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неизвестная роль")
+		return
+	}
+
+	err = h.userService.SetUserRole(c.Request.Context(), c.Param("username"), role)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Пользователь не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка назначения роли")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
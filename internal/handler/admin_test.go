@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUnlock(t *testing.T) {
+	t.Run("успешное снятие блокировки", func(t *testing.T) {
+		loginGuard := new(mockLoginGuard)
+		h := NewAdminHandler(&mockUserService{}, loginGuard)
+
+		loginGuard.On("UnlockUser", mock.Anything, "testuser").Return(nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("DELETE", "/admin/lockouts/testuser", http.NoBody)
+		c.Params = []gin.Param{{Key: "username", Value: "testuser"}}
+
+		h.Unlock(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		loginGuard.AssertExpectations(t)
+	})
+
+	t.Run("ошибка хранилища", func(t *testing.T) {
+		loginGuard := new(mockLoginGuard)
+		h := NewAdminHandler(&mockUserService{}, loginGuard)
+
+		loginGuard.On("UnlockUser", mock.Anything, "testuser").Return(errors.New("db error"))
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("DELETE", "/admin/lockouts/testuser", http.NoBody)
+		c.Params = []gin.Param{{Key: "username", Value: "testuser"}}
+
+		h.Unlock(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestSetRole(t *testing.T) {
+	t.Run("успешное назначение роли", func(t *testing.T) {
+		userService := new(mockUserService)
+		h := NewAdminHandler(userService, &mockLoginGuard{})
+
+		userService.On("SetUserRole", mock.Anything, "testuser", domain.RoleAdmin).Return(nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("PUT", "/admin/users/testuser/role", bytes.NewBufferString(`{"role":"admin"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = []gin.Param{{Key: "username", Value: "testuser"}}
+
+		h.SetRole(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("неизвестная роль", func(t *testing.T) {
+		userService := new(mockUserService)
+		h := NewAdminHandler(userService, &mockLoginGuard{})
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("PUT", "/admin/users/testuser/role", bytes.NewBufferString(`{"role":"root"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = []gin.Param{{Key: "username", Value: "testuser"}}
+
+		h.SetRole(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userService.AssertNotCalled(t, "SetUserRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("пользователь не найден", func(t *testing.T) {
+		userService := new(mockUserService)
+		h := NewAdminHandler(userService, &mockLoginGuard{})
+
+		userService.On("SetUserRole", mock.Anything, "nonexistent", domain.RoleAdmin).Return(domain.ErrUserNotFound)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("PUT", "/admin/users/nonexistent/role", bytes.NewBufferString(`{"role":"admin"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = []gin.Param{{Key: "username", Value: "nonexistent"}}
+
+		h.SetRole(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
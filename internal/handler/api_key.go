@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// APIKeyHandler обрабатывает запросы управления API-ключами сервисных учетных записей
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyHandler создает новый экземпляр обработчика API-ключей
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// Create выпускает API-ключ. Значение ключа возвращается только в этом ответе.
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	rawKey, key, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), req.Account, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidUsername):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrInvalidScope):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неизвестная область доступа")
+		case errors.Is(err, domain.ErrInvalidAPIKey):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Срок действия ключа уже истек")
+		case errors.Is(err, domain.ErrNotServiceAccount):
+			handleError(c, http.StatusConflict, ErrCodeUserAlreadyExists, "Имя занято пользователем, не являющимся сервисной учетной записью")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка выпуска API-ключа")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CreateAPIKeyResponse{
+		Key:            rawKey,
+		APIKeyResponse: apiKeyResponse(key),
+	})
+}
+
+// List возвращает API-ключи, при указании account — только ключи этой учетной записи
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context(), c.Query("account"))
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения API-ключей")
+		return
+	}
+
+	resp := make([]model.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, apiKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Revoke отзывает API-ключ
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный идентификатор ключа")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAPIKeyNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "API-ключ не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка отзыва API-ключа")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+func apiKeyResponse(key *domain.APIKey) model.APIKeyResponse {
+	return model.APIKeyResponse{
+		Id:         key.Id,
+		Account:    key.Account,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
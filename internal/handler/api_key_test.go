@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *mockAPIKeyService) CreateAPIKey(ctx context.Context, account, name string, scopes []string, expiresAt *time.Time) (string, *domain.APIKey, error) {
+	args := m.Called(ctx, account, name, scopes, expiresAt)
+	if args.Get(1) == nil {
+		return "", nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).(*domain.APIKey), args.Error(2)
+}
+
+func (m *mockAPIKeyService) ListAPIKeys(ctx context.Context, account string) ([]*domain.APIKey, error) {
+	args := m.Called(ctx, account)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	args := m.Called(ctx, rawKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("успешный выпуск", func(t *testing.T) {
+		apiKeyService := new(mockAPIKeyService)
+		h := NewAPIKeyHandler(apiKeyService)
+
+		apiKeyService.On("CreateAPIKey", mock.Anything, "hr-bot", "начисления", []string{"coins:grant"}, (*time.Time)(nil)).
+			Return("ak_0a1b2c3d_secret", &domain.APIKey{Id: 1, Account: "hr-bot", Prefix: "ak_0a1b2c3d", Scopes: []string{"coins:grant"}}, nil)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"account":"hr-bot","name":"начисления","scopes":["coins:grant"]}`)
+		c.Request = httptest.NewRequest("POST", "/admin/api-keys", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response model.CreateAPIKeyResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "ak_0a1b2c3d_secret", response.Key)
+		assert.Equal(t, "ak_0a1b2c3d", response.Prefix)
+		apiKeyService.AssertExpectations(t)
+	})
+
+	t.Run("неизвестная область доступа", func(t *testing.T) {
+		apiKeyService := new(mockAPIKeyService)
+		h := NewAPIKeyHandler(apiKeyService)
+
+		apiKeyService.On("CreateAPIKey", mock.Anything, "hr-bot", "", []string{"coins:burn"}, (*time.Time)(nil)).
+			Return("", nil, domain.ErrInvalidScope)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"account":"hr-bot","scopes":["coins:burn"]}`)
+		c.Request = httptest.NewRequest("POST", "/admin/api-keys", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestListAPIKeys(t *testing.T) {
+	apiKeyService := new(mockAPIKeyService)
+	h := NewAPIKeyHandler(apiKeyService)
+
+	apiKeyService.On("ListAPIKeys", mock.Anything, "hr-bot").
+		Return([]*domain.APIKey{{Id: 1, Account: "hr-bot", Prefix: "ak_0a1b2c3d", KeyHash: "hash"}}, nil)
+
+	c, w := setupTestContext()
+	c.Request = httptest.NewRequest("GET", "/admin/api-keys?account=hr-bot", http.NoBody)
+
+	h.List(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ak_0a1b2c3d")
+	assert.NotContains(t, w.Body.String(), "hash")
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	t.Run("успешный отзыв", func(t *testing.T) {
+		apiKeyService := new(mockAPIKeyService)
+		h := NewAPIKeyHandler(apiKeyService)
+
+		apiKeyService.On("RevokeAPIKey", mock.Anything, int64(1)).Return(nil)
+
+		c, w := setupTestContext()
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request = httptest.NewRequest("DELETE", "/admin/api-keys/1", http.NoBody)
+
+		h.Revoke(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ключ не найден", func(t *testing.T) {
+		apiKeyService := new(mockAPIKeyService)
+		h := NewAPIKeyHandler(apiKeyService)
+
+		apiKeyService.On("RevokeAPIKey", mock.Anything, int64(42)).Return(domain.ErrAPIKeyNotFound)
+
+		c, w := setupTestContext()
+		c.Params = gin.Params{{Key: "id", Value: "42"}}
+		c.Request = httptest.NewRequest("DELETE", "/admin/api-keys/42", http.NoBody)
+
+		h.Revoke(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+	"github.com/netscrawler/avito-shop/internal/signer"
+)
+
+// AuthHandler обрабатывает запросы управления сессиями
+type AuthHandler struct {
+	tokenService service.TokenService
+	signer       signer.Signer
+}
+
+// NewAuthHandler создает новый экземпляр обработчика сессий
+func NewAuthHandler(tokenService service.TokenService, signer signer.Signer) *AuthHandler {
+	return &AuthHandler{
+		tokenService: tokenService,
+		signer:       signer,
+	}
+}
+
+// JWKS возвращает открытые ключи для проверки токенов другими сервисами
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.signer.JWKS())
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req model.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	tokens, err := h.tokenService.RefreshTokens(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken), errors.Is(err, domain.ErrTokenReused):
+			handleError(c, http.StatusUnauthorized, ErrCodeInvalidToken, "Недействительный refresh-токен")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка обновления токена")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// Logout отзывает текущий access-токен и семейство переданного refresh-токена
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req model.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+			return
+		}
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	expiresAt := c.GetTime("tokenExpiresAt")
+	if expiresAt.IsZero() {
+		expiresAt = time.Now()
+	}
+
+	err := h.tokenService.RevokeTokens(c.Request.Context(), username, req.RefreshToken, c.GetString("jti"), expiresAt)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка выхода")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockTokenService struct {
+	mock.Mock
+}
+
+func (m *mockTokenService) IssueTokens(ctx context.Context, username string, role domain.Role, amr []string) (*domain.TokenPair, error) {
+	args := m.Called(ctx, username, role, amr)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TokenPair), args.Error(1)
+}
+
+func (m *mockTokenService) RefreshTokens(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	args := m.Called(ctx, refreshToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TokenPair), args.Error(1)
+}
+
+func (m *mockTokenService) RevokeTokens(ctx context.Context, username, refreshToken, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, username, refreshToken, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *mockTokenService) RevokeAllTokens(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
+
+func (m *mockTokenService) IssueMFAToken(username string) (string, error) {
+	args := m.Called(username)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockTokenService) ParseMFAToken(token string) (string, error) {
+	args := m.Called(token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockTokenService) IsTokenRevoked(ctx context.Context, username, jti string, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, username, jti, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestRefresh(t *testing.T) {
+	t.Run("успешное обновление токенов", func(t *testing.T) {
+		tokenService := new(mockTokenService)
+		h := NewAuthHandler(tokenService, nil)
+
+		tokenService.On("RefreshTokens", mock.Anything, "old-refresh").
+			Return(&domain.TokenPair{AccessToken: "new-access", RefreshToken: "new-refresh"}, nil)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"refreshToken":"old-refresh"}`)
+		c.Request = httptest.NewRequest("POST", "/auth/refresh", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Refresh(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "new-access", response["token"])
+		assert.Equal(t, "new-refresh", response["refreshToken"])
+		tokenService.AssertExpectations(t)
+	})
+
+	t.Run("повторное использование refresh-токена", func(t *testing.T) {
+		tokenService := new(mockTokenService)
+		h := NewAuthHandler(tokenService, nil)
+
+		tokenService.On("RefreshTokens", mock.Anything, "used-refresh").Return(nil, domain.ErrTokenReused)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"refreshToken":"used-refresh"}`)
+		c.Request = httptest.NewRequest("POST", "/auth/refresh", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Refresh(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidToken)
+		tokenService.AssertExpectations(t)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	tokenService := new(mockTokenService)
+	h := NewAuthHandler(tokenService, nil)
+
+	expiresAt := time.Now().Add(time.Minute)
+	tokenService.On("RevokeTokens", mock.Anything, "testuser", "refresh", "jti-1", expiresAt).Return(nil)
+
+	c, w := setupTestContext()
+	c.Set("username", "testuser")
+	c.Set("jti", "jti-1")
+	c.Set("tokenExpiresAt", expiresAt)
+	body := bytes.NewBufferString(`{"refreshToken":"refresh"}`)
+	c.Request = httptest.NewRequest("POST", "/auth/logout", body)
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.Logout(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	tokenService.AssertExpectations(t)
+}
+
+func TestJWKS(t *testing.T) {
+	key, err := signer.NewHMACKey("hmac", []byte("handler-test-secret-of-32-bytes!"))
+	assert.NoError(t, err)
+	keys, err := signer.New(key)
+	assert.NoError(t, err)
+	h := NewAuthHandler(new(mockTokenService), keys)
+
+	c, w := setupTestContext()
+	c.Request = httptest.NewRequest("GET", "/.well-known/jwks.json", http.NoBody)
+
+	h.JWKS(c)
+
+	// Секреты HMAC не публикуются
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"keys":[]}`, w.Body.String())
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// CartHandler обрабатывает запросы к корзине и оформление заказов
+type CartHandler struct {
+	idempotencyGuard
+	cartService service.CartService
+}
+
+// NewCartHandler создает новый экземпляр обработчика корзины
+func NewCartHandler(cartService service.CartService, idempotency service.IdempotencyService) *CartHandler {
+	return &CartHandler{
+		idempotencyGuard: idempotencyGuard{idempotency: idempotency},
+		cartService:      cartService,
+	}
+}
+
+// List возвращает содержимое корзины
+func (h *CartHandler) List(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	cart, err := h.cartService.GetCart(c.Request.Context(), username)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения корзины")
+		return
+	}
+
+	respondCart(c, cart)
+}
+
+// Add добавляет товар в корзину
+func (h *CartHandler) Add(c *gin.Context) {
+	var req model.AddCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	cart, err := h.cartService.AddItem(c.Request.Context(), username, req.Item, req.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidQuantity):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrCartFull):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Корзина заполнена")
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		case errors.Is(err, domain.ErrMerchUnavailable):
+			handleError(c, http.StatusConflict, ErrCodeItemUnavailable, "Товар недоступен для покупки")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка добавления товара в корзину")
+		}
+		return
+	}
+
+	respondCart(c, cart)
+}
+
+// Remove удаляет товар из корзины
+func (h *CartHandler) Remove(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	cart, err := h.cartService.RemoveItem(c.Request.Context(), username, c.Param("item"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrCartItemNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товара нет в корзине")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка удаления товара из корзины")
+		}
+		return
+	}
+
+	respondCart(c, cart)
+}
+
+// Checkout оформляет заказ из всей корзины
+func (h *CartHandler) Checkout(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	idem, done := h.beginIdempotent(c, username, nil, gin.H{})
+	if done {
+		return
+	}
+	if idem != nil {
+		// Номер заказа известен только после оформления, поэтому ответ формируется в транзакции
+		idem.StatusCode = http.StatusCreated
+		idem.Render = func(result interface{}) ([]byte, error) {
+			return json.Marshal(toOrderResponse(result.(*domain.Order)))
+		}
+	}
+
+	order, err := h.cartService.Checkout(c.Request.Context(), username, idem)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			h.handleIdempotentRace(c, idem)
+		case errors.Is(err, domain.ErrCartEmpty):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Корзина пуста")
+		case errors.Is(err, domain.ErrInvalidQuantity):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrInsufficientFunds):
+			handleError(c, http.StatusBadRequest, ErrCodeInsufficientFunds, "Недостаточно средств")
+		case errors.Is(err, domain.ErrPriceChanged):
+			handleError(c, http.StatusConflict, ErrCodePriceChanged, validationMessage(err))
+		case errors.Is(err, domain.ErrMerchUnavailable):
+			handleError(c, http.StatusConflict, ErrCodeItemUnavailable, validationMessage(err))
+		case errors.Is(err, domain.ErrOutOfStock):
+			handleError(c, http.StatusConflict, ErrCodeOutOfStock, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка оформления заказа")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, toOrderResponse(order))
+}
+
+// respondCart отправляет содержимое корзины
+func respondCart(c *gin.Context, cart *domain.Cart) {
+	total, err := cart.Total()
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка расчета стоимости корзины")
+		return
+	}
+
+	resp := model.CartResponse{Items: make([]model.CartItemResponse, 0, len(cart.Items)), Total: total}
+	for _, item := range cart.Items {
+		resp.Items = append(resp.Items, model.CartItemResponse{
+			Item:     item.ItemName,
+			Quantity: item.Quantity,
+			Price:    item.UnitPrice,
+			Total:    item.Quantity * item.UnitPrice,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
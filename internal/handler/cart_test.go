@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockCartService struct {
+	mock.Mock
+}
+
+func (m *mockCartService) GetCart(ctx context.Context, username string) (*domain.Cart, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *mockCartService) AddItem(ctx context.Context, username, itemName string, quantity uint64) (*domain.Cart, error) {
+	args := m.Called(ctx, username, itemName, quantity)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *mockCartService) RemoveItem(ctx context.Context, username, itemName string) (*domain.Cart, error) {
+	args := m.Called(ctx, username, itemName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *mockCartService) Checkout(ctx context.Context, username string, idem *domain.IdempotencyRecord) (*domain.Order, error) {
+	args := m.Called(ctx, username, idem)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func TestCartList(t *testing.T) {
+	cartService := new(mockCartService)
+	h := NewCartHandler(cartService, &mockIdempotencyService{})
+	cartService.On("GetCart", mock.Anything, "buyer").Return(&domain.Cart{Username: "buyer", Items: []*domain.CartItem{
+		{ItemName: "cup", Quantity: 2, UnitPrice: 20},
+		{ItemName: "pen", Quantity: 1, UnitPrice: 10},
+	}}, nil)
+
+	c, w := setupTestContext()
+	c.Set("username", "buyer")
+	c.Request = httptest.NewRequest("GET", "/api/cart", http.NoBody)
+
+	h.List(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp model.CartResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, uint64(50), resp.Total)
+	assert.Equal(t, model.CartItemResponse{Item: "cup", Quantity: 2, Price: 20, Total: 40}, resp.Items[0])
+}
+
+func TestCartAdd(t *testing.T) {
+	t.Run("товар добавлен", func(t *testing.T) {
+		cartService := new(mockCartService)
+		h := NewCartHandler(cartService, &mockIdempotencyService{})
+		cartService.On("AddItem", mock.Anything, "buyer", "cup", uint64(2)).
+			Return(&domain.Cart{Username: "buyer", Items: []*domain.CartItem{{ItemName: "cup", Quantity: 2, UnitPrice: 20}}}, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/api/cart", bytes.NewBufferString(`{"item":"cup","quantity":2}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Add(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cartService.AssertExpectations(t)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		cartService := new(mockCartService)
+		h := NewCartHandler(cartService, &mockIdempotencyService{})
+		cartService.On("AddItem", mock.Anything, "buyer", "unknown", uint64(1)).
+			Return(nil, fmt.Errorf("CartService.AddItem: %w", domain.ErrMerchNotFound))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/api/cart", bytes.NewBufferString(`{"item":"unknown","quantity":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Add(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCartRemove(t *testing.T) {
+	cartService := new(mockCartService)
+	h := NewCartHandler(cartService, &mockIdempotencyService{})
+	cartService.On("RemoveItem", mock.Anything, "buyer", "cup").
+		Return(nil, fmt.Errorf("CartService.RemoveItem: %w", domain.ErrCartItemNotFound))
+
+	c, w := setupTestContext()
+	c.Set("username", "buyer")
+	c.Params = []gin.Param{{Key: "item", Value: "cup"}}
+	c.Request = httptest.NewRequest("DELETE", "/api/cart/cup", http.NoBody)
+
+	h.Remove(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCartCheckout(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	order := &domain.Order{
+		Id:        7,
+		Username:  "buyer",
+		Total:     50,
+		Items:     []*domain.OrderItem{{ItemName: "cup", Quantity: 2, UnitPrice: 20}, {ItemName: "pen", Quantity: 1, UnitPrice: 10}},
+		CreatedAt: createdAt,
+	}
+
+	t.Run("заказ оформлен", func(t *testing.T) {
+		cartService := new(mockCartService)
+		h := NewCartHandler(cartService, &mockIdempotencyService{})
+		cartService.On("Checkout", mock.Anything, "buyer", (*domain.IdempotencyRecord)(nil)).Return(order, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/api/cart/checkout", http.NoBody)
+
+		h.Checkout(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp model.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, int64(7), resp.Id)
+		assert.Equal(t, uint64(50), resp.Total)
+		assert.Len(t, resp.Items, 2)
+	})
+
+	t.Run("ответ сохраняется по ключу идемпотентности", func(t *testing.T) {
+		cartService := new(mockCartService)
+		idempotency := new(mockIdempotencyService)
+		h := NewCartHandler(cartService, idempotency)
+
+		record := &domain.IdempotencyRecord{Username: "buyer", Key: "key-1"}
+		idempotency.On("Lookup", mock.Anything, "buyer", "key-1", mock.Anything).Return(nil, nil)
+		idempotency.On("NewRecord", "buyer", "key-1", mock.Anything).Return(record)
+		cartService.On("Checkout", mock.Anything, "buyer", record).Return(order, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/api/cart/checkout", http.NoBody)
+		c.Request.Header.Set(IdempotencyKeyHeader, "key-1")
+
+		h.Checkout(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, http.StatusCreated, record.StatusCode)
+		require.NotNil(t, record.Render)
+		body, err := record.Render(order)
+		require.NoError(t, err)
+		assert.JSONEq(t, w.Body.String(), string(body))
+	})
+
+	t.Run("цена изменилась", func(t *testing.T) {
+		cartService := new(mockCartService)
+		h := NewCartHandler(cartService, &mockIdempotencyService{})
+		cartService.On("Checkout", mock.Anything, "buyer", (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("CartService.Checkout: %w: cup", domain.ErrPriceChanged))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/api/cart/checkout", http.NoBody)
+
+		h.Checkout(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodePriceChanged)
+		assert.Contains(t, w.Body.String(), "cup")
+	})
+
+	t.Run("пустая корзина", func(t *testing.T) {
+		cartService := new(mockCartService)
+		h := NewCartHandler(cartService, &mockIdempotencyService{})
+		cartService.On("Checkout", mock.Anything, "buyer", (*domain.IdempotencyRecord)(nil)).Return(nil, domain.ErrCartEmpty)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/api/cart/checkout", http.NoBody)
+
+		h.Checkout(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
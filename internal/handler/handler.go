@@ -2,7 +2,10 @@ package handler
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/netscrawler/avito-shop/internal/domain"
@@ -12,11 +15,29 @@ import (
 
 // Коды ошибок
 const (
-	ErrCodeInvalidRequest     = "INVALID_REQUEST"
-	ErrCodeInvalidCredentials = "INVALID_CREDENTIALS"
-	ErrCodeInsufficientFunds  = "INSUFFICIENT_FUNDS"
-	ErrCodeNotFound           = "NOT_FOUND"
-	ErrCodeInternalError      = "INTERNAL_ERROR"
+	ErrCodeInvalidRequest      = "INVALID_REQUEST"
+	ErrCodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	ErrCodeInvalidToken        = "INVALID_TOKEN"
+	ErrCodeInsufficientFunds   = "INSUFFICIENT_FUNDS"
+	ErrCodeUserAlreadyExists   = "USER_ALREADY_EXISTS"
+	ErrCodeNotFound            = "NOT_FOUND"
+	ErrCodeAccountLocked       = "ACCOUNT_LOCKED"
+	ErrCodeInvalidMFACode      = "INVALID_MFA_CODE"
+	ErrCodeMFAAlreadyEnabled   = "MFA_ALREADY_ENABLED"
+	ErrCodeIdempotencyConflict = "IDEMPOTENCY_CONFLICT"
+	ErrCodeItemUnavailable     = "ITEM_UNAVAILABLE"
+	ErrCodeMerchAlreadyExists  = "MERCH_ALREADY_EXISTS"
+	ErrCodeOutOfStock          = "OUT_OF_STOCK"
+	ErrCodeStockUnlimited      = "STOCK_UNLIMITED"
+	ErrCodePriceChanged        = "PRICE_CHANGED"
+	ErrCodeInvalidOrderStatus  = "INVALID_ORDER_STATUS"
+	ErrCodeInventoryShortage   = "INVENTORY_SHORTAGE"
+	ErrCodePromoCodeRejected   = "PROMO_CODE_REJECTED"
+	ErrCodePromoCodeExists     = "PROMO_CODE_EXISTS"
+	ErrCodeItemNotTransferable = "ITEM_NOT_TRANSFERABLE"
+	ErrCodeListingClosed       = "LISTING_CLOSED"
+	ErrCodeOwnListing          = "OWN_LISTING"
+	ErrCodeInternalError       = "INTERNAL_ERROR"
 )
 
 // Handler обрабатывает HTTP запросы
@@ -24,14 +45,18 @@ type Handler struct {
 	userService     service.UserService
 	transferService service.TransferService
 	merchService    service.MerchService
+	loginGuard      service.LoginGuard
+	idempotencyGuard
 }
 
 // NewHandler создает новый экземпляр обработчика
-func NewHandler(userService service.UserService, transferService service.TransferService, merchService service.MerchService) *Handler {
+func NewHandler(userService service.UserService, transferService service.TransferService, merchService service.MerchService, loginGuard service.LoginGuard, idempotency service.IdempotencyService) *Handler {
 	return &Handler{
-		userService:     userService,
-		transferService: transferService,
-		merchService:    merchService,
+		userService:      userService,
+		transferService:  transferService,
+		merchService:     merchService,
+		loginGuard:       loginGuard,
+		idempotencyGuard: idempotencyGuard{idempotency: idempotency},
 	}
 }
 
@@ -44,46 +69,119 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 func (h *Handler) Authenticate(c *gin.Context) {
 	var req model.AuthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
 		return
 	}
 
-	token, err := h.userService.AuthenticateUser(c.Request.Context(), req.Username, req.Password)
+	ctx := c.Request.Context()
+	clientIP := c.ClientIP()
+
+	// Проверяем блокировку до проверки пароля, чтобы не тратить ресурсы на перебор
+	if err := h.loginGuard.CheckLogin(ctx, req.Username, clientIP); err != nil {
+		var lockout *domain.LockoutError
+		if errors.As(err, &lockout) {
+			handleLockout(c, lockout)
+			return
+		}
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка аутентификации")
+		return
+	}
+
+	tokens, err := h.userService.AuthenticateUser(ctx, req.Username, req.Password)
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrInvalidCredentials):
-			h.handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Неверные учетные данные")
+			if err := h.loginGuard.RecordLoginFailure(ctx, req.Username, clientIP); err != nil {
+				handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка аутентификации")
+				return
+			}
+			handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Неверные учетные данные")
+		case errors.Is(err, domain.ErrInvalidUsername):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
 		default:
-			h.handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка аутентификации")
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка аутентификации")
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	// Счетчик попыток не сбрасываем, пока не пройден второй фактор,
+	// иначе знание пароля позволило бы перебирать коды без ограничений
+	if tokens.MFAToken != "" {
+		c.JSON(http.StatusOK, model.AuthResponse{
+			MFARequired: true,
+			MFAToken:    tokens.MFAToken,
+		})
+		return
+	}
+
+	if err := h.loginGuard.RecordLoginSuccess(ctx, req.Username); err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка аутентификации")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// Register регистрирует нового пользователя
+func (h *Handler) Register(c *gin.Context) {
+	var req model.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	err := h.userService.SignUp(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserAlreadyExists):
+			handleError(c, http.StatusConflict, ErrCodeUserAlreadyExists, "Пользователь уже существует")
+		case errors.Is(err, domain.ErrInvalidUsername), errors.Is(err, domain.ErrWeakPassword):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка регистрации")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success"})
 }
 
 // GetInfo возвращает информацию о пользователе
 func (h *Handler) GetInfo(c *gin.Context) {
 	username := c.GetString("username")
 	if username == "" {
-		h.handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
 		return
 	}
 
+	// historyLimit ограничивает историю последними N операциями
+	historyLimit := 0
+	if raw := c.Query("historyLimit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверное значение historyLimit")
+			return
+		}
+		historyLimit = limit
+	}
+
 	user, err := h.userService.GetUserInfo(c.Request.Context(), username)
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrUserNotFound):
-			h.handleError(c, http.StatusNotFound, ErrCodeNotFound, "Пользователь не найден")
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Пользователь не найден")
 		default:
-			h.handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения информации")
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения информации")
 		}
 		return
 	}
 
-	transactions, err := h.transferService.GetTransactionHistory(c.Request.Context(), username)
+	transactions, err := h.transferService.GetTransactionHistory(c.Request.Context(), username, historyLimit)
 	if err != nil {
-		h.handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения истории транзакций")
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения истории транзакций")
 		return
 	}
 
@@ -95,29 +193,115 @@ func (h *Handler) GetInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// GetTransactions возвращает страницу истории операций пользователя с фильтрами
+func (h *Handler) GetTransactions(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	var query model.TransactionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверные параметры запроса")
+		return
+	}
+
+	filter := domain.LedgerFilter{
+		Username:     username,
+		Direction:    domain.LedgerDirection(strings.ToUpper(query.Direction)),
+		Counterparty: query.Counterparty,
+		Type:         domain.TransactionType(strings.ToUpper(query.Type)),
+		MinAmount:    query.MinAmount,
+		MaxAmount:    query.MaxAmount,
+		From:         query.From,
+		To:           query.To,
+		Limit:        query.Limit,
+	}
+	if query.Cursor != "" {
+		cursor, err := domain.ParseLedgerCursor(query.Cursor)
+		if err != nil {
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный курсор")
+			return
+		}
+		filter.After = cursor
+	}
+
+	page, err := h.transferService.ListTransactions(c.Request.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidFilter):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Недопустимые параметры фильтра")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения истории транзакций")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 // SendCoin отправляет монеты другому пользователю
 func (h *Handler) SendCoin(c *gin.Context) {
 	var req model.SendCoinRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
 		return
 	}
 
 	sender := c.GetString("username")
 	if sender == "" {
-		h.handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
 		return
 	}
 
-	err := h.transferService.SendCoins(c.Request.Context(), sender, req.ToUser, req.Amount)
+	success := gin.H{"status": "success"}
+	idem, done := h.beginIdempotent(c, sender, req, success)
+	if done {
+		return
+	}
+
+	err := h.transferService.SendCoins(c.Request.Context(), sender, req.ToUser, req.Amount, idem)
 	if err != nil {
 		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			h.handleIdempotentRace(c, idem)
 		case errors.Is(err, domain.ErrInsufficientFunds):
-			h.handleError(c, http.StatusBadRequest, ErrCodeInsufficientFunds, "Недостаточно средств")
-		case errors.Is(err, domain.ErrUserNotFound):
-			h.handleError(c, http.StatusNotFound, ErrCodeNotFound, "Получатель не найден")
+			handleError(c, http.StatusBadRequest, ErrCodeInsufficientFunds, "Недостаточно средств")
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Получатель не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка перевода")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, success)
+}
+
+// GrantCoins начисляет монеты сотруднику от имени сервисной учетной записи или администратора
+func (h *Handler) GrantCoins(c *gin.Context) {
+	var req model.GrantCoinsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	granter := c.GetString("username")
+	if granter == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	err := h.transferService.GrantCoins(c.Request.Context(), granter, req.ToUser, req.Amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidAmount):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверная сумма начисления")
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Получатель не найден")
 		default:
-			h.handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка перевода")
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка начисления")
 		}
 		return
 	}
@@ -125,41 +309,227 @@ func (h *Handler) SendCoin(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
-// BuyMerch обрабатывает покупку товара
+// GetBalance возвращает баланс указанного сотрудника
+func (h *Handler) GetBalance(c *gin.Context) {
+	username := c.Param("username")
+
+	user, err := h.userService.GetUserInfo(c.Request.Context(), username)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Пользователь не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения баланса")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.BalanceResponse{
+		Username: user.Username,
+		Coins:    user.Coins,
+	})
+}
+
+// BuyMerch обрабатывает покупку одной единицы товара
 func (h *Handler) BuyMerch(c *gin.Context) {
 	username := c.GetString("username")
 	if username == "" {
-		h.handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
 		return
 	}
 
 	merchName := c.Param("item")
 	if merchName == "" {
-		h.handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Не указан товар")
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Не указан товар")
+		return
+	}
+
+	h.buyMerch(c, username, merchName, 1, "", nil)
+}
+
+// BuyMerchQuantity обрабатывает покупку нескольких единиц товара одним запросом
+func (h *Handler) BuyMerchQuantity(c *gin.Context) {
+	var req model.BuyMerchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
 		return
 	}
 
-	err := h.merchService.BuyMerch(c.Request.Context(), username, merchName)
+	h.buyMerch(c, username, req.Item, req.Quantity, req.PromoCode, req)
+}
+
+// buyMerch выполняет покупку и формирует ответ. payload учитывается при проверке ключа идемпотентности.
+func (h *Handler) buyMerch(c *gin.Context, username, merchName string, quantity uint64, promoCode string, payload interface{}) {
+	success := gin.H{"status": "success"}
+	idem, done := h.beginIdempotent(c, username, payload, success)
+	if done {
+		return
+	}
+
+	err := h.merchService.BuyMerch(c.Request.Context(), username, merchName, quantity, promoCode, idem)
 	if err != nil {
 		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			h.handleIdempotentRace(c, idem)
+		case errors.Is(err, domain.ErrInvalidQuantity):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
 		case errors.Is(err, domain.ErrInsufficientFunds):
-			h.handleError(c, http.StatusBadRequest, ErrCodeInsufficientFunds, "Недостаточно средств")
+			handleError(c, http.StatusBadRequest, ErrCodeInsufficientFunds, "Недостаточно средств")
 		case errors.Is(err, domain.ErrMerchNotFound):
-			h.handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		case errors.Is(err, domain.ErrMerchUnavailable):
+			handleError(c, http.StatusConflict, ErrCodeItemUnavailable, "Товар недоступен для покупки")
+		case errors.Is(err, domain.ErrOutOfStock):
+			handleError(c, http.StatusConflict, ErrCodeOutOfStock, "Товар закончился")
+		case errors.Is(err, domain.ErrPriceChanged):
+			handleError(c, http.StatusConflict, ErrCodePriceChanged, validationMessage(err))
+		case errors.Is(err, domain.ErrPromoCodeNotFound):
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Промокод не найден")
+		case errors.Is(err, domain.ErrPromoNotApplicable), errors.Is(err, domain.ErrPromoCodeExhausted):
+			handleError(c, http.StatusConflict, ErrCodePromoCodeRejected, validationMessage(err))
 		default:
-			h.handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка покупки")
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка покупки")
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "success"})
+	c.JSON(http.StatusOK, success)
+}
+
+// Gift обрабатывает покупку товара в подарок другому сотруднику
+func (h *Handler) Gift(c *gin.Context) {
+	var req model.GiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	success := gin.H{"status": "success"}
+	idem, done := h.beginIdempotent(c, username, req, success)
+	if done {
+		return
+	}
+
+	err := h.merchService.GiftMerch(c.Request.Context(), username, req.ToUser, req.Item, req.Quantity, req.Message, idem)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			h.handleIdempotentRace(c, idem)
+		case errors.Is(err, domain.ErrInvalidGift):
+			h.handleIdempotentError(c, idem, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrInvalidQuantity):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Получатель не найден")
+		case errors.Is(err, domain.ErrInsufficientFunds):
+			handleError(c, http.StatusBadRequest, ErrCodeInsufficientFunds, "Недостаточно средств")
+		case errors.Is(err, domain.ErrMerchNotFound):
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		case errors.Is(err, domain.ErrMerchUnavailable):
+			handleError(c, http.StatusConflict, ErrCodeItemUnavailable, "Товар недоступен для покупки")
+		case errors.Is(err, domain.ErrOutOfStock):
+			handleError(c, http.StatusConflict, ErrCodeOutOfStock, "Товар закончился")
+		case errors.Is(err, domain.ErrPriceChanged):
+			handleError(c, http.StatusConflict, ErrCodePriceChanged, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка покупки подарка")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, success)
+}
+
+// TransferItem передает единицы купленного товара из инвентаря пользователя другому сотруднику
+func (h *Handler) TransferItem(c *gin.Context) {
+	var req model.TransferItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	success := gin.H{"status": "success"}
+	idem, done := h.beginIdempotent(c, username, req, success)
+	if done {
+		return
+	}
+
+	err := h.transferService.TransferItem(c.Request.Context(), username, req.ToUser, req.Item, req.Quantity, idem)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			h.handleIdempotentRace(c, idem)
+		case errors.Is(err, domain.ErrInvalidItemTransfer), errors.Is(err, domain.ErrInvalidQuantity):
+			h.handleIdempotentError(c, idem, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Получатель не найден")
+		case errors.Is(err, domain.ErrMerchNotFound):
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		case errors.Is(err, domain.ErrItemNotTransferable):
+			handleError(c, http.StatusConflict, ErrCodeItemNotTransferable, validationMessage(err))
+		case errors.Is(err, domain.ErrInventoryShortage):
+			handleError(c, http.StatusConflict, ErrCodeInventoryShortage, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка передачи товара")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, success)
 }
 
 // handleError обрабатывает ошибки и отправляет соответствующий ответ
-func (h *Handler) handleError(c *gin.Context, status int, code, message string) {
-	c.JSON(status, gin.H{
+func handleError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, errorBody(code, message))
+}
+
+// errorBody формирует тело ответа с ошибкой
+func errorBody(code, message string) gin.H {
+	return gin.H{
 		"errors": code + ": " + message,
-	})
+	}
+}
+
+// handleLockout отвечает 429 с заголовком Retry-After в секундах
+func handleLockout(c *gin.Context, lockout *domain.LockoutError) {
+	retryAfter := int(math.Ceil(lockout.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	handleError(c, http.StatusTooManyRequests, ErrCodeAccountLocked, "Слишком много неудачных попыток входа, повторите позже")
+}
+
+// validationMessage возвращает описание ошибки валидации без префиксов слоев
+func validationMessage(err error) string {
+	for _, target := range []error{domain.ErrInvalidUsername, domain.ErrWeakPassword, domain.ErrInvalidMerch, domain.ErrInvalidQuantity,
+		domain.ErrPriceChanged, domain.ErrMerchUnavailable, domain.ErrOutOfStock, domain.ErrOrderTransition, domain.ErrInventoryShortage,
+		domain.ErrInvalidPromoCode, domain.ErrPromoNotApplicable, domain.ErrPromoCodeExhausted, domain.ErrInvalidPriceSchedule, domain.ErrInvalidGift,
+		domain.ErrInvalidItemTransfer, domain.ErrItemNotTransferable, domain.ErrInvalidListing} {
+		if errors.Is(err, target) {
+			msg := err.Error()
+			if i := strings.Index(msg, target.Error()); i >= 0 {
+				return msg[i:]
+			}
+			return target.Error()
+		}
+	}
+	return err.Error()
 }
 
 type SendCoinRequest struct {
@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/middleware"
 	"github.com/netscrawler/avito-shop/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Моки сервисов
@@ -20,14 +24,22 @@ type mockUserService struct {
 	mock.Mock
 }
 
+func (m *mockUserService) SignUp(ctx context.Context, username, password string) error {
+	args := m.Called(ctx, username, password)
+	return args.Error(0)
+}
+
 func (m *mockUserService) RegisterUser(ctx context.Context, username, password string) error {
 	args := m.Called(ctx, username, password)
 	return args.Error(0)
 }
 
-func (m *mockUserService) AuthenticateUser(ctx context.Context, username, password string) (string, error) {
+func (m *mockUserService) AuthenticateUser(ctx context.Context, username, password string) (*domain.TokenPair, error) {
 	args := m.Called(ctx, username, password)
-	return args.String(0), args.Error(1)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TokenPair), args.Error(1)
 }
 
 func (m *mockUserService) GetUserInfo(ctx context.Context, username string) (*domain.User, error) {
@@ -35,26 +47,55 @@ func (m *mockUserService) GetUserInfo(ctx context.Context, username string) (*do
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *mockUserService) SetUserRole(ctx context.Context, username string, role domain.Role) error {
+	args := m.Called(ctx, username, role)
+	return args.Error(0)
+}
+
 type mockTransferService struct {
 	mock.Mock
 }
 
-func (m *mockTransferService) SendCoins(ctx context.Context, sender, receiver string, amount uint64) error {
-	args := m.Called(ctx, sender, receiver, amount)
+func (m *mockTransferService) SendCoins(ctx context.Context, sender, receiver string, amount uint64, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, sender, receiver, amount, idem)
 	return args.Error(0)
 }
 
-func (m *mockTransferService) GetTransactionHistory(ctx context.Context, username string) (model.CoinHistory, error) {
-	args := m.Called(ctx, username)
+func (m *mockTransferService) GetTransactionHistory(ctx context.Context, username string, limit int) (model.CoinHistory, error) {
+	args := m.Called(ctx, username, limit)
 	return args.Get(0).(model.CoinHistory), args.Error(1)
 }
 
+func (m *mockTransferService) ListTransactions(ctx context.Context, filter domain.LedgerFilter) (model.TransactionPage, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(model.TransactionPage), args.Error(1)
+}
+
+func (m *mockTransferService) GrantCoins(ctx context.Context, granter, receiver string, amount uint64) error {
+	args := m.Called(ctx, granter, receiver, amount)
+	return args.Error(0)
+}
+
+func (m *mockTransferService) TransferItem(ctx context.Context, from, to, itemName string, quantity uint64, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, from, to, itemName, quantity, idem)
+	return args.Error(0)
+}
+
 type mockMerchService struct {
 	mock.Mock
 }
 
-func (m *mockMerchService) BuyMerch(ctx context.Context, username, merchName string) error {
-	args := m.Called(ctx, username, merchName)
+func (m *mockMerchService) InvalidateMerch(names ...string) {
+	m.Called(names)
+}
+
+func (m *mockMerchService) BuyMerch(ctx context.Context, username, merchName string, quantity uint64, promoCode string, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, username, merchName, quantity, promoCode, idem)
+	return args.Error(0)
+}
+
+func (m *mockMerchService) GiftMerch(ctx context.Context, sender, recipient, merchName string, quantity uint64, message string, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, sender, recipient, merchName, quantity, message, idem)
 	return args.Error(0)
 }
 
@@ -63,6 +104,87 @@ func (m *mockMerchService) GetAllMerch(ctx context.Context) ([]*domain.Merch, er
 	return args.Get(0).([]*domain.Merch), args.Error(1)
 }
 
+func (m *mockMerchService) GetCatalog(ctx context.Context) ([]*domain.Merch, string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.Merch), args.String(1), args.Error(2)
+}
+
+func (m *mockMerchService) GetMerch(ctx context.Context, name string) (*domain.Merch, string, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*domain.Merch), args.String(1), args.Error(2)
+}
+
+func (m *mockMerchService) CreateMerch(ctx context.Context, item *domain.Merch) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *mockMerchService) UpdateMerch(ctx context.Context, name string, update domain.MerchUpdate) (*domain.Merch, error) {
+	args := m.Called(ctx, name, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Merch), args.Error(1)
+}
+
+func (m *mockMerchService) RetireMerch(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *mockMerchService) RestockMerch(ctx context.Context, name string, quantity uint64) (*domain.Merch, error) {
+	args := m.Called(ctx, name, quantity)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Merch), args.Error(1)
+}
+
+func (m *mockMerchService) SchedulePrice(ctx context.Context, schedule *domain.PriceSchedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *mockMerchService) CancelPriceSchedule(ctx context.Context, name string, id int64) error {
+	args := m.Called(ctx, name, id)
+	return args.Error(0)
+}
+
+func (m *mockMerchService) GetPriceHistory(ctx context.Context, name string) (*domain.PriceHistory, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PriceHistory), args.Error(1)
+}
+
+type mockLoginGuard struct {
+	mock.Mock
+}
+
+func (m *mockLoginGuard) CheckLogin(ctx context.Context, username, ip string) error {
+	args := m.Called(ctx, username, ip)
+	return args.Error(0)
+}
+
+func (m *mockLoginGuard) RecordLoginFailure(ctx context.Context, username, ip string) error {
+	args := m.Called(ctx, username, ip)
+	return args.Error(0)
+}
+
+func (m *mockLoginGuard) RecordLoginSuccess(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
+
+func (m *mockLoginGuard) UnlockUser(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
+
 func setupTestContext() (*gin.Context, *httptest.ResponseRecorder) {
 	gin.SetMode(gin.TestMode)
 	w := httptest.NewRecorder()
@@ -75,7 +197,7 @@ func setupTestContext() (*gin.Context, *httptest.ResponseRecorder) {
 
 func TestHealthCheck(t *testing.T) {
 	c, w := setupTestContext()
-	h := NewHandler(&mockUserService{}, &mockTransferService{}, &mockMerchService{})
+	h := NewHandler(&mockUserService{}, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
 
 	h.HealthCheck(c)
 
@@ -86,9 +208,13 @@ func TestHealthCheck(t *testing.T) {
 func TestAuthenticate(t *testing.T) {
 	t.Run("успешная аутентификация", func(t *testing.T) {
 		userService := new(mockUserService)
-		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{})
+		loginGuard := new(mockLoginGuard)
+		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, loginGuard, &mockIdempotencyService{})
 
-		userService.On("AuthenticateUser", mock.Anything, "testuser", "password").Return("test-token", nil)
+		loginGuard.On("CheckLogin", mock.Anything, "testuser", "192.0.2.1").Return(nil)
+		userService.On("AuthenticateUser", mock.Anything, "testuser", "password").
+			Return(&domain.TokenPair{AccessToken: "test-token", RefreshToken: "refresh-token"}, nil)
+		loginGuard.On("RecordLoginSuccess", mock.Anything, "testuser").Return(nil)
 
 		c, w := setupTestContext()
 		body := bytes.NewBufferString(`{"username":"testuser","password":"password"}`)
@@ -101,15 +227,19 @@ func TestAuthenticate(t *testing.T) {
 		var response map[string]string
 		json.Unmarshal(w.Body.Bytes(), &response)
 		assert.Equal(t, "test-token", response["token"])
+		assert.Equal(t, "refresh-token", response["refreshToken"])
 		userService.AssertExpectations(t)
 	})
 
 	t.Run("неверные учетные данные", func(t *testing.T) {
 		userService := new(mockUserService)
-		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{})
+		loginGuard := new(mockLoginGuard)
+		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, loginGuard, &mockIdempotencyService{})
 
+		loginGuard.On("CheckLogin", mock.Anything, "testuser", "192.0.2.1").Return(nil)
 		userService.On("AuthenticateUser", mock.Anything, "testuser", "wrongpass").
-			Return("", domain.ErrInvalidCredentials)
+			Return(nil, domain.ErrInvalidCredentials)
+		loginGuard.On("RecordLoginFailure", mock.Anything, "testuser", "192.0.2.1").Return(nil)
 
 		c, w := setupTestContext()
 		body := bytes.NewBufferString(`{"username":"testuser","password":"wrongpass"}`)
@@ -120,6 +250,109 @@ func TestAuthenticate(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 		userService.AssertExpectations(t)
+		loginGuard.AssertExpectations(t)
+	})
+
+	t.Run("требуется второй фактор", func(t *testing.T) {
+		userService := new(mockUserService)
+		loginGuard := new(mockLoginGuard)
+		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, loginGuard, &mockIdempotencyService{})
+
+		loginGuard.On("CheckLogin", mock.Anything, "testuser", "192.0.2.1").Return(nil)
+		userService.On("AuthenticateUser", mock.Anything, "testuser", "password").
+			Return(&domain.TokenPair{MFAToken: "mfa-token"}, nil)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"username":"testuser","password":"password"}`)
+		c.Request = httptest.NewRequest("POST", "/auth", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Authenticate(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, true, response["mfaRequired"])
+		assert.Equal(t, "mfa-token", response["mfaToken"])
+		assert.NotContains(t, response, "token")
+		loginGuard.AssertNotCalled(t, "RecordLoginSuccess", mock.Anything, mock.Anything)
+	})
+
+	t.Run("вход заблокирован", func(t *testing.T) {
+		userService := new(mockUserService)
+		loginGuard := new(mockLoginGuard)
+		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, loginGuard, &mockIdempotencyService{})
+
+		loginGuard.On("CheckLogin", mock.Anything, "testuser", "192.0.2.1").
+			Return(&domain.LockoutError{RetryAfter: 1500 * time.Millisecond})
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"username":"testuser","password":"password"}`)
+		c.Request = httptest.NewRequest("POST", "/auth", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Authenticate(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+		assert.Contains(t, w.Body.String(), ErrCodeAccountLocked)
+		userService.AssertNotCalled(t, "AuthenticateUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRegister(t *testing.T) {
+	t.Run("успешная регистрация", func(t *testing.T) {
+		userService := new(mockUserService)
+		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		userService.On("SignUp", mock.Anything, "newuser", "password123").Return(nil)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"username":"newuser","password":"password123"}`)
+		c.Request = httptest.NewRequest("POST", "/register", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Register(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("пользователь уже существует", func(t *testing.T) {
+		userService := new(mockUserService)
+		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		userService.On("SignUp", mock.Anything, "existing", "password123").
+			Return(fmt.Errorf("UserService.SignUp: %w", domain.ErrUserAlreadyExists))
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"username":"existing","password":"password123"}`)
+		c.Request = httptest.NewRequest("POST", "/register", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Register(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeUserAlreadyExists)
+	})
+
+	t.Run("слабый пароль", func(t *testing.T) {
+		userService := new(mockUserService)
+		h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		userService.On("SignUp", mock.Anything, "newuser", "weak").
+			Return(fmt.Errorf("UserService.SignUp: %w: минимальная длина 8 символов", domain.ErrWeakPassword))
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"username":"newuser","password":"weak"}`)
+		c.Request = httptest.NewRequest("POST", "/register", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Register(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "минимальная длина 8 символов")
+		assert.NotContains(t, w.Body.String(), "UserService")
 	})
 }
 
@@ -127,7 +360,7 @@ func TestGetInfo(t *testing.T) {
 	t.Run("успешное получение информации", func(t *testing.T) {
 		userService := new(mockUserService)
 		transferService := new(mockTransferService)
-		h := NewHandler(userService, transferService, &mockMerchService{})
+		h := NewHandler(userService, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
 
 		user := &domain.User{
 			Username: "testuser",
@@ -143,7 +376,7 @@ func TestGetInfo(t *testing.T) {
 		}
 
 		userService.On("GetUserInfo", mock.Anything, "testuser").Return(user, nil)
-		transferService.On("GetTransactionHistory", mock.Anything, "testuser").Return(history, nil)
+		transferService.On("GetTransactionHistory", mock.Anything, "testuser", 0).Return(history, nil)
 
 		c, w := setupTestContext()
 		c.Set("username", "testuser")
@@ -160,8 +393,38 @@ func TestGetInfo(t *testing.T) {
 		transferService.AssertExpectations(t)
 	})
 
+	t.Run("история ограничена последними операциями", func(t *testing.T) {
+		userService := new(mockUserService)
+		transferService := new(mockTransferService)
+		h := NewHandler(userService, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		userService.On("GetUserInfo", mock.Anything, "testuser").Return(&domain.User{Username: "testuser"}, nil)
+		transferService.On("GetTransactionHistory", mock.Anything, "testuser", 10).Return(model.CoinHistory{}, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/info?historyLimit=10", http.NoBody)
+
+		h.GetInfo(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		transferService.AssertExpectations(t)
+	})
+
+	t.Run("неверное значение historyLimit", func(t *testing.T) {
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/info?historyLimit=-1", http.NoBody)
+
+		h.GetInfo(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
 	t.Run("пользователь не аутентифицирован", func(t *testing.T) {
-		h := NewHandler(&mockUserService{}, &mockTransferService{}, &mockMerchService{})
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
 
 		c, w := setupTestContext()
 		c.Request = httptest.NewRequest("GET", "/info", http.NoBody)
@@ -172,12 +435,84 @@ func TestGetInfo(t *testing.T) {
 	})
 }
 
+func TestGetTransactions(t *testing.T) {
+	t.Run("фильтры передаются в сервис", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		cursor := &domain.LedgerCursor{Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), TransactionId: 42}
+		expected := domain.LedgerFilter{
+			Username:     "testuser",
+			Direction:    domain.LedgerDebit,
+			Counterparty: "user1",
+			Type:         domain.TransactionTypeTransfer,
+			MinAmount:    10,
+			MaxAmount:    500,
+			From:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			After:        cursor,
+			Limit:        20,
+		}
+		page := model.TransactionPage{
+			Transactions: []model.TransactionEntry{{Id: 41, Type: "TRANSFER", Direction: "DEBIT", Counterparty: "user1", Amount: 100}},
+			NextCursor:   "next",
+		}
+		transferService.On("ListTransactions", mock.Anything, mock.MatchedBy(func(f domain.LedgerFilter) bool {
+			return f.Username == expected.Username && f.Direction == expected.Direction &&
+				f.Counterparty == expected.Counterparty && f.Type == expected.Type &&
+				f.MinAmount == expected.MinAmount && f.MaxAmount == expected.MaxAmount &&
+				f.From.Equal(expected.From) && f.To.IsZero() && f.Limit == expected.Limit &&
+				f.After != nil && f.After.TransactionId == 42 && f.After.Timestamp.Equal(cursor.Timestamp)
+		})).Return(page, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/transactions?direction=debit&counterparty=user1&type=transfer"+
+			"&minAmount=10&maxAmount=500&from=2025-01-01T00:00:00Z&limit=20&cursor="+cursor.Encode(), http.NoBody)
+
+		h.GetTransactions(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response model.TransactionPage
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, page, response)
+		transferService.AssertExpectations(t)
+	})
+
+	t.Run("неверный курсор", func(t *testing.T) {
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/transactions?cursor=bm9wZQ", http.NoBody)
+
+		h.GetTransactions(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("недопустимый фильтр", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("ListTransactions", mock.Anything, mock.Anything).
+			Return(model.TransactionPage{}, domain.ErrInvalidFilter)
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/transactions?minAmount=500&maxAmount=10", http.NoBody)
+
+		h.GetTransactions(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
 func TestSendCoin(t *testing.T) {
 	t.Run("успешная отправка монет", func(t *testing.T) {
 		transferService := new(mockTransferService)
-		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{})
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
 
-		transferService.On("SendCoins", mock.Anything, "sender", mock.AnythingOfType("string"), uint64(100)).Return(nil)
+		transferService.On("SendCoins", mock.Anything, "sender", mock.AnythingOfType("string"), uint64(100), (*domain.IdempotencyRecord)(nil)).Return(nil)
 
 		c, w := setupTestContext()
 		c.Set("username", "sender")
@@ -193,9 +528,9 @@ func TestSendCoin(t *testing.T) {
 
 	t.Run("недостаточно средств", func(t *testing.T) {
 		transferService := new(mockTransferService)
-		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{})
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
 
-		transferService.On("SendCoins", mock.Anything, "sender", mock.AnythingOfType("string"), uint64(1000)).
+		transferService.On("SendCoins", mock.Anything, "sender", mock.AnythingOfType("string"), uint64(1000), (*domain.IdempotencyRecord)(nil)).
 			Return(domain.ErrInsufficientFunds)
 
 		c, w := setupTestContext()
@@ -209,14 +544,34 @@ func TestSendCoin(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		transferService.AssertExpectations(t)
 	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("SendCoins", mock.Anything, "sender", "unknown", uint64(100), (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("TransferService.SendCoins: %w", domain.ErrRecipientNotFound))
+
+		c, w := setupTestContext()
+		c.Set("username", "sender")
+		body := bytes.NewBufferString(`{"toUser":"unknown","amount":100}`)
+		c.Request = httptest.NewRequest("POST", "/sendCoin", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.SendCoin(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeNotFound)
+		transferService.AssertExpectations(t)
+	})
 }
 
 func TestBuyMerch(t *testing.T) {
 	t.Run("успешная покупка", func(t *testing.T) {
 		merchService := new(mockMerchService)
-		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
 
-		merchService.On("BuyMerch", mock.Anything, "buyer", "item1").Return(nil)
+		merchService.On("BuyMerch", mock.Anything, "buyer", "item1", uint64(1), "", (*domain.IdempotencyRecord)(nil)).Return(nil)
 
 		c, w := setupTestContext()
 		c.Set("username", "buyer")
@@ -231,9 +586,9 @@ func TestBuyMerch(t *testing.T) {
 
 	t.Run("недостаточно средств для покупки", func(t *testing.T) {
 		merchService := new(mockMerchService)
-		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
 
-		merchService.On("BuyMerch", mock.Anything, "buyer", "expensive-item").
+		merchService.On("BuyMerch", mock.Anything, "buyer", "expensive-item", uint64(1), "", (*domain.IdempotencyRecord)(nil)).
 			Return(domain.ErrInsufficientFunds)
 
 		c, w := setupTestContext()
@@ -246,4 +601,404 @@ func TestBuyMerch(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		merchService.AssertExpectations(t)
 	})
+
+	t.Run("товар недоступен", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("BuyMerch", mock.Anything, "buyer", "umbrella", uint64(1), "", (*domain.IdempotencyRecord)(nil)).
+			Return(domain.ErrMerchUnavailable)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "item", Value: "umbrella"}}
+		c.Request = httptest.NewRequest("GET", "/buy/umbrella", http.NoBody)
+
+		h.BuyMerch(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeItemUnavailable)
+	})
+
+	t.Run("товар закончился", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("BuyMerch", mock.Anything, "buyer", "limited", uint64(1), "", (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("MerchService.BuyMerch: %w", domain.ErrOutOfStock))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "item", Value: "limited"}}
+		c.Request = httptest.NewRequest("GET", "/buy/limited", http.NoBody)
+
+		h.BuyMerch(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeOutOfStock)
+	})
+
+	t.Run("цена изменилась", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("BuyMerch", mock.Anything, "buyer", "cup", uint64(1), "", (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("MerchService.BuyMerch: %w: cup", domain.ErrPriceChanged))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "item", Value: "cup"}}
+		c.Request = httptest.NewRequest("GET", "/buy/cup", http.NoBody)
+
+		h.BuyMerch(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodePriceChanged)
+		assert.NotContains(t, w.Body.String(), "MerchService")
+	})
+}
+
+func TestBuyMerchQuantity(t *testing.T) {
+	t.Run("покупка нескольких единиц", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("BuyMerch", mock.Anything, "buyer", "cup", uint64(3), "", (*domain.IdempotencyRecord)(nil)).Return(nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/buy", bytes.NewBufferString(`{"item":"cup","quantity":3}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.BuyMerchQuantity(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		merchService.AssertExpectations(t)
+	})
+
+	t.Run("не указано количество", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/buy", bytes.NewBufferString(`{"item":"cup"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.BuyMerchQuantity(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		merchService.AssertNotCalled(t, "BuyMerch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("слишком большое количество", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("BuyMerch", mock.Anything, "buyer", "cup", uint64(5000), "", (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("MerchService.BuyMerch: %w: количество должно быть от 1 до 1000", domain.ErrInvalidQuantity))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/buy", bytes.NewBufferString(`{"item":"cup","quantity":5000}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.BuyMerchQuantity(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "количество должно быть от 1 до 1000")
+	})
+
+	t.Run("покупка с промокодом", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("BuyMerch", mock.Anything, "buyer", "hoody", uint64(1), "FIRST-10", (*domain.IdempotencyRecord)(nil)).Return(nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/buy", bytes.NewBufferString(`{"item":"hoody","quantity":1,"promoCode":"FIRST-10"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.BuyMerchQuantity(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		merchService.AssertExpectations(t)
+	})
+
+	t.Run("промокод исчерпан", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("BuyMerch", mock.Anything, "buyer", "hoody", uint64(1), "FIRST-10", (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("MerchService.BuyMerch: TransactionRepository.ExecutePurchase: применение промокода: %w: вы уже использовали этот промокод", domain.ErrPromoCodeExhausted))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("POST", "/buy", bytes.NewBufferString(`{"item":"hoody","quantity":1,"promoCode":"FIRST-10"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.BuyMerchQuantity(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodePromoCodeRejected)
+		assert.Contains(t, w.Body.String(), "промокод больше нельзя использовать: вы уже использовали этот промокод")
+	})
+}
+
+func TestGift(t *testing.T) {
+	t.Run("подарок с сообщением", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("GiftMerch", mock.Anything, "alice", "bob", "cup", uint64(2), "С днем рождения!", (*domain.IdempotencyRecord)(nil)).Return(nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/gift", bytes.NewBufferString(`{"toUser":"bob","item":"cup","quantity":2,"message":"С днем рождения!"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Gift(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		merchService.AssertExpectations(t)
+	})
+
+	t.Run("не указан получатель", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/gift", bytes.NewBufferString(`{"item":"cup","quantity":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Gift(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		merchService.AssertNotCalled(t, "GiftMerch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("подарок самому себе", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("GiftMerch", mock.Anything, "alice", "alice", "cup", uint64(1), "", (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("MerchService.GiftMerch: %w: нельзя подарить товар самому себе", domain.ErrInvalidGift))
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/gift", bytes.NewBufferString(`{"toUser":"alice","item":"cup","quantity":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Gift(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidRequest+": некорректный подарок: нельзя подарить товар самому себе")
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("GiftMerch", mock.Anything, "alice", "unknown", "cup", uint64(1), "", (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("MerchService.GiftMerch: %w", domain.ErrRecipientNotFound))
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/gift", bytes.NewBufferString(`{"toUser":"unknown","item":"cup","quantity":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Gift(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("недостаточно средств", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		merchService.On("GiftMerch", mock.Anything, "alice", "bob", "hoody", uint64(1), "", (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("MerchService.GiftMerch: %w", domain.ErrInsufficientFunds))
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/gift", bytes.NewBufferString(`{"toUser":"bob","item":"hoody","quantity":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Gift(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInsufficientFunds)
+	})
+}
+
+func TestTransferItem(t *testing.T) {
+	t.Run("успешная передача", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("TransferItem", mock.Anything, "alice", "bob", "cup", uint64(2), (*domain.IdempotencyRecord)(nil)).Return(nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/inventory/transfer", bytes.NewBufferString(`{"toUser":"bob","item":"cup","quantity":2}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.TransferItem(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		transferService.AssertExpectations(t)
+	})
+
+	t.Run("не указано количество", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/inventory/transfer", bytes.NewBufferString(`{"toUser":"bob","item":"cup"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.TransferItem(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		transferService.AssertNotCalled(t, "TransferItem", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("товар нельзя передать", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("TransferItem", mock.Anything, "alice", "bob", "badge", uint64(1), (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("TransferService.TransferItem: %w: товар снят с продажи", domain.ErrItemNotTransferable))
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/inventory/transfer", bytes.NewBufferString(`{"toUser":"bob","item":"badge","quantity":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.TransferItem(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeItemNotTransferable+": товар нельзя передать: товар снят с продажи")
+	})
+
+	t.Run("недостаточно товара", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("TransferItem", mock.Anything, "alice", "bob", "cup", uint64(5), (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("TransferService.TransferItem: %w: cup", domain.ErrInventoryShortage))
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/inventory/transfer", bytes.NewBufferString(`{"toUser":"bob","item":"cup","quantity":5}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.TransferItem(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInventoryShortage)
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("TransferItem", mock.Anything, "alice", "unknown", "cup", uint64(1), (*domain.IdempotencyRecord)(nil)).
+			Return(fmt.Errorf("TransferService.TransferItem: %w", domain.ErrRecipientNotFound))
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/inventory/transfer", bytes.NewBufferString(`{"toUser":"unknown","item":"cup","quantity":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.TransferItem(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGrantCoins(t *testing.T) {
+	t.Run("успешное начисление", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("GrantCoins", mock.Anything, "hr-bot", "employee", uint64(100)).Return(nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "hr-bot")
+		body := bytes.NewBufferString(`{"toUser":"employee","amount":100}`)
+		c.Request = httptest.NewRequest("POST", "/coins/grant", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.GrantCoins(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		transferService.AssertExpectations(t)
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		transferService.On("GrantCoins", mock.Anything, "hr-bot", "unknown", uint64(100)).Return(domain.ErrRecipientNotFound)
+
+		c, w := setupTestContext()
+		c.Set("username", "hr-bot")
+		body := bytes.NewBufferString(`{"toUser":"unknown","amount":100}`)
+		c.Request = httptest.NewRequest("POST", "/coins/grant", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.GrantCoins(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("администратор без второго фактора", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+		// Маршрут собирается так же, как группа machine при включенном MFA.RequireForAdmin
+		r := gin.New()
+		r.POST("/api/coins/grant",
+			func(c *gin.Context) {
+				c.Set("username", "admin-user")
+				c.Set("role", string(domain.RoleAdmin))
+				c.Set("amr", []string{domain.AMRPassword})
+				c.Next()
+			},
+			middleware.RequireUserMFA(),
+			middleware.RequireScope(domain.ScopeCoinsGrant, domain.RoleAdmin),
+			h.GrantCoins,
+		)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/coins/grant", bytes.NewBufferString(`{"toUser":"employee","amount":100}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		transferService.AssertNotCalled(t, "GrantCoins", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetBalance(t *testing.T) {
+	userService := new(mockUserService)
+	h := NewHandler(userService, &mockTransferService{}, &mockMerchService{}, &mockLoginGuard{}, &mockIdempotencyService{})
+
+	userService.On("GetUserInfo", mock.Anything, "employee").
+		Return(&domain.User{Username: "employee", Coins: 700}, nil)
+
+	c, w := setupTestContext()
+	c.Params = gin.Params{{Key: "username", Value: "employee"}}
+	c.Request = httptest.NewRequest("GET", "/users/employee/balance", http.NoBody)
+
+	h.GetBalance(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response model.BalanceResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, uint64(700), response.Coins)
 }
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// IdempotencyKeyHeader содержит ключ, по которому повтор запроса получает сохраненный ответ
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader отмечает ответ, возвращенный из сохраненного результата
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// idempotencyGuard обрабатывает заголовок Idempotency-Key в обработчиках, изменяющих баланс
+type idempotencyGuard struct {
+	idempotency service.IdempotencyService
+}
+
+// beginIdempotent проверяет заголовок Idempotency-Key и подготавливает запись для сохранения успешного ответа.
+// Возвращает done = true, если ответ уже отправлен: повтор, конфликт ключа или ошибка.
+func (g *idempotencyGuard) beginIdempotent(c *gin.Context, username string, payload interface{}, success gin.H) (*domain.IdempotencyRecord, bool) {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" {
+		return nil, false
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Слишком длинный ключ идемпотентности")
+		return nil, true
+	}
+
+	requestHash, err := hashRequest(c, payload)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка обработки ключа идемпотентности")
+		return nil, true
+	}
+
+	if g.replayIfCompleted(c, username, key, requestHash) {
+		return nil, true
+	}
+
+	record := g.idempotency.NewRecord(username, key, requestHash)
+	record.StatusCode = http.StatusOK
+	record.Response, err = json.Marshal(success)
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка обработки ключа идемпотентности")
+		return nil, true
+	}
+
+	return record, false
+}
+
+// replayIfCompleted отправляет сохраненный ответ, если запрос с этим ключом уже выполнен
+func (g *idempotencyGuard) replayIfCompleted(c *gin.Context, username, key, requestHash string) bool {
+	record, err := g.idempotency.Lookup(c.Request.Context(), username, key, requestHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrIdempotencyReused) {
+			handleError(c, http.StatusConflict, ErrCodeIdempotencyConflict, "Ключ идемпотентности уже использован с другим запросом")
+			return true
+		}
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка обработки ключа идемпотентности")
+		return true
+	}
+	if record == nil {
+		return false
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.StatusCode, "application/json; charset=utf-8", record.Response)
+	return true
+}
+
+// handleIdempotentRace отвечает на запрос, проигравший параллельному запросу с тем же ключом
+func (g *idempotencyGuard) handleIdempotentRace(c *gin.Context, record *domain.IdempotencyRecord) {
+	if !g.replayIfCompleted(c, record.Username, record.Key, record.RequestHash) {
+		handleError(c, http.StatusConflict, ErrCodeIdempotencyConflict, "Запрос с этим ключом идемпотентности еще выполняется")
+	}
+}
+
+// handleIdempotentError отправляет ошибку и сохраняет ее как результат запроса.
+// Используется только для ошибок, которые определяются самим запросом: неизвестный получатель, собственный лот.
+// Ошибки, зависящие от состояния (баланс, остаток, цена, статус лота), отправляются через handleError
+// без сохранения, чтобы повтор с тем же ключом выполнил запрос заново.
+func (g *idempotencyGuard) handleIdempotentError(c *gin.Context, record *domain.IdempotencyRecord, status int, code, message string) {
+	handleError(c, status, code, message)
+	if record == nil {
+		return
+	}
+
+	body, err := json.Marshal(errorBody(code, message))
+	if err != nil {
+		logrus.Errorf("Handler: ошибка сериализации ответа для ключа идемпотентности: %v", err)
+		return
+	}
+	record.StatusCode = status
+	record.Response = body
+
+	if err := g.idempotency.SaveResult(c.Request.Context(), record); err != nil && !errors.Is(err, domain.ErrIdempotencyKeyUsed) {
+		logrus.Errorf("Handler: ошибка сохранения результата по ключу идемпотентности: %v", err)
+	}
+}
+
+// hashRequest возвращает хэш метода, пути и разобранного тела запроса
+func hashRequest(c *gin.Context, payload interface{}) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
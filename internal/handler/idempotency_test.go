@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockIdempotencyService struct {
+	mock.Mock
+}
+
+func (m *mockIdempotencyService) Lookup(ctx context.Context, username, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	args := m.Called(ctx, username, key, requestHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IdempotencyRecord), args.Error(1)
+}
+
+func (m *mockIdempotencyService) NewRecord(username, key, requestHash string) *domain.IdempotencyRecord {
+	args := m.Called(username, key, requestHash)
+	return args.Get(0).(*domain.IdempotencyRecord)
+}
+
+func (m *mockIdempotencyService) SaveResult(ctx context.Context, record *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func newSendCoinContext(key, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	c, w := setupTestContext()
+	c.Set("username", "sender")
+	c.Request = httptest.NewRequest("POST", "/api/sendCoin", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set(IdempotencyKeyHeader, key)
+	return c, w
+}
+
+func TestSendCoinIdempotency(t *testing.T) {
+	t.Run("первый запрос сохраняет результат вместе с переводом", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		idempotency := new(mockIdempotencyService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, idempotency)
+
+		record := &domain.IdempotencyRecord{Username: "sender", Key: "key-1"}
+		idempotency.On("Lookup", mock.Anything, "sender", "key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		idempotency.On("NewRecord", "sender", "key-1", mock.AnythingOfType("string")).Return(record)
+		transferService.On("SendCoins", mock.Anything, "sender", "receiver", uint64(100), record).Return(nil)
+
+		c, w := newSendCoinContext("key-1", `{"toUser":"receiver","amount":100}`)
+		h.SendCoin(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusOK, record.StatusCode)
+		assert.JSONEq(t, `{"status":"success"}`, string(record.Response))
+		transferService.AssertExpectations(t)
+		idempotency.AssertExpectations(t)
+	})
+
+	t.Run("повтор возвращает сохраненный ответ", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		idempotency := new(mockIdempotencyService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, idempotency)
+
+		stored := &domain.IdempotencyRecord{StatusCode: http.StatusOK, Response: []byte(`{"status":"success"}`)}
+		idempotency.On("Lookup", mock.Anything, "sender", "key-1", mock.AnythingOfType("string")).Return(stored, nil)
+
+		c, w := newSendCoinContext("key-1", `{"toUser":"receiver","amount":100}`)
+		h.SendCoin(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+		assert.JSONEq(t, `{"status":"success"}`, w.Body.String())
+		transferService.AssertNotCalled(t, "SendCoins", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ключ с другим запросом", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		idempotency := new(mockIdempotencyService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, idempotency)
+
+		idempotency.On("Lookup", mock.Anything, "sender", "key-1", mock.AnythingOfType("string")).
+			Return(nil, domain.ErrIdempotencyReused)
+
+		c, w := newSendCoinContext("key-1", `{"toUser":"receiver","amount":500}`)
+		h.SendCoin(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeIdempotencyConflict)
+		transferService.AssertNotCalled(t, "SendCoins", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка, определяемая запросом, сохраняется", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		idempotency := new(mockIdempotencyService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, idempotency)
+
+		record := &domain.IdempotencyRecord{Username: "sender", Key: "key-1"}
+		idempotency.On("Lookup", mock.Anything, "sender", "key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		idempotency.On("NewRecord", "sender", "key-1", mock.AnythingOfType("string")).Return(record)
+		idempotency.On("SaveResult", mock.Anything, record).Return(nil)
+		transferService.On("SendCoins", mock.Anything, "sender", "unknown", uint64(100), record).
+			Return(domain.ErrRecipientNotFound)
+
+		c, w := newSendCoinContext("key-1", `{"toUser":"unknown","amount":100}`)
+		h.SendCoin(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, http.StatusNotFound, record.StatusCode)
+		assert.JSONEq(t, w.Body.String(), string(record.Response))
+		idempotency.AssertExpectations(t)
+	})
+
+	t.Run("ошибка, зависящая от баланса, не сохраняется", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		idempotency := new(mockIdempotencyService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, idempotency)
+
+		record := &domain.IdempotencyRecord{Username: "sender", Key: "key-1"}
+		idempotency.On("Lookup", mock.Anything, "sender", "key-1", mock.AnythingOfType("string")).Return(nil, nil)
+		idempotency.On("NewRecord", "sender", "key-1", mock.AnythingOfType("string")).Return(record)
+		transferService.On("SendCoins", mock.Anything, "sender", "receiver", uint64(1000), record).
+			Return(domain.ErrInsufficientFunds)
+
+		c, w := newSendCoinContext("key-1", `{"toUser":"receiver","amount":1000}`)
+		h.SendCoin(c)
+
+		// После пополнения баланса повтор с тем же ключом должен выполнить перевод
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInsufficientFunds)
+		idempotency.AssertNotCalled(t, "SaveResult", mock.Anything, mock.Anything)
+	})
+
+	t.Run("параллельный запрос с тем же ключом", func(t *testing.T) {
+		transferService := new(mockTransferService)
+		idempotency := new(mockIdempotencyService)
+		h := NewHandler(&mockUserService{}, transferService, &mockMerchService{}, &mockLoginGuard{}, idempotency)
+
+		record := &domain.IdempotencyRecord{Username: "sender", Key: "key-1", RequestHash: "hash"}
+		stored := &domain.IdempotencyRecord{StatusCode: http.StatusOK, Response: []byte(`{"status":"success"}`)}
+		idempotency.On("Lookup", mock.Anything, "sender", "key-1", mock.AnythingOfType("string")).Return(nil, nil).Once()
+		idempotency.On("NewRecord", "sender", "key-1", mock.AnythingOfType("string")).Return(record)
+		idempotency.On("Lookup", mock.Anything, "sender", "key-1", "hash").Return(stored, nil).Once()
+		transferService.On("SendCoins", mock.Anything, "sender", "receiver", uint64(100), record).
+			Return(domain.ErrIdempotencyKeyUsed)
+
+		c, w := newSendCoinContext("key-1", `{"toUser":"receiver","amount":100}`)
+		h.SendCoin(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+		idempotency.AssertExpectations(t)
+	})
+}
+
+func TestBuyMerchIdempotency(t *testing.T) {
+	t.Run("повтор покупки не списывает монеты", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		idempotency := new(mockIdempotencyService)
+		h := NewHandler(&mockUserService{}, &mockTransferService{}, merchService, &mockLoginGuard{}, idempotency)
+
+		stored := &domain.IdempotencyRecord{StatusCode: http.StatusOK, Response: []byte(`{"status":"success"}`)}
+		idempotency.On("Lookup", mock.Anything, "buyer", "key-1", mock.AnythingOfType("string")).Return(stored, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "item", Value: "item1"}}
+		c.Request = httptest.NewRequest("GET", "/api/buy/item1", http.NoBody)
+		c.Request.Header.Set(IdempotencyKeyHeader, "key-1")
+
+		h.BuyMerch(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		merchService.AssertNotCalled(t, "BuyMerch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("разные товары дают разный хэш запроса", func(t *testing.T) {
+		c1, _ := setupTestContext()
+		c1.Request = httptest.NewRequest("GET", "/api/buy/item1", http.NoBody)
+		c2, _ := setupTestContext()
+		c2.Request = httptest.NewRequest("GET", "/api/buy/item2", http.NoBody)
+
+		h1, err := hashRequest(c1, nil)
+		assert.NoError(t, err)
+		h2, err := hashRequest(c2, nil)
+		assert.NoError(t, err)
+		assert.NotEqual(t, h1, h2)
+	})
+}
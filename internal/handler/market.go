@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// MarketHandler обрабатывает запросы к маркетплейсу, на котором сотрудники перепродают товары
+type MarketHandler struct {
+	idempotencyGuard
+	marketService service.MarketService
+}
+
+// NewMarketHandler создает новый экземпляр обработчика маркетплейса
+func NewMarketHandler(marketService service.MarketService, idempotency service.IdempotencyService) *MarketHandler {
+	return &MarketHandler{
+		idempotencyGuard: idempotencyGuard{idempotency: idempotency},
+		marketService:    marketService,
+	}
+}
+
+// Create выставляет товар из инвентаря пользователя на продажу
+func (h *MarketHandler) Create(c *gin.Context) {
+	var req model.CreateListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	listing, err := h.marketService.CreateListing(c.Request.Context(), username, req.Item, req.Quantity, req.Price)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidListing), errors.Is(err, domain.ErrInvalidQuantity):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		case errors.Is(err, domain.ErrItemNotTransferable):
+			handleError(c, http.StatusConflict, ErrCodeItemNotTransferable, validationMessage(err))
+		case errors.Is(err, domain.ErrInventoryShortage):
+			handleError(c, http.StatusConflict, ErrCodeInventoryShortage, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка создания лота")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, toListingResponse(listing))
+}
+
+// List возвращает лоты маркетплейса с фильтрами по товару, продавцу, состоянию и цене
+func (h *MarketHandler) List(c *gin.Context) {
+	var query model.ListingsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверные параметры запроса")
+		return
+	}
+
+	status := domain.ListingStatus(query.Status)
+	if status == "" {
+		status = domain.ListingStatusActive
+	}
+
+	listings, err := h.marketService.ListListings(c.Request.Context(), domain.ListingFilter{
+		ItemName: query.Item,
+		Seller:   query.Seller,
+		Status:   status,
+		MinPrice: query.MinPrice,
+		MaxPrice: query.MaxPrice,
+		Limit:    query.Limit,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidFilter):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Недопустимые параметры фильтра")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения лотов")
+		}
+		return
+	}
+
+	resp := model.ListingListResponse{Listings: make([]model.ListingResponse, 0, len(listings))}
+	for _, listing := range listings {
+		resp.Listings = append(resp.Listings, toListingResponse(listing))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get возвращает лот по номеру
+func (h *MarketHandler) Get(c *gin.Context) {
+	id, ok := listingId(c)
+	if !ok {
+		return
+	}
+
+	listing, err := h.marketService.GetListing(c.Request.Context(), id)
+	if err != nil {
+		handleListingError(c, err, "Ошибка получения лота")
+		return
+	}
+
+	c.JSON(http.StatusOK, toListingResponse(listing))
+}
+
+// Cancel снимает лот пользователя с продажи и возвращает товар в инвентарь
+func (h *MarketHandler) Cancel(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	id, ok := listingId(c)
+	if !ok {
+		return
+	}
+
+	listing, err := h.marketService.CancelListing(c.Request.Context(), username, id)
+	if err != nil {
+		handleListingError(c, err, "Ошибка снятия лота")
+		return
+	}
+
+	c.JSON(http.StatusOK, toListingResponse(listing))
+}
+
+// Buy покупает лот целиком
+func (h *MarketHandler) Buy(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	id, ok := listingId(c)
+	if !ok {
+		return
+	}
+
+	idem, done := h.beginIdempotent(c, username, nil, gin.H{})
+	if done {
+		return
+	}
+	if idem != nil {
+		// Комиссия и время продажи известны только после покупки, поэтому ответ формируется в транзакции
+		idem.Render = func(result interface{}) ([]byte, error) {
+			return json.Marshal(toListingResponse(result.(*domain.Listing)))
+		}
+	}
+
+	listing, err := h.marketService.BuyListing(c.Request.Context(), username, id, idem)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			h.handleIdempotentRace(c, idem)
+		case errors.Is(err, domain.ErrListingNotFound):
+			h.handleIdempotentError(c, idem, http.StatusNotFound, ErrCodeNotFound, "Лот не найден")
+		case errors.Is(err, domain.ErrOwnListing):
+			h.handleIdempotentError(c, idem, http.StatusBadRequest, ErrCodeOwnListing, "Нельзя купить собственный лот")
+		case errors.Is(err, domain.ErrInsufficientFunds):
+			handleError(c, http.StatusBadRequest, ErrCodeInsufficientFunds, "Недостаточно средств")
+		case errors.Is(err, domain.ErrListingClosed):
+			handleError(c, http.StatusConflict, ErrCodeListingClosed, "Лот уже продан или снят")
+		case errors.Is(err, domain.ErrItemNotTransferable):
+			handleError(c, http.StatusConflict, ErrCodeItemNotTransferable, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка покупки лота")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toListingResponse(listing))
+}
+
+// listingId разбирает номер лота из пути. Возвращает false, если ответ с ошибкой уже отправлен.
+func listingId(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный номер лота")
+		return 0, false
+	}
+	return id, true
+}
+
+func handleListingError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrListingNotFound):
+		handleError(c, http.StatusNotFound, ErrCodeNotFound, "Лот не найден")
+	case errors.Is(err, domain.ErrListingClosed):
+		handleError(c, http.StatusConflict, ErrCodeListingClosed, "Лот уже продан или снят")
+	default:
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, message)
+	}
+}
+
+func toListingResponse(listing *domain.Listing) model.ListingResponse {
+	return model.ListingResponse{
+		Id:        listing.Id,
+		Seller:    listing.Seller,
+		Item:      listing.ItemName,
+		Quantity:  listing.Quantity,
+		Price:     listing.Price,
+		Status:    string(listing.Status),
+		Buyer:     listing.Buyer,
+		Fee:       listing.Fee,
+		CreatedAt: listing.CreatedAt,
+		ClosedAt:  listing.ClosedAt,
+	}
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMarketService struct {
+	mock.Mock
+}
+
+func (m *mockMarketService) CreateListing(ctx context.Context, seller, itemName string, quantity, price uint64) (*domain.Listing, error) {
+	args := m.Called(ctx, seller, itemName, quantity, price)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Listing), args.Error(1)
+}
+
+func (m *mockMarketService) ListListings(ctx context.Context, filter domain.ListingFilter) ([]*domain.Listing, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Listing), args.Error(1)
+}
+
+func (m *mockMarketService) GetListing(ctx context.Context, id int64) (*domain.Listing, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Listing), args.Error(1)
+}
+
+func (m *mockMarketService) CancelListing(ctx context.Context, seller string, id int64) (*domain.Listing, error) {
+	args := m.Called(ctx, seller, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Listing), args.Error(1)
+}
+
+func (m *mockMarketService) BuyListing(ctx context.Context, buyer string, id int64, idem *domain.IdempotencyRecord) (*domain.Listing, error) {
+	args := m.Called(ctx, buyer, id, idem)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Listing), args.Error(1)
+}
+
+func TestMarketCreate(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("лот выставлен", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("CreateListing", mock.Anything, "alice", "cup", uint64(2), uint64(100)).
+			Return(&domain.Listing{Id: 5, Seller: "alice", ItemName: "cup", Quantity: 2, Price: 100, Status: domain.ListingStatusActive, CreatedAt: createdAt}, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/api/market/listings", bytes.NewBufferString(`{"item":"cup","quantity":2,"price":100}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp model.ListingResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, int64(5), resp.Id)
+		assert.Equal(t, "active", resp.Status)
+	})
+
+	t.Run("недостаточно товара", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("CreateListing", mock.Anything, "alice", "cup", uint64(2), uint64(100)).
+			Return(nil, fmt.Errorf("MarketService.CreateListing: MarketRepository.CreateListing: %w: cup", domain.ErrInventoryShortage))
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/api/market/listings", bytes.NewBufferString(`{"item":"cup","quantity":2,"price":100}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInventoryShortage)
+		assert.NotContains(t, w.Body.String(), "MarketRepository")
+	})
+
+	t.Run("цена не указана", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+
+		c, w := setupTestContext()
+		c.Set("username", "alice")
+		c.Request = httptest.NewRequest("POST", "/api/market/listings", bytes.NewBufferString(`{"item":"cup","quantity":2}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		marketService.AssertNotCalled(t, "CreateListing", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMarketList(t *testing.T) {
+	t.Run("по умолчанию только активные лоты", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("ListListings", mock.Anything, domain.ListingFilter{ItemName: "cup", Status: domain.ListingStatusActive, MaxPrice: 150}).
+			Return([]*domain.Listing{{Id: 5, Seller: "alice", ItemName: "cup", Quantity: 2, Price: 100, Status: domain.ListingStatusActive}}, nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("GET", "/api/market/listings?item=cup&maxPrice=150", http.NoBody)
+
+		h.List(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.ListingListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Listings, 1)
+		assert.Equal(t, "alice", resp.Listings[0].Seller)
+	})
+
+	t.Run("недопустимый фильтр", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("ListListings", mock.Anything, mock.Anything).
+			Return(nil, fmt.Errorf("MarketService.ListListings: %w", domain.ErrInvalidFilter))
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("GET", "/api/market/listings?status=lost", http.NoBody)
+
+		h.List(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestMarketCancel(t *testing.T) {
+	t.Run("чужой лот", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("CancelListing", mock.Anything, "bob", int64(5)).
+			Return(nil, fmt.Errorf("MarketService.CancelListing: %w", domain.ErrListingNotFound))
+
+		c, w := setupTestContext()
+		c.Set("username", "bob")
+		c.Params = []gin.Param{{Key: "id", Value: "5"}}
+		c.Request = httptest.NewRequest("POST", "/api/market/listings/5/cancel", http.NoBody)
+
+		h.Cancel(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("неверный номер лота", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+
+		c, w := setupTestContext()
+		c.Set("username", "bob")
+		c.Params = []gin.Param{{Key: "id", Value: "abc"}}
+		c.Request = httptest.NewRequest("POST", "/api/market/listings/abc/cancel", http.NoBody)
+
+		h.Cancel(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestMarketBuy(t *testing.T) {
+	closedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sold := &domain.Listing{Id: 5, Seller: "alice", ItemName: "cup", Quantity: 2, Price: 100, Status: domain.ListingStatusSold, Buyer: "bob", Fee: 5, ClosedAt: &closedAt}
+
+	newBuyContext := func() (*gin.Context, *httptest.ResponseRecorder) {
+		c, w := setupTestContext()
+		c.Set("username", "bob")
+		c.Params = []gin.Param{{Key: "id", Value: "5"}}
+		c.Request = httptest.NewRequest("POST", "/api/market/listings/5/buy", http.NoBody)
+		return c, w
+	}
+
+	t.Run("лот куплен", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("BuyListing", mock.Anything, "bob", int64(5), (*domain.IdempotencyRecord)(nil)).Return(sold, nil)
+
+		c, w := newBuyContext()
+		h.Buy(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.ListingResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "sold", resp.Status)
+		assert.Equal(t, "bob", resp.Buyer)
+		assert.Equal(t, uint64(5), resp.Fee)
+	})
+
+	t.Run("ответ сохраняется по ключу идемпотентности", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		idempotency := new(mockIdempotencyService)
+		h := NewMarketHandler(marketService, idempotency)
+
+		record := &domain.IdempotencyRecord{Username: "bob", Key: "key-1"}
+		idempotency.On("Lookup", mock.Anything, "bob", "key-1", mock.Anything).Return(nil, nil)
+		idempotency.On("NewRecord", "bob", "key-1", mock.Anything).Return(record)
+		marketService.On("BuyListing", mock.Anything, "bob", int64(5), record).Return(sold, nil)
+
+		c, w := newBuyContext()
+		c.Request.Header.Set(IdempotencyKeyHeader, "key-1")
+		h.Buy(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, record.Render)
+		body, err := record.Render(sold)
+		require.NoError(t, err)
+		assert.JSONEq(t, w.Body.String(), string(body))
+	})
+
+	t.Run("лот уже продан", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("BuyListing", mock.Anything, "bob", int64(5), (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("MarketService.BuyListing: %w", domain.ErrListingClosed))
+
+		c, w := newBuyContext()
+		h.Buy(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeListingClosed)
+	})
+
+	t.Run("собственный лот", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("BuyListing", mock.Anything, "bob", int64(5), (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("MarketService.BuyListing: %w", domain.ErrOwnListing))
+
+		c, w := newBuyContext()
+		h.Buy(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeOwnListing)
+	})
+
+	t.Run("недостаточно средств", func(t *testing.T) {
+		marketService := new(mockMarketService)
+		h := NewMarketHandler(marketService, &mockIdempotencyService{})
+		marketService.On("BuyListing", mock.Anything, "bob", int64(5), (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("MarketService.BuyListing: %w", domain.ErrInsufficientFunds))
+
+		c, w := newBuyContext()
+		h.Buy(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInsufficientFunds)
+	})
+}
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// MerchHandler обрабатывает запросы к каталогу товаров
+type MerchHandler struct {
+	merchService service.MerchService
+}
+
+// NewMerchHandler создает новый экземпляр обработчика каталога
+func NewMerchHandler(merchService service.MerchService) *MerchHandler {
+	return &MerchHandler{merchService: merchService}
+}
+
+// List возвращает все товары каталога. Поддерживает условный запрос по If-None-Match.
+func (h *MerchHandler) List(c *gin.Context) {
+	items, etag, err := h.merchService.GetCatalog(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения каталога")
+		return
+	}
+
+	if notModified(c, etag) {
+		return
+	}
+
+	resp := model.MerchListResponse{Items: make([]model.MerchResponse, 0, len(items))}
+	for _, item := range items {
+		resp.Items = append(resp.Items, toMerchResponse(item))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get возвращает товар по названию. Поддерживает условный запрос по If-None-Match.
+func (h *MerchHandler) Get(c *gin.Context) {
+	item, etag, err := h.merchService.GetMerch(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения товара")
+		}
+		return
+	}
+
+	if notModified(c, etag) {
+		return
+	}
+
+	c.JSON(http.StatusOK, toMerchResponse(item))
+}
+
+// Create добавляет товар в каталог
+func (h *MerchHandler) Create(c *gin.Context) {
+	var req model.CreateMerchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	item := &domain.Merch{
+		Name:         req.Name,
+		Price:        *req.Price,
+		Description:  req.Description,
+		Category:     req.Category,
+		Available:    req.Available == nil || *req.Available,
+		Stock:        req.Stock,
+		Transferable: req.Transferable == nil || *req.Transferable,
+	}
+	if err := h.merchService.CreateMerch(c.Request.Context(), item); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMerch):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrMerchAlreadyExists):
+			handleError(c, http.StatusConflict, ErrCodeMerchAlreadyExists, "Товар с таким названием уже существует")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка создания товара")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, toMerchResponse(item))
+}
+
+// Update изменяет цену, описание, категорию или доступность товара
+func (h *MerchHandler) Update(c *gin.Context) {
+	var req model.UpdateMerchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	item, err := h.merchService.UpdateMerch(c.Request.Context(), c.Param("name"), domain.MerchUpdate{
+		Price:        req.Price,
+		Description:  req.Description,
+		Category:     req.Category,
+		Available:    req.Available,
+		Transferable: req.Transferable,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMerch):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка изменения товара")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toMerchResponse(item))
+}
+
+// Retire снимает товар с продажи. Товар остается в истории покупок и инвентаре пользователей.
+func (h *MerchHandler) Retire(c *gin.Context) {
+	if err := h.merchService.RetireMerch(c.Request.Context(), c.Param("name")); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка снятия товара с продажи")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// Restock пополняет склад товара с ограниченным количеством
+func (h *MerchHandler) Restock(c *gin.Context) {
+	var req model.RestockMerchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	item, err := h.merchService.RestockMerch(c.Request.Context(), c.Param("name"), req.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidMerch):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		case errors.Is(err, domain.ErrStockUnlimited):
+			handleError(c, http.StatusConflict, ErrCodeStockUnlimited, "Количество товара не ограничено")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка пополнения склада")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toMerchResponse(item))
+}
+
+// SchedulePrice планирует цену товара, например временную распродажу
+func (h *MerchHandler) SchedulePrice(c *gin.Context) {
+	var req model.CreatePriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	schedule := &domain.PriceSchedule{
+		ItemName:    c.Param("name"),
+		Price:       req.Price,
+		EffectiveTo: req.EffectiveTo,
+		Priority:    req.Priority,
+		CreatedBy:   c.GetString("username"),
+	}
+	if req.EffectiveFrom != nil {
+		schedule.EffectiveFrom = *req.EffectiveFrom
+	}
+	if err := h.merchService.SchedulePrice(c.Request.Context(), schedule); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidPriceSchedule):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка планирования цены")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPriceScheduleResponse(schedule))
+}
+
+// CancelPriceSchedule отменяет расписание цены товара. Отмененное расписание остается в истории цен.
+func (h *MerchHandler) CancelPriceSchedule(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный номер расписания")
+		return
+	}
+
+	if err := h.merchService.CancelPriceSchedule(c.Request.Context(), c.Param("name"), id); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPriceScheduleNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Расписание цены не найдено или уже завершено")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка отмены расписания цены")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// PriceHistory возвращает историю цен товара. Если передан at, вычисляет цену, действовавшую в этот момент.
+func (h *MerchHandler) PriceHistory(c *gin.Context) {
+	var query model.PriceHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверные параметры запроса")
+		return
+	}
+
+	history, err := h.merchService.GetPriceHistory(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения истории цен")
+		}
+		return
+	}
+
+	resp := model.PriceHistoryResponse{
+		Item:      history.ItemName,
+		Changes:   make([]model.PriceChangeResponse, 0, len(history.Changes)),
+		Schedules: make([]model.PriceScheduleResponse, 0, len(history.Schedules)),
+	}
+	for _, change := range history.Changes {
+		resp.Changes = append(resp.Changes, model.PriceChangeResponse{Price: change.Price, ChangedAt: change.ChangedAt})
+	}
+	for _, schedule := range history.Schedules {
+		resp.Schedules = append(resp.Schedules, toPriceScheduleResponse(schedule))
+	}
+	if !query.At.IsZero() {
+		if price, ok := history.PriceAt(query.At); ok {
+			resp.PriceAt = &price
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// notModified устанавливает ETag и отвечает 304, если клиент уже получил эту версию
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	match := c.GetHeader("If-None-Match")
+	if match == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(match, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+func toMerchResponse(item *domain.Merch) model.MerchResponse {
+	resp := model.MerchResponse{
+		Name:         item.Name,
+		Price:        item.Price,
+		Description:  item.Description,
+		Category:     item.Category,
+		Available:    item.Available,
+		RetiredAt:    item.RetiredAt,
+		Stock:        item.Stock,
+		Transferable: item.Transferable,
+	}
+	if item.RegularPrice != 0 && item.RegularPrice != item.Price {
+		regular := item.RegularPrice
+		resp.RegularPrice = &regular
+	}
+	return resp
+}
+
+func toPriceScheduleResponse(schedule *domain.PriceSchedule) model.PriceScheduleResponse {
+	return model.PriceScheduleResponse{
+		Id:            schedule.Id,
+		Item:          schedule.ItemName,
+		Price:         schedule.Price,
+		EffectiveFrom: schedule.EffectiveFrom,
+		EffectiveTo:   schedule.EffectiveTo,
+		Priority:      schedule.Priority,
+		CreatedBy:     schedule.CreatedBy,
+		CreatedAt:     schedule.CreatedAt,
+		CancelledAt:   schedule.CancelledAt,
+	}
+}
@@ -0,0 +1,469 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerchList(t *testing.T) {
+	items := []*domain.Merch{
+		{Name: "cup", Price: 20, Description: "Кружка", Category: "tableware", Available: true},
+		{Name: "umbrella", Price: 200, Category: "accessories"},
+	}
+
+	t.Run("список товаров с ETag", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetCatalog", mock.Anything).Return(items, `"v1"`, nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("GET", "/merch", http.NoBody)
+
+		h.List(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+
+		var resp model.MerchListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Items, 2)
+		assert.Equal(t, model.MerchResponse{Name: "cup", Price: 20, Description: "Кружка", Category: "tableware", Available: true}, resp.Items[0])
+		assert.False(t, resp.Items[1].Available)
+	})
+
+	t.Run("каталог не изменился", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetCatalog", mock.Anything).Return(items, `"v1"`, nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("GET", "/merch", http.NoBody)
+		c.Request.Header.Set("If-None-Match", `"v0", W/"v1"`)
+
+		h.List(c)
+		c.Writer.WriteHeaderNow()
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestMerchGet(t *testing.T) {
+	t.Run("товар найден", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetMerch", mock.Anything, "cup").
+			Return(&domain.Merch{Name: "cup", Price: 20, Available: true}, `"v1"`, nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("GET", "/merch/cup", http.NoBody)
+		c.Request.Header.Set("If-None-Match", `"v0"`)
+
+		h.Get(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"v1"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("товар по цене распродажи", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetMerch", mock.Anything, "cup").
+			Return(&domain.Merch{Name: "cup", Price: 15, RegularPrice: 20, Available: true}, `"v2"`, nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("GET", "/merch/cup", http.NoBody)
+
+		h.Get(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.MerchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint64(15), resp.Price)
+		require.NotNil(t, resp.RegularPrice)
+		assert.Equal(t, uint64(20), *resp.RegularPrice)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetMerch", mock.Anything, "unknown").Return(nil, "", domain.ErrMerchNotFound)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "unknown"}}
+		c.Request = httptest.NewRequest("GET", "/merch/unknown", http.NoBody)
+
+		h.Get(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMerchCreate(t *testing.T) {
+	t.Run("товар создан", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("CreateMerch", mock.Anything, &domain.Merch{Name: "sticker", Price: 5, Category: "other", Available: true, Transferable: true}).
+			Return(nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/merch", bytes.NewBufferString(`{"name":"sticker","price":5,"category":"other"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp model.MerchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "sticker", resp.Name)
+		assert.True(t, resp.Available)
+		assert.True(t, resp.Transferable)
+	})
+
+	t.Run("непередаваемый товар", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("CreateMerch", mock.Anything, &domain.Merch{Name: "badge", Price: 50, Category: "other", Available: true}).
+			Return(nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/merch", bytes.NewBufferString(`{"name":"badge","price":50,"category":"other","transferable":false}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"transferable":false`)
+	})
+
+	t.Run("не указана цена", func(t *testing.T) {
+		h := NewMerchHandler(new(mockMerchService))
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/merch", bytes.NewBufferString(`{"name":"sticker"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("недопустимое название", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("CreateMerch", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("MerchService.CreateMerch: %w: недопустимый символ ' ' в названии", domain.ErrInvalidMerch))
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/merch", bytes.NewBufferString(`{"name":"red pen","price":10}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), domain.ErrInvalidMerch.Error())
+		assert.NotContains(t, w.Body.String(), "MerchService")
+	})
+
+	t.Run("товар уже существует", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("CreateMerch", mock.Anything, mock.Anything).Return(domain.ErrMerchAlreadyExists)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/merch", bytes.NewBufferString(`{"name":"pen","price":10}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeMerchAlreadyExists)
+	})
+}
+
+func TestMerchUpdate(t *testing.T) {
+	price := uint64(15)
+
+	t.Run("товар изменен", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("UpdateMerch", mock.Anything, "pen", domain.MerchUpdate{Price: &price}).
+			Return(&domain.Merch{Name: "pen", Price: 15, Available: true}, nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "pen"}}
+		c.Request = httptest.NewRequest("PATCH", "/admin/merch/pen", bytes.NewBufferString(`{"price":15}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Update(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.MerchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint64(15), resp.Price)
+	})
+
+	t.Run("остаток не перезаписывается", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("UpdateMerch", mock.Anything, "pen", domain.MerchUpdate{Price: &price}).
+			Return(&domain.Merch{Name: "pen", Price: 15, Available: true}, nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "pen"}}
+		c.Request = httptest.NewRequest("PATCH", "/admin/merch/pen", bytes.NewBufferString(`{"price":15,"stock":3}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Update(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		merchService.AssertExpectations(t)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("UpdateMerch", mock.Anything, "unknown", mock.Anything).Return(nil, domain.ErrMerchNotFound)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "unknown"}}
+		c.Request = httptest.NewRequest("PATCH", "/admin/merch/unknown", bytes.NewBufferString(`{"price":15}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Update(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMerchRetire(t *testing.T) {
+	t.Run("товар снят с продажи", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("RetireMerch", mock.Anything, "pen").Return(nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "pen"}}
+		c.Request = httptest.NewRequest("DELETE", "/admin/merch/pen", http.NoBody)
+
+		h.Retire(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		merchService.AssertExpectations(t)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("RetireMerch", mock.Anything, "unknown").Return(domain.ErrMerchNotFound)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "unknown"}}
+		c.Request = httptest.NewRequest("DELETE", "/admin/merch/unknown", http.NoBody)
+
+		h.Retire(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMerchRestock(t *testing.T) {
+	t.Run("склад пополнен", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		stock := uint64(12)
+		merchService.On("RestockMerch", mock.Anything, "cup", uint64(10)).
+			Return(&domain.Merch{Name: "cup", Price: 20, Available: true, Stock: &stock}, nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("POST", "/admin/merch/cup/restock", bytes.NewBufferString(`{"quantity":10}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Restock(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.MerchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.NotNil(t, resp.Stock)
+		assert.Equal(t, uint64(12), *resp.Stock)
+	})
+
+	t.Run("нулевое количество", func(t *testing.T) {
+		h := NewMerchHandler(new(mockMerchService))
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("POST", "/admin/merch/cup/restock", bytes.NewBufferString(`{"quantity":0}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Restock(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("количество товара не ограничено", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("RestockMerch", mock.Anything, "pen", uint64(10)).Return(nil, domain.ErrStockUnlimited)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "pen"}}
+		c.Request = httptest.NewRequest("POST", "/admin/merch/pen/restock", bytes.NewBufferString(`{"quantity":10}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Restock(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeStockUnlimited)
+	})
+}
+
+func TestMerchSchedulePrice(t *testing.T) {
+	t.Run("распродажа запланирована", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+		merchService.On("SchedulePrice", mock.Anything, &domain.PriceSchedule{
+			ItemName: "cup", Price: 15, EffectiveFrom: from, EffectiveTo: &to, Priority: 1, CreatedBy: "admin",
+		}).Return(nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "admin")
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("POST", "/admin/merch/cup/price-schedules",
+			bytes.NewBufferString(`{"price":15,"effectiveFrom":"2024-03-01T00:00:00Z","effectiveTo":"2024-03-03T00:00:00Z","priority":1}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.SchedulePrice(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp model.PriceScheduleResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "cup", resp.Item)
+		assert.Equal(t, uint64(15), resp.Price)
+	})
+
+	t.Run("начало в прошлом", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("SchedulePrice", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("MerchService.SchedulePrice: %w: начало действия не может быть в прошлом", domain.ErrInvalidPriceSchedule))
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("POST", "/admin/merch/cup/price-schedules",
+			bytes.NewBufferString(`{"price":15,"effectiveFrom":"2020-01-01T00:00:00Z"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.SchedulePrice(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "начало действия не может быть в прошлом")
+	})
+}
+
+func TestMerchCancelPriceSchedule(t *testing.T) {
+	t.Run("расписание отменено", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("CancelPriceSchedule", mock.Anything, "cup", int64(3)).Return(nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}, {Key: "id", Value: "3"}}
+		c.Request = httptest.NewRequest("DELETE", "/admin/merch/cup/price-schedules/3", http.NoBody)
+
+		h.CancelPriceSchedule(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("расписание не найдено", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("CancelPriceSchedule", mock.Anything, "cup", int64(4)).Return(domain.ErrPriceScheduleNotFound)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}, {Key: "id", Value: "4"}}
+		c.Request = httptest.NewRequest("DELETE", "/admin/merch/cup/price-schedules/4", http.NoBody)
+
+		h.CancelPriceSchedule(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMerchPriceHistory(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	saleFrom := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	saleTo := saleFrom.Add(48 * time.Hour)
+	history := &domain.PriceHistory{
+		ItemName:  "cup",
+		Changes:   []*domain.PriceChange{{Price: 20, ChangedAt: created}},
+		Schedules: []*domain.PriceSchedule{{Id: 3, ItemName: "cup", Price: 15, EffectiveFrom: saleFrom, EffectiveTo: &saleTo}},
+	}
+
+	t.Run("цена на момент покупки", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetPriceHistory", mock.Anything, "cup").Return(history, nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("GET", "/admin/merch/cup/prices?at=2024-02-01T12:00:00Z", http.NoBody)
+
+		h.PriceHistory(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.PriceHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Changes, 1)
+		assert.Len(t, resp.Schedules, 1)
+		require.NotNil(t, resp.PriceAt)
+		assert.Equal(t, uint64(15), *resp.PriceAt)
+	})
+
+	t.Run("цена до начала истории неизвестна", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetPriceHistory", mock.Anything, "cup").Return(history, nil)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "cup"}}
+		c.Request = httptest.NewRequest("GET", "/admin/merch/cup/prices?at=2023-12-01T00:00:00Z", http.NoBody)
+
+		h.PriceHistory(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "priceAt")
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		merchService := new(mockMerchService)
+		h := NewMerchHandler(merchService)
+		merchService.On("GetPriceHistory", mock.Anything, "unknown").Return(nil, domain.ErrMerchNotFound)
+
+		c, w := setupTestContext()
+		c.Params = []gin.Param{{Key: "name", Value: "unknown"}}
+		c.Request = httptest.NewRequest("GET", "/admin/merch/unknown/prices", http.NoBody)
+
+		h.PriceHistory(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
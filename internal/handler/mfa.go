@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// MFAHandler обрабатывает запросы подключения и проверки второго фактора
+type MFAHandler struct {
+	mfaService service.MFAService
+}
+
+// NewMFAHandler создает новый экземпляр обработчика двухфакторной аутентификации
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+// Enroll выдает новый секрет TOTP текущему пользователю
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	enrollment, err := h.mfaService.Enroll(c.Request.Context(), username)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+			handleError(c, http.StatusConflict, ErrCodeMFAAlreadyEnabled, "Двухфакторная аутентификация уже подключена")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка подключения второго фактора")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.MFAEnrollResponse{
+		Secret:     enrollment.Secret,
+		OtpauthURI: enrollment.URI,
+	})
+}
+
+// Confirm включает второй фактор после проверки первого кода и возвращает коды восстановления
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	var req model.MFAConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	codes, err := h.mfaService.Confirm(c.Request.Context(), username, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMFANotEnrolled):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Сначала получите секрет второго фактора")
+		case errors.Is(err, domain.ErrMFAAlreadyEnabled):
+			handleError(c, http.StatusConflict, ErrCodeMFAAlreadyEnabled, "Двухфакторная аутентификация уже подключена")
+		case errors.Is(err, domain.ErrInvalidMFACode):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidMFACode, "Неверный код")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка подключения второго фактора")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Verify завершает вход пользователя с подключенным вторым фактором
+func (h *MFAHandler) Verify(c *gin.Context) {
+	var req model.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	tokens, err := h.mfaService.VerifyLogin(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		var lockout *domain.LockoutError
+		switch {
+		case errors.As(err, &lockout):
+			handleLockout(c, lockout)
+		case errors.Is(err, domain.ErrInvalidToken):
+			handleError(c, http.StatusUnauthorized, ErrCodeInvalidToken, "Недействительный или истекший токен")
+		case errors.Is(err, domain.ErrInvalidMFACode):
+			handleError(c, http.StatusUnauthorized, ErrCodeInvalidMFACode, "Неверный код")
+		case errors.Is(err, domain.ErrMFANotEnrolled):
+			handleError(c, http.StatusUnauthorized, ErrCodeInvalidToken, "Недействительный или истекший токен")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка аутентификации")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.AuthResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockMFAService struct {
+	mock.Mock
+}
+
+func (m *mockMFAService) Enroll(ctx context.Context, username string) (*domain.MFAEnrollment, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MFAEnrollment), args.Error(1)
+}
+
+func (m *mockMFAService) Confirm(ctx context.Context, username, code string) ([]string, error) {
+	args := m.Called(ctx, username, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockMFAService) IsEnabled(ctx context.Context, username string) (bool, error) {
+	args := m.Called(ctx, username)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockMFAService) VerifyLogin(ctx context.Context, mfaToken, code string) (*domain.TokenPair, error) {
+	args := m.Called(ctx, mfaToken, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TokenPair), args.Error(1)
+}
+
+func TestMFAEnroll(t *testing.T) {
+	t.Run("выдача секрета", func(t *testing.T) {
+		mfaService := new(mockMFAService)
+		h := NewMFAHandler(mfaService)
+
+		mfaService.On("Enroll", mock.Anything, "testuser").
+			Return(&domain.MFAEnrollment{Secret: "SECRET", URI: "otpauth://totp/test"}, nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/mfa/enroll", http.NoBody)
+		c.Set("username", "testuser")
+
+		h.Enroll(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "SECRET", response["secret"])
+		assert.Equal(t, "otpauth://totp/test", response["otpauthUri"])
+	})
+
+	t.Run("второй фактор уже подключен", func(t *testing.T) {
+		mfaService := new(mockMFAService)
+		h := NewMFAHandler(mfaService)
+
+		mfaService.On("Enroll", mock.Anything, "testuser").Return(nil, domain.ErrMFAAlreadyEnabled)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/mfa/enroll", http.NoBody)
+		c.Set("username", "testuser")
+
+		h.Enroll(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeMFAAlreadyEnabled)
+	})
+}
+
+func TestMFAConfirm(t *testing.T) {
+	t.Run("успешное подключение", func(t *testing.T) {
+		mfaService := new(mockMFAService)
+		h := NewMFAHandler(mfaService)
+
+		mfaService.On("Confirm", mock.Anything, "testuser", "123456").Return([]string{"abcde-fghij"}, nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/mfa/confirm", bytes.NewBufferString(`{"code":"123456"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("username", "testuser")
+
+		h.Confirm(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "abcde-fghij")
+	})
+
+	t.Run("неверный код", func(t *testing.T) {
+		mfaService := new(mockMFAService)
+		h := NewMFAHandler(mfaService)
+
+		mfaService.On("Confirm", mock.Anything, "testuser", "000000").Return(nil, domain.ErrInvalidMFACode)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/mfa/confirm", bytes.NewBufferString(`{"code":"000000"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("username", "testuser")
+
+		h.Confirm(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidMFACode)
+	})
+}
+
+func TestMFAVerify(t *testing.T) {
+	t.Run("успешный вход", func(t *testing.T) {
+		mfaService := new(mockMFAService)
+		h := NewMFAHandler(mfaService)
+
+		mfaService.On("VerifyLogin", mock.Anything, "mfa-token", "123456").
+			Return(&domain.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/auth/mfa", bytes.NewBufferString(`{"mfaToken":"mfa-token","code":"123456"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Verify(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "access", response["token"])
+		assert.Equal(t, "refresh", response["refreshToken"])
+	})
+
+	t.Run("неверный код", func(t *testing.T) {
+		mfaService := new(mockMFAService)
+		h := NewMFAHandler(mfaService)
+
+		mfaService.On("VerifyLogin", mock.Anything, "mfa-token", "000000").Return(nil, domain.ErrInvalidMFACode)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/auth/mfa", bytes.NewBufferString(`{"mfaToken":"mfa-token","code":"000000"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Verify(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidMFACode)
+	})
+
+	t.Run("вход заблокирован", func(t *testing.T) {
+		mfaService := new(mockMFAService)
+		h := NewMFAHandler(mfaService)
+
+		mfaService.On("VerifyLogin", mock.Anything, "mfa-token", "000000").
+			Return(nil, &domain.LockoutError{RetryAfter: time.Minute})
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/auth/mfa", bytes.NewBufferString(`{"mfaToken":"mfa-token","code":"000000"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Verify(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	})
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// OrderHandler обрабатывает запросы к заказам и их выдаче
+type OrderHandler struct {
+	orderService service.OrderService
+}
+
+// NewOrderHandler создает новый экземпляр обработчика заказов
+func NewOrderHandler(orderService service.OrderService) *OrderHandler {
+	return &OrderHandler{orderService: orderService}
+}
+
+// List возвращает заказы пользователя
+func (h *OrderHandler) List(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	var query model.OrdersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверные параметры запроса")
+		return
+	}
+
+	h.listOrders(c, domain.OrderFilter{
+		Username: username,
+		Status:   domain.OrderStatus(query.Status),
+		Limit:    query.Limit,
+	})
+}
+
+// Get возвращает заказ пользователя с журналом изменений статуса
+func (h *OrderHandler) Get(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	id, ok := orderId(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orderService.GetUserOrder(c.Request.Context(), username, id)
+	if err != nil {
+		handleOrderError(c, err, "Ошибка получения заказа")
+		return
+	}
+
+	c.JSON(http.StatusOK, toOrderResponse(order))
+}
+
+// Cancel отменяет невыданный заказ пользователя и возвращает монеты
+func (h *OrderHandler) Cancel(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	id, ok := orderId(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orderService.CancelUserOrder(c.Request.Context(), username, id)
+	if err != nil {
+		handleOrderError(c, err, "Ошибка отмены заказа")
+		return
+	}
+
+	c.JSON(http.StatusOK, toOrderResponse(order))
+}
+
+// AdminList возвращает заказы всех пользователей с фильтром по статусу и покупателю
+func (h *OrderHandler) AdminList(c *gin.Context) {
+	var query model.OrdersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверные параметры запроса")
+		return
+	}
+
+	h.listOrders(c, domain.OrderFilter{
+		Username: query.Username,
+		Status:   domain.OrderStatus(query.Status),
+		Limit:    query.Limit,
+	})
+}
+
+// AdminGet возвращает любой заказ с журналом изменений статуса
+func (h *OrderHandler) AdminGet(c *gin.Context) {
+	id, ok := orderId(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.orderService.GetOrder(c.Request.Context(), id)
+	if err != nil {
+		handleOrderError(c, err, "Ошибка получения заказа")
+		return
+	}
+
+	c.JSON(http.StatusOK, toOrderResponse(order))
+}
+
+// UpdateStatus переводит заказ в следующий статус выдачи
+func (h *OrderHandler) UpdateStatus(c *gin.Context) {
+	id, ok := orderId(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	status, err := domain.ParseOrderStatus(req.Status)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неизвестный статус заказа")
+		return
+	}
+
+	order, err := h.orderService.ChangeOrderStatus(c.Request.Context(), id, status, c.GetString("username"), req.Comment)
+	if err != nil {
+		handleOrderError(c, err, "Ошибка изменения статуса заказа")
+		return
+	}
+
+	c.JSON(http.StatusOK, toOrderResponse(order))
+}
+
+func (h *OrderHandler) listOrders(c *gin.Context, filter domain.OrderFilter) {
+	orders, err := h.orderService.ListOrders(c.Request.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidFilter):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Недопустимые параметры фильтра")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения заказов")
+		}
+		return
+	}
+
+	resp := model.OrderListResponse{Orders: make([]model.OrderResponse, 0, len(orders))}
+	for _, order := range orders {
+		resp.Orders = append(resp.Orders, toOrderResponse(order))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// orderId разбирает номер заказа из пути. Возвращает false, если ответ с ошибкой уже отправлен.
+func orderId(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный номер заказа")
+		return 0, false
+	}
+	return id, true
+}
+
+func handleOrderError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrOrderNotFound):
+		handleError(c, http.StatusNotFound, ErrCodeNotFound, "Заказ не найден")
+	case errors.Is(err, domain.ErrOrderTransition):
+		handleError(c, http.StatusConflict, ErrCodeInvalidOrderStatus, validationMessage(err))
+	case errors.Is(err, domain.ErrInventoryShortage):
+		handleError(c, http.StatusConflict, ErrCodeInventoryShortage, validationMessage(err))
+	default:
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, message)
+	}
+}
+
+func toOrderResponse(order *domain.Order) model.OrderResponse {
+	resp := model.OrderResponse{
+		Id:        order.Id,
+		Username:  order.Username,
+		Recipient: order.Recipient,
+		Status:    string(order.Status),
+		Items:     make([]model.OrderItemResponse, 0, len(order.Items)),
+		Total:     order.Total,
+		Discount:  order.Discount,
+		CreatedAt: order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+	for _, item := range order.Items {
+		resp.Items = append(resp.Items, model.OrderItemResponse{
+			Item:     item.ItemName,
+			Quantity: item.Quantity,
+			Price:    item.UnitPrice,
+		})
+	}
+	for _, change := range order.History {
+		resp.History = append(resp.History, model.OrderStatusChangeResponse{
+			From:      string(change.From),
+			To:        string(change.To),
+			ChangedBy: change.ChangedBy,
+			Comment:   change.Comment,
+			ChangedAt: change.ChangedAt,
+		})
+	}
+	return resp
+}
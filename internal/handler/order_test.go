@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockOrderService struct {
+	mock.Mock
+}
+
+func (m *mockOrderService) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderService) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderService) GetUserOrder(ctx context.Context, username string, id int64) (*domain.Order, error) {
+	args := m.Called(ctx, username, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderService) ChangeOrderStatus(ctx context.Context, id int64, status domain.OrderStatus, changedBy, comment string) (*domain.Order, error) {
+	args := m.Called(ctx, id, status, changedBy, comment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderService) CancelUserOrder(ctx context.Context, username string, id int64) (*domain.Order, error) {
+	args := m.Called(ctx, username, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func TestOrderList(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("заказы пользователя", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+		orderService.On("ListOrders", mock.Anything, domain.OrderFilter{Username: "buyer", Status: domain.OrderStatusPlaced}).
+			Return([]*domain.Order{{
+				Id:        7,
+				Username:  "buyer",
+				Total:     40,
+				Status:    domain.OrderStatusPlaced,
+				Items:     []*domain.OrderItem{{ItemName: "cup", Quantity: 2, UnitPrice: 20}},
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			}}, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("GET", "/api/orders?status=placed", http.NoBody)
+
+		h.List(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.OrderListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Orders, 1)
+		assert.Equal(t, "placed", resp.Orders[0].Status)
+		assert.Equal(t, []model.OrderItemResponse{{Item: "cup", Quantity: 2, Price: 20}}, resp.Orders[0].Items)
+	})
+
+	t.Run("неизвестный статус", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+		orderService.On("ListOrders", mock.Anything, mock.Anything).
+			Return(nil, fmt.Errorf("OrderService.ListOrders: %w", domain.ErrInvalidFilter))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Request = httptest.NewRequest("GET", "/api/orders?status=lost", http.NoBody)
+
+		h.List(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestOrderGet(t *testing.T) {
+	t.Run("чужой заказ не найден", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+		orderService.On("GetUserOrder", mock.Anything, "buyer", int64(7)).
+			Return(nil, fmt.Errorf("OrderService.GetUserOrder: %w", domain.ErrOrderNotFound))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "id", Value: "7"}}
+		c.Request = httptest.NewRequest("GET", "/api/orders/7", http.NoBody)
+
+		h.Get(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("неверный номер заказа", func(t *testing.T) {
+		h := NewOrderHandler(new(mockOrderService))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "id", Value: "abc"}}
+		c.Request = httptest.NewRequest("GET", "/api/orders/abc", http.NoBody)
+
+		h.Get(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestOrderUpdateStatus(t *testing.T) {
+	t.Run("заказ готов к выдаче", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+		orderService.On("ChangeOrderStatus", mock.Anything, int64(7), domain.OrderStatusReadyForPickup, "manager", "стойка 3").
+			Return(&domain.Order{
+				Id:     7,
+				Status: domain.OrderStatusReadyForPickup,
+				History: []*domain.OrderStatusChange{
+					{OrderId: 7, To: domain.OrderStatusPlaced, ChangedBy: "buyer"},
+					{OrderId: 7, From: domain.OrderStatusPlaced, To: domain.OrderStatusReadyForPickup, ChangedBy: "manager", Comment: "стойка 3"},
+				},
+			}, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "manager")
+		c.Params = []gin.Param{{Key: "id", Value: "7"}}
+		c.Request = httptest.NewRequest("PUT", "/admin/orders/7/status", bytes.NewBufferString(`{"status":"ready_for_pickup","comment":"стойка 3"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.UpdateStatus(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "ready_for_pickup", resp.Status)
+		require.Len(t, resp.History, 2)
+		assert.Equal(t, "placed", resp.History[1].From)
+	})
+
+	t.Run("неизвестный статус", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+
+		c, w := setupTestContext()
+		c.Set("username", "manager")
+		c.Params = []gin.Param{{Key: "id", Value: "7"}}
+		c.Request = httptest.NewRequest("PUT", "/admin/orders/7/status", bytes.NewBufferString(`{"status":"lost"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.UpdateStatus(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		orderService.AssertNotCalled(t, "ChangeOrderStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("недопустимый переход", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+		orderService.On("ChangeOrderStatus", mock.Anything, int64(7), domain.OrderStatusCancelled, "manager", "").
+			Return(nil, fmt.Errorf("OrderService.ChangeOrderStatus: %w: из delivered в cancelled", domain.ErrOrderTransition))
+
+		c, w := setupTestContext()
+		c.Set("username", "manager")
+		c.Params = []gin.Param{{Key: "id", Value: "7"}}
+		c.Request = httptest.NewRequest("PUT", "/admin/orders/7/status", bytes.NewBufferString(`{"status":"cancelled"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.UpdateStatus(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidOrderStatus)
+		assert.Contains(t, w.Body.String(), "из delivered в cancelled")
+	})
+}
+
+func TestOrderCancel(t *testing.T) {
+	t.Run("заказ отменен", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+		orderService.On("CancelUserOrder", mock.Anything, "buyer", int64(7)).
+			Return(&domain.Order{Id: 7, Username: "buyer", Status: domain.OrderStatusCancelled}, nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "id", Value: "7"}}
+		c.Request = httptest.NewRequest("POST", "/api/orders/7/cancel", http.NoBody)
+
+		h.Cancel(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.OrderResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "cancelled", resp.Status)
+	})
+
+	t.Run("товар уже передан", func(t *testing.T) {
+		orderService := new(mockOrderService)
+		h := NewOrderHandler(orderService)
+		orderService.On("CancelUserOrder", mock.Anything, "buyer", int64(7)).
+			Return(nil, fmt.Errorf("OrderService.ChangeOrderStatus: OrderRepository.UpdateOrderStatus: %w: cup", domain.ErrInventoryShortage))
+
+		c, w := setupTestContext()
+		c.Set("username", "buyer")
+		c.Params = []gin.Param{{Key: "id", Value: "7"}}
+		c.Request = httptest.NewRequest("POST", "/api/orders/7/cancel", http.NoBody)
+
+		h.Cancel(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInventoryShortage)
+		assert.Contains(t, w.Body.String(), "недостаточно товара в инвентаре: cup")
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// PasswordHandler обрабатывает запросы смены и сброса пароля
+type PasswordHandler struct {
+	passwordService service.PasswordService
+	tokenService    service.TokenService
+}
+
+// NewPasswordHandler создает новый экземпляр обработчика паролей
+func NewPasswordHandler(passwordService service.PasswordService, tokenService service.TokenService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: passwordService,
+		tokenService:    tokenService,
+	}
+}
+
+// ChangePassword меняет пароль текущего пользователя и завершает его сессии
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var req model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	err := h.passwordService.ChangePassword(c.Request.Context(), username, req.OldPassword, req.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			handleError(c, http.StatusForbidden, ErrCodeInvalidCredentials, "Неверный текущий пароль")
+		case errors.Is(err, domain.ErrWeakPassword):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка смены пароля")
+		}
+		return
+	}
+
+	// Отзываем и текущий access-токен, чтобы клиент прошел аутентификацию с новым паролем
+	expiresAt := c.GetTime("tokenExpiresAt")
+	if expiresAt.IsZero() {
+		expiresAt = time.Now()
+	}
+	if jti := c.GetString("jti"); jti != "" {
+		if err := h.tokenService.RevokeTokens(c.Request.Context(), username, "", jti, expiresAt); err != nil {
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка смены пароля")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// ResetPassword устанавливает новый пароль по одноразовому токену сброса
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	err := h.passwordService.ResetPassword(c.Request.Context(), req.ResetToken, req.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidToken, "Недействительный или истекший токен сброса")
+		case errors.Is(err, domain.ErrWeakPassword):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка сброса пароля")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// IssueResetToken выдает администратору одноразовый токен сброса пароля пользователя
+func (h *PasswordHandler) IssueResetToken(c *gin.Context) {
+	resetToken, expiresAt, err := h.passwordService.IssueResetToken(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Пользователь не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка выпуска токена сброса")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.PasswordResetResponse{
+		ResetToken: resetToken,
+		ExpiresAt:  expiresAt,
+	})
+}
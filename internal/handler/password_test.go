@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockPasswordService struct {
+	mock.Mock
+}
+
+func (m *mockPasswordService) ChangePassword(ctx context.Context, username, oldPassword, newPassword string) error {
+	args := m.Called(ctx, username, oldPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *mockPasswordService) IssueResetToken(ctx context.Context, username string) (string, time.Time, error) {
+	args := m.Called(ctx, username)
+	return args.String(0), args.Get(1).(time.Time), args.Error(2)
+}
+
+func (m *mockPasswordService) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	args := m.Called(ctx, resetToken, newPassword)
+	return args.Error(0)
+}
+
+func TestChangePassword(t *testing.T) {
+	t.Run("успешная смена пароля", func(t *testing.T) {
+		passwordService := new(mockPasswordService)
+		tokenService := new(mockTokenService)
+		h := NewPasswordHandler(passwordService, tokenService)
+		expiresAt := time.Now().Add(time.Minute)
+
+		passwordService.On("ChangePassword", mock.Anything, "testuser", "old-password1", "new-password1").Return(nil)
+		tokenService.On("RevokeTokens", mock.Anything, "testuser", "", "access-jti", expiresAt).Return(nil)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"oldPassword":"old-password1","newPassword":"new-password1"}`)
+		c.Request = httptest.NewRequest("POST", "/password", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("username", "testuser")
+		c.Set("jti", "access-jti")
+		c.Set("tokenExpiresAt", expiresAt)
+
+		h.ChangePassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		passwordService.AssertExpectations(t)
+		tokenService.AssertExpectations(t)
+	})
+
+	t.Run("неверный текущий пароль", func(t *testing.T) {
+		passwordService := new(mockPasswordService)
+		h := NewPasswordHandler(passwordService, new(mockTokenService))
+
+		passwordService.On("ChangePassword", mock.Anything, "testuser", "wrong-password1", "new-password1").
+			Return(domain.ErrInvalidCredentials)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"oldPassword":"wrong-password1","newPassword":"new-password1"}`)
+		c.Request = httptest.NewRequest("POST", "/password", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("username", "testuser")
+
+		h.ChangePassword(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidCredentials)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Run("успешный сброс", func(t *testing.T) {
+		passwordService := new(mockPasswordService)
+		h := NewPasswordHandler(passwordService, new(mockTokenService))
+
+		passwordService.On("ResetPassword", mock.Anything, "reset-token", "new-password1").Return(nil)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"resetToken":"reset-token","newPassword":"new-password1"}`)
+		c.Request = httptest.NewRequest("POST", "/password/reset", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.ResetPassword(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		passwordService.AssertExpectations(t)
+	})
+
+	t.Run("недействительный токен", func(t *testing.T) {
+		passwordService := new(mockPasswordService)
+		h := NewPasswordHandler(passwordService, new(mockTokenService))
+
+		passwordService.On("ResetPassword", mock.Anything, "used-token", "new-password1").Return(domain.ErrInvalidToken)
+
+		c, w := setupTestContext()
+		body := bytes.NewBufferString(`{"resetToken":"used-token","newPassword":"new-password1"}`)
+		c.Request = httptest.NewRequest("POST", "/password/reset", body)
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.ResetPassword(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodeInvalidToken)
+	})
+}
+
+func TestIssueResetToken(t *testing.T) {
+	passwordService := new(mockPasswordService)
+	h := NewPasswordHandler(passwordService, new(mockTokenService))
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	passwordService.On("IssueResetToken", mock.Anything, "testuser").Return("reset-token", expiresAt, nil)
+
+	c, w := setupTestContext()
+	c.Request = httptest.NewRequest("POST", "/admin/users/testuser/password-reset", http.NoBody)
+	c.Params = []gin.Param{{Key: "username", Value: "testuser"}}
+
+	h.IssueResetToken(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "reset-token", response["resetToken"])
+	assert.Equal(t, expiresAt.Format(time.RFC3339), response["expiresAt"])
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+)
+
+// PromoCodeHandler обрабатывает административные запросы к промокодам
+type PromoCodeHandler struct {
+	promoService service.PromoCodeService
+}
+
+// NewPromoCodeHandler создает новый экземпляр обработчика промокодов
+func NewPromoCodeHandler(promoService service.PromoCodeService) *PromoCodeHandler {
+	return &PromoCodeHandler{promoService: promoService}
+}
+
+// Create создает промокод
+func (h *PromoCodeHandler) Create(c *gin.Context) {
+	var req model.CreatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверный формат запроса")
+		return
+	}
+
+	promo := &domain.PromoCode{
+		Code:           req.Code,
+		DiscountType:   domain.PromoDiscountType(req.DiscountType),
+		DiscountValue:  req.DiscountValue,
+		ItemName:       req.Item,
+		Category:       req.Category,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		MaxRedemptions: req.MaxRedemptions,
+		MaxPerUser:     req.MaxPerUser,
+	}
+	if err := h.promoService.CreatePromoCode(c.Request.Context(), promo); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidPromoCode):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, validationMessage(err))
+		case errors.Is(err, domain.ErrMerchNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Товар не найден")
+		case errors.Is(err, domain.ErrPromoCodeExists):
+			handleError(c, http.StatusConflict, ErrCodePromoCodeExists, "Промокод уже существует")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка создания промокода")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPromoCodeResponse(promo, time.Now()))
+}
+
+// List возвращает все промокоды
+func (h *PromoCodeHandler) List(c *gin.Context) {
+	promos, err := h.promoService.ListPromoCodes(c.Request.Context())
+	if err != nil {
+		handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка получения промокодов")
+		return
+	}
+
+	now := time.Now()
+	resp := model.PromoCodeListResponse{PromoCodes: make([]model.PromoCodeResponse, 0, len(promos))}
+	for _, promo := range promos {
+		resp.PromoCodes = append(resp.PromoCodes, toPromoCodeResponse(promo, now))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Deactivate досрочно завершает действие промокода
+func (h *PromoCodeHandler) Deactivate(c *gin.Context) {
+	if err := h.promoService.DeactivatePromoCode(c.Request.Context(), c.Param("code")); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPromoCodeNotFound):
+			handleError(c, http.StatusNotFound, ErrCodeNotFound, "Промокод не найден")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка отключения промокода")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+func toPromoCodeResponse(promo *domain.PromoCode, now time.Time) model.PromoCodeResponse {
+	return model.PromoCodeResponse{
+		Code:           promo.Code,
+		DiscountType:   string(promo.DiscountType),
+		DiscountValue:  promo.DiscountValue,
+		Item:           promo.ItemName,
+		Category:       promo.Category,
+		ValidFrom:      promo.ValidFrom,
+		ValidUntil:     promo.ValidUntil,
+		MaxRedemptions: promo.MaxRedemptions,
+		MaxPerUser:     promo.MaxPerUser,
+		Redemptions:    promo.Redemptions,
+		Active:         promo.ActiveAt(now),
+		CreatedAt:      promo.CreatedAt,
+	}
+}
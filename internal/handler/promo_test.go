@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPromoCodeService struct {
+	mock.Mock
+}
+
+func (m *mockPromoCodeService) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	args := m.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (m *mockPromoCodeService) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoCode), args.Error(1)
+}
+
+func (m *mockPromoCodeService) DeactivatePromoCode(ctx context.Context, code string) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func TestPromoCodeCreate(t *testing.T) {
+	t.Run("промокод создан", func(t *testing.T) {
+		promoService := new(mockPromoCodeService)
+		h := NewPromoCodeHandler(promoService)
+		category := "clothes"
+		promoService.On("CreatePromoCode", mock.Anything, &domain.PromoCode{
+			Code:          "hoodies20",
+			DiscountType:  domain.PromoDiscountPercent,
+			DiscountValue: 20,
+			Category:      &category,
+		}).Run(func(args mock.Arguments) {
+			promo := args.Get(1).(*domain.PromoCode)
+			promo.Code = "HOODIES20"
+		}).Return(nil)
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/promo-codes", bytes.NewBufferString(`{"code":"hoodies20","discountType":"percent","discountValue":20,"category":"clothes"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp model.PromoCodeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "HOODIES20", resp.Code)
+		assert.True(t, resp.Active)
+	})
+
+	t.Run("некорректный промокод", func(t *testing.T) {
+		promoService := new(mockPromoCodeService)
+		h := NewPromoCodeHandler(promoService)
+		promoService.On("CreatePromoCode", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("PromoCodeService.CreatePromoCode: %w: процент скидки должен быть от 1 до 100", domain.ErrInvalidPromoCode))
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/promo-codes", bytes.NewBufferString(`{"code":"ALL","discountType":"percent","discountValue":150}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "процент скидки должен быть от 1 до 100")
+	})
+
+	t.Run("промокод уже существует", func(t *testing.T) {
+		promoService := new(mockPromoCodeService)
+		h := NewPromoCodeHandler(promoService)
+		promoService.On("CreatePromoCode", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("PromoCodeService.CreatePromoCode: %w", domain.ErrPromoCodeExists))
+
+		c, w := setupTestContext()
+		c.Request = httptest.NewRequest("POST", "/admin/promo-codes", bytes.NewBufferString(`{"code":"FIRST-10","discountType":"fixed","discountValue":10}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+
+		h.Create(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), ErrCodePromoCodeExists)
+	})
+}
+
+func TestPromoCodeList(t *testing.T) {
+	promoService := new(mockPromoCodeService)
+	h := NewPromoCodeHandler(promoService)
+	expired := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	promoService.On("ListPromoCodes", mock.Anything).Return([]*domain.PromoCode{
+		{Code: "FIRST-10", DiscountType: domain.PromoDiscountFixed, DiscountValue: 10, Redemptions: 3},
+		{Code: "OLD", DiscountType: domain.PromoDiscountFixed, DiscountValue: 5, ValidUntil: &expired},
+	}, nil)
+
+	c, w := setupTestContext()
+	c.Request = httptest.NewRequest("GET", "/admin/promo-codes", http.NoBody)
+
+	h.List(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp model.PromoCodeListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.PromoCodes, 2)
+	assert.Equal(t, uint64(3), resp.PromoCodes[0].Redemptions)
+	assert.True(t, resp.PromoCodes[0].Active)
+	assert.False(t, resp.PromoCodes[1].Active)
+}
+
+func TestPromoCodeDeactivate(t *testing.T) {
+	promoService := new(mockPromoCodeService)
+	h := NewPromoCodeHandler(promoService)
+	promoService.On("DeactivatePromoCode", mock.Anything, "NOPE").
+		Return(fmt.Errorf("PromoCodeService.DeactivatePromoCode: %w", domain.ErrPromoCodeNotFound))
+
+	c, w := setupTestContext()
+	c.Params = []gin.Param{{Key: "code", Value: "NOPE"}}
+	c.Request = httptest.NewRequest("DELETE", "/admin/promo-codes/NOPE", http.NoBody)
+
+	h.Deactivate(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
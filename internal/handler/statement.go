@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// StatementHandler обрабатывает запросы выгрузки выписок
+type StatementHandler struct {
+	statementService service.StatementService
+}
+
+// NewStatementHandler создает новый экземпляр обработчика выписок
+func NewStatementHandler(statementService service.StatementService) *StatementHandler {
+	return &StatementHandler{statementService: statementService}
+}
+
+// Export выгружает выписку текущего пользователя
+func (h *StatementHandler) Export(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		handleError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "Пользователь не аутентифицирован")
+		return
+	}
+
+	var query model.StatementQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверные параметры запроса")
+		return
+	}
+
+	h.export(c, query, username)
+}
+
+// ExportAll выгружает выписку всех сотрудников или одного, если передан username
+func (h *StatementHandler) ExportAll(c *gin.Context) {
+	var query model.StatementQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неверные параметры запроса")
+		return
+	}
+
+	h.export(c, query, query.Username)
+}
+
+func (h *StatementHandler) export(c *gin.Context, query model.StatementQuery, username string) {
+	format, err := domain.ParseStatementFormat(query.Format)
+	if err != nil {
+		handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Неизвестный формат выписки")
+		return
+	}
+
+	filter := domain.StatementFilter{
+		Username: username,
+		From:     query.From,
+		To:       query.To,
+	}
+
+	w := &statementWriter{c: c, format: format}
+	if err := h.statementService.ExportStatement(c.Request.Context(), filter, format, w); err != nil {
+		if w.started {
+			// Заголовки уже отправлены, поэтому обрываем поток
+			logrus.Errorf("StatementHandler.export: выгрузка прервана: %v", err)
+			c.Abort()
+			return
+		}
+		switch {
+		case errors.Is(err, domain.ErrInvalidFilter):
+			handleError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Недопустимый период выписки")
+		default:
+			handleError(c, http.StatusInternalServerError, ErrCodeInternalError, "Ошибка выгрузки выписки")
+		}
+		return
+	}
+
+	w.start()
+}
+
+// statementWriter отправляет заголовки ответа при первой записи,
+// чтобы ошибки до начала выгрузки возвращались обычным JSON-ответом
+type statementWriter struct {
+	c       *gin.Context
+	format  domain.StatementFormat
+	started bool
+}
+
+func (w *statementWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.c.Writer.Write(p)
+}
+
+func (w *statementWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+
+	contentType := "text/csv; charset=utf-8"
+	if w.format == domain.StatementFormatJSONL {
+		contentType = "application/x-ndjson; charset=utf-8"
+	}
+	w.c.Header("Content-Type", contentType)
+	w.c.Header("Content-Disposition", `attachment; filename="statement.`+string(w.format)+`"`)
+	w.c.Status(http.StatusOK)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockStatementService struct {
+	mock.Mock
+}
+
+func (m *mockStatementService) ExportStatement(ctx context.Context, filter domain.StatementFilter, format domain.StatementFormat, w io.Writer) error {
+	args := m.Called(ctx, filter, format, w)
+	if body := args.String(0); body != "" {
+		if _, err := io.WriteString(w, body); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func TestStatementExport(t *testing.T) {
+	t.Run("выписка текущего пользователя", func(t *testing.T) {
+		statementService := new(mockStatementService)
+		h := NewStatementHandler(statementService)
+
+		filter := domain.StatementFilter{
+			Username: "testuser",
+			From:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:       time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		}
+		statementService.On("ExportStatement", mock.Anything, mock.MatchedBy(func(f domain.StatementFilter) bool {
+			return f.Username == filter.Username && f.From.Equal(filter.From) && f.To.Equal(filter.To)
+		}), domain.StatementFormatJSONL, mock.Anything).Return("{}\n", nil)
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/transactions/export?format=jsonl&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z", http.NoBody)
+
+		h.Export(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "statement.jsonl")
+		assert.Equal(t, "{}\n", w.Body.String())
+		statementService.AssertExpectations(t)
+	})
+
+	t.Run("неизвестный формат", func(t *testing.T) {
+		h := NewStatementHandler(new(mockStatementService))
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/transactions/export?format=xml", http.NoBody)
+
+		h.Export(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("недопустимый период", func(t *testing.T) {
+		statementService := new(mockStatementService)
+		h := NewStatementHandler(statementService)
+
+		statementService.On("ExportStatement", mock.Anything, mock.Anything, domain.StatementFormatCSV, mock.Anything).
+			Return("", domain.ErrInvalidFilter)
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/transactions/export?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", http.NoBody)
+
+		h.Export(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	})
+
+	t.Run("ошибка после начала выгрузки", func(t *testing.T) {
+		statementService := new(mockStatementService)
+		h := NewStatementHandler(statementService)
+
+		statementService.On("ExportStatement", mock.Anything, mock.Anything, domain.StatementFormatCSV, mock.Anything).
+			Return("username,record\n", errors.New("db error"))
+
+		c, w := setupTestContext()
+		c.Set("username", "testuser")
+		c.Request = httptest.NewRequest("GET", "/transactions/export", http.NoBody)
+
+		h.Export(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "username,record\n", w.Body.String())
+		assert.True(t, c.IsAborted())
+	})
+}
+
+func TestStatementExportAll(t *testing.T) {
+	statementService := new(mockStatementService)
+	h := NewStatementHandler(statementService)
+
+	statementService.On("ExportStatement", mock.Anything, domain.StatementFilter{}, domain.StatementFormatCSV, mock.Anything).
+		Return("username,record\n", nil)
+
+	c, w := setupTestContext()
+	c.Set("username", "admin")
+	c.Request = httptest.NewRequest("GET", "/admin/transactions/export", http.NoBody)
+
+	h.ExportAll(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	statementService.AssertExpectations(t)
+}
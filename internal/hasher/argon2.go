@@ -0,0 +1,116 @@
+package hasher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idPrefix    = "$argon2id$"
+	defaultSaltLength = 16
+	defaultKeyLength  = 32
+)
+
+// Argon2Params содержит параметры argon2id
+type Argon2Params struct {
+	Memory      uint32 // Объем памяти в КиБ
+	Iterations  uint32 // Количество проходов
+	Parallelism uint8  // Количество потоков
+	SaltLength  uint32 // Длина соли в байтах
+	KeyLength   uint32 // Длина хэша в байтах
+}
+
+// argon2idAlgorithm хэширует пароли argon2id в формате PHC:
+// $argon2id$v=19$m=<память>,t=<проходы>,p=<потоки>$<соль>$<хэш>
+type argon2idAlgorithm struct {
+	params Argon2Params
+}
+
+// NewArgon2id создает алгоритм argon2id с указанными параметрами
+func NewArgon2id(params Argon2Params) Algorithm {
+	return &argon2idAlgorithm{params: params}
+}
+
+func (a *argon2idAlgorithm) Hash(password string) ([]byte, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("генерация соли: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	encoded := fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version,
+		a.params.Memory, a.params.Iterations, a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+	return []byte(encoded), nil
+}
+
+func (a *argon2idAlgorithm) Verify(encoded []byte, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, actual) == 1, nil
+}
+
+func (a *argon2idAlgorithm) NeedsRehash(encoded []byte) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != a.params.Memory ||
+		params.Iterations != a.params.Iterations ||
+		params.Parallelism != a.params.Parallelism ||
+		params.SaltLength != a.params.SaltLength ||
+		params.KeyLength != a.params.KeyLength
+}
+
+func (a *argon2idAlgorithm) Identifies(encoded []byte) bool {
+	return bytes.HasPrefix(encoded, []byte(argon2idPrefix))
+}
+
+// decodeArgon2id разбирает хэш в формате PHC
+func decodeArgon2id(encoded []byte) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	parts := strings.Split(string(encoded), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("%w: версия: %v", ErrUnknownHash, err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: неподдерживаемая версия %d", ErrUnknownHash, version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("%w: параметры: %v", ErrUnknownHash, err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("%w: соль: %v", ErrUnknownHash, err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("%w: хэш: %v", ErrUnknownHash, err)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
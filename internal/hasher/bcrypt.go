@@ -0,0 +1,47 @@
+package hasher
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptAlgorithm хэширует пароли bcrypt, хэш вида $2a$<cost>$...
+type bcryptAlgorithm struct {
+	cost int
+}
+
+// NewBcrypt создает алгоритм bcrypt с указанной стоимостью
+func NewBcrypt(cost int) Algorithm {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &bcryptAlgorithm{cost: cost}
+}
+
+func (a *bcryptAlgorithm) Hash(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), a.cost)
+}
+
+func (a *bcryptAlgorithm) Verify(encoded []byte, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword(encoded, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *bcryptAlgorithm) NeedsRehash(encoded []byte) bool {
+	cost, err := bcrypt.Cost(encoded)
+	return err != nil || cost != a.cost
+}
+
+func (a *bcryptAlgorithm) Identifies(encoded []byte) bool {
+	return bytes.HasPrefix(encoded, []byte("$2a$")) ||
+		bytes.HasPrefix(encoded, []byte("$2b$")) ||
+		bytes.HasPrefix(encoded, []byte("$2y$"))
+}
@@ -0,0 +1,88 @@
+// Package hasher хэширует пароли в самоописывающем формате, чтобы алгоритм и его
+// параметры можно было менять без принудительного сброса паролей пользователей
+package hasher
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+)
+
+var (
+	ErrUnknownHash      = errors.New("неизвестный формат хэша пароля")
+	ErrUnknownAlgorithm = errors.New("неизвестный алгоритм хэширования")
+)
+
+// PasswordHasher хэширует и проверяет пароли
+type PasswordHasher interface {
+	// Hash возвращает хэш пароля, включающий алгоритм и параметры
+	Hash(password string) ([]byte, error)
+	// Verify проверяет пароль по сохраненному хэшу
+	Verify(encoded []byte, password string) (bool, error)
+	// NeedsRehash сообщает, что хэш получен устаревшим алгоритмом или параметрами
+	NeedsRehash(encoded []byte) bool
+}
+
+// Algorithm реализует конкретный алгоритм хэширования
+type Algorithm interface {
+	PasswordHasher
+	// Identifies проверяет, получен ли хэш этим алгоритмом
+	Identifies(encoded []byte) bool
+}
+
+// hasher хэширует новые пароли предпочтительным алгоритмом и проверяет хэши всех известных алгоритмов
+type hasher struct {
+	preferred Algorithm
+	known     []Algorithm
+}
+
+// New создает хэшер с предпочтительным алгоритмом и алгоритмами, хэши которых еще нужно проверять
+func New(preferred Algorithm, legacy ...Algorithm) PasswordHasher {
+	return &hasher{
+		preferred: preferred,
+		known:     append([]Algorithm{preferred}, legacy...),
+	}
+}
+
+// NewFromConfig создает хэшер по конфигурации. Хэши остальных поддерживаемых
+// алгоритмов проверяются и обновляются при входе.
+func NewFromConfig(cfg config.PasswordConfig) (PasswordHasher, error) {
+	bcryptAlgorithm := NewBcrypt(cfg.BcryptCost)
+	argon2Algorithm := NewArgon2id(Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  defaultSaltLength,
+		KeyLength:   defaultKeyLength,
+	})
+
+	switch cfg.Algorithm {
+	case "bcrypt":
+		return New(bcryptAlgorithm, argon2Algorithm), nil
+	case "argon2id":
+		return New(argon2Algorithm, bcryptAlgorithm), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, cfg.Algorithm)
+	}
+}
+
+func (h *hasher) Hash(password string) ([]byte, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *hasher) Verify(encoded []byte, password string) (bool, error) {
+	for _, algorithm := range h.known {
+		if algorithm.Identifies(encoded) {
+			return algorithm.Verify(encoded, password)
+		}
+	}
+	return false, ErrUnknownHash
+}
+
+func (h *hasher) NeedsRehash(encoded []byte) bool {
+	if !h.preferred.Identifies(encoded) {
+		return true
+	}
+	return h.preferred.NeedsRehash(encoded)
+}
@@ -0,0 +1,121 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params уменьшены, чтобы тесты выполнялись быстро
+var testArgon2Params = Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2id(t *testing.T) {
+	algorithm := NewArgon2id(testArgon2Params)
+
+	encoded, err := algorithm.Hash("password123")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(encoded), "$argon2id$v=19$m=1024,t=1,p=1$"))
+	require.True(t, algorithm.Identifies(encoded))
+
+	t.Run("верный пароль", func(t *testing.T) {
+		ok, err := algorithm.Verify(encoded, "password123")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("неверный пароль", func(t *testing.T) {
+		ok, err := algorithm.Verify(encoded, "wrong-password")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("изменились параметры", func(t *testing.T) {
+		require.False(t, algorithm.NeedsRehash(encoded))
+
+		stronger := testArgon2Params
+		stronger.Iterations = 2
+		require.True(t, NewArgon2id(stronger).NeedsRehash(encoded))
+	})
+
+	t.Run("поврежденный хэш", func(t *testing.T) {
+		_, err := algorithm.Verify([]byte("$argon2id$v=19$broken"), "password123")
+		require.ErrorIs(t, err, ErrUnknownHash)
+	})
+}
+
+func TestBcrypt(t *testing.T) {
+	algorithm := NewBcrypt(bcrypt.MinCost)
+
+	encoded, err := algorithm.Hash("password123")
+	require.NoError(t, err)
+	require.True(t, algorithm.Identifies(encoded))
+
+	ok, err := algorithm.Verify(encoded, "password123")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = algorithm.Verify(encoded, "wrong-password")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.False(t, algorithm.NeedsRehash(encoded))
+	require.True(t, NewBcrypt(bcrypt.MinCost+1).NeedsRehash(encoded))
+}
+
+func TestHasher(t *testing.T) {
+	legacy := NewBcrypt(bcrypt.MinCost)
+	h := New(NewArgon2id(testArgon2Params), legacy)
+
+	t.Run("проверка хэша устаревшего алгоритма", func(t *testing.T) {
+		encoded, err := legacy.Hash("password123")
+		require.NoError(t, err)
+
+		ok, err := h.Verify(encoded, "password123")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.True(t, h.NeedsRehash(encoded))
+	})
+
+	t.Run("новый хэш предпочтительным алгоритмом", func(t *testing.T) {
+		encoded, err := h.Hash("password123")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(encoded), argon2idPrefix))
+		require.False(t, h.NeedsRehash(encoded))
+	})
+
+	t.Run("неизвестный формат", func(t *testing.T) {
+		_, err := h.Verify([]byte("plaintext"), "plaintext")
+		require.ErrorIs(t, err, ErrUnknownHash)
+	})
+}
+
+func TestNewFromConfig(t *testing.T) {
+	t.Run("argon2id", func(t *testing.T) {
+		h, err := NewFromConfig(config.PasswordConfig{
+			Algorithm:         "argon2id",
+			BcryptCost:        bcrypt.MinCost,
+			Argon2Memory:      1024,
+			Argon2Iterations:  1,
+			Argon2Parallelism: 1,
+		})
+		require.NoError(t, err)
+
+		encoded, err := h.Hash("password123")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(encoded), argon2idPrefix))
+	})
+
+	t.Run("неизвестный алгоритм", func(t *testing.T) {
+		_, err := NewFromConfig(config.PasswordConfig{Algorithm: "md5"})
+		require.ErrorIs(t, err, ErrUnknownAlgorithm)
+	})
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+)
+
+// APIKeyHeader содержит API-ключ сервисной учетной записи
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator проверяет API-ключ и возвращает его описание
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*domain.APIKey, error)
+}
+
+// AuthMiddleware принимает API-ключ в заголовке X-API-Key или Authorization: Bearer ak_...,
+// а остальные запросы передает JWTAuthMiddleware.
+func AuthMiddleware(verifier TokenVerifier, revocations TokenRevocationChecker, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
+	jwtAuth := JWTAuthMiddleware(verifier, revocations)
+
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(APIKeyHeader)
+		if rawKey == "" {
+			bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if strings.HasPrefix(bearer, domain.APIKeyPrefix) {
+				rawKey = bearer
+			}
+		}
+		if rawKey == "" {
+			jwtAuth(c)
+			return
+		}
+
+		key, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), rawKey)
+		if err != nil {
+			if errors.Is(err, domain.ErrInvalidAPIKey) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("username", key.Account)
+		c.Set("role", string(domain.RoleService))
+		c.Set("scopes", key.Scopes)
+		c.Set("apiKeyId", key.Id)
+		c.Next()
+	}
+}
+
+// RequireScope пропускает API-ключи с указанной областью доступа.
+// Пользователи с JWT проходят, если у них одна из ролей roles; без ролей проходит любой пользователь.
+// Должен подключаться после AuthMiddleware.
+func RequireScope(scope string, roles ...domain.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, isAPIKey := c.Get("scopes"); isAPIKey {
+			scopes, _ := value.([]string)
+			if !(&domain.APIKey{Scopes: scopes}).HasScope(scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient scope"})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if len(roles) > 0 {
+			role := domain.Role(c.GetString("role"))
+			allowed := false
+			for _, r := range roles {
+				if r == role {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAPIKeys хранит действующие API-ключи по их значению
+type stubAPIKeys map[string]*domain.APIKey
+
+func (s stubAPIKeys) AuthenticateAPIKey(_ context.Context, rawKey string) (*domain.APIKey, error) {
+	key, ok := s[rawKey]
+	if !ok {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const testSecret = "test-secret-at-least-32-bytes-long"
+	key, err := signer.NewHMACKey("test", []byte(testSecret))
+	require.NoError(t, err)
+	verifier, err := signer.New(key)
+	require.NoError(t, err)
+	apiKeys := stubAPIKeys{
+		"ak_0a1b2c3d_secret": {Id: 1, Account: "hr-bot", Scopes: []string{domain.ScopeCoinsGrant}},
+	}
+
+	newRouter := func() *gin.Engine {
+		r := gin.New()
+		r.Use(AuthMiddleware(verifier, stubRevocations{}, apiKeys))
+		r.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"username": c.GetString("username"), "role": c.GetString("role")})
+		})
+		return r
+	}
+
+	t.Run("ключ в заголовке X-API-Key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set(APIKeyHeader, "ak_0a1b2c3d_secret")
+		newRouter().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "hr-bot")
+		assert.Contains(t, w.Body.String(), "service")
+	})
+
+	t.Run("ключ в заголовке Authorization", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer ak_0a1b2c3d_secret")
+		newRouter().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "hr-bot")
+	})
+
+	t.Run("неизвестный ключ", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set(APIKeyHeader, "ak_0a1b2c3d_wrong")
+		newRouter().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("JWT без ключа", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"username": "testuser",
+			"jti":      "test-jti",
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, err := token.SignedString([]byte(testSecret))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		newRouter().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "testuser")
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(setup func(c *gin.Context)) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			setup(c)
+			c.Next()
+		})
+		r.Use(RequireScope(domain.ScopeCoinsGrant, domain.RoleAdmin))
+		r.POST("/coins/grant", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		setup func(c *gin.Context)
+		want  int
+	}{
+		{
+			name:  "ключ с нужной областью",
+			setup: func(c *gin.Context) { c.Set("scopes", []string{domain.ScopeCoinsGrant}) },
+			want:  http.StatusOK,
+		},
+		{
+			name:  "ключ без нужной области",
+			setup: func(c *gin.Context) { c.Set("scopes", []string{domain.ScopeInfoRead}) },
+			want:  http.StatusForbidden,
+		},
+		{
+			name:  "администратор с JWT",
+			setup: func(c *gin.Context) { c.Set("role", "admin") },
+			want:  http.StatusOK,
+		},
+		{
+			name:  "сотрудник с JWT",
+			setup: func(c *gin.Context) { c.Set("role", "employee") },
+			want:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/coins/grant", http.NoBody)
+			newRouter(tt.setup).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -1,14 +1,27 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
 )
 
-func JWTAuthMiddleware(secret string) gin.HandlerFunc {
+// TokenVerifier проверяет подпись и срок действия JWT
+type TokenVerifier interface {
+	Verify(tokenString string) (jwt.MapClaims, error)
+}
+
+// TokenRevocationChecker проверяет, был ли отозван токен пользователя с указанным идентификатором и временем выпуска
+type TokenRevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, username, jti string, issuedAt time.Time) (bool, error)
+}
+
+func JWTAuthMiddleware(verifier TokenVerifier, revocations TokenRevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -24,22 +37,16 @@ func JWTAuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorSignatureInvalid)
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := verifier.Verify(tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		// Промежуточный токен двухфакторного входа не дает доступа к API
+		if typ, _ := claims["typ"].(string); typ == domain.MFAPendingTokenType {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "MFA verification is required"})
 			c.Abort()
 			return
 		}
@@ -51,7 +58,58 @@ func JWTAuthMiddleware(secret string) gin.HandlerFunc {
 			return
 		}
 
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
+
+		// Токен без iat считается выпущенным раньше любого отзыва всех токенов пользователя
+		var issuedAt time.Time
+		if iat, ok := claims["iat"].(float64); ok {
+			issuedAt = time.Unix(int64(iat), 0)
+		}
+
+		revoked, err := revocations.IsTokenRevoked(c.Request.Context(), username, jti, issuedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// Токены, выпущенные до появления ролей, считаются токенами сотрудника
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = string(domain.RoleEmployee)
+		}
+
+		// Токены без amr выпущены до появления второго фактора
+		amr := []string{domain.AMRPassword}
+		if values, ok := claims["amr"].([]interface{}); ok {
+			amr = make([]string, 0, len(values))
+			for _, v := range values {
+				if method, ok := v.(string); ok {
+					amr = append(amr, method)
+				}
+			}
+		}
+
+		var expiresAt time.Time
+		if exp, ok := claims["exp"].(float64); ok {
+			expiresAt = time.Unix(int64(exp), 0)
+		}
+
 		c.Set("username", username)
+		c.Set("role", role)
+		c.Set("amr", amr)
+		c.Set("jti", jti)
+		c.Set("tokenExpiresAt", expiresAt)
 		c.Next()
 	}
 }
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -8,19 +9,43 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/signer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// stubRevocations хранит отозванные идентификаторы токенов
+type stubRevocations map[string]bool
+
+func (s stubRevocations) IsTokenRevoked(_ context.Context, _, jti string, _ time.Time) (bool, error) {
+	return s[jti], nil
+}
+
+// stubUserRevocations хранит время отзыва всех токенов пользователя
+type stubUserRevocations map[string]time.Time
+
+func (s stubUserRevocations) IsTokenRevoked(_ context.Context, username, _ string, issuedAt time.Time) (bool, error) {
+	validAfter, ok := s[username]
+	return ok && validAfter.After(issuedAt), nil
+}
+
 func TestJWTAuthMiddleware(t *testing.T) {
 	// Отключаем режим Gin по умолчанию для тестов
 	gin.SetMode(gin.TestMode)
 
-	const testSecret = "test-secret"
+	const testSecret = "test-secret-at-least-32-bytes-long"
+	revocations := stubRevocations{"revoked-jti": true}
+	key, err := signer.NewHMACKey("test", []byte(testSecret))
+	require.NoError(t, err)
+	verifier, err := signer.New(key)
+	require.NoError(t, err)
 
 	t.Run("успешная аутентификация", func(t *testing.T) {
 		// Создаем валидный токен
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"username": "testuser",
+			"jti":      "test-jti",
 			"exp":      time.Now().Add(time.Hour).Unix(),
 		})
 		tokenString, err := token.SignedString([]byte(testSecret))
@@ -28,7 +53,7 @@ func TestJWTAuthMiddleware(t *testing.T) {
 
 		// Создаем тестовый роутер
 		r := gin.New()
-		r.Use(JWTAuthMiddleware(testSecret))
+		r.Use(JWTAuthMiddleware(verifier, revocations))
 		r.GET("/test", func(c *gin.Context) {
 			username := c.GetString("username")
 			c.JSON(http.StatusOK, gin.H{"username": username})
@@ -47,7 +72,7 @@ func TestJWTAuthMiddleware(t *testing.T) {
 
 	t.Run("отсутствие токена", func(t *testing.T) {
 		r := gin.New()
-		r.Use(JWTAuthMiddleware(testSecret))
+		r.Use(JWTAuthMiddleware(verifier, revocations))
 		r.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		})
@@ -61,7 +86,7 @@ func TestJWTAuthMiddleware(t *testing.T) {
 
 	t.Run("некорректный формат токена", func(t *testing.T) {
 		r := gin.New()
-		r.Use(JWTAuthMiddleware(testSecret))
+		r.Use(JWTAuthMiddleware(verifier, revocations))
 		r.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		})
@@ -78,13 +103,14 @@ func TestJWTAuthMiddleware(t *testing.T) {
 		// Создаем токен с истекшим временем
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"username": "testuser",
+			"jti":      "test-jti",
 			"exp":      time.Now().Add(-time.Hour).Unix(), // Токен истек час назад
 		})
 		tokenString, err := token.SignedString([]byte(testSecret))
 		assert.NoError(t, err)
 
 		r := gin.New()
-		r.Use(JWTAuthMiddleware(testSecret))
+		r.Use(JWTAuthMiddleware(verifier, revocations))
 		r.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		})
@@ -101,13 +127,116 @@ func TestJWTAuthMiddleware(t *testing.T) {
 		// Создаем токен с другим секретом
 		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 			"username": "testuser",
+			"jti":      "test-jti",
 			"exp":      time.Now().Add(time.Hour).Unix(),
 		})
 		tokenString, err := token.SignedString([]byte("wrong-secret"))
 		assert.NoError(t, err)
 
 		r := gin.New()
-		r.Use(JWTAuthMiddleware(testSecret))
+		r.Use(JWTAuthMiddleware(verifier, revocations))
+		r.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+	t.Run("отозванный токен", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"username": "testuser",
+			"jti":      "revoked-jti",
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, err := token.SignedString([]byte(testSecret))
+		assert.NoError(t, err)
+
+		r := gin.New()
+		r.Use(JWTAuthMiddleware(verifier, revocations))
+		r.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "revoked")
+	})
+
+	t.Run("токены, выпущенные до смены пароля", func(t *testing.T) {
+		changedAt := time.Now().Truncate(time.Second)
+		r := gin.New()
+		r.Use(JWTAuthMiddleware(verifier, stubUserRevocations{"testuser": changedAt}))
+		r.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		for issuedAt, code := range map[time.Time]int{
+			changedAt.Add(-time.Minute): http.StatusUnauthorized,
+			changedAt:                   http.StatusOK,
+		} {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"username": "testuser",
+				"jti":      "test-jti",
+				"iat":      issuedAt.Unix(),
+				"exp":      time.Now().Add(time.Hour).Unix(),
+			})
+			tokenString, err := token.SignedString([]byte(testSecret))
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/test", http.NoBody)
+			req.Header.Set("Authorization", "Bearer "+tokenString)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, code, w.Code)
+		}
+	})
+
+	t.Run("роль из токена", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"username": "testuser",
+			"role":     "admin",
+			"jti":      "test-jti",
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, err := token.SignedString([]byte(testSecret))
+		assert.NoError(t, err)
+
+		r := gin.New()
+		r.Use(JWTAuthMiddleware(verifier, revocations))
+		r.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"role": c.GetString("role")})
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "admin")
+	})
+
+	t.Run("токен ожидания второго фактора", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"username": "testuser",
+			"typ":      domain.MFAPendingTokenType,
+			"jti":      "mfa-jti",
+			"exp":      time.Now().Add(time.Minute).Unix(),
+		})
+		tokenString, err := token.SignedString([]byte(testSecret))
+		assert.NoError(t, err)
+
+		r := gin.New()
+		r.Use(JWTAuthMiddleware(verifier, revocations))
 		r.GET("/test", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
 		})
@@ -119,4 +248,31 @@ func TestJWTAuthMiddleware(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
+
+	t.Run("методы аутентификации из токена", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"username": "testuser",
+			"amr":      []string{"pwd", "mfa"},
+			"jti":      "test-jti",
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		})
+		tokenString, err := token.SignedString([]byte(testSecret))
+		assert.NoError(t, err)
+
+		var amr interface{}
+		r := gin.New()
+		r.Use(JWTAuthMiddleware(verifier, revocations))
+		r.GET("/test", func(c *gin.Context) {
+			amr, _ = c.Get("amr")
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/test", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"pwd", "mfa"}, amr)
+	})
 }
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+)
+
+// RequireRole пропускает только пользователей с одной из указанных ролей.
+// Должен подключаться после JWTAuthMiddleware.
+func RequireRole(roles ...domain.Role) gin.HandlerFunc {
+	allowed := make(map[domain.Role]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		if _, ok := allowed[domain.Role(c.GetString("role"))]; !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireMFA пропускает только сессии, в которых пройден второй фактор.
+// Должен подключаться после JWTAuthMiddleware.
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		amr, _ := c.Get("amr")
+		methods, _ := amr.([]string)
+		if !domain.HasAMR(methods, domain.AMRMFA) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "MFA is required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireUserMFA применяет RequireMFA к сессиям пользователей и пропускает API-ключи,
+// у которых нет второго фактора. Должен подключаться после AuthMiddleware.
+func RequireUserMFA() gin.HandlerFunc {
+	requireMFA := RequireMFA()
+
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get("scopes"); isAPIKey {
+			c.Next()
+			return
+		}
+
+		requireMFA(c)
+	}
+}
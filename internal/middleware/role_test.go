@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(role string) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if role != "" {
+				c.Set("role", role)
+			}
+			c.Next()
+		})
+		r.Use(RequireRole(domain.RoleAdmin))
+		r.GET("/admin", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		return r
+	}
+
+	tests := []struct {
+		name string
+		role string
+		want int
+	}{
+		{name: "администратор", role: "admin", want: http.StatusOK},
+		{name: "сотрудник", role: "employee", want: http.StatusForbidden},
+		{name: "роль не установлена", role: "", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin", http.NoBody)
+			newRouter(tt.role).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRequireMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(amr []string) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if amr != nil {
+				c.Set("amr", amr)
+			}
+			c.Next()
+		})
+		r.Use(RequireMFA())
+		r.POST("/sendCoin", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		return r
+	}
+
+	tests := []struct {
+		name string
+		amr  []string
+		want int
+	}{
+		{name: "второй фактор пройден", amr: []string{domain.AMRPassword, domain.AMRMFA}, want: http.StatusOK},
+		{name: "только пароль", amr: []string{domain.AMRPassword}, want: http.StatusForbidden},
+		{name: "методы не установлены", amr: nil, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/sendCoin", http.NoBody)
+			newRouter(tt.amr).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRequireUserMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(setup func(c *gin.Context)) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			setup(c)
+			c.Next()
+		})
+		r.Use(RequireUserMFA())
+		r.POST("/coins/grant", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		return r
+	}
+
+	tests := []struct {
+		name  string
+		setup func(c *gin.Context)
+		want  int
+	}{
+		{
+			name:  "API-ключ",
+			setup: func(c *gin.Context) { c.Set("scopes", []string{domain.ScopeCoinsGrant}) },
+			want:  http.StatusOK,
+		},
+		{
+			name:  "второй фактор пройден",
+			setup: func(c *gin.Context) { c.Set("amr", []string{domain.AMRPassword, domain.AMRMFA}) },
+			want:  http.StatusOK,
+		},
+		{
+			name:  "только пароль",
+			setup: func(c *gin.Context) { c.Set("amr", []string{domain.AMRPassword}) },
+			want:  http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/coins/grant", http.NoBody)
+			newRouter(tt.setup).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
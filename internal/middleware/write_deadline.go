@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type responseControllerKey struct{}
+
+// ResponseControllerHandler сохраняет в контексте запроса управление исходным ответом сервера.
+// Обертка gin над ResponseWriter не дает доступа к соединению, поэтому без нее
+// маршрут не может продлить срок записи, заданный http.Server.WriteTimeout.
+func ResponseControllerHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), responseControllerKey{}, http.NewResponseController(w))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WriteTimeout продлевает срок записи ответа для маршрута, отдающего данные дольше,
+// чем позволяет таймаут сервера. Тот же срок задается контексту запроса,
+// чтобы запросы к базе могли рассчитать по нему свои таймауты.
+func WriteTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deadline := time.Now().Add(timeout)
+
+		rc, ok := c.Request.Context().Value(responseControllerKey{}).(*http.ResponseController)
+		if !ok {
+			logrus.Warnf("WriteTimeout: управление ответом недоступно, используется таймаут сервера")
+		} else if err := rc.SetWriteDeadline(deadline); err != nil {
+			logrus.Warnf("WriteTimeout: не удалось продлить срок записи: %v", err)
+		}
+
+		ctx, cancel := context.WithDeadline(c.Request.Context(), deadline)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Ответ отдается дольше, чем позволяет таймаут сервера
+	slow := func(c *gin.Context) {
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	}
+
+	newServer := func(handlers ...gin.HandlerFunc) *httptest.Server {
+		r := gin.New()
+		r.GET("/export", handlers...)
+
+		server := httptest.NewUnstartedServer(ResponseControllerHandler(r))
+		server.Config.WriteTimeout = 50 * time.Millisecond
+		server.Start()
+		return server
+	}
+
+	t.Run("срок записи продлен", func(t *testing.T) {
+		server := newServer(WriteTimeout(time.Second), slow)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/export")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "done", string(body))
+	})
+
+	t.Run("без продления ответ обрывается", func(t *testing.T) {
+		server := newServer(slow)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/export")
+		if err == nil {
+			resp.Body.Close()
+		}
+		require.Error(t, err)
+	})
+
+	t.Run("обертка не подключена", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/export", WriteTimeout(time.Second), func(c *gin.Context) {
+			c.String(http.StatusOK, "done")
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/export", http.NoBody))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "done", w.Body.String())
+	})
+
+	t.Run("срок задан контексту запроса", func(t *testing.T) {
+		var deadline time.Time
+		var ok bool
+		r := gin.New()
+		r.GET("/export", WriteTimeout(time.Minute), func(c *gin.Context) {
+			deadline, ok = c.Request.Context().Deadline()
+			c.Status(http.StatusOK)
+		})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/export", http.NoBody))
+
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+}
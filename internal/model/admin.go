@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// SetRoleRequest содержит новую роль пользователя.
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// PasswordResetResponse содержит выданный администратором токен сброса пароля.
+type PasswordResetResponse struct {
+	ResetToken string    `json:"resetToken"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
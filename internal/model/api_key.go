@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// CreateAPIKeyRequest содержит параметры нового API-ключа сервисной учетной записи.
+type CreateAPIKeyRequest struct {
+	Account   string     `json:"account" binding:"required"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKeyResponse описывает API-ключ без его значения.
+type APIKeyResponse struct {
+	Id         int64      `json:"id"`
+	Account    string     `json:"account"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreateAPIKeyResponse содержит значение ключа, которое показывается только при выпуске.
+type CreateAPIKeyResponse struct {
+	Key string `json:"key"`
+	APIKeyResponse
+}
+
+// GrantCoinsRequest используется для начисления монет сотруднику.
+type GrantCoinsRequest struct {
+	ToUser string `json:"toUser" binding:"required"`
+	Amount uint64 `json:"amount" binding:"required"`
+}
+
+// BalanceResponse содержит баланс сотрудника.
+type BalanceResponse struct {
+	Username string `json:"username"`
+	Coins    uint64 `json:"coins"`
+}
@@ -6,7 +6,61 @@ type AuthRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RegisterRequest содержит данные для регистрации нового пользователя.
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
 // AuthResponse содержит JWT-токен после успешной аутентификации.
+// Если у пользователя подключен второй фактор, вместо токенов возвращается mfaToken.
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	MFARequired  bool   `json:"mfaRequired,omitempty"`
+	MFAToken     string `json:"mfaToken,omitempty"`
+}
+
+// RefreshRequest содержит refresh-токен для получения новой пары токенов.
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// LogoutRequest содержит refresh-токен, семейство которого нужно отозвать.
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// ChangePasswordRequest содержит текущий и новый пароль пользователя.
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// ResetPasswordRequest содержит одноразовый токен сброса и новый пароль.
+type ResetPasswordRequest struct {
+	ResetToken  string `json:"resetToken" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required"`
+}
+
+// MFAVerifyRequest содержит токен ожидания второго фактора и код TOTP или код восстановления.
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFAConfirmRequest содержит первый код из приложения-аутентификатора.
+type MFAConfirmRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAEnrollResponse содержит секрет и otpauth-ссылку для приложения-аутентификатора.
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauthUri"`
+}
+
+// MFARecoveryCodesResponse содержит одноразовые коды восстановления.
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
 }
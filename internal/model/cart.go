@@ -0,0 +1,21 @@
+package model
+
+// AddCartItemRequest представляет запрос на добавление товара в корзину
+type AddCartItemRequest struct {
+	Item     string `json:"item" binding:"required"`
+	Quantity uint64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// CartItemResponse описывает позицию корзины
+type CartItemResponse struct {
+	Item     string `json:"item"`
+	Quantity uint64 `json:"quantity"`
+	Price    uint64 `json:"price"` // Цена единицы, зафиксированная при добавлении
+	Total    uint64 `json:"total"`
+}
+
+// CartResponse содержит содержимое корзины и ее стоимость
+type CartResponse struct {
+	Items []CartItemResponse `json:"items"`
+	Total uint64             `json:"total"`
+}
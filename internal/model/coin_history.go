@@ -1,8 +1,11 @@
 package model
 
+import "time"
+
 type CoinHistory struct {
-	Received []ReceivedTransaction `json:"received"`
-	Sent     []SentTransaction     `json:"sent"`
+	Received  []ReceivedTransaction `json:"received"`
+	Sent      []SentTransaction     `json:"sent"`
+	Purchases []PurchaseTransaction `json:"purchases"`
 }
 
 type ReceivedTransaction struct {
@@ -14,3 +17,10 @@ type SentTransaction struct {
 	ToUser string `json:"toUser"`
 	Amount uint64 `json:"amount"`
 }
+
+type PurchaseTransaction struct {
+	Item      string    `json:"item"`
+	Price     uint64    `json:"price"`
+	Quantity  uint64    `json:"quantity"`
+	Timestamp time.Time `json:"timestamp"`
+}
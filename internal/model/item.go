@@ -6,8 +6,24 @@ type Item struct {
 	Quantity int    `json:"quantity"`
 }
 
-// BuyMerchRequest представляет запрос на покупку мерча
+// BuyMerchRequest представляет запрос на покупку нескольких единиц мерча
 type BuyMerchRequest struct {
-	MerchID uint64 `json:"merch_id"`
-	Amount  uint64 `json:"amount"`
+	Item      string `json:"item" binding:"required"`
+	Quantity  uint64 `json:"quantity" binding:"required,gt=0"`
+	PromoCode string `json:"promoCode"`
+}
+
+// GiftRequest представляет запрос на покупку мерча в подарок другому сотруднику
+type GiftRequest struct {
+	ToUser   string `json:"toUser" binding:"required"`
+	Item     string `json:"item" binding:"required"`
+	Quantity uint64 `json:"quantity" binding:"required,gt=0"`
+	Message  string `json:"message"`
+}
+
+// TransferItemRequest представляет запрос на передачу купленного мерча другому сотруднику
+type TransferItemRequest struct {
+	ToUser   string `json:"toUser" binding:"required"`
+	Item     string `json:"item" binding:"required"`
+	Quantity uint64 `json:"quantity" binding:"required,gt=0"`
 }
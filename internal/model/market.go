@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// CreateListingRequest представляет запрос на выставление товара из инвентаря на маркетплейс
+type CreateListingRequest struct {
+	Item     string `json:"item" binding:"required"`
+	Quantity uint64 `json:"quantity" binding:"required,gt=0"`
+	Price    uint64 `json:"price" binding:"required,gt=0"` // Цена всего лота
+}
+
+// ListingsQuery содержит параметры выборки лотов.
+type ListingsQuery struct {
+	Item     string `form:"item"`
+	Seller   string `form:"seller"`
+	Status   string `form:"status"` // По умолчанию показываются только активные лоты
+	MinPrice uint64 `form:"minPrice"`
+	MaxPrice uint64 `form:"maxPrice"`
+	Limit    int    `form:"limit"`
+}
+
+// ListingResponse описывает лот маркетплейса
+type ListingResponse struct {
+	Id        int64      `json:"id"`
+	Seller    string     `json:"seller"`
+	Item      string     `json:"item"`
+	Quantity  uint64     `json:"quantity"`
+	Price     uint64     `json:"price"`
+	Status    string     `json:"status"`
+	Buyer     string     `json:"buyer,omitempty"`
+	Fee       uint64     `json:"fee,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+}
+
+// ListingListResponse содержит список лотов.
+type ListingListResponse struct {
+	Listings []ListingResponse `json:"listings"`
+}
@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// MerchResponse описывает товар каталога.
+type MerchResponse struct {
+	Name         string     `json:"name"`
+	Price        uint64     `json:"price"`
+	RegularPrice *uint64    `json:"regularPrice,omitempty"` // Указывается, если действует цена по расписанию
+	Description  string     `json:"description"`
+	Category     string     `json:"category"`
+	Available    bool       `json:"available"`
+	RetiredAt    *time.Time `json:"retiredAt,omitempty"`
+	Stock        *uint64    `json:"stock,omitempty"` // Отсутствует, если количество не ограничено
+	Transferable bool       `json:"transferable"`
+}
+
+// MerchListResponse содержит список товаров каталога.
+type MerchListResponse struct {
+	Items []MerchResponse `json:"items"`
+}
+
+// CreateMerchRequest содержит параметры нового товара. Если available не указан, товар доступен для покупки,
+// если не указан stock — количество товара не ограничено, если не указан transferable — купленный товар можно передавать.
+type CreateMerchRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	Price        *uint64 `json:"price" binding:"required,gt=0"`
+	Description  string  `json:"description"`
+	Category     string  `json:"category"`
+	Available    *bool   `json:"available"`
+	Stock        *uint64 `json:"stock"`
+	Transferable *bool   `json:"transferable"`
+}
+
+// UpdateMerchRequest содержит изменяемые поля товара. Неуказанные поля остаются без изменений.
+// Остаток не изменяется: склад пополняется отдельным запросом, чтобы не перезаписать одновременные покупки.
+type UpdateMerchRequest struct {
+	Price        *uint64 `json:"price"`
+	Description  *string `json:"description"`
+	Category     *string `json:"category"`
+	Available    *bool   `json:"available"`
+	Transferable *bool   `json:"transferable"`
+}
+
+// RestockMerchRequest содержит количество товара, добавляемого на склад.
+type RestockMerchRequest struct {
+	Quantity uint64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// CreatePriceScheduleRequest содержит параметры запланированной цены. Если effectiveFrom не указан,
+// цена действует сразу, если не указан effectiveTo — до отмены.
+type CreatePriceScheduleRequest struct {
+	Price         uint64     `json:"price" binding:"required,gt=0"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo"`
+	Priority      int        `json:"priority"`
+}
+
+// PriceScheduleResponse описывает запланированную цену товара.
+type PriceScheduleResponse struct {
+	Id            int64      `json:"id"`
+	Item          string     `json:"item"`
+	Price         uint64     `json:"price"`
+	EffectiveFrom time.Time  `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo,omitempty"`
+	Priority      int        `json:"priority"`
+	CreatedBy     string     `json:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	CancelledAt   *time.Time `json:"cancelledAt,omitempty"`
+}
+
+// PriceHistoryQuery содержит момент, на который нужно вычислить цену товара.
+type PriceHistoryQuery struct {
+	At time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// PriceChangeResponse описывает изменение базовой цены товара.
+type PriceChangeResponse struct {
+	Price     uint64    `json:"price"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// PriceHistoryResponse содержит историю базовых цен и расписания товара.
+// priceAt заполняется, если в запросе указан момент at и цена на него известна.
+type PriceHistoryResponse struct {
+	Item      string                  `json:"item"`
+	Changes   []PriceChangeResponse   `json:"changes"`
+	Schedules []PriceScheduleResponse `json:"schedules"`
+	PriceAt   *uint64                 `json:"priceAt,omitempty"`
+}
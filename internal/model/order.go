@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// OrdersQuery содержит параметры выборки заказов.
+type OrdersQuery struct {
+	Status   string `form:"status"`
+	Username string `form:"username"` // Учитывается только в административном списке
+	Limit    int    `form:"limit"`
+}
+
+// OrderItemResponse описывает позицию заказа
+type OrderItemResponse struct {
+	Item     string `json:"item"`
+	Quantity uint64 `json:"quantity"`
+	Price    uint64 `json:"price"`
+}
+
+// OrderStatusChangeResponse описывает изменение статуса заказа
+type OrderStatusChangeResponse struct {
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	ChangedBy string    `json:"changedBy"`
+	Comment   string    `json:"comment,omitempty"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// OrderResponse описывает заказ
+type OrderResponse struct {
+	Id        int64                       `json:"id"`
+	Username  string                      `json:"username"`
+	Recipient string                      `json:"recipient,omitempty"`
+	Status    string                      `json:"status"`
+	Items     []OrderItemResponse         `json:"items"`
+	Total     uint64                      `json:"total"`
+	Discount  uint64                      `json:"discount,omitempty"`
+	History   []OrderStatusChangeResponse `json:"history,omitempty"`
+	CreatedAt time.Time                   `json:"createdAt"`
+	UpdatedAt time.Time                   `json:"updatedAt"`
+}
+
+// OrderListResponse содержит список заказов.
+type OrderListResponse struct {
+	Orders []OrderResponse `json:"orders"`
+}
+
+// UpdateOrderStatusRequest содержит новый статус заказа.
+type UpdateOrderStatusRequest struct {
+	Status  string `json:"status" binding:"required"`
+	Comment string `json:"comment"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// CreatePromoCodeRequest содержит параметры нового промокода. Если не указаны ни item, ни category,
+// промокод действует на любой товар.
+type CreatePromoCodeRequest struct {
+	Code           string     `json:"code" binding:"required"`
+	DiscountType   string     `json:"discountType" binding:"required"`
+	DiscountValue  uint64     `json:"discountValue" binding:"required,gt=0"`
+	Item           *string    `json:"item"`
+	Category       *string    `json:"category"`
+	ValidFrom      *time.Time `json:"validFrom"`
+	ValidUntil     *time.Time `json:"validUntil"`
+	MaxRedemptions *uint64    `json:"maxRedemptions"`
+	MaxPerUser     *uint64    `json:"maxPerUser"`
+}
+
+// PromoCodeResponse описывает промокод и число его использований.
+type PromoCodeResponse struct {
+	Code           string     `json:"code"`
+	DiscountType   string     `json:"discountType"`
+	DiscountValue  uint64     `json:"discountValue"`
+	Item           *string    `json:"item,omitempty"`
+	Category       *string    `json:"category,omitempty"`
+	ValidFrom      *time.Time `json:"validFrom,omitempty"`
+	ValidUntil     *time.Time `json:"validUntil,omitempty"`
+	MaxRedemptions *uint64    `json:"maxRedemptions,omitempty"`
+	MaxPerUser     *uint64    `json:"maxPerUser,omitempty"`
+	Redemptions    uint64     `json:"redemptions"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// PromoCodeListResponse содержит список промокодов.
+type PromoCodeListResponse struct {
+	PromoCodes []PromoCodeResponse `json:"promoCodes"`
+}
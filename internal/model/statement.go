@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// StatementQuery содержит параметры выгрузки выписки.
+type StatementQuery struct {
+	Format   string    `form:"format"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Username string    `form:"username"`
+}
+
+// Типы строк выписки
+const (
+	StatementRecordOpening     = "opening_balance"
+	StatementRecordTransaction = "transaction"
+	StatementRecordClosing     = "closing_balance"
+	// StatementRecordEnd завершает выписку. Выгрузка без этой строки была оборвана.
+	StatementRecordEnd = "end_of_statement"
+)
+
+// StatementLine описывает строку выписки: баланс на начало или конец периода либо операцию
+// с балансом после нее.
+type StatementLine struct {
+	Username     string    `json:"username"`
+	Record       string    `json:"record"`
+	Id           int64     `json:"id,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+	Type         string    `json:"type,omitempty"`
+	Direction    string    `json:"direction,omitempty"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Amount       uint64    `json:"amount,omitempty"`
+	Item         string    `json:"item,omitempty"`
+	Quantity     uint64    `json:"quantity,omitempty"`
+	Balance      int64     `json:"balance"`
+}
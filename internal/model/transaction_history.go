@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// TransactionsQuery содержит параметры выборки истории транзакций.
+type TransactionsQuery struct {
+	Cursor       string    `form:"cursor"`
+	Limit        int       `form:"limit"`
+	Direction    string    `form:"direction"`
+	Counterparty string    `form:"counterparty"`
+	Type         string    `form:"type"`
+	MinAmount    uint64    `form:"minAmount"`
+	MaxAmount    uint64    `form:"maxAmount"`
+	From         time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To           time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// TransactionEntry описывает одну операцию в истории пользователя.
+type TransactionEntry struct {
+	Id           int64     `json:"id"`
+	Type         string    `json:"type"`
+	Direction    string    `json:"direction"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Amount       uint64    `json:"amount"`
+	Item         string    `json:"item,omitempty"`
+	Quantity     uint64    `json:"quantity,omitempty"`
+	Message      string    `json:"message,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// TransactionPage содержит страницу истории и курсор следующей страницы.
+type TransactionPage struct {
+	Transactions []TransactionEntry `json:"transactions"`
+	NextCursor   string             `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,105 @@
+// Package memory содержит реализации репозиториев, хранящие данные в памяти процесса
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// loginAttempt реализует интерфейс LoginAttemptRepository в памяти процесса.
+// Подходит для одного экземпляра сервиса; счетчики не разделяются между репликами.
+type loginAttempt struct {
+	attempts  map[string]domain.LoginAttempts
+	mu        sync.Mutex
+	retention time.Duration
+}
+
+// NewLoginAttemptRepository создает новый экземпляр репозитория попыток входа.
+// Записи без активности дольше retention периодически удаляются.
+func NewLoginAttemptRepository(retention time.Duration) repository.LoginAttemptRepository {
+	repo := &loginAttempt{
+		attempts:  make(map[string]domain.LoginAttempts),
+		retention: retention,
+	}
+
+	// Запускаем очистку устаревших записей
+	go repo.cleanup()
+
+	return repo
+}
+
+func (l *loginAttempt) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		_, _ = l.DeleteStaleLoginAttempts(context.Background(), time.Now(), l.retention)
+	}
+}
+
+// GetLoginAttempts возвращает счетчик попыток по ключу
+func (l *loginAttempt) GetLoginAttempts(_ context.Context, key string) (*domain.LoginAttempts, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attempts, ok := l.attempts[key]
+	if !ok {
+		return &domain.LoginAttempts{Key: key}, nil
+	}
+	return &attempts, nil
+}
+
+// RecordLoginFailure увеличивает счетчик неудачных попыток
+func (l *loginAttempt) RecordLoginFailure(_ context.Context, key string, now time.Time, resetAfter time.Duration) (*domain.LoginAttempts, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attempts, ok := l.attempts[key]
+	if !ok || attempts.LastFailure.Before(now.Add(-resetAfter)) {
+		attempts = domain.LoginAttempts{Key: key, LockedUntil: attempts.LockedUntil}
+	}
+	attempts.Failures++
+	attempts.LastFailure = now
+	l.attempts[key] = attempts
+
+	return &attempts, nil
+}
+
+// LockLogin блокирует вход по ключу до указанного времени
+func (l *loginAttempt) LockLogin(_ context.Context, key string, until time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attempts := l.attempts[key]
+	attempts.Key = key
+	attempts.LockedUntil = until
+	l.attempts[key] = attempts
+
+	return nil
+}
+
+// ResetLoginAttempts сбрасывает счетчик и снимает блокировку
+func (l *loginAttempt) ResetLoginAttempts(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, key)
+	return nil
+}
+
+// DeleteStaleLoginAttempts удаляет счетчики без активной блокировки и без ошибок за последние resetAfter
+func (l *loginAttempt) DeleteStaleLoginAttempts(_ context.Context, now time.Time, resetAfter time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var deleted int64
+	for key, attempts := range l.attempts {
+		if now.Sub(attempts.LastFailure) > resetAfter && !attempts.IsLocked(now) {
+			delete(l.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
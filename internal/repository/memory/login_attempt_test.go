@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAttemptRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLoginAttemptRepository(time.Hour)
+	now := time.Now()
+
+	t.Run("счетчик увеличивается", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			attempts, err := repo.RecordLoginFailure(ctx, "user:testuser", now, time.Hour)
+			require.NoError(t, err)
+			require.Equal(t, i, attempts.Failures)
+		}
+	})
+
+	t.Run("счетчик сбрасывается после окна", func(t *testing.T) {
+		attempts, err := repo.RecordLoginFailure(ctx, "user:testuser", now.Add(2*time.Hour), time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, 1, attempts.Failures)
+	})
+
+	t.Run("блокировка и сброс", func(t *testing.T) {
+		require.NoError(t, repo.LockLogin(ctx, "user:testuser", now.Add(time.Minute)))
+
+		attempts, err := repo.GetLoginAttempts(ctx, "user:testuser")
+		require.NoError(t, err)
+		require.True(t, attempts.IsLocked(now))
+
+		require.NoError(t, repo.ResetLoginAttempts(ctx, "user:testuser"))
+
+		attempts, err = repo.GetLoginAttempts(ctx, "user:testuser")
+		require.NoError(t, err)
+		require.False(t, attempts.IsLocked(now))
+		require.Equal(t, 0, attempts.Failures)
+	})
+
+	t.Run("устаревшие счетчики удаляются", func(t *testing.T) {
+		_, err := repo.RecordLoginFailure(ctx, "ip:10.0.0.1", now, time.Hour)
+		require.NoError(t, err)
+		_, err = repo.RecordLoginFailure(ctx, "ip:10.0.0.2", now, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, repo.LockLogin(ctx, "ip:10.0.0.2", now.Add(3*time.Hour)))
+
+		deleted, err := repo.DeleteStaleLoginAttempts(ctx, now.Add(2*time.Hour), time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+
+		attempts, err := repo.GetLoginAttempts(ctx, "ip:10.0.0.2")
+		require.NoError(t, err)
+		require.Equal(t, 1, attempts.Failures)
+	})
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// apiKey реализует интерфейс APIKeyRepository для работы с API-ключами в PostgreSQL
+type apiKey struct {
+	db DBPool
+}
+
+// NewAPIKeyRepository создает новый экземпляр репозитория API-ключей
+func NewAPIKeyRepository(db DBPool) repository.APIKeyRepository {
+	return &apiKey{db: db}
+}
+
+const apiKeyColumns = "id, account, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at, revoked_at"
+
+// CreateAPIKey сохраняет новый API-ключ
+func (a *apiKey) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	const op = "APIKeyRepository.CreateAPIKey"
+
+	err := a.db.QueryRow(ctx, `
+		INSERT INTO api_keys (account, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		key.Account, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt, key.CreatedAt,
+	).Scan(&key.Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetAPIKeyByPrefix возвращает API-ключ по его открытой части
+func (a *apiKey) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	const op = "APIKeyRepository.GetAPIKeyByPrefix"
+
+	key, err := scanAPIKey(a.db.QueryRow(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1",
+		prefix,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys возвращает API-ключи сервисной учетной записи или все ключи, если account пуст
+func (a *apiKey) ListAPIKeys(ctx context.Context, account string) ([]*domain.APIKey, error) {
+	const op = "APIKeyRepository.ListAPIKeys"
+
+	rows, err := a.db.Query(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE $1 = '' OR account = $1 ORDER BY id",
+		account,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ. Повторный отзыв не меняет время отзыва.
+func (a *apiKey) RevokeAPIKey(ctx context.Context, id int64, now time.Time) error {
+	const op = "APIKeyRepository.RevokeAPIKey"
+
+	result, err := a.db.Exec(ctx,
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2",
+		now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchAPIKey обновляет время последнего использования ключа
+func (a *apiKey) TouchAPIKey(ctx context.Context, id int64, now time.Time) error {
+	const op = "APIKeyRepository.TouchAPIKey"
+
+	_, err := a.db.Exec(ctx,
+		"UPDATE api_keys SET last_used_at = $1 WHERE id = $2",
+		now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	err := row.Scan(
+		&key.Id,
+		&key.Account,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
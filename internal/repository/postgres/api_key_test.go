@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+)
+
+var apiKeyRowColumns = []string{"id", "account", "name", "prefix", "key_hash", "scopes", "expires_at", "last_used_at", "created_at", "revoked_at"}
+
+func TestCreateAPIKey(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewAPIKeyRepository(mock)
+	key := &domain.APIKey{
+		Account:   "hr-bot",
+		Name:      "начисления",
+		Prefix:    "ak_abcdef",
+		KeyHash:   "hash",
+		Scopes:    []string{domain.ScopeCoinsGrant},
+		CreatedAt: time.Now(),
+	}
+
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs(key.Account, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt, key.CreatedAt).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(7)))
+
+	err = repo.CreateAPIKey(context.Background(), key)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(7), key.Id)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	t.Run("ключ найден", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewAPIKeyRepository(mock)
+
+		mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE prefix = \\$1").
+			WithArgs("ak_abcdef").
+			WillReturnRows(pgxmock.NewRows(apiKeyRowColumns).
+				AddRow(int64(7), "hr-bot", "начисления", "ak_abcdef", "hash", []string{"coins:grant"}, nil, nil, time.Now(), nil))
+
+		key, err := repo.GetAPIKeyByPrefix(context.Background(), "ak_abcdef")
+
+		require.NoError(t, err)
+		require.Equal(t, "hr-bot", key.Account)
+		require.Equal(t, []string{"coins:grant"}, key.Scopes)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ключ не найден", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewAPIKeyRepository(mock)
+
+		mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE prefix = \\$1").
+			WithArgs("ak_unknown").
+			WillReturnRows(pgxmock.NewRows(apiKeyRowColumns))
+
+		_, err = repo.GetAPIKeyByPrefix(context.Background(), "ak_unknown")
+
+		require.ErrorIs(t, err, domain.ErrAPIKeyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestListAPIKeys(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewAPIKeyRepository(mock)
+
+	mock.ExpectQuery("SELECT (.+) FROM api_keys WHERE \\$1 = '' OR account = \\$1 ORDER BY id").
+		WithArgs("hr-bot").
+		WillReturnRows(pgxmock.NewRows(apiKeyRowColumns).
+			AddRow(int64(1), "hr-bot", "first", "ak_1", "hash1", []string{"info:read"}, nil, nil, time.Now(), nil).
+			AddRow(int64(2), "hr-bot", "second", "ak_2", "hash2", []string{"coins:grant"}, nil, nil, time.Now(), nil))
+
+	keys, err := repo.ListAPIKeys(context.Background(), "hr-bot")
+
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, "ak_2", keys[1].Prefix)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	t.Run("ключ отозван", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewAPIKeyRepository(mock)
+		now := time.Now()
+
+		mock.ExpectExec("UPDATE api_keys SET revoked_at = COALESCE\\(revoked_at, \\$1\\) WHERE id = \\$2").
+			WithArgs(now, int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		require.NoError(t, repo.RevokeAPIKey(context.Background(), 7, now))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ключ не найден", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewAPIKeyRepository(mock)
+		now := time.Now()
+
+		mock.ExpectExec("UPDATE api_keys SET revoked_at").
+			WithArgs(now, int64(42)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		require.ErrorIs(t, repo.RevokeAPIKey(context.Background(), 42, now), domain.ErrAPIKeyNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
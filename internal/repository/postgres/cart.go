@@ -0,0 +1,343 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// cart реализует интерфейс CartRepository для работы с корзиной и оформлением заказов в PostgreSQL
+type cart struct {
+	db DBPool
+}
+
+// NewCartRepository создает новый экземпляр репозитория корзины
+func NewCartRepository(db DBPool) repository.CartRepository {
+	return &cart{db: db}
+}
+
+// GetCart возвращает корзину пользователя в порядке добавления товаров
+func (c *cart) GetCart(ctx context.Context, username string) (*domain.Cart, error) {
+	const op = "CartRepository.GetCart"
+
+	rows, err := c.db.Query(ctx,
+		"SELECT item_name, quantity, unit_price, added_at FROM cart_items WHERE username = $1 ORDER BY added_at, item_name",
+		username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	result := &domain.Cart{Username: username}
+	for rows.Next() {
+		item := &domain.CartItem{}
+		if err := rows.Scan(&item.ItemName, &item.Quantity, &item.UnitPrice, &item.AddedAt); err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// AddCartItem добавляет товар в корзину или увеличивает количество уже добавленного.
+// Цена позиции обновляется до переданной, количество не может превысить MaxPurchaseQuantity.
+func (c *cart) AddCartItem(ctx context.Context, username string, item *domain.CartItem) error {
+	const op = "CartRepository.AddCartItem"
+
+	result, err := c.db.Exec(ctx, `
+		INSERT INTO cart_items (username, item_name, quantity, unit_price, added_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (username, item_name)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, unit_price = EXCLUDED.unit_price
+		WHERE cart_items.quantity + EXCLUDED.quantity <= $6`,
+		username, item.ItemName, item.Quantity, item.UnitPrice, item.AddedAt, domain.MaxPurchaseQuantity,
+	)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23503" { // foreign_key_violation
+			return fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w: в корзине может быть не больше %d единиц товара", op, domain.ErrInvalidQuantity, domain.MaxPurchaseQuantity)
+	}
+
+	return nil
+}
+
+// RemoveCartItem удаляет товар из корзины
+func (c *cart) RemoveCartItem(ctx context.Context, username, itemName string) error {
+	const op = "CartRepository.RemoveCartItem"
+
+	result, err := c.db.Exec(ctx,
+		"DELETE FROM cart_items WHERE username = $1 AND item_name = $2",
+		username, itemName,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrCartItemNotFound)
+	}
+
+	return nil
+}
+
+// RefreshCartPrices заменяет зафиксированные в корзине цены ценами товаров, действующими в момент now
+func (c *cart) RefreshCartPrices(ctx context.Context, username string, now time.Time) error {
+	const op = "CartRepository.RefreshCartPrices"
+
+	rows, err := c.db.Query(ctx, `
+		SELECT c.item_name, c.unit_price, m.price
+		FROM cart_items c
+		JOIN merch m ON m.name = c.item_name
+		WHERE c.username = $1
+		ORDER BY c.item_name`,
+		username,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var items []*domain.CartItem
+	basePrices := make(map[string]uint64)
+	for rows.Next() {
+		item := &domain.CartItem{}
+		var price uint64
+		if err := rows.Scan(&item.ItemName, &item.UnitPrice, &price); err != nil {
+			return fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		items = append(items, item)
+		basePrices[item.ItemName] = price
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+	rows.Close()
+
+	schedules, err := loadPriceSchedules(ctx, c.db, cartItemNames(items), now)
+	if err != nil {
+		return fmt.Errorf("%s: расписания цен: %w", op, err)
+	}
+
+	for _, item := range items {
+		price := domain.EffectivePrice(basePrices[item.ItemName], schedules[item.ItemName], now)
+		if price == item.UnitPrice {
+			continue
+		}
+		_, err := c.db.Exec(ctx,
+			"UPDATE cart_items SET unit_price = $3 WHERE username = $1 AND item_name = $2",
+			username, item.ItemName, price,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// ExecuteCheckout оформляет заказ из всей корзины пользователя в рамках одной транзакции:
+// списывает стоимость, остатки товаров, пополняет инвентарь и очищает корзину.
+// Заказ либо оформляется целиком, либо не меняет ничего.
+// Если передан idem, результат запроса сохраняется в той же транзакции.
+func (c *cart) ExecuteCheckout(ctx context.Context, username string, now time.Time, idem *domain.IdempotencyRecord) (*domain.Order, error) {
+	const op = "CartRepository.ExecuteCheckout"
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%v, rollback error: %v", err, rollbackErr)
+			}
+		}
+	}()
+
+	// Занимаем ключ идемпотентности до изменения балансов
+	if idem != nil {
+		if err := saveIdempotencyRecord(ctx, tx, idem); err != nil {
+			if err == domain.ErrIdempotencyKeyUsed {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s: сохранение ключа идемпотентности: %w", op, err)
+		}
+	}
+
+	// Блокируем баланс пользователя один раз на весь заказ
+	var coins uint64
+	err = tx.QueryRow(ctx,
+		"SELECT coins FROM users WHERE username = $1 FOR UPDATE",
+		username,
+	).Scan(&coins)
+	if err != nil {
+		return nil, fmt.Errorf("%s: получение данных пользователя: %w", op, err)
+	}
+
+	items, err := lockCartItems(ctx, tx, username, now)
+	if err != nil {
+		if isCheckoutError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: чтение корзины: %w", op, err)
+	}
+
+	order, err := domain.NewOrder(username, items, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if coins < order.Total {
+		return nil, domain.ErrInsufficientFunds
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE users SET coins = coins - $1 WHERE username = $2",
+		order.Total, username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: обновление баланса: %w", op, err)
+	}
+
+	// Позиции отсортированы по названию, поэтому строки товаров блокируются в одном порядке
+	// во всех транзакциях и параллельные заказы не приводят к взаимной блокировке
+	for _, item := range order.Items {
+		if err := takeMerchStock(ctx, tx, item.ItemName, item.Quantity); err != nil {
+			if err == domain.ErrOutOfStock {
+				return nil, fmt.Errorf("%w: %s", domain.ErrOutOfStock, item.ItemName)
+			}
+			return nil, fmt.Errorf("%s: списание со склада: %w", op, err)
+		}
+	}
+
+	if err := insertOrder(ctx, tx, order); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	names := make([]string, 0, len(order.Items))
+	for _, purchase := range order.Purchases() {
+		total, err := purchase.Total()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addToInventory(ctx, tx, purchase.Username, purchase.ItemName, purchase.Quantity); err != nil {
+			return nil, fmt.Errorf("%s: обновление инвентаря: %w", op, err)
+		}
+		if err := insertPurchaseTransaction(ctx, tx, purchase, total, &order.Id, now); err != nil {
+			return nil, fmt.Errorf("%s: создание записи о транзакции: %w", op, err)
+		}
+		names = append(names, purchase.ItemName)
+	}
+
+	// Удаляем только оплаченные позиции: товар, добавленный во время оформления, остается в корзине
+	_, err = tx.Exec(ctx,
+		"DELETE FROM cart_items WHERE username = $1 AND item_name = ANY($2)",
+		username, names,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: очистка корзины: %w", op, err)
+	}
+
+	if err := renderIdempotencyResponse(ctx, tx, idem, order); err != nil {
+		return nil, fmt.Errorf("%s: сохранение ответа по ключу идемпотентности: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return order, nil
+}
+
+// lockCartItems блокирует позиции корзины и строки их товаров и проверяет, что товары доступны
+// и их цены в момент now совпадают с зафиксированными в корзине. Блокировка товаров не дает
+// снять товар с продажи или изменить его цену до фиксации заказа. Товары блокируются сразу
+// на изменение: при разделяемой блокировке два заказа одного товара взаимно блокировались бы
+// при списании остатка.
+func lockCartItems(ctx context.Context, tx pgx.Tx, username string, now time.Time) ([]*domain.CartItem, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT c.item_name, c.quantity, c.unit_price, m.price, m.available AND m.retired_at IS NULL
+		FROM cart_items c
+		JOIN merch m ON m.name = c.item_name
+		WHERE c.username = $1
+		ORDER BY c.item_name
+		FOR UPDATE OF c
+		FOR NO KEY UPDATE OF m`,
+		username,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domain.CartItem
+	basePrices := make(map[string]uint64)
+	for rows.Next() {
+		item := &domain.CartItem{}
+		var price uint64
+		var purchasable bool
+		if err := rows.Scan(&item.ItemName, &item.Quantity, &item.UnitPrice, &price, &purchasable); err != nil {
+			return nil, fmt.Errorf("сканирование строки: %w", err)
+		}
+		if !purchasable {
+			return nil, fmt.Errorf("%w: %s", domain.ErrMerchUnavailable, item.ItemName)
+		}
+		items = append(items, item)
+		basePrices[item.ItemName] = price
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("итерация по результатам: %w", err)
+	}
+	rows.Close()
+
+	schedules, err := loadPriceSchedules(ctx, tx, cartItemNames(items), now)
+	if err != nil {
+		return nil, fmt.Errorf("расписания цен: %w", err)
+	}
+	for _, item := range items {
+		if domain.EffectivePrice(basePrices[item.ItemName], schedules[item.ItemName], now) != item.UnitPrice {
+			return nil, fmt.Errorf("%w: %s", domain.ErrPriceChanged, item.ItemName)
+		}
+	}
+
+	return items, nil
+}
+
+// cartItemNames возвращает названия товаров в позициях корзины
+func cartItemNames(items []*domain.CartItem) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.ItemName)
+	}
+	return names
+}
+
+// isCheckoutError проверяет, что ошибка вызвана содержимым корзины, а не сбоем базы
+func isCheckoutError(err error) bool {
+	for _, target := range []error{domain.ErrMerchUnavailable, domain.ErrPriceChanged} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,253 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cartLockColumns = []string{"item_name", "quantity", "unit_price", "price", "purchasable"}
+
+func TestGetCart(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCartRepository(mock)
+	addedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT item_name, quantity, unit_price, added_at FROM cart_items WHERE username = \\$1 ORDER BY added_at, item_name").
+		WithArgs("buyer").
+		WillReturnRows(pgxmock.NewRows([]string{"item_name", "quantity", "unit_price", "added_at"}).
+			AddRow("cup", uint64(2), uint64(20), addedAt).
+			AddRow("pen", uint64(1), uint64(10), addedAt))
+
+	cart, err := repo.GetCart(context.Background(), "buyer")
+	require.NoError(t, err)
+	require.Len(t, cart.Items, 2)
+	assert.Equal(t, &domain.CartItem{ItemName: "cup", Quantity: 2, UnitPrice: 20, AddedAt: addedAt}, cart.Items[0])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddCartItem(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCartRepository(mock)
+	ctx := context.Background()
+	item := &domain.CartItem{ItemName: "cup", Quantity: 2, UnitPrice: 20, AddedAt: time.Now()}
+
+	t.Run("товар добавлен", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO cart_items").
+			WithArgs("buyer", "cup", uint64(2), uint64(20), item.AddedAt, domain.MaxPurchaseQuantity).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		assert.NoError(t, repo.AddCartItem(ctx, "buyer", item))
+	})
+
+	t.Run("превышено количество", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO cart_items").
+			WithArgs("buyer", "cup", uint64(2), uint64(20), item.AddedAt, domain.MaxPurchaseQuantity).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+		assert.ErrorIs(t, repo.AddCartItem(ctx, "buyer", item), domain.ErrInvalidQuantity)
+	})
+
+	t.Run("товар не существует", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO cart_items").
+			WithArgs("buyer", "cup", uint64(2), uint64(20), item.AddedAt, domain.MaxPurchaseQuantity).
+			WillReturnError(&pgconn.PgError{Code: "23503"})
+
+		assert.ErrorIs(t, repo.AddCartItem(ctx, "buyer", item), domain.ErrMerchNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveCartItem(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCartRepository(mock)
+
+	mock.ExpectExec("DELETE FROM cart_items WHERE username = \\$1 AND item_name = \\$2").
+		WithArgs("buyer", "cup").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err = repo.RemoveCartItem(context.Background(), "buyer", "cup")
+	assert.ErrorIs(t, err, domain.ErrCartItemNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshCartPrices(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCartRepository(mock)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT c.item_name, c.unit_price, m.price FROM cart_items c").
+		WithArgs("buyer").
+		WillReturnRows(pgxmock.NewRows([]string{"item_name", "unit_price", "price"}).
+			AddRow("cup", uint64(20), uint64(20)).
+			AddRow("pen", uint64(10), uint64(12)))
+	expectPriceSchedules(mock, []string{"cup", "pen"}, now,
+		priceScheduleRow(3, "cup", 15, now.Add(-time.Hour), nil, 0))
+	mock.ExpectExec("UPDATE cart_items SET unit_price = \\$3 WHERE username = \\$1 AND item_name = \\$2").
+		WithArgs("buyer", "cup", uint64(15)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("UPDATE cart_items SET unit_price = \\$3 WHERE username = \\$1 AND item_name = \\$2").
+		WithArgs("buyer", "pen", uint64(12)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	require.NoError(t, repo.RefreshCartPrices(context.Background(), "buyer", now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecuteCheckout(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCartRepository(mock)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("заказ из двух товаров", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		// Строки товаров блокируются вместе с позициями, чтобы цена и доступность не изменились до оплаты
+		mock.ExpectQuery("FROM cart_items c(.+)FOR UPDATE OF c\\s+FOR NO KEY UPDATE OF m").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows(cartLockColumns).
+				AddRow("cup", uint64(2), uint64(20), uint64(20), true).
+				AddRow("pen", uint64(1), uint64(10), uint64(10), true))
+		expectPriceSchedules(mock, []string{"cup", "pen"}, now)
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(uint64(50), "buyer").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs("pen", uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectInsertOrder(mock, "buyer", 50, 0, now, 7,
+			&domain.OrderItem{ItemName: "cup", Quantity: 2, UnitPrice: 20},
+			&domain.OrderItem{ItemName: "pen", Quantity: 1, UnitPrice: 10})
+		for _, line := range []struct {
+			name     string
+			quantity uint64
+			price    uint64
+		}{{"cup", 2, 20}, {"pen", 1, 10}} {
+			mock.ExpectExec("INSERT INTO user_inventory").
+				WithArgs("buyer", line.name, line.quantity).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			mock.ExpectExec("INSERT INTO transactions").
+				WithArgs("buyer", "SHOP", line.quantity*line.price, domain.TransactionTypePurchase, line.name, line.quantity, pgxmock.AnyArg(), now).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		}
+		mock.ExpectExec("DELETE FROM cart_items WHERE username = \\$1 AND item_name = ANY\\(\\$2\\)").
+			WithArgs("buyer", []string{"cup", "pen"}).
+			WillReturnResult(pgxmock.NewResult("DELETE", 2))
+		mock.ExpectCommit()
+
+		order, err := repo.ExecuteCheckout(ctx, "buyer", now, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), order.Id)
+		assert.Equal(t, uint64(50), order.Total)
+		assert.Len(t, order.Items, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("цена изменилась", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectQuery("FROM cart_items c").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows(cartLockColumns).AddRow("cup", uint64(2), uint64(20), uint64(25), true))
+		expectPriceSchedules(mock, []string{"cup"}, now)
+		mock.ExpectRollback()
+
+		_, err := repo.ExecuteCheckout(ctx, "buyer", now, nil)
+		assert.ErrorIs(t, err, domain.ErrPriceChanged)
+		assert.Contains(t, err.Error(), "cup")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("корзина пуста", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectQuery("FROM cart_items c").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows(cartLockColumns))
+		mock.ExpectRollback()
+
+		_, err := repo.ExecuteCheckout(ctx, "buyer", now, nil)
+		assert.ErrorIs(t, err, domain.ErrCartEmpty)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("недостаточно средств для покупки по цене распродажи", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(30)))
+		mock.ExpectQuery("FROM cart_items c").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows(cartLockColumns).AddRow("cup", uint64(2), uint64(20), uint64(25), true))
+		expectPriceSchedules(mock, []string{"cup"}, now,
+			priceScheduleRow(3, "cup", 20, now.Add(-time.Hour), nil, 0))
+		mock.ExpectRollback()
+
+		_, err := repo.ExecuteCheckout(ctx, "buyer", now, nil)
+		assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("один из товаров закончился", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectQuery("FROM cart_items c").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows(cartLockColumns).
+				AddRow("cup", uint64(2), uint64(20), uint64(20), true).
+				AddRow("pen", uint64(1), uint64(10), uint64(10), true))
+		expectPriceSchedules(mock, []string{"cup", "pen"}, now)
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(uint64(50), "buyer").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs("pen", uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery("SELECT stock IS NOT NULL FROM merch WHERE name = \\$1").
+			WithArgs("pen").
+			WillReturnRows(pgxmock.NewRows([]string{"limited"}).AddRow(true))
+		mock.ExpectRollback()
+
+		_, err := repo.ExecuteCheckout(ctx, "buyer", now, nil)
+		assert.ErrorIs(t, err, domain.ErrOutOfStock)
+		assert.Contains(t, err.Error(), "pen")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
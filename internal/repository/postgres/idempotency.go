@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// idempotency реализует интерфейс IdempotencyRepository для работы с ключами идемпотентности в PostgreSQL
+type idempotency struct {
+	db DBPool
+}
+
+// NewIdempotencyRepository создает новый экземпляр репозитория ключей идемпотентности
+func NewIdempotencyRepository(db DBPool) repository.IdempotencyRepository {
+	return &idempotency{db: db}
+}
+
+// execer выполняет запрос в пуле соединений или внутри транзакции
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+// GetIdempotencyRecord возвращает сохраненный результат запроса или nil, если ключ еще не использован
+func (i *idempotency) GetIdempotencyRecord(ctx context.Context, username, key string) (*domain.IdempotencyRecord, error) {
+	const op = "IdempotencyRepository.GetIdempotencyRecord"
+
+	record := &domain.IdempotencyRecord{Username: username, Key: key}
+	err := i.db.QueryRow(ctx, `
+		SELECT request_hash, status_code, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE username = $1 AND key = $2 AND expires_at > $3`,
+		username, key, time.Now(),
+	).Scan(&record.RequestHash, &record.StatusCode, &record.Response, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return record, nil
+}
+
+// SaveIdempotencyRecord сохраняет результат запроса, который не менял баланс
+func (i *idempotency) SaveIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	const op = "IdempotencyRepository.SaveIdempotencyRecord"
+
+	if err := saveIdempotencyRecord(ctx, i.db, record); err != nil {
+		if err == domain.ErrIdempotencyKeyUsed {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyRecords удаляет истекшие записи и возвращает их количество
+func (i *idempotency) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error) {
+	const op = "IdempotencyRepository.DeleteExpiredIdempotencyRecords"
+
+	result, err := i.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// saveIdempotencyRecord вставляет запись о результате запроса.
+// Внутри транзакции вставка выполняется первой: параллельный запрос с тем же ключом
+// ждет на уникальном индексе и после фиксации первой транзакции получает ErrIdempotencyKeyUsed.
+// Истекшая запись, которую еще не удалила фоновая очистка, заменяется новой.
+func saveIdempotencyRecord(ctx context.Context, db execer, record *domain.IdempotencyRecord) error {
+	result, err := db.Exec(ctx, `
+		INSERT INTO idempotency_keys (username, key, request_hash, status_code, response, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (username, key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status_code = EXCLUDED.status_code,
+			response = EXCLUDED.response,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at`,
+		record.Username, record.Key, record.RequestHash, record.StatusCode, record.Response, record.CreatedAt, record.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrIdempotencyKeyUsed
+	}
+
+	return nil
+}
+
+// renderIdempotencyResponse заменяет сохраненный ответ ответом, сформированным по результату операции.
+// Вызывается в той же транзакции, что и saveIdempotencyRecord.
+func renderIdempotencyResponse(ctx context.Context, db execer, record *domain.IdempotencyRecord, result interface{}) error {
+	if record == nil || record.Render == nil {
+		return nil
+	}
+
+	body, err := record.Render(result)
+	if err != nil {
+		return err
+	}
+	record.Response = body
+
+	_, err = db.Exec(ctx,
+		"UPDATE idempotency_keys SET response = $3 WHERE username = $1 AND key = $2",
+		record.Username, record.Key, record.Response,
+	)
+	return err
+}
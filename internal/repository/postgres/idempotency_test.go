@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIdempotencyRecord(t *testing.T) {
+	t.Run("запись найдена", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewIdempotencyRepository(mock)
+		now := time.Now()
+
+		mock.ExpectQuery("SELECT request_hash, status_code, response, created_at, expires_at FROM idempotency_keys").
+			WithArgs("user", "key-1", pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{"request_hash", "status_code", "response", "created_at", "expires_at"}).
+				AddRow("hash", 200, []byte(`{"status":"success"}`), now, now.Add(time.Hour)))
+
+		record, err := repo.GetIdempotencyRecord(context.Background(), "user", "key-1")
+
+		require.NoError(t, err)
+		require.Equal(t, "hash", record.RequestHash)
+		require.Equal(t, 200, record.StatusCode)
+		require.Equal(t, "key-1", record.Key)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ключ не использован", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewIdempotencyRepository(mock)
+
+		mock.ExpectQuery("SELECT request_hash, status_code, response, created_at, expires_at FROM idempotency_keys").
+			WithArgs("user", "key-1", pgxmock.AnyArg()).
+			WillReturnError(pgx.ErrNoRows)
+
+		record, err := repo.GetIdempotencyRecord(context.Background(), "user", "key-1")
+
+		require.NoError(t, err)
+		require.Nil(t, record)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSaveIdempotencyRecord(t *testing.T) {
+	record := domain.NewIdempotencyRecord("user", "key-1", "hash", time.Hour)
+	record.StatusCode = 400
+	record.Response = []byte(`{"errors":"INSUFFICIENT_FUNDS: Недостаточно средств"}`)
+
+	t.Run("успешное сохранение", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewIdempotencyRepository(mock)
+
+		mock.ExpectExec("INSERT INTO idempotency_keys").
+			WithArgs(record.Username, record.Key, record.RequestHash, record.StatusCode, record.Response, record.CreatedAt, record.ExpiresAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err = repo.SaveIdempotencyRecord(context.Background(), record)
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("истекшая запись заменяется", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewIdempotencyRepository(mock)
+
+		mock.ExpectExec("ON CONFLICT \\(username, key\\) DO UPDATE SET .+ WHERE idempotency_keys.expires_at <= EXCLUDED.created_at").
+			WithArgs(record.Username, record.Key, record.RequestHash, record.StatusCode, record.Response, record.CreatedAt, record.ExpiresAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err = repo.SaveIdempotencyRecord(context.Background(), record)
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ключ уже занят", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewIdempotencyRepository(mock)
+
+		mock.ExpectExec("INSERT INTO idempotency_keys").
+			WithArgs(record.Username, record.Key, record.RequestHash, record.StatusCode, record.Response, record.CreatedAt, record.ExpiresAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+		err = repo.SaveIdempotencyRecord(context.Background(), record)
+
+		require.ErrorIs(t, err, domain.ErrIdempotencyKeyUsed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteExpiredIdempotencyRecords(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewIdempotencyRepository(mock)
+	now := time.Now()
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE expires_at <= \\$1").
+		WithArgs(now).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	deleted, err := repo.DeleteExpiredIdempotencyRecords(context.Background(), now)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// loginAttempt реализует интерфейс LoginAttemptRepository в PostgreSQL,
+// что позволяет разделять счетчики между репликами сервиса
+type loginAttempt struct {
+	db DBPool
+}
+
+// NewLoginAttemptRepository создает новый экземпляр репозитория попыток входа
+func NewLoginAttemptRepository(db DBPool) repository.LoginAttemptRepository {
+	return &loginAttempt{db: db}
+}
+
+// GetLoginAttempts возвращает счетчик попыток по ключу, пустой счетчик если попыток не было
+func (l *loginAttempt) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	const op = "LoginAttemptRepository.GetLoginAttempts"
+
+	attempts := &domain.LoginAttempts{Key: key}
+	var lockedUntil *time.Time
+	err := l.db.QueryRow(ctx,
+		"SELECT failures, last_failure, locked_until FROM login_attempts WHERE key = $1",
+		key,
+	).Scan(&attempts.Failures, &attempts.LastFailure, &lockedUntil)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return attempts, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lockedUntil != nil {
+		attempts.LockedUntil = *lockedUntil
+	}
+	return attempts, nil
+}
+
+// RecordLoginFailure атомарно увеличивает счетчик неудачных попыток.
+// Если последняя ошибка была раньше now-resetAfter, счетчик начинается заново.
+func (l *loginAttempt) RecordLoginFailure(ctx context.Context, key string, now time.Time, resetAfter time.Duration) (*domain.LoginAttempts, error) {
+	const op = "LoginAttemptRepository.RecordLoginFailure"
+
+	attempts := &domain.LoginAttempts{Key: key}
+	var lockedUntil *time.Time
+	err := l.db.QueryRow(ctx, `
+		INSERT INTO login_attempts (key, failures, last_failure)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure = $2
+		RETURNING failures, last_failure, locked_until`,
+		key, now, now.Add(-resetAfter),
+	).Scan(&attempts.Failures, &attempts.LastFailure, &lockedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lockedUntil != nil {
+		attempts.LockedUntil = *lockedUntil
+	}
+	return attempts, nil
+}
+
+// LockLogin блокирует вход по ключу до указанного времени
+func (l *loginAttempt) LockLogin(ctx context.Context, key string, until time.Time) error {
+	const op = "LoginAttemptRepository.LockLogin"
+
+	_, err := l.db.Exec(ctx,
+		"UPDATE login_attempts SET locked_until = $1 WHERE key = $2",
+		until, key,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResetLoginAttempts сбрасывает счетчик и снимает блокировку
+func (l *loginAttempt) ResetLoginAttempts(ctx context.Context, key string) error {
+	const op = "LoginAttemptRepository.ResetLoginAttempts"
+
+	_, err := l.db.Exec(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteStaleLoginAttempts удаляет счетчики без активной блокировки, последняя ошибка
+// в которых была раньше now-resetAfter. Такие счетчики все равно начались бы заново.
+func (l *loginAttempt) DeleteStaleLoginAttempts(ctx context.Context, now time.Time, resetAfter time.Duration) (int64, error) {
+	const op = "LoginAttemptRepository.DeleteStaleLoginAttempts"
+
+	tag, err := l.db.Exec(ctx,
+		"DELETE FROM login_attempts WHERE last_failure < $1 AND (locked_until IS NULL OR locked_until <= $2)",
+		now.Add(-resetAfter), now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLoginAttempts(t *testing.T) {
+	t.Run("попыток не было", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewLoginAttemptRepository(mock)
+
+		mock.ExpectQuery("SELECT failures, last_failure, locked_until FROM login_attempts WHERE key = \\$1").
+			WithArgs("user:testuser").
+			WillReturnRows(pgxmock.NewRows([]string{"failures", "last_failure", "locked_until"}))
+
+		attempts, err := repo.GetLoginAttempts(context.Background(), "user:testuser")
+
+		require.NoError(t, err)
+		require.Equal(t, 0, attempts.Failures)
+		require.False(t, attempts.IsLocked(time.Now()))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("активная блокировка", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewLoginAttemptRepository(mock)
+		lockedUntil := time.Now().Add(time.Minute)
+
+		mock.ExpectQuery("SELECT failures, last_failure, locked_until FROM login_attempts WHERE key = \\$1").
+			WithArgs("user:testuser").
+			WillReturnRows(pgxmock.NewRows([]string{"failures", "last_failure", "locked_until"}).
+				AddRow(5, time.Now(), &lockedUntil))
+
+		attempts, err := repo.GetLoginAttempts(context.Background(), "user:testuser")
+
+		require.NoError(t, err)
+		require.Equal(t, 5, attempts.Failures)
+		require.True(t, attempts.IsLocked(time.Now()))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLoginAttemptRepository(mock)
+	now := time.Now()
+
+	mock.ExpectQuery("INSERT INTO login_attempts").
+		WithArgs("ip:10.0.0.1", now, now.Add(-time.Hour)).
+		WillReturnRows(pgxmock.NewRows([]string{"failures", "last_failure", "locked_until"}).
+			AddRow(2, now, nil))
+
+	attempts, err := repo.RecordLoginFailure(context.Background(), "ip:10.0.0.1", now, time.Hour)
+
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts.Failures)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteStaleLoginAttempts(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewLoginAttemptRepository(mock)
+	now := time.Now()
+
+	mock.ExpectExec("DELETE FROM login_attempts WHERE last_failure < \\$1 AND \\(locked_until IS NULL OR locked_until <= \\$2\\)").
+		WithArgs(now.Add(-time.Hour), now).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+
+	deleted, err := repo.DeleteStaleLoginAttempts(context.Background(), now, time.Hour)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,353 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// market реализует интерфейс MarketRepository для работы с лотами маркетплейса в PostgreSQL
+type market struct {
+	db DBPool
+}
+
+// NewMarketRepository создает новый экземпляр репозитория маркетплейса
+func NewMarketRepository(db DBPool) repository.MarketRepository {
+	return &market{db: db}
+}
+
+// listingColumns перечисляет колонки лота в порядке, который ожидает scanListing
+const listingColumns = "id, seller, item_name, quantity, price, status, COALESCE(buyer, ''), fee, created_at, closed_at"
+
+// scanListing считывает лот из строки результата
+func scanListing(row pgx.Row) (*domain.Listing, error) {
+	l := &domain.Listing{}
+	if err := row.Scan(&l.Id, &l.Seller, &l.ItemName, &l.Quantity, &l.Price, &l.Status, &l.Buyer, &l.Fee, &l.CreatedAt, &l.ClosedAt); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// CreateListing выставляет лот и удерживает его товар: единицы списываются из инвентаря продавца
+// в той же транзакции, что и создание лота. Снятые с продажи и непередаваемые товары не выставляются.
+func (m *market) CreateListing(ctx context.Context, listing *domain.Listing) error {
+	const op = "MarketRepository.CreateListing"
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%v, rollback error: %v", err, rollbackErr)
+			}
+		}
+	}()
+
+	if err := checkTransferable(ctx, tx, listing.ItemName); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := takeFromInventory(ctx, tx, listing.Seller, listing.ItemName, listing.Quantity); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO market_listings (seller, item_name, quantity, price, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		listing.Seller, listing.ItemName, listing.Quantity, listing.Price, string(listing.Status), listing.CreatedAt,
+	).Scan(&listing.Id)
+	if err != nil {
+		return fmt.Errorf("%s: создание лота: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// GetListing возвращает лот по номеру
+func (m *market) GetListing(ctx context.Context, id int64) (*domain.Listing, error) {
+	const op = "MarketRepository.GetListing"
+
+	listing, err := scanListing(m.db.QueryRow(ctx, "SELECT "+listingColumns+" FROM market_listings WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrListingNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return listing, nil
+}
+
+// GetListings возвращает лоты, подходящие под фильтр, начиная с последних
+func (m *market) GetListings(ctx context.Context, filter domain.ListingFilter) ([]*domain.Listing, error) {
+	const op = "MarketRepository.GetListings"
+
+	query, args := buildListingsQuery(filter)
+	rows, err := m.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var listings []*domain.Listing
+	for rows.Next() {
+		listing, err := scanListing(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		listings = append(listings, listing)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	return listings, nil
+}
+
+// CancelListing снимает активный лот продавца и возвращает товар в его инвентарь.
+// Чужой лот считается ненайденным.
+func (m *market) CancelListing(ctx context.Context, id int64, seller string, now time.Time) (*domain.Listing, error) {
+	const op = "MarketRepository.CancelListing"
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%v, rollback error: %v", err, rollbackErr)
+			}
+		}
+	}()
+
+	listing, err := lockListing(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if listing.Seller != seller {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrListingNotFound)
+	}
+	if !listing.IsActive() {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrListingClosed)
+	}
+
+	listing.Status = domain.ListingStatusCancelled
+	listing.ClosedAt = &now
+	if err := closeListing(ctx, tx, listing); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := addToInventory(ctx, tx, listing.Seller, listing.ItemName, listing.Quantity); err != nil {
+		return nil, fmt.Errorf("%s: возврат товара: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return listing, nil
+}
+
+// BuyListing покупает лот в рамках одной транзакции: покупатель платит цену лота продавцу,
+// продавец платит магазину комиссию feePercent процентов, а удерживаемый товар зачисляется покупателю.
+// Если передан idem, результат запроса сохраняется вместе с покупкой.
+func (m *market) BuyListing(ctx context.Context, id int64, buyer string, feePercent uint64, now time.Time, idem *domain.IdempotencyRecord) (*domain.Listing, error) {
+	const op = "MarketRepository.BuyListing"
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%v, rollback error: %v", err, rollbackErr)
+			}
+		}
+	}()
+
+	if idem != nil {
+		if err := saveIdempotencyRecord(ctx, tx, idem); err != nil {
+			if err == domain.ErrIdempotencyKeyUsed {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s: сохранение ключа идемпотентности: %w", op, err)
+		}
+	}
+
+	// Блокировка лота не дает двум покупателям купить его одновременно
+	listing, err := lockListing(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !listing.IsActive() {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrListingClosed)
+	}
+	if listing.Seller == buyer {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrOwnListing)
+	}
+	listing.Fee = domain.MarketFee(listing.Price, feePercent)
+
+	accounts := []string{buyer, listing.Seller}
+	if listing.Fee > 0 {
+		accounts = append(accounts, domain.ShopAccount)
+	}
+	coins, err := lockUsers(ctx, tx, accounts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: получение данных пользователей: %w", op, err)
+	}
+	buyerCoins, ok := coins[buyer]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
+	}
+	if buyerCoins < listing.Price {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrInsufficientFunds)
+	}
+
+	// Товар могли снять с продажи после выставления лота
+	if err := checkTransferable(ctx, tx, listing.ItemName); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := moveCoins(ctx, tx, buyer, listing.Seller, listing.Price); err != nil {
+		return nil, fmt.Errorf("%s: оплата лота: %w", op, err)
+	}
+	if listing.Fee > 0 {
+		if err := moveCoins(ctx, tx, listing.Seller, domain.ShopAccount, listing.Fee); err != nil {
+			return nil, fmt.Errorf("%s: комиссия: %w", op, err)
+		}
+	}
+
+	if err := addToInventory(ctx, tx, buyer, listing.ItemName, listing.Quantity); err != nil {
+		return nil, fmt.Errorf("%s: обновление инвентаря: %w", op, err)
+	}
+
+	listing.Status = domain.ListingStatusSold
+	listing.Buyer = buyer
+	listing.ClosedAt = &now
+	if err := closeListing(ctx, tx, listing); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertListingTransaction(ctx, tx, listing, buyer, listing.Seller, listing.Price, domain.TransactionTypeMarketSale); err != nil {
+		return nil, fmt.Errorf("%s: создание записи о транзакции: %w", op, err)
+	}
+	if listing.Fee > 0 {
+		if err := insertListingTransaction(ctx, tx, listing, listing.Seller, domain.ShopAccount, listing.Fee, domain.TransactionTypeMarketFee); err != nil {
+			return nil, fmt.Errorf("%s: создание записи о комиссии: %w", op, err)
+		}
+	}
+
+	if err := renderIdempotencyResponse(ctx, tx, idem, listing); err != nil {
+		return nil, fmt.Errorf("%s: сохранение ответа: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return listing, nil
+}
+
+// buildListingsQuery формирует запрос списка лотов по фильтру
+func buildListingsQuery(filter domain.ListingFilter) (string, []interface{}) {
+	var b strings.Builder
+	b.WriteString("SELECT " + listingColumns + " FROM market_listings WHERE TRUE")
+	var args []interface{}
+
+	if filter.ItemName != "" {
+		args = append(args, filter.ItemName)
+		fmt.Fprintf(&b, " AND item_name = $%d", len(args))
+	}
+	if filter.Seller != "" {
+		args = append(args, filter.Seller)
+		fmt.Fprintf(&b, " AND seller = $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		fmt.Fprintf(&b, " AND status = $%d", len(args))
+	}
+	if filter.MinPrice > 0 {
+		args = append(args, filter.MinPrice)
+		fmt.Fprintf(&b, " AND price >= $%d", len(args))
+	}
+	if filter.MaxPrice > 0 {
+		args = append(args, filter.MaxPrice)
+		fmt.Fprintf(&b, " AND price <= $%d", len(args))
+	}
+
+	b.WriteString(" ORDER BY created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		fmt.Fprintf(&b, " LIMIT $%d", len(args))
+	}
+
+	return b.String(), args
+}
+
+// lockListing блокирует лот до конца транзакции
+func lockListing(ctx context.Context, tx pgx.Tx, id int64) (*domain.Listing, error) {
+	listing, err := scanListing(tx.QueryRow(ctx, "SELECT "+listingColumns+" FROM market_listings WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrListingNotFound
+		}
+		return nil, fmt.Errorf("получение лота: %w", err)
+	}
+	return listing, nil
+}
+
+// closeListing сохраняет итоговое состояние проданного или снятого лота
+func closeListing(ctx context.Context, tx pgx.Tx, listing *domain.Listing) error {
+	_, err := tx.Exec(ctx,
+		"UPDATE market_listings SET status = $2, buyer = NULLIF($3, ''), fee = $4, closed_at = $5 WHERE id = $1",
+		listing.Id, string(listing.Status), listing.Buyer, listing.Fee, listing.ClosedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("обновление лота: %w", err)
+	}
+	return nil
+}
+
+// moveCoins переводит монеты между заблокированными пользователями
+func moveCoins(ctx context.Context, tx pgx.Tx, from, to string, amount uint64) error {
+	if _, err := tx.Exec(ctx, "UPDATE users SET coins = coins - $1 WHERE username = $2", amount, from); err != nil {
+		return fmt.Errorf("списание: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE users SET coins = coins + $1 WHERE username = $2", amount, to); err != nil {
+		return fmt.Errorf("зачисление: %w", err)
+	}
+	return nil
+}
+
+// insertListingTransaction записывает в историю оплату лота или комиссию с него
+func insertListingTransaction(ctx context.Context, tx pgx.Tx, listing *domain.Listing, from, to string, amount uint64, transferType domain.TransactionType) error {
+	_, err := tx.Exec(ctx,
+		"INSERT INTO transactions (sender_name, receiver_name, amount, transfer_type, item_name, quantity, listing_id, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		from, to, amount, transferType, listing.ItemName, listing.Quantity, listing.Id, *listing.ClosedAt,
+	)
+	return err
+}
@@ -0,0 +1,345 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var listingTestColumns = []string{"id", "seller", "item_name", "quantity", "price", "status", "buyer", "fee", "created_at", "closed_at"}
+
+// expectLockListing ожидает блокировку лота с указанным состоянием
+func expectLockListing(mock pgxmock.PgxPoolIface, id int64, seller string, status domain.ListingStatus, createdAt time.Time) {
+	mock.ExpectQuery("SELECT (.+) FROM market_listings WHERE id = \\$1 FOR UPDATE").
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows(listingTestColumns).
+			AddRow(id, seller, "cup", uint64(2), uint64(100), status, "", uint64(0), createdAt, (*time.Time)(nil)))
+}
+
+// expectTransferableMerch ожидает проверку, что товар можно передавать
+func expectTransferableMerch(mock pgxmock.PgxPoolIface, transferable bool) {
+	mock.ExpectQuery("SELECT (.+) FROM merch WHERE name = \\$1 FOR SHARE").
+		WithArgs("cup").
+		WillReturnRows(pgxmock.NewRows(merchRowColumns).
+			AddRow("cup", uint64(20), "Кружка", "tableware", true, nil, nil, transferable))
+}
+
+func TestCreateListing(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMarketRepository(mock)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("товар удерживается за лотом", func(t *testing.T) {
+		listing := domain.NewListing("alice", "cup", 2, 100, now)
+		mock.ExpectBegin()
+		expectTransferableMerch(mock, true)
+		mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - \\$3").
+			WithArgs("alice", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectQuery("INSERT INTO market_listings").
+			WithArgs("alice", "cup", uint64(2), uint64(100), "active", now).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(5)))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.CreateListing(ctx, listing))
+		assert.Equal(t, int64(5), listing.Id)
+	})
+
+	t.Run("недостаточно товара", func(t *testing.T) {
+		listing := domain.NewListing("alice", "cup", 2, 100, now)
+		mock.ExpectBegin()
+		expectTransferableMerch(mock, true)
+		mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - \\$3").
+			WithArgs("alice", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectRollback()
+
+		err := repo.CreateListing(ctx, listing)
+		assert.ErrorIs(t, err, domain.ErrInventoryShortage)
+	})
+
+	t.Run("непередаваемый товар", func(t *testing.T) {
+		listing := domain.NewListing("alice", "cup", 2, 100, now)
+		mock.ExpectBegin()
+		expectTransferableMerch(mock, false)
+		mock.ExpectRollback()
+
+		err := repo.CreateListing(ctx, listing)
+		assert.ErrorIs(t, err, domain.ErrItemNotTransferable)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetListings(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMarketRepository(mock)
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("активные лоты товара в диапазоне цен", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM market_listings WHERE TRUE AND item_name = \\$1 AND status = \\$2 AND price >= \\$3 AND price <= \\$4 ORDER BY created_at DESC, id DESC LIMIT \\$5").
+			WithArgs("cup", "active", uint64(50), uint64(150), 50).
+			WillReturnRows(pgxmock.NewRows(listingTestColumns).
+				AddRow(int64(6), "carol", "cup", uint64(1), uint64(60), domain.ListingStatusActive, "", uint64(0), createdAt, (*time.Time)(nil)).
+				AddRow(int64(5), "alice", "cup", uint64(2), uint64(100), domain.ListingStatusActive, "", uint64(0), createdAt, (*time.Time)(nil)))
+
+		listings, err := repo.GetListings(context.Background(), domain.ListingFilter{
+			ItemName: "cup",
+			Status:   domain.ListingStatusActive,
+			MinPrice: 50,
+			MaxPrice: 150,
+			Limit:    50,
+		})
+		require.NoError(t, err)
+		require.Len(t, listings, 2)
+		assert.Equal(t, int64(6), listings[0].Id)
+		assert.Equal(t, "carol", listings[0].Seller)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("лоты продавца", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM market_listings WHERE TRUE AND seller = \\$1 ORDER BY created_at DESC, id DESC").
+			WithArgs("alice").
+			WillReturnRows(pgxmock.NewRows(listingTestColumns))
+
+		listings, err := repo.GetListings(context.Background(), domain.ListingFilter{Seller: "alice"})
+		require.NoError(t, err)
+		assert.Empty(t, listings)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetListing(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMarketRepository(mock)
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	closedAt := createdAt.Add(time.Hour)
+
+	t.Run("проданный лот", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM market_listings WHERE id = \\$1").
+			WithArgs(int64(5)).
+			WillReturnRows(pgxmock.NewRows(listingTestColumns).
+				AddRow(int64(5), "alice", "cup", uint64(2), uint64(100), domain.ListingStatusSold, "bob", uint64(10), createdAt, &closedAt))
+
+		listing, err := repo.GetListing(context.Background(), 5)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ListingStatusSold, listing.Status)
+		assert.Equal(t, "bob", listing.Buyer)
+		assert.Equal(t, uint64(90), listing.SellerProceeds())
+	})
+
+	t.Run("лот не найден", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM market_listings WHERE id = \\$1").
+			WithArgs(int64(404)).
+			WillReturnRows(pgxmock.NewRows(listingTestColumns))
+
+		_, err := repo.GetListing(context.Background(), 404)
+		assert.ErrorIs(t, err, domain.ErrListingNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelListing(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMarketRepository(mock)
+	ctx := context.Background()
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := createdAt.Add(time.Hour)
+
+	t.Run("товар возвращается продавцу", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusActive, createdAt)
+		mock.ExpectExec("UPDATE market_listings SET status = \\$2").
+			WithArgs(int64(5), "cancelled", "", uint64(0), &now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs("alice", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		listing, err := repo.CancelListing(ctx, 5, "alice", now)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ListingStatusCancelled, listing.Status)
+		assert.Equal(t, &now, listing.ClosedAt)
+	})
+
+	t.Run("чужой лот", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusActive, createdAt)
+		mock.ExpectRollback()
+
+		_, err := repo.CancelListing(ctx, 5, "bob", now)
+		assert.ErrorIs(t, err, domain.ErrListingNotFound)
+	})
+
+	t.Run("лот уже продан", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusSold, createdAt)
+		mock.ExpectRollback()
+
+		_, err := repo.CancelListing(ctx, 5, "alice", now)
+		assert.ErrorIs(t, err, domain.ErrListingClosed)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuyListing(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMarketRepository(mock)
+	ctx := context.Background()
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := createdAt.Add(time.Hour)
+
+	expectCoinMove := func(from, to string, amount uint64) {
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(amount, from).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE users SET coins = coins \\+ \\$1 WHERE username = \\$2").
+			WithArgs(amount, to).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	}
+
+	t.Run("покупка с комиссией", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusActive, createdAt)
+		mock.ExpectQuery("SELECT username, coins FROM users WHERE username = ANY\\(\\$1\\) ORDER BY username FOR UPDATE").
+			WithArgs([]string{"bob", "alice", domain.ShopAccount}).
+			WillReturnRows(pgxmock.NewRows([]string{"username", "coins"}).
+				AddRow(domain.ShopAccount, uint64(0)).
+				AddRow("alice", uint64(0)).
+				AddRow("bob", uint64(150)))
+		expectTransferableMerch(mock, true)
+		expectCoinMove("bob", "alice", 100)
+		expectCoinMove("alice", domain.ShopAccount, 10)
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs("bob", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("UPDATE market_listings SET status = \\$2").
+			WithArgs(int64(5), "sold", "bob", uint64(10), &now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("bob", "alice", uint64(100), domain.TransactionTypeMarketSale, "cup", uint64(2), int64(5), now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("alice", domain.ShopAccount, uint64(10), domain.TransactionTypeMarketFee, "cup", uint64(2), int64(5), now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		listing, err := repo.BuyListing(ctx, 5, "bob", 10, now, nil)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ListingStatusSold, listing.Status)
+		assert.Equal(t, "bob", listing.Buyer)
+		assert.Equal(t, uint64(10), listing.Fee)
+		assert.Equal(t, uint64(90), listing.SellerProceeds())
+	})
+
+	t.Run("без комиссии ответ сохраняется по ключу идемпотентности", func(t *testing.T) {
+		idem := domain.NewIdempotencyRecord("bob", "key-1", "hash", time.Hour)
+		idem.Render = func(result interface{}) ([]byte, error) {
+			return json.Marshal(map[string]string{"status": string(result.(*domain.Listing).Status)})
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO idempotency_keys").
+			WithArgs(idem.Username, idem.Key, idem.RequestHash, idem.StatusCode, idem.Response, idem.CreatedAt, idem.ExpiresAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		expectLockListing(mock, 5, "alice", domain.ListingStatusActive, createdAt)
+		mock.ExpectQuery("SELECT username, coins FROM users").
+			WithArgs([]string{"bob", "alice"}).
+			WillReturnRows(pgxmock.NewRows([]string{"username", "coins"}).
+				AddRow("alice", uint64(0)).
+				AddRow("bob", uint64(100)))
+		expectTransferableMerch(mock, true)
+		expectCoinMove("bob", "alice", 100)
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs("bob", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("UPDATE market_listings SET status = \\$2").
+			WithArgs(int64(5), "sold", "bob", uint64(0), &now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("bob", "alice", uint64(100), domain.TransactionTypeMarketSale, "cup", uint64(2), int64(5), now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("UPDATE idempotency_keys SET response = \\$3").
+			WithArgs("bob", "key-1", []byte(`{"status":"sold"}`)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectCommit()
+
+		listing, err := repo.BuyListing(ctx, 5, "bob", 0, now, idem)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0), listing.Fee)
+	})
+
+	t.Run("недостаточно средств", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusActive, createdAt)
+		mock.ExpectQuery("SELECT username, coins FROM users").
+			WithArgs([]string{"bob", "alice"}).
+			WillReturnRows(pgxmock.NewRows([]string{"username", "coins"}).
+				AddRow("alice", uint64(0)).
+				AddRow("bob", uint64(99)))
+		mock.ExpectRollback()
+
+		_, err := repo.BuyListing(ctx, 5, "bob", 0, now, nil)
+		assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	})
+
+	t.Run("собственный лот", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusActive, createdAt)
+		mock.ExpectRollback()
+
+		_, err := repo.BuyListing(ctx, 5, "alice", 0, now, nil)
+		assert.ErrorIs(t, err, domain.ErrOwnListing)
+	})
+
+	t.Run("лот уже продан", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusSold, createdAt)
+		mock.ExpectRollback()
+
+		_, err := repo.BuyListing(ctx, 5, "bob", 0, now, nil)
+		assert.ErrorIs(t, err, domain.ErrListingClosed)
+	})
+
+	t.Run("товар стал непередаваемым", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLockListing(mock, 5, "alice", domain.ListingStatusActive, createdAt)
+		mock.ExpectQuery("SELECT username, coins FROM users").
+			WithArgs([]string{"bob", "alice"}).
+			WillReturnRows(pgxmock.NewRows([]string{"username", "coins"}).
+				AddRow("alice", uint64(0)).
+				AddRow("bob", uint64(100)))
+		expectTransferableMerch(mock, false)
+		mock.ExpectRollback()
+
+		_, err := repo.BuyListing(ctx, 5, "bob", 0, now, nil)
+		assert.ErrorIs(t, err, domain.ErrItemNotTransferable)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/netscrawler/avito-shop/internal/domain"
 	"github.com/netscrawler/avito-shop/internal/repository"
 )
@@ -19,20 +21,36 @@ func NewMerchRepository(db DBPool) repository.MerchRepository {
 	return &merch{db: db}
 }
 
+// merchColumns перечисляет колонки товара в порядке, который ожидает scanMerch
+const merchColumns = "name, price, description, category, available, retired_at, stock, transferable"
+
+// scanMerch считывает товар из строки результата
+func scanMerch(row pgx.Row) (*domain.Merch, error) {
+	merch := &domain.Merch{}
+	if err := row.Scan(&merch.Name, &merch.Price, &merch.Description, &merch.Category, &merch.Available, &merch.RetiredAt, &merch.Stock, &merch.Transferable); err != nil {
+		return nil, err
+	}
+	return merch, nil
+}
+
 // GetMerchByName возвращает товар по его названию
 func (m *merch) GetMerchByName(ctx context.Context, name string) (*domain.Merch, error) {
 	const op = "MerchRepository.GetMerchByName"
 
-	row := m.db.QueryRow(ctx, "SELECT name, price FROM merch WHERE name = $1", name)
+	row := m.db.QueryRow(ctx, "SELECT "+merchColumns+" FROM merch WHERE name = $1", name)
 
-	merch := &domain.Merch{}
-	if err := row.Scan(&merch.Name, &merch.Price); err != nil {
+	merch, err := scanMerch(row)
+	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := attachPriceSchedules(ctx, m.db, merch); err != nil {
+		return nil, fmt.Errorf("%s: расписания цен: %w", op, err)
+	}
+
 	return merch, nil
 }
 
@@ -40,24 +58,28 @@ func (m *merch) GetMerchByName(ctx context.Context, name string) (*domain.Merch,
 func (m *merch) GetMerchById(ctx context.Context, id int) (*domain.Merch, error) {
 	const op = "MerchRepository.GetMerchById"
 
-	row := m.db.QueryRow(ctx, "SELECT name, price FROM merch WHERE id = $1", id)
+	row := m.db.QueryRow(ctx, "SELECT "+merchColumns+" FROM merch WHERE id = $1", id)
 
-	merch := &domain.Merch{}
-	if err := row.Scan(&merch.Name, &merch.Price); err != nil {
+	merch, err := scanMerch(row)
+	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := attachPriceSchedules(ctx, m.db, merch); err != nil {
+		return nil, fmt.Errorf("%s: расписания цен: %w", op, err)
+	}
+
 	return merch, nil
 }
 
-// GetAllMerch возвращает список всех доступных товаров
+// GetAllMerch возвращает список товаров, не снятых с продажи
 func (m *merch) GetAllMerch(ctx context.Context) ([]*domain.Merch, error) {
 	const op = "MerchRepository.GetAllMerch"
 
-	rows, err := m.db.Query(ctx, "SELECT name, price FROM merch ORDER BY name")
+	rows, err := m.db.Query(ctx, "SELECT "+merchColumns+" FROM merch WHERE retired_at IS NULL ORDER BY name")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -65,8 +87,8 @@ func (m *merch) GetAllMerch(ctx context.Context) ([]*domain.Merch, error) {
 
 	var items []*domain.Merch
 	for rows.Next() {
-		item := &domain.Merch{}
-		if err := rows.Scan(&item.Name, &item.Price); err != nil {
+		item, err := scanMerch(rows)
+		if err != nil {
 			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
 		}
 		items = append(items, item)
@@ -75,6 +97,121 @@ func (m *merch) GetAllMerch(ctx context.Context) ([]*domain.Merch, error) {
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
 	}
+	rows.Close()
+
+	if err := attachPriceSchedules(ctx, m.db, items...); err != nil {
+		return nil, fmt.Errorf("%s: расписания цен: %w", op, err)
+	}
 
 	return items, nil
 }
+
+// CreateMerch добавляет новый товар в каталог и начинает историю его цены
+func (m *merch) CreateMerch(ctx context.Context, item *domain.Merch) error {
+	const op = "MerchRepository.CreateMerch"
+
+	_, err := m.db.Exec(ctx, `
+		WITH created AS (
+			INSERT INTO merch (name, price, description, category, available, stock, transferable) VALUES ($1, $2, $3, $4, $5, $6, $8)
+			RETURNING name, price
+		)
+		INSERT INTO merch_price_history (item_name, price, changed_at) SELECT name, price, $7 FROM created`,
+		item.Name, item.Price, item.Description, item.Category, item.Available, item.Stock, time.Now(), item.Transferable,
+	)
+	if err != nil {
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == "23505" { // unique_violation
+			return fmt.Errorf("%s: %w", op, domain.ErrMerchAlreadyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateMerch изменяет переданные поля товара, не снятого с продажи, и возвращает обновленный товар.
+// Новая цена записывается в историю цен тем же запросом. Остаток меняется только через RestockMerch.
+func (m *merch) UpdateMerch(ctx context.Context, name string, update domain.MerchUpdate) (*domain.Merch, error) {
+	const op = "MerchRepository.UpdateMerch"
+
+	row := m.db.QueryRow(ctx, `
+		WITH updated AS (
+			UPDATE merch SET
+				price = COALESCE($2, price),
+				description = COALESCE($3, description),
+				category = COALESCE($4, category),
+				available = COALESCE($5, available),
+				transferable = COALESCE($7, transferable)
+			WHERE name = $1 AND retired_at IS NULL
+			RETURNING `+merchColumns+`
+		), history AS (
+			INSERT INTO merch_price_history (item_name, price, changed_at)
+			SELECT name, price, $6 FROM updated WHERE $2::INT IS NOT NULL
+		)
+		SELECT `+merchColumns+` FROM updated`,
+		name, update.Price, update.Description, update.Category, update.Available, time.Now(), update.Transferable,
+	)
+
+	item, err := scanMerch(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return item, nil
+}
+
+// RetireMerch снимает товар с продажи. Запись остается, чтобы на нее ссылались покупки и инвентарь.
+func (m *merch) RetireMerch(ctx context.Context, name string, now time.Time) error {
+	const op = "MerchRepository.RetireMerch"
+
+	result, err := m.db.Exec(ctx,
+		"UPDATE merch SET retired_at = $2, available = FALSE WHERE name = $1 AND retired_at IS NULL",
+		name, now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+	}
+
+	return nil
+}
+
+// RestockMerch увеличивает остаток товара с ограниченным количеством и возвращает обновленный товар.
+// Остаток увеличивается относительно текущего значения, чтобы не потерять одновременные покупки.
+func (m *merch) RestockMerch(ctx context.Context, name string, quantity uint64) (*domain.Merch, error) {
+	const op = "MerchRepository.RestockMerch"
+
+	row := m.db.QueryRow(ctx,
+		"UPDATE merch SET stock = stock + $2 WHERE name = $1 AND retired_at IS NULL AND stock IS NOT NULL RETURNING "+merchColumns,
+		name, quantity,
+	)
+
+	item, err := scanMerch(row)
+	if err == nil {
+		return item, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Строка не обновлена: товара нет, он снят с продажи или его количество не ограничено
+	var unlimited bool
+	err = m.db.QueryRow(ctx,
+		"SELECT stock IS NULL FROM merch WHERE name = $1 AND retired_at IS NULL",
+		name,
+	).Scan(&unlimited)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		return nil, fmt.Errorf("%s: проверка товара: %w", op, err)
+	}
+	if unlimited {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrStockUnlimited)
+	}
+	return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+}
@@ -3,14 +3,18 @@ package postgres
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/netscrawler/avito-shop/internal/domain"
 	"github.com/pashagolub/pgxmock/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+var merchRowColumns = []string{"name", "price", "description", "category", "available", "retired_at", "stock", "transferable"}
+
 func TestGetMerchByName(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
@@ -21,20 +25,23 @@ func TestGetMerchByName(t *testing.T) {
 	merchName := "test-item"
 
 	t.Run("успешное получение товара", func(t *testing.T) {
-		mock.ExpectQuery("SELECT name, price FROM merch WHERE name = \\$1").
+		mock.ExpectQuery("SELECT name, price, description, category, available, retired_at, stock, transferable FROM merch WHERE name = \\$1").
 			WithArgs(merchName).
-			WillReturnRows(pgxmock.NewRows([]string{"name", "price"}).
-				AddRow(merchName, uint64(100)))
+			WillReturnRows(pgxmock.NewRows(merchRowColumns).
+				AddRow(merchName, uint64(100), "Описание", "clothing", true, nil, nil, true))
+		expectPriceSchedules(mock, []string{merchName}, pgxmock.AnyArg())
 
 		merch, err := repo.GetMerchByName(ctx, merchName)
 		assert.NoError(t, err)
 		assert.NotNil(t, merch)
 		assert.Equal(t, merchName, merch.Name)
 		assert.Equal(t, uint64(100), merch.Price)
+		assert.Equal(t, "clothing", merch.Category)
+		assert.True(t, merch.Available)
 	})
 
 	t.Run("товар не найден", func(t *testing.T) {
-		mock.ExpectQuery("SELECT name, price FROM merch WHERE name = \\$1").
+		mock.ExpectQuery("SELECT (.+) FROM merch WHERE name = \\$1").
 			WithArgs(merchName).
 			WillReturnError(pgx.ErrNoRows)
 
@@ -44,3 +51,164 @@ func TestGetMerchByName(t *testing.T) {
 		assert.ErrorIs(t, err, domain.ErrMerchNotFound)
 	})
 }
+
+func TestGetAllMerch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+
+	mock.ExpectQuery("SELECT name, price, description, category, available, retired_at, stock, transferable FROM merch WHERE retired_at IS NULL ORDER BY name").
+		WillReturnRows(pgxmock.NewRows(merchRowColumns).
+			AddRow("cup", uint64(20), "Кружка", "tableware", true, nil, nil, true).
+			AddRow("umbrella", uint64(200), "Зонт", "accessories", false, nil, nil, true))
+	expectPriceSchedules(mock, []string{"cup", "umbrella"}, pgxmock.AnyArg())
+
+	items, err := repo.GetAllMerch(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "tableware", items[0].Category)
+	assert.False(t, items[1].Available)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateMerch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	ctx := context.Background()
+	item := &domain.Merch{Name: "sticker", Price: 5, Description: "Наклейка", Category: "other", Available: true, Transferable: true}
+
+	t.Run("успешное создание товара", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO merch").
+			WithArgs("sticker", uint64(5), "Наклейка", "other", true, (*uint64)(nil), pgxmock.AnyArg(), true).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		require.NoError(t, repo.CreateMerch(ctx, item))
+	})
+
+	t.Run("товар уже существует", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO merch").
+			WithArgs("sticker", uint64(5), "Наклейка", "other", true, (*uint64)(nil), pgxmock.AnyArg(), true).
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		err := repo.CreateMerch(ctx, item)
+		assert.ErrorIs(t, err, domain.ErrMerchAlreadyExists)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateMerch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	ctx := context.Background()
+	price := uint64(600)
+
+	t.Run("успешное изменение цены", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE merch SET (.+) WHERE name = \\$1 AND retired_at IS NULL RETURNING").
+			WithArgs("hoody", &price, (*string)(nil), (*string)(nil), (*bool)(nil), pgxmock.AnyArg(), (*bool)(nil)).
+			WillReturnRows(pgxmock.NewRows(merchRowColumns).
+				AddRow("hoody", uint64(600), "Худи", "clothing", true, nil, nil, true))
+
+		item, err := repo.UpdateMerch(ctx, "hoody", domain.MerchUpdate{Price: &price})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(600), item.Price)
+	})
+
+	t.Run("товар не найден или снят с продажи", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE merch SET").
+			WithArgs("unknown", &price, (*string)(nil), (*string)(nil), (*bool)(nil), pgxmock.AnyArg(), (*bool)(nil)).
+			WillReturnError(pgx.ErrNoRows)
+
+		item, err := repo.UpdateMerch(ctx, "unknown", domain.MerchUpdate{Price: &price})
+		assert.ErrorIs(t, err, domain.ErrMerchNotFound)
+		assert.Nil(t, item)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetireMerch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("успешное снятие с продажи", func(t *testing.T) {
+		mock.ExpectExec("UPDATE merch SET retired_at = \\$2, available = FALSE WHERE name = \\$1 AND retired_at IS NULL").
+			WithArgs("pen", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		require.NoError(t, repo.RetireMerch(ctx, "pen", now))
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		mock.ExpectExec("UPDATE merch SET retired_at").
+			WithArgs("pen", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.RetireMerch(ctx, "pen", now)
+		assert.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestockMerch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	ctx := context.Background()
+
+	t.Run("успешное пополнение склада", func(t *testing.T) {
+		stock := uint64(12)
+		mock.ExpectQuery("UPDATE merch SET stock = stock \\+ \\$2 WHERE name = \\$1 AND retired_at IS NULL AND stock IS NOT NULL RETURNING").
+			WithArgs("cup", uint64(10)).
+			WillReturnRows(pgxmock.NewRows(merchRowColumns).
+				AddRow("cup", uint64(20), "Кружка", "tableware", true, nil, &stock, true))
+
+		item, err := repo.RestockMerch(ctx, "cup", 10)
+		require.NoError(t, err)
+		require.NotNil(t, item.Stock)
+		assert.Equal(t, uint64(12), *item.Stock)
+	})
+
+	t.Run("количество товара не ограничено", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE merch SET stock").
+			WithArgs("pen", uint64(10)).
+			WillReturnError(pgx.ErrNoRows)
+		mock.ExpectQuery("SELECT stock IS NULL FROM merch WHERE name = \\$1 AND retired_at IS NULL").
+			WithArgs("pen").
+			WillReturnRows(pgxmock.NewRows([]string{"unlimited"}).AddRow(true))
+
+		_, err := repo.RestockMerch(ctx, "pen", 10)
+		assert.ErrorIs(t, err, domain.ErrStockUnlimited)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE merch SET stock").
+			WithArgs("unknown", uint64(10)).
+			WillReturnError(pgx.ErrNoRows)
+		mock.ExpectQuery("SELECT stock IS NULL FROM merch").
+			WithArgs("unknown").
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.RestockMerch(ctx, "unknown", 10)
+		assert.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// mfa реализует интерфейс MFARepository для работы с аутентификаторами в PostgreSQL
+type mfa struct {
+	db DBPool
+}
+
+// NewMFARepository создает новый экземпляр репозитория аутентификаторов
+func NewMFARepository(db DBPool) repository.MFARepository {
+	return &mfa{db: db}
+}
+
+// GetMFAFactor возвращает аутентификатор пользователя
+func (m *mfa) GetMFAFactor(ctx context.Context, username string) (*domain.MFAFactor, error) {
+	const op = "MFARepository.GetMFAFactor"
+
+	factor := &domain.MFAFactor{Username: username}
+	err := m.db.QueryRow(ctx,
+		"SELECT secret, enabled, last_used_step, created_at, confirmed_at FROM mfa_factors WHERE username = $1",
+		username,
+	).Scan(&factor.Secret, &factor.Enabled, &factor.LastUsedStep, &factor.CreatedAt, &factor.ConfirmedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrMFANotEnrolled
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return factor, nil
+}
+
+// SaveMFASecret сохраняет секрет неподтвержденного аутентификатора.
+// Подтвержденный аутентификатор не перезаписывается.
+func (m *mfa) SaveMFASecret(ctx context.Context, username, secret string) error {
+	const op = "MFARepository.SaveMFASecret"
+
+	result, err := m.db.Exec(ctx, `
+		INSERT INTO mfa_factors (username, secret, enabled, created_at)
+		VALUES ($1, $2, FALSE, $3)
+		ON CONFLICT (username) DO UPDATE SET secret = $2, created_at = $3, last_used_step = NULL
+		WHERE mfa_factors.enabled = FALSE`,
+		username, secret, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableMFA подтверждает аутентификатор и заменяет коды восстановления
+func (m *mfa) EnableMFA(ctx context.Context, username string, recoveryCodeHashes []string) error {
+	const op = "MFARepository.EnableMFA"
+
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	result, err := tx.Exec(ctx,
+		"UPDATE mfa_factors SET enabled = TRUE, confirmed_at = $1 WHERE username = $2 AND enabled = FALSE",
+		time.Now(), username,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: подтверждение аутентификатора: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM mfa_recovery_codes WHERE username = $1", username); err != nil {
+		return fmt.Errorf("%s: удаление старых кодов восстановления: %w", op, err)
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err := tx.Exec(ctx,
+			"INSERT INTO mfa_recovery_codes (username, code_hash) VALUES ($1, $2)",
+			username, codeHash,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: сохранение кода восстановления: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// UseMFAStep атомарно отмечает временной шаг использованным.
+// Возвращает false, если код этого или более позднего шага уже был предъявлен.
+func (m *mfa) UseMFAStep(ctx context.Context, username string, step int64) (bool, error) {
+	const op = "MFARepository.UseMFAStep"
+
+	result, err := m.db.Exec(ctx, `
+		UPDATE mfa_factors SET last_used_step = $1
+		WHERE username = $2 AND (last_used_step IS NULL OR last_used_step < $1)`,
+		step, username,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode погашает код восстановления. Возвращает false, если код не найден или уже использован.
+func (m *mfa) UseRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	const op = "MFARepository.UseRecoveryCode"
+
+	result, err := m.db.Exec(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = $1
+		WHERE id = (
+			SELECT id FROM mfa_recovery_codes
+			WHERE username = $2 AND code_hash = $3 AND used_at IS NULL
+			LIMIT 1
+		)`,
+		time.Now(), username, codeHash,
+	)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
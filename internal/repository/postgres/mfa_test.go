@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMFAFactor(t *testing.T) {
+	t.Run("аутентификатор подключен", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewMFARepository(mock)
+		confirmedAt := time.Now()
+
+		mock.ExpectQuery("SELECT secret, enabled, last_used_step, created_at, confirmed_at FROM mfa_factors WHERE username = \\$1").
+			WithArgs("testuser").
+			WillReturnRows(pgxmock.NewRows([]string{"secret", "enabled", "last_used_step", "created_at", "confirmed_at"}).
+				AddRow("SECRET", true, nil, time.Now(), &confirmedAt))
+
+		factor, err := repo.GetMFAFactor(context.Background(), "testuser")
+
+		require.NoError(t, err)
+		require.True(t, factor.Enabled)
+		require.Equal(t, "SECRET", factor.Secret)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("аутентификатор не подключен", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewMFARepository(mock)
+
+		mock.ExpectQuery("SELECT secret, enabled, last_used_step, created_at, confirmed_at FROM mfa_factors").
+			WithArgs("testuser").
+			WillReturnRows(pgxmock.NewRows([]string{"secret", "enabled", "last_used_step", "created_at", "confirmed_at"}))
+
+		_, err = repo.GetMFAFactor(context.Background(), "testuser")
+
+		require.ErrorIs(t, err, domain.ErrMFANotEnrolled)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSaveMFASecret_AlreadyEnabled(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMFARepository(mock)
+
+	mock.ExpectExec("INSERT INTO mfa_factors").
+		WithArgs("testuser", "SECRET", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	err = repo.SaveMFASecret(context.Background(), "testuser", "SECRET")
+
+	require.ErrorIs(t, err, domain.ErrMFAAlreadyEnabled)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnableMFA(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMFARepository(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE mfa_factors SET enabled = TRUE").
+		WithArgs(pgxmock.AnyArg(), "testuser").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec("DELETE FROM mfa_recovery_codes WHERE username = \\$1").
+		WithArgs("testuser").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec("INSERT INTO mfa_recovery_codes").
+		WithArgs("testuser", "hash-1").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec("INSERT INTO mfa_recovery_codes").
+		WithArgs("testuser", "hash-2").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = repo.EnableMFA(context.Background(), "testuser", []string{"hash-1", "hash-2"})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseMFAStep(t *testing.T) {
+	t.Run("новый шаг", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewMFARepository(mock)
+
+		mock.ExpectExec("UPDATE mfa_factors SET last_used_step = \\$1").
+			WithArgs(int64(100), "testuser").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		ok, err := repo.UseMFAStep(context.Background(), "testuser", 100)
+
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("повторное использование шага", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewMFARepository(mock)
+
+		mock.ExpectExec("UPDATE mfa_factors SET last_used_step = \\$1").
+			WithArgs(int64(100), "testuser").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		ok, err := repo.UseMFAStep(context.Background(), "testuser", 100)
+
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
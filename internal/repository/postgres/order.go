@@ -0,0 +1,349 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+const orderColumns = "id, username, COALESCE(recipient, ''), total, discount, status, created_at, updated_at"
+
+// order реализует интерфейс OrderRepository для работы с заказами в PostgreSQL
+type order struct {
+	db DBPool
+}
+
+// NewOrderRepository создает новый экземпляр репозитория заказов
+func NewOrderRepository(db DBPool) repository.OrderRepository {
+	return &order{db: db}
+}
+
+// GetOrders возвращает заказы с позициями, начиная с последних
+func (o *order) GetOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	const op = "OrderRepository.GetOrders"
+
+	query, args := buildOrdersQuery(filter)
+	rows, err := o.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var orders []*domain.Order
+	byId := make(map[int64]*domain.Order)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		orders = append(orders, order)
+		byId[order.Id] = order
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]int64, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.Id)
+	}
+	if err := o.loadOrderItems(ctx, ids, byId); err != nil {
+		return nil, fmt.Errorf("%s: получение позиций заказов: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+// GetOrder возвращает заказ с позициями и журналом изменений статуса
+func (o *order) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	const op = "OrderRepository.GetOrder"
+
+	order, err := scanOrder(o.db.QueryRow(ctx,
+		"SELECT "+orderColumns+" FROM orders WHERE id = $1",
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrOrderNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := o.loadOrderItems(ctx, []int64{id}, map[int64]*domain.Order{id: order}); err != nil {
+		return nil, fmt.Errorf("%s: получение позиций заказа: %w", op, err)
+	}
+
+	rows, err := o.db.Query(ctx, `
+		SELECT COALESCE(from_status, ''), to_status, changed_by, comment, changed_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY changed_at, id`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: получение журнала статусов: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change := &domain.OrderStatusChange{OrderId: id}
+		if err := rows.Scan(&change.From, &change.To, &change.ChangedBy, &change.Comment, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		order.History = append(order.History, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	return order, nil
+}
+
+// UpdateOrderStatus переводит заказ в новый статус и записывает изменение в журнал.
+// Текущий статус проверяется под блокировкой строки заказа, поэтому параллельные изменения
+// не могут нарушить порядок переходов. При отмене в той же транзакции выполняется возврат.
+func (o *order) UpdateOrderStatus(ctx context.Context, change *domain.OrderStatusChange) error {
+	const op = "OrderRepository.UpdateOrderStatus"
+
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%v, rollback error: %v", err, rollbackErr)
+			}
+		}
+	}()
+
+	var current domain.OrderStatus
+	order := &domain.Order{Id: change.OrderId}
+	err = tx.QueryRow(ctx,
+		"SELECT status, username, COALESCE(recipient, '') FROM orders WHERE id = $1 FOR UPDATE",
+		change.OrderId,
+	).Scan(&current, &order.Username, &order.Recipient)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, domain.ErrOrderNotFound)
+		}
+		return fmt.Errorf("%s: получение заказа: %w", op, err)
+	}
+
+	if !current.CanTransitionTo(change.To) {
+		return fmt.Errorf("%s: %w: из %s в %s", op, domain.ErrOrderTransition, current, change.To)
+	}
+	change.From = current
+
+	if change.To == domain.OrderStatusCancelled {
+		if err := refundOrder(ctx, tx, order, change.ChangedAt); err != nil {
+			if errors.Is(err, domain.ErrInventoryShortage) {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			return fmt.Errorf("%s: возврат по заказу: %w", op, err)
+		}
+	}
+
+	if err := setOrderStatus(ctx, tx, change); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// loadOrderItems заполняет позиции заказов с указанными идентификаторами
+func (o *order) loadOrderItems(ctx context.Context, ids []int64, byId map[int64]*domain.Order) error {
+	rows, err := o.db.Query(ctx,
+		"SELECT order_id, item_name, quantity, unit_price FROM order_items WHERE order_id = ANY($1) ORDER BY order_id, item_name",
+		ids,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderId int64
+		item := &domain.OrderItem{}
+		if err := rows.Scan(&orderId, &item.ItemName, &item.Quantity, &item.UnitPrice); err != nil {
+			return fmt.Errorf("сканирование строки: %w", err)
+		}
+		if order, ok := byId[orderId]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+
+	return rows.Err()
+}
+
+// buildOrdersQuery формирует запрос к заказам с условиями фильтра
+func buildOrdersQuery(filter domain.OrderFilter) (string, []interface{}) {
+	var b strings.Builder
+	b.WriteString("SELECT " + orderColumns + " FROM orders WHERE TRUE")
+	var args []interface{}
+
+	if filter.Username != "" {
+		args = append(args, filter.Username)
+		fmt.Fprintf(&b, " AND username = $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		fmt.Fprintf(&b, " AND status = $%d", len(args))
+	}
+
+	b.WriteString(" ORDER BY created_at DESC, id DESC")
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		fmt.Fprintf(&b, " LIMIT $%d", len(args))
+	}
+
+	return b.String(), args
+}
+
+func scanOrder(row pgx.Row) (*domain.Order, error) {
+	order := &domain.Order{}
+	if err := row.Scan(&order.Id, &order.Username, &order.Recipient, &order.Total, &order.Discount, &order.Status, &order.CreatedAt, &order.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// insertOrder создает заказ с позициями и первой записью журнала статусов
+func insertOrder(ctx context.Context, tx pgx.Tx, order *domain.Order) error {
+	err := tx.QueryRow(ctx,
+		"INSERT INTO orders (username, recipient, total, discount, status, created_at, updated_at) VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7) RETURNING id",
+		order.Username, order.Recipient, order.Total, order.Discount, string(order.Status), order.CreatedAt, order.UpdatedAt,
+	).Scan(&order.Id)
+	if err != nil {
+		return fmt.Errorf("создание заказа: %w", err)
+	}
+
+	for _, item := range order.Items {
+		_, err := tx.Exec(ctx,
+			"INSERT INTO order_items (order_id, item_name, quantity, unit_price) VALUES ($1, $2, $3, $4)",
+			order.Id, item.ItemName, item.Quantity, item.UnitPrice,
+		)
+		if err != nil {
+			return fmt.Errorf("создание позиции заказа: %w", err)
+		}
+	}
+
+	return insertOrderStatusChange(ctx, tx, &domain.OrderStatusChange{
+		OrderId:   order.Id,
+		To:        order.Status,
+		ChangedBy: order.Username,
+		ChangedAt: order.CreatedAt,
+	})
+}
+
+// setOrderStatus сохраняет новый статус заказа и запись журнала
+func setOrderStatus(ctx context.Context, tx pgx.Tx, change *domain.OrderStatusChange) error {
+	_, err := tx.Exec(ctx,
+		"UPDATE orders SET status = $2, updated_at = $3 WHERE id = $1",
+		change.OrderId, string(change.To), change.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("обновление статуса заказа: %w", err)
+	}
+
+	return insertOrderStatusChange(ctx, tx, change)
+}
+
+func insertOrderStatusChange(ctx context.Context, tx pgx.Tx, change *domain.OrderStatusChange) error {
+	var from *string
+	if change.From != "" {
+		s := string(change.From)
+		from = &s
+	}
+
+	_, err := tx.Exec(ctx,
+		"INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, comment, changed_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		change.OrderId, from, string(change.To), change.ChangedBy, change.Comment, change.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("запись журнала статусов: %w", err)
+	}
+	return nil
+}
+
+// refundOrder возвращает покупателю оплаченную сумму, забирает товар из инвентаря получателя и возвращает его на склад.
+// Суммы берутся из транзакций покупки или подарка, поэтому возвращается ровно то, что было списано.
+// Использование промокода в заказе отменяется, как и при покупке, последним.
+func refundOrder(ctx context.Context, tx pgx.Tx, order *domain.Order, now time.Time) error {
+	orderId, username := order.Id, order.Username
+	rows, err := tx.Query(ctx,
+		"SELECT item_name, quantity, amount FROM transactions WHERE order_id = $1 AND transfer_type IN ($2, $3) ORDER BY item_name",
+		orderId, domain.TransactionTypePurchase, domain.TransactionTypeGift,
+	)
+	if err != nil {
+		return err
+	}
+
+	var refunds []*domain.Transaction
+	var total uint64
+	for rows.Next() {
+		refund := domain.NewTransaction(domain.ShopAccount, username, 0, domain.TransactionTypeRefund, now)
+		if err := rows.Scan(&refund.ItemName, &refund.Quantity, &refund.Amount); err != nil {
+			rows.Close()
+			return fmt.Errorf("сканирование строки: %w", err)
+		}
+		refunds = append(refunds, refund)
+		total += refund.Amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("итерация по результатам: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE users SET coins = coins + $1 WHERE username = $2",
+		total, username,
+	)
+	if err != nil {
+		return fmt.Errorf("обновление баланса: %w", err)
+	}
+
+	// Строки товаров блокируются в порядке названий, как и при покупке
+	for _, refund := range refunds {
+		_, err := tx.Exec(ctx,
+			"UPDATE merch SET stock = stock + $2 WHERE name = $1 AND stock IS NOT NULL",
+			refund.ItemName, refund.Quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("возврат на склад: %w", err)
+		}
+	}
+
+	for _, refund := range refunds {
+		if err := takeFromInventory(ctx, tx, order.Holder(), refund.ItemName, refund.Quantity); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx,
+			"INSERT INTO transactions (sender_name, receiver_name, amount, transfer_type, item_name, quantity, order_id, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+			refund.SenderName, refund.ReceiverName, refund.Amount, refund.Type, refund.ItemName, refund.Quantity, orderId, refund.Timestamp,
+		)
+		if err != nil {
+			return fmt.Errorf("создание записи о возврате: %w", err)
+		}
+	}
+
+	return releasePromoRedemption(ctx, tx, orderId)
+}
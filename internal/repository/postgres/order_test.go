@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var orderTestColumns = []string{"id", "username", "recipient", "total", "discount", "status", "created_at", "updated_at"}
+
+// expectInsertOrder ожидает создание заказа с позициями и первой записью журнала статусов
+func expectInsertOrder(mock pgxmock.PgxPoolIface, username string, total, discount uint64, createdAt interface{}, id int64, items ...*domain.OrderItem) {
+	expectInsertGiftOrder(mock, username, "", total, discount, createdAt, id, items...)
+}
+
+// expectInsertGiftOrder ожидает создание заказа, товар которого выдается получателю recipient
+func expectInsertGiftOrder(mock pgxmock.PgxPoolIface, username, recipient string, total, discount uint64, createdAt interface{}, id int64, items ...*domain.OrderItem) {
+	mock.ExpectQuery("INSERT INTO orders").
+		WithArgs(username, recipient, total, discount, string(domain.OrderStatusPlaced), createdAt, createdAt).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(id))
+	for _, item := range items {
+		mock.ExpectExec("INSERT INTO order_items").
+			WithArgs(id, item.ItemName, item.Quantity, item.UnitPrice).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+	mock.ExpectExec("INSERT INTO order_status_history").
+		WithArgs(id, (*string)(nil), string(domain.OrderStatusPlaced), username, "", createdAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
+func TestGetOrders(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOrderRepository(mock)
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("заказы пользователя с позициями", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, username, COALESCE\\(recipient, ''\\), total, discount, status, created_at, updated_at FROM orders WHERE TRUE AND username = \\$1 AND status = \\$2 ORDER BY created_at DESC, id DESC LIMIT \\$3").
+			WithArgs("buyer", "placed", 50).
+			WillReturnRows(pgxmock.NewRows(orderTestColumns).
+				AddRow(int64(8), "buyer", "", uint64(20), uint64(0), domain.OrderStatusPlaced, createdAt, createdAt).
+				AddRow(int64(7), "buyer", "", uint64(50), uint64(0), domain.OrderStatusPlaced, createdAt, createdAt))
+		mock.ExpectQuery("SELECT order_id, item_name, quantity, unit_price FROM order_items WHERE order_id = ANY\\(\\$1\\)").
+			WithArgs([]int64{8, 7}).
+			WillReturnRows(pgxmock.NewRows([]string{"order_id", "item_name", "quantity", "unit_price"}).
+				AddRow(int64(7), "cup", uint64(2), uint64(20)).
+				AddRow(int64(7), "pen", uint64(1), uint64(10)).
+				AddRow(int64(8), "cup", uint64(1), uint64(20)))
+
+		orders, err := repo.GetOrders(context.Background(), domain.OrderFilter{Username: "buyer", Status: domain.OrderStatusPlaced, Limit: 50})
+		require.NoError(t, err)
+		require.Len(t, orders, 2)
+		assert.Equal(t, int64(8), orders[0].Id)
+		assert.Equal(t, domain.OrderStatusPlaced, orders[0].Status)
+		assert.Len(t, orders[0].Items, 1)
+		assert.Len(t, orders[1].Items, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("заказов нет", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, username, COALESCE\\(recipient, ''\\), total, discount, status, created_at, updated_at FROM orders WHERE TRUE ORDER BY created_at DESC, id DESC").
+			WillReturnRows(pgxmock.NewRows(orderTestColumns))
+
+		orders, err := repo.GetOrders(context.Background(), domain.OrderFilter{})
+		require.NoError(t, err)
+		assert.Empty(t, orders)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetOrder(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOrderRepository(mock)
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("заказ с журналом статусов", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, username, COALESCE\\(recipient, ''\\), total, discount, status, created_at, updated_at FROM orders WHERE id = \\$1").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows(orderTestColumns).
+				AddRow(int64(7), "buyer", "", uint64(40), uint64(0), domain.OrderStatusReadyForPickup, createdAt, createdAt.Add(time.Hour)))
+		mock.ExpectQuery("FROM order_items").
+			WithArgs([]int64{7}).
+			WillReturnRows(pgxmock.NewRows([]string{"order_id", "item_name", "quantity", "unit_price"}).
+				AddRow(int64(7), "cup", uint64(2), uint64(20)))
+		mock.ExpectQuery("FROM order_status_history").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"from_status", "to_status", "changed_by", "comment", "changed_at"}).
+				AddRow(domain.OrderStatus(""), domain.OrderStatusPlaced, "buyer", "", createdAt).
+				AddRow(domain.OrderStatusPlaced, domain.OrderStatusReadyForPickup, "manager", "стойка 3", createdAt.Add(time.Hour)))
+
+		order, err := repo.GetOrder(context.Background(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, domain.OrderStatusReadyForPickup, order.Status)
+		require.Len(t, order.History, 2)
+		assert.Equal(t, &domain.OrderStatusChange{
+			OrderId:   7,
+			From:      domain.OrderStatusPlaced,
+			To:        domain.OrderStatusReadyForPickup,
+			ChangedBy: "manager",
+			Comment:   "стойка 3",
+			ChangedAt: createdAt.Add(time.Hour),
+		}, order.History[1])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("заказ не найден", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, username, COALESCE\\(recipient, ''\\), total, discount, status, created_at, updated_at FROM orders WHERE id = \\$1").
+			WithArgs(int64(404)).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetOrder(context.Background(), 404)
+		assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOrderRepository(mock)
+	now := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+
+	t.Run("заказ готов к выдаче", func(t *testing.T) {
+		change := &domain.OrderStatusChange{OrderId: 7, To: domain.OrderStatusReadyForPickup, ChangedBy: "manager", Comment: "стойка 3", ChangedAt: now}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT status, username, COALESCE\\(recipient, ''\\) FROM orders WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"status", "username", "recipient"}).AddRow(domain.OrderStatusPlaced, "buyer", ""))
+		mock.ExpectExec("UPDATE orders SET status = \\$2, updated_at = \\$3 WHERE id = \\$1").
+			WithArgs(int64(7), "ready_for_pickup", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		placed := "placed"
+		mock.ExpectExec("INSERT INTO order_status_history").
+			WithArgs(int64(7), &placed, "ready_for_pickup", "manager", "стойка 3", now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.UpdateOrderStatus(context.Background(), change))
+		assert.Equal(t, domain.OrderStatusPlaced, change.From)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("отмена с возвратом", func(t *testing.T) {
+		change := &domain.OrderStatusChange{OrderId: 7, To: domain.OrderStatusCancelled, ChangedBy: "buyer", ChangedAt: now}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT status, username, COALESCE\\(recipient, ''\\) FROM orders WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"status", "username", "recipient"}).AddRow(domain.OrderStatusReadyForPickup, "buyer", ""))
+		mock.ExpectQuery("SELECT item_name, quantity, amount FROM transactions WHERE order_id = \\$1 AND transfer_type IN \\(\\$2, \\$3\\)").
+			WithArgs(int64(7), domain.TransactionTypePurchase, domain.TransactionTypeGift).
+			WillReturnRows(pgxmock.NewRows([]string{"item_name", "quantity", "amount"}).
+				AddRow("cup", uint64(2), uint64(40)).
+				AddRow("pen", uint64(1), uint64(10)))
+		mock.ExpectExec("UPDATE users SET coins = coins \\+ \\$1 WHERE username = \\$2").
+			WithArgs(uint64(50), "buyer").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock \\+ \\$2 WHERE name = \\$1 AND stock IS NOT NULL").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock \\+ \\$2 WHERE name = \\$1 AND stock IS NOT NULL").
+			WithArgs("pen", uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - \\$3").
+			WithArgs("buyer", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("SHOP", "buyer", uint64(40), domain.TransactionTypeRefund, "cup", uint64(2), int64(7), now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - \\$3").
+			WithArgs("buyer", "pen", uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("SHOP", "buyer", uint64(10), domain.TransactionTypeRefund, "pen", uint64(1), int64(7), now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("DELETE FROM promo_redemptions WHERE order_id = \\$1 RETURNING code(.|\\n)*UPDATE promo_codes SET redemptions = redemptions -").
+			WithArgs(int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE orders SET status = \\$2, updated_at = \\$3 WHERE id = \\$1").
+			WithArgs(int64(7), "cancelled", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		ready := "ready_for_pickup"
+		mock.ExpectExec("INSERT INTO order_status_history").
+			WithArgs(int64(7), &ready, "cancelled", "buyer", "", now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.UpdateOrderStatus(context.Background(), change))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("отмена подарка забирает товар у получателя", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT status, username, COALESCE\\(recipient, ''\\) FROM orders WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(9)).
+			WillReturnRows(pgxmock.NewRows([]string{"status", "username", "recipient"}).AddRow(domain.OrderStatusPlaced, "alice", "bob"))
+		mock.ExpectQuery("FROM transactions WHERE order_id").
+			WithArgs(int64(9), domain.TransactionTypePurchase, domain.TransactionTypeGift).
+			WillReturnRows(pgxmock.NewRows([]string{"item_name", "quantity", "amount"}).AddRow("cup", uint64(2), uint64(40)))
+		mock.ExpectExec("UPDATE users SET coins = coins \\+ \\$1 WHERE username = \\$2").
+			WithArgs(uint64(40), "alice").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectExec("UPDATE user_inventory SET quantity").
+			WithArgs("bob", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("SHOP", "alice", uint64(40), domain.TransactionTypeRefund, "cup", uint64(2), int64(9), now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("DELETE FROM promo_redemptions").
+			WithArgs(int64(9)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectExec("UPDATE orders SET status").
+			WithArgs(int64(9), "cancelled", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO order_status_history").
+			WithArgs(int64(9), pgxmock.AnyArg(), "cancelled", "alice", "", now).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdateOrderStatus(context.Background(), &domain.OrderStatusChange{OrderId: 9, To: domain.OrderStatusCancelled, ChangedBy: "alice", ChangedAt: now})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("товар уже передан", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT status, username, COALESCE\\(recipient, ''\\) FROM orders WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"status", "username", "recipient"}).AddRow(domain.OrderStatusPlaced, "buyer", ""))
+		mock.ExpectQuery("FROM transactions WHERE order_id").
+			WithArgs(int64(7), domain.TransactionTypePurchase, domain.TransactionTypeGift).
+			WillReturnRows(pgxmock.NewRows([]string{"item_name", "quantity", "amount"}).AddRow("cup", uint64(2), uint64(40)))
+		mock.ExpectExec("UPDATE users SET coins").
+			WithArgs(uint64(40), "buyer").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectExec("UPDATE user_inventory SET quantity").
+			WithArgs("buyer", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectRollback()
+
+		err := repo.UpdateOrderStatus(context.Background(), &domain.OrderStatusChange{OrderId: 7, To: domain.OrderStatusCancelled, ChangedBy: "buyer", ChangedAt: now})
+		assert.ErrorIs(t, err, domain.ErrInventoryShortage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("выданный заказ не меняется", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT status, username, COALESCE\\(recipient, ''\\) FROM orders WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(7)).
+			WillReturnRows(pgxmock.NewRows([]string{"status", "username", "recipient"}).AddRow(domain.OrderStatusDelivered, "buyer", ""))
+		mock.ExpectRollback()
+
+		err := repo.UpdateOrderStatus(context.Background(), &domain.OrderStatusChange{OrderId: 7, To: domain.OrderStatusCancelled, ChangedBy: "manager", ChangedAt: now})
+		assert.ErrorIs(t, err, domain.ErrOrderTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("заказ не найден", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT status, username, COALESCE\\(recipient, ''\\) FROM orders WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int64(404)).
+			WillReturnError(pgx.ErrNoRows)
+		mock.ExpectRollback()
+
+		err := repo.UpdateOrderStatus(context.Background(), &domain.OrderStatusChange{OrderId: 404, To: domain.OrderStatusDelivered, ChangedBy: "manager", ChangedAt: now})
+		assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
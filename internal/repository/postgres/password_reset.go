@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// passwordReset реализует интерфейс PasswordResetRepository для работы с токенами сброса пароля в PostgreSQL
+type passwordReset struct {
+	db DBPool
+}
+
+// NewPasswordResetRepository создает новый экземпляр репозитория токенов сброса пароля
+func NewPasswordResetRepository(db DBPool) repository.PasswordResetRepository {
+	return &passwordReset{db: db}
+}
+
+// CreatePasswordResetToken сохраняет новый токен сброса пароля
+func (p *passwordReset) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	const op = "PasswordResetRepository.CreatePasswordResetToken"
+
+	_, err := p.db.Exec(ctx, `
+		INSERT INTO password_reset_tokens (token_hash, username, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)`,
+		token.TokenHash, token.Username, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResetPassword в одной транзакции погашает токен сброса и устанавливает новый хэш пароля.
+// Возвращает имя пользователя, которому принадлежал токен.
+func (p *passwordReset) ResetPassword(ctx context.Context, tokenHash string, password []byte, now time.Time) (string, error) {
+	const op = "PasswordResetRepository.ResetPassword"
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Погашаем токен: повторное или просроченное использование не найдет строку
+	var username string
+	err = tx.QueryRow(ctx, `
+		UPDATE password_reset_tokens SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING username`,
+		tokenHash, now,
+	).Scan(&username)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", domain.ErrInvalidToken
+		}
+		return "", fmt.Errorf("%s: погашение токена: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE users SET password = $1 WHERE username = $2",
+		password, username,
+	)
+	if err != nil {
+		return "", fmt.Errorf("%s: обновление пароля: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return username, nil
+}
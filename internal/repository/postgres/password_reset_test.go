@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResetPassword(t *testing.T) {
+	t.Run("успешный сброс пароля", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewPasswordResetRepository(mock)
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("UPDATE password_reset_tokens SET used_at = \\$2").
+			WithArgs("token-hash", now).
+			WillReturnRows(pgxmock.NewRows([]string{"username"}).AddRow("testuser"))
+		mock.ExpectExec("UPDATE users SET password = \\$1 WHERE username = \\$2").
+			WithArgs([]byte("new-hash"), "testuser").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectCommit()
+
+		username, err := repo.ResetPassword(context.Background(), "token-hash", []byte("new-hash"), now)
+
+		require.NoError(t, err)
+		require.Equal(t, "testuser", username)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("токен использован или истек", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewPasswordResetRepository(mock)
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("UPDATE password_reset_tokens SET used_at = \\$2").
+			WithArgs("token-hash", now).
+			WillReturnRows(pgxmock.NewRows([]string{"username"}))
+		mock.ExpectRollback()
+
+		_, err = repo.ResetPassword(context.Background(), "token-hash", []byte("new-hash"), now)
+
+		require.ErrorIs(t, err, domain.ErrInvalidToken)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreatePasswordResetToken(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPasswordResetRepository(mock)
+	token := domain.NewPasswordResetToken("token-hash", "testuser", time.Now().Add(time.Hour))
+
+	mock.ExpectExec("INSERT INTO password_reset_tokens").
+		WithArgs("token-hash", "testuser", token.ExpiresAt, token.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	err = repo.CreatePasswordResetToken(context.Background(), token)
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+)
+
+// querier выполняет запросы как через пул соединений, так и внутри транзакции
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// priceScheduleColumns перечисляет колонки расписания цены в порядке, который ожидает scanPriceSchedule
+const priceScheduleColumns = "id, item_name, price, effective_from, effective_to, priority, created_by, created_at, cancelled_at"
+
+// scanPriceSchedule считывает расписание цены из строки результата
+func scanPriceSchedule(row pgx.Row) (*domain.PriceSchedule, error) {
+	s := &domain.PriceSchedule{}
+	if err := row.Scan(&s.Id, &s.ItemName, &s.Price, &s.EffectiveFrom, &s.EffectiveTo, &s.Priority, &s.CreatedBy, &s.CreatedAt, &s.CancelledAt); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadPriceSchedules возвращает неотмененные расписания, которые еще не закончились к моменту now,
+// сгруппированные по названию товара
+func loadPriceSchedules(ctx context.Context, db querier, names []string, now time.Time) (map[string][]*domain.PriceSchedule, error) {
+	schedules := make(map[string][]*domain.PriceSchedule)
+	if len(names) == 0 {
+		return schedules, nil
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT `+priceScheduleColumns+` FROM merch_price_schedules
+		WHERE item_name = ANY($1) AND cancelled_at IS NULL AND (effective_to IS NULL OR effective_to > $2)
+		ORDER BY item_name, effective_from, id`,
+		names, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanPriceSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("сканирование строки: %w", err)
+		}
+		schedules[s.ItemName] = append(schedules[s.ItemName], s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("итерация по результатам: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// attachPriceSchedules загружает расписания цен для переданных товаров
+func attachPriceSchedules(ctx context.Context, db querier, items ...*domain.Merch) error {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+
+	schedules, err := loadPriceSchedules(ctx, db, names, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.PriceSchedules = schedules[item.Name]
+	}
+	return nil
+}
+
+// CreatePriceSchedule сохраняет расписание цены и заполняет его идентификатор.
+// Строка товара блокируется, чтобы расписание не появилось между проверкой цены и оплатой покупки.
+func (m *merch) CreatePriceSchedule(ctx context.Context, schedule *domain.PriceSchedule) error {
+	const op = "MerchRepository.CreatePriceSchedule"
+
+	err := m.db.QueryRow(ctx, `
+		WITH locked AS (
+			SELECT name FROM merch WHERE name = $1 FOR NO KEY UPDATE
+		)
+		INSERT INTO merch_price_schedules (item_name, price, effective_from, effective_to, priority, created_by, created_at)
+		SELECT name, $2, $3, $4, $5, $6, $7 FROM locked
+		RETURNING id`,
+		schedule.ItemName, schedule.Price, schedule.EffectiveFrom, schedule.EffectiveTo, schedule.Priority, schedule.CreatedBy, schedule.CreatedAt,
+	).Scan(&schedule.Id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CancelPriceSchedule отменяет расписание цены товара, которое еще не закончилось.
+// Запись остается, чтобы по ней можно было восстановить цену прошлых покупок.
+// Строка товара блокируется так же, как при создании расписания.
+func (m *merch) CancelPriceSchedule(ctx context.Context, name string, id int64, now time.Time) error {
+	const op = "MerchRepository.CancelPriceSchedule"
+
+	result, err := m.db.Exec(ctx, `
+		WITH locked AS (
+			SELECT name FROM merch WHERE name = $2 FOR NO KEY UPDATE
+		)
+		UPDATE merch_price_schedules SET cancelled_at = $3
+		WHERE id = $1 AND item_name IN (SELECT name FROM locked) AND cancelled_at IS NULL AND (effective_to IS NULL OR effective_to > $3)`,
+		id, name, now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrPriceScheduleNotFound)
+	}
+
+	return nil
+}
+
+// GetPriceHistory возвращает историю базовых цен товара и все его расписания, включая отмененные
+func (m *merch) GetPriceHistory(ctx context.Context, name string) (*domain.PriceHistory, error) {
+	const op = "MerchRepository.GetPriceHistory"
+
+	rows, err := m.db.Query(ctx,
+		"SELECT price, changed_at FROM merch_price_history WHERE item_name = $1 ORDER BY changed_at, id",
+		name,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	history := &domain.PriceHistory{ItemName: name}
+	for rows.Next() {
+		change := &domain.PriceChange{}
+		if err := rows.Scan(&change.Price, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		history.Changes = append(history.Changes, change)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+	// История пишется при создании товара, поэтому ее отсутствие означает, что товара нет
+	if len(history.Changes) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+	}
+
+	rows, err = m.db.Query(ctx,
+		"SELECT "+priceScheduleColumns+" FROM merch_price_schedules WHERE item_name = $1 ORDER BY effective_from, id",
+		name,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: расписания: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanPriceSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		history.Schedules = append(history.Schedules, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	return history, nil
+}
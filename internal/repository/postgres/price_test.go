@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var priceScheduleTestColumns = []string{"id", "item_name", "price", "effective_from", "effective_to", "priority", "created_by", "created_at", "cancelled_at"}
+
+// priceScheduleRow возвращает строку расписания цены в порядке priceScheduleTestColumns
+func priceScheduleRow(id int64, item string, price uint64, from time.Time, to *time.Time, priority int) []interface{} {
+	return []interface{}{id, item, price, from, to, priority, "admin", from, (*time.Time)(nil)}
+}
+
+// expectPriceSchedules ожидает загрузку действующих расписаний цен для товаров
+func expectPriceSchedules(mock pgxmock.PgxPoolIface, names []string, at interface{}, rows ...[]interface{}) {
+	result := pgxmock.NewRows(priceScheduleTestColumns)
+	for _, row := range rows {
+		result.AddRow(row...)
+	}
+	mock.ExpectQuery("FROM merch_price_schedules WHERE item_name = ANY\\(\\$1\\)").
+		WithArgs(names, at).
+		WillReturnRows(result)
+}
+
+func TestGetMerchByName_PriceSchedules(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	mock.ExpectQuery("SELECT (.+) FROM merch WHERE name = \\$1").
+		WithArgs("cup").
+		WillReturnRows(pgxmock.NewRows(merchRowColumns).
+			AddRow("cup", uint64(20), "Кружка", "accessories", true, nil, nil, true))
+	expectPriceSchedules(mock, []string{"cup"}, pgxmock.AnyArg(),
+		priceScheduleRow(3, "cup", 15, from, &to, 1))
+
+	item, err := repo.GetMerchByName(context.Background(), "cup")
+	require.NoError(t, err)
+	require.Len(t, item.PriceSchedules, 1)
+	assert.Equal(t, uint64(15), item.PriceSchedules[0].Price)
+	assert.Equal(t, uint64(15), item.PriceAt(from.Add(time.Hour)))
+	assert.Equal(t, uint64(20), item.PriceAt(to))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreatePriceSchedule(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	to := now.Add(24 * time.Hour)
+	schedule := &domain.PriceSchedule{ItemName: "cup", Price: 15, EffectiveFrom: now, EffectiveTo: &to, Priority: 1, CreatedBy: "admin", CreatedAt: now}
+	args := []interface{}{"cup", uint64(15), now, &to, 1, "admin", now}
+
+	t.Run("расписание создано", func(t *testing.T) {
+		mock.ExpectQuery("SELECT name FROM merch WHERE name = \\$1 FOR NO KEY UPDATE(.+)INSERT INTO merch_price_schedules").
+			WithArgs(args...).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(3)))
+
+		require.NoError(t, repo.CreatePriceSchedule(ctx, schedule))
+		assert.Equal(t, int64(3), schedule.Id)
+	})
+
+	t.Run("товар не существует", func(t *testing.T) {
+		mock.ExpectQuery("INSERT INTO merch_price_schedules").
+			WithArgs(args...).
+			WillReturnError(pgx.ErrNoRows)
+
+		err := repo.CreatePriceSchedule(ctx, schedule)
+		assert.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelPriceSchedule(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("расписание отменено", func(t *testing.T) {
+		mock.ExpectExec("UPDATE merch_price_schedules SET cancelled_at = \\$3").
+			WithArgs(int64(3), "cup", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		require.NoError(t, repo.CancelPriceSchedule(ctx, "cup", 3, now))
+	})
+
+	t.Run("расписание уже закончилось", func(t *testing.T) {
+		mock.ExpectExec("UPDATE merch_price_schedules SET cancelled_at = \\$3").
+			WithArgs(int64(4), "cup", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err := repo.CancelPriceSchedule(ctx, "cup", 4, now)
+		assert.ErrorIs(t, err, domain.ErrPriceScheduleNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPriceHistory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewMerchRepository(mock)
+	ctx := context.Background()
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	raised := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	saleFrom := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+	saleTo := saleFrom.Add(48 * time.Hour)
+
+	t.Run("история цен", func(t *testing.T) {
+		mock.ExpectQuery("SELECT price, changed_at FROM merch_price_history WHERE item_name = \\$1").
+			WithArgs("cup").
+			WillReturnRows(pgxmock.NewRows([]string{"price", "changed_at"}).
+				AddRow(uint64(20), created).
+				AddRow(uint64(25), raised))
+		mock.ExpectQuery("FROM merch_price_schedules WHERE item_name = \\$1").
+			WithArgs("cup").
+			WillReturnRows(pgxmock.NewRows(priceScheduleTestColumns).
+				AddRow(priceScheduleRow(3, "cup", 15, saleFrom, &saleTo, 0)...))
+
+		history, err := repo.GetPriceHistory(ctx, "cup")
+		require.NoError(t, err)
+		require.Len(t, history.Changes, 2)
+		require.Len(t, history.Schedules, 1)
+
+		price, ok := history.PriceAt(saleFrom.Add(time.Hour))
+		assert.True(t, ok)
+		assert.Equal(t, uint64(15), price)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		mock.ExpectQuery("SELECT price, changed_at FROM merch_price_history").
+			WithArgs("unknown").
+			WillReturnRows(pgxmock.NewRows([]string{"price", "changed_at"}))
+
+		_, err := repo.GetPriceHistory(ctx, "unknown")
+		assert.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
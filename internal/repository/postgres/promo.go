@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// promoCodeColumns перечисляет колонки промокода в порядке, который ожидает scanPromoCode
+const promoCodeColumns = "code, discount_type, discount_value, item_name, category, valid_from, valid_until, max_redemptions, max_per_user, redemptions, created_at"
+
+// promoCode реализует интерфейс PromoCodeRepository для работы с промокодами в PostgreSQL
+type promoCode struct {
+	db DBPool
+}
+
+// NewPromoCodeRepository создает новый экземпляр репозитория промокодов
+func NewPromoCodeRepository(db DBPool) repository.PromoCodeRepository {
+	return &promoCode{db: db}
+}
+
+// scanPromoCode считывает промокод из строки результата
+func scanPromoCode(row pgx.Row) (*domain.PromoCode, error) {
+	promo := &domain.PromoCode{}
+	err := row.Scan(&promo.Code, &promo.DiscountType, &promo.DiscountValue, &promo.ItemName, &promo.Category,
+		&promo.ValidFrom, &promo.ValidUntil, &promo.MaxRedemptions, &promo.MaxPerUser, &promo.Redemptions, &promo.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+// CreatePromoCode сохраняет новый промокод
+func (p *promoCode) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	const op = "PromoCodeRepository.CreatePromoCode"
+
+	_, err := p.db.Exec(ctx, `
+		INSERT INTO promo_codes (code, discount_type, discount_value, item_name, category, valid_from, valid_until, max_redemptions, max_per_user, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		promo.Code, string(promo.DiscountType), promo.DiscountValue, promo.ItemName, promo.Category,
+		promo.ValidFrom, promo.ValidUntil, promo.MaxRedemptions, promo.MaxPerUser, promo.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505": // unique_violation
+				return fmt.Errorf("%s: %w", op, domain.ErrPromoCodeExists)
+			case "23503": // foreign_key_violation
+				return fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+			}
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetPromoCode возвращает промокод по коду
+func (p *promoCode) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	const op = "PromoCodeRepository.GetPromoCode"
+
+	promo, err := scanPromoCode(p.db.QueryRow(ctx, "SELECT "+promoCodeColumns+" FROM promo_codes WHERE code = $1", code))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrPromoCodeNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return promo, nil
+}
+
+// GetPromoCodes возвращает все промокоды, начиная с последних созданных
+func (p *promoCode) GetPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	const op = "PromoCodeRepository.GetPromoCodes"
+
+	rows, err := p.db.Query(ctx, "SELECT "+promoCodeColumns+" FROM promo_codes ORDER BY created_at DESC, code")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var promos []*domain.PromoCode
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		promos = append(promos, promo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	return promos, nil
+}
+
+// DeactivatePromoCode досрочно завершает действие промокода. История использований сохраняется.
+func (p *promoCode) DeactivatePromoCode(ctx context.Context, code string, now time.Time) error {
+	const op = "PromoCodeRepository.DeactivatePromoCode"
+
+	result, err := p.db.Exec(ctx,
+		"UPDATE promo_codes SET valid_until = $2 WHERE code = $1 AND (valid_until IS NULL OR valid_until > $2)",
+		code, now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() > 0 {
+		return nil
+	}
+
+	// Код уже не действует или не существует
+	var exists bool
+	if err := p.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM promo_codes WHERE code = $1)", code).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s: %w", op, domain.ErrPromoCodeNotFound)
+	}
+	return nil
+}
+
+// redeemPromoCode учитывает использование промокода в покупке. Строка промокода блокируется,
+// поэтому при одновременных покупках общий лимит и лимит на пользователя не будут превышены.
+func redeemPromoCode(ctx context.Context, tx pgx.Tx, purchase *domain.Purchase, orderId int64, now time.Time) error {
+	promo, err := scanPromoCode(tx.QueryRow(ctx,
+		"SELECT "+promoCodeColumns+" FROM promo_codes WHERE code = $1 FOR UPDATE",
+		purchase.PromoCode,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrPromoCodeNotFound
+		}
+		return fmt.Errorf("блокировка промокода: %w", err)
+	}
+
+	var used uint64
+	err = tx.QueryRow(ctx,
+		"SELECT COUNT(*) FROM promo_redemptions WHERE code = $1 AND username = $2",
+		promo.Code, purchase.Username,
+	).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("подсчет использований: %w", err)
+	}
+
+	if err := promo.CheckRedeemable(now, used); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE promo_codes SET redemptions = redemptions + 1 WHERE code = $1", promo.Code)
+	if err != nil {
+		return fmt.Errorf("обновление счетчика использований: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO promo_redemptions (code, username, order_id, discount, redeemed_at) VALUES ($1, $2, $3, $4, $5)",
+		promo.Code, purchase.Username, orderId, purchase.Discount, now,
+	)
+	if err != nil {
+		return fmt.Errorf("запись использования: %w", err)
+	}
+
+	return nil
+}
+
+// releasePromoRedemption отменяет использование промокода в заказе: запись удаляется,
+// а счетчик уменьшается, поэтому отмененный заказ не расходует ни общий лимит, ни лимит на пользователя.
+func releasePromoRedemption(ctx context.Context, tx pgx.Tx, orderId int64) error {
+	_, err := tx.Exec(ctx, `
+		WITH released AS (
+			DELETE FROM promo_redemptions WHERE order_id = $1 RETURNING code
+		)
+		UPDATE promo_codes SET redemptions = redemptions - (SELECT COUNT(*) FROM released WHERE released.code = promo_codes.code)
+		WHERE code IN (SELECT code FROM released)`,
+		orderId,
+	)
+	if err != nil {
+		return fmt.Errorf("отмена использования промокода: %w", err)
+	}
+	return nil
+}
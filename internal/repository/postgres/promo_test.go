@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var promoCodeTestColumns = []string{"code", "discount_type", "discount_value", "item_name", "category", "valid_from", "valid_until", "max_redemptions", "max_per_user", "redemptions", "created_at"}
+
+// promoCodeRows возвращает результат запроса с одним промокодом
+func promoCodeRows(promo *domain.PromoCode) *pgxmock.Rows {
+	return pgxmock.NewRows(promoCodeTestColumns).AddRow(promo.Code, promo.DiscountType, promo.DiscountValue, promo.ItemName, promo.Category,
+		promo.ValidFrom, promo.ValidUntil, promo.MaxRedemptions, promo.MaxPerUser, promo.Redemptions, promo.CreatedAt)
+}
+
+func TestCreatePromoCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPromoCodeRepository(mock)
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	category := "clothes"
+	promo := &domain.PromoCode{Code: "HOODIES20", DiscountType: domain.PromoDiscountPercent, DiscountValue: 20, Category: &category, CreatedAt: createdAt}
+	args := []interface{}{"HOODIES20", "percent", uint64(20), (*string)(nil), &category, (*time.Time)(nil), (*time.Time)(nil), (*uint64)(nil), (*uint64)(nil), createdAt}
+
+	t.Run("промокод создан", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO promo_codes").
+			WithArgs(args...).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		require.NoError(t, repo.CreatePromoCode(context.Background(), promo))
+	})
+
+	t.Run("промокод уже существует", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO promo_codes").
+			WithArgs(args...).
+			WillReturnError(&pgconn.PgError{Code: "23505"})
+
+		err := repo.CreatePromoCode(context.Background(), promo)
+		assert.ErrorIs(t, err, domain.ErrPromoCodeExists)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO promo_codes").
+			WithArgs(args...).
+			WillReturnError(&pgconn.PgError{Code: "23503"})
+
+		err := repo.CreatePromoCode(context.Background(), promo)
+		assert.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPromoCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPromoCodeRepository(mock)
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	limit := uint64(1)
+
+	t.Run("промокод найден", func(t *testing.T) {
+		want := &domain.PromoCode{Code: "FIRST-10", DiscountType: domain.PromoDiscountFixed, DiscountValue: 10, MaxPerUser: &limit, Redemptions: 3, CreatedAt: createdAt}
+		mock.ExpectQuery("SELECT code, discount_type, discount_value, item_name, category, valid_from, valid_until, max_redemptions, max_per_user, redemptions, created_at FROM promo_codes WHERE code = \\$1").
+			WithArgs("FIRST-10").
+			WillReturnRows(promoCodeRows(want))
+
+		promo, err := repo.GetPromoCode(context.Background(), "FIRST-10")
+		require.NoError(t, err)
+		assert.Equal(t, want, promo)
+	})
+
+	t.Run("промокод не найден", func(t *testing.T) {
+		mock.ExpectQuery("FROM promo_codes WHERE code = \\$1").
+			WithArgs("NOPE").
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.GetPromoCode(context.Background(), "NOPE")
+		assert.ErrorIs(t, err, domain.ErrPromoCodeNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeactivatePromoCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewPromoCodeRepository(mock)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("промокод отключен", func(t *testing.T) {
+		mock.ExpectExec("UPDATE promo_codes SET valid_until = \\$2 WHERE code = \\$1").
+			WithArgs("FIRST-10", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		require.NoError(t, repo.DeactivatePromoCode(context.Background(), "FIRST-10", now))
+	})
+
+	t.Run("промокод уже не действует", func(t *testing.T) {
+		mock.ExpectExec("UPDATE promo_codes SET valid_until").
+			WithArgs("OLD", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("OLD").
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+		require.NoError(t, repo.DeactivatePromoCode(context.Background(), "OLD", now))
+	})
+
+	t.Run("промокод не найден", func(t *testing.T) {
+		mock.ExpectExec("UPDATE promo_codes SET valid_until").
+			WithArgs("NOPE", now).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs("NOPE").
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+		err := repo.DeactivatePromoCode(context.Background(), "NOPE", now)
+		assert.ErrorIs(t, err, domain.ErrPromoCodeNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+)
+
+// token реализует интерфейс TokenRepository для работы с токенами в PostgreSQL
+type token struct {
+	db DBPool
+}
+
+// NewTokenRepository создает новый экземпляр репозитория токенов
+func NewTokenRepository(db DBPool) repository.TokenRepository {
+	return &token{db: db}
+}
+
+// CreateRefreshToken сохраняет новый refresh-токен
+func (t *token) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	const op = "TokenRepository.CreateRefreshToken"
+
+	err := t.db.QueryRow(ctx, `
+		INSERT INTO refresh_tokens (token_hash, username, family_id, expires_at, created_at, amr)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		token.TokenHash, token.Username, token.FamilyId, token.ExpiresAt, token.CreatedAt, token.Amr,
+	).Scan(&token.Id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken атомарно заменяет refresh-токен новым из того же семейства.
+// Повторное предъявление уже отозванного токена отзывает все семейство.
+func (t *token) RotateRefreshToken(ctx context.Context, tokenHash string, next *domain.RefreshToken) (*domain.RefreshToken, error) {
+	const op = "TokenRepository.RotateRefreshToken"
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Блокируем строку предъявленного токена
+	current := &domain.RefreshToken{TokenHash: tokenHash}
+	err = tx.QueryRow(ctx,
+		"SELECT id, username, family_id, expires_at, revoked_at, amr FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE",
+		tokenHash,
+	).Scan(&current.Id, &current.Username, &current.FamilyId, &current.ExpiresAt, &current.RevokedAt, &current.Amr)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("%s: получение токена: %w", op, err)
+	}
+
+	// Токен уже был использован: считаем семейство скомпрометированным
+	if current.IsRevoked() {
+		_, err = tx.Exec(ctx,
+			"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+			time.Now(), current.FamilyId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: отзыв семейства токенов: %w", op, err)
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+		}
+		committed = true
+
+		return nil, domain.ErrTokenReused
+	}
+
+	if current.IsExpired(time.Now()) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	next.Username = current.Username
+	next.FamilyId = current.FamilyId
+	next.Amr = current.Amr
+
+	// Создаем новый токен семейства
+	err = tx.QueryRow(ctx, `
+		INSERT INTO refresh_tokens (token_hash, username, family_id, expires_at, created_at, amr)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		next.TokenHash, next.Username, next.FamilyId, next.ExpiresAt, next.CreatedAt, next.Amr,
+	).Scan(&next.Id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: создание нового токена: %w", op, err)
+	}
+
+	// Отзываем предъявленный токен
+	_, err = tx.Exec(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3",
+		time.Now(), next.Id, current.Id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: отзыв предыдущего токена: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return next, nil
+}
+
+// RevokeRefreshTokenFamily отзывает все активные токены семейства, к которому относится токен пользователя
+func (t *token) RevokeRefreshTokenFamily(ctx context.Context, username, tokenHash string) error {
+	const op = "TokenRepository.RevokeRefreshTokenFamily"
+
+	_, err := t.db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = $1
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $2 AND username = $3)
+		AND revoked_at IS NULL`,
+		time.Now(), tokenHash, username,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens отзывает все активные refresh-токены пользователя
+func (t *token) RevokeUserRefreshTokens(ctx context.Context, username string) error {
+	const op = "TokenRepository.RevokeUserRefreshTokens"
+
+	_, err := t.db.Exec(ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE username = $2 AND revoked_at IS NULL",
+		time.Now(), username,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeUserAccessTokens отзывает все access-токены пользователя, выпущенные раньше issuedBefore
+func (t *token) RevokeUserAccessTokens(ctx context.Context, username string, issuedBefore time.Time) error {
+	const op = "TokenRepository.RevokeUserAccessTokens"
+
+	_, err := t.db.Exec(ctx,
+		"UPDATE users SET tokens_valid_after = $2 WHERE username = $1",
+		username, issuedBefore,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken добавляет идентификатор JWT в список отозванных
+func (t *token) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "TokenRepository.RevokeAccessToken"
+
+	_, err := t.db.Exec(ctx,
+		"INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+		jti, expiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked проверяет, находится ли идентификатор JWT в списке отозванных
+// или выпущен ли токен до отзыва всех токенов пользователя
+func (t *token) IsAccessTokenRevoked(ctx context.Context, jti, username string, issuedAt time.Time) (bool, error) {
+	const op = "TokenRepository.IsAccessTokenRevoked"
+
+	var revoked bool
+	err := t.db.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)
+		OR EXISTS(SELECT 1 FROM users WHERE username = $2 AND tokens_valid_after > $3)`,
+		jti, username, issuedAt,
+	).Scan(&revoked)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpiredTokens удаляет истекшие refresh-токены и записи об отозванных access-токенах,
+// срок действия которых закончился. Возвращает общее количество удаленных строк.
+func (t *token) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	const op = "TokenRepository.DeleteExpiredTokens"
+
+	revoked, err := t.db.Exec(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, fmt.Errorf("%s: отозванные токены: %w", op, err)
+	}
+
+	// Токен, на который ссылается еще действующий токен семейства через replaced_by, остается до его истечения
+	refresh, err := t.db.Exec(ctx, `
+		DELETE FROM refresh_tokens
+		WHERE expires_at <= $1
+		AND NOT EXISTS (SELECT 1 FROM refresh_tokens r WHERE r.replaced_by = refresh_tokens.id AND r.expires_at > $1)`,
+		now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: refresh-токены: %w", op, err)
+	}
+
+	return revoked.RowsAffected() + refresh.RowsAffected(), nil
+}
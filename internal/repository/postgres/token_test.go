@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	t.Run("успешная ротация", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTokenRepository(mock)
+		next := domain.NewRefreshToken("new-hash", "", "", time.Now().Add(time.Hour))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, username, family_id, expires_at, revoked_at, amr FROM refresh_tokens WHERE token_hash = \\$1 FOR UPDATE").
+			WithArgs("old-hash").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "username", "family_id", "expires_at", "revoked_at", "amr"}).
+				AddRow(int64(1), "testuser", "family", time.Now().Add(time.Hour), nil, []string{"pwd", "mfa"}))
+		mock.ExpectQuery("INSERT INTO refresh_tokens").
+			WithArgs("new-hash", "testuser", "family", next.ExpiresAt, next.CreatedAt, []string{"pwd", "mfa"}).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(2)))
+		mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = \\$1, replaced_by = \\$2 WHERE id = \\$3").
+			WithArgs(pgxmock.AnyArg(), int64(2), int64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectCommit()
+
+		rotated, err := repo.RotateRefreshToken(context.Background(), "old-hash", next)
+
+		require.NoError(t, err)
+		require.Equal(t, "testuser", rotated.Username)
+		require.Equal(t, "family", rotated.FamilyId)
+		require.Equal(t, int64(2), rotated.Id)
+		require.Equal(t, []string{"pwd", "mfa"}, rotated.Amr)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("повторное использование отзывает семейство", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTokenRepository(mock)
+		revokedAt := time.Now().Add(-time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, username, family_id, expires_at, revoked_at, amr FROM refresh_tokens").
+			WithArgs("old-hash").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "username", "family_id", "expires_at", "revoked_at", "amr"}).
+				AddRow(int64(1), "testuser", "family", time.Now().Add(time.Hour), &revokedAt, []string{"pwd"}))
+		mock.ExpectExec("UPDATE refresh_tokens SET revoked_at = \\$1 WHERE family_id = \\$2 AND revoked_at IS NULL").
+			WithArgs(pgxmock.AnyArg(), "family").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+		mock.ExpectCommit()
+
+		_, err = repo.RotateRefreshToken(context.Background(), "old-hash", domain.NewRefreshToken("new-hash", "", "", time.Now()))
+
+		require.ErrorIs(t, err, domain.ErrTokenReused)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("неизвестный токен", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTokenRepository(mock)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT id, username, family_id, expires_at, revoked_at, amr FROM refresh_tokens").
+			WithArgs("unknown").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "username", "family_id", "expires_at", "revoked_at", "amr"}))
+		mock.ExpectRollback()
+
+		_, err = repo.RotateRefreshToken(context.Background(), "unknown", domain.NewRefreshToken("new-hash", "", "", time.Now()))
+
+		require.ErrorIs(t, err, domain.ErrInvalidToken)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRevokeUserAccessTokens(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTokenRepository(mock)
+	issuedBefore := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("UPDATE users SET tokens_valid_after = \\$2 WHERE username = \\$1").
+		WithArgs("testuser", issuedBefore).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	require.NoError(t, repo.RevokeUserAccessTokens(context.Background(), "testuser", issuedBefore))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIsAccessTokenRevoked(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTokenRepository(mock)
+
+	issuedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM revoked_tokens WHERE jti = \\$1\\) OR EXISTS\\(SELECT 1 FROM users WHERE username = \\$2 AND tokens_valid_after > \\$3\\)").
+		WithArgs("jti-1", "testuser", issuedAt).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+	revoked, err := repo.IsAccessTokenRevoked(context.Background(), "jti-1", "testuser", issuedAt)
+
+	require.NoError(t, err)
+	require.True(t, revoked)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteExpiredTokens(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTokenRepository(mock)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("DELETE FROM revoked_tokens WHERE expires_at <= \\$1").
+		WithArgs(now).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	mock.ExpectExec("DELETE FROM refresh_tokens WHERE expires_at <= \\$1 AND NOT EXISTS").
+		WithArgs(now).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+
+	deleted, err := repo.DeleteExpiredTokens(context.Background(), now)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(5), deleted)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/netscrawler/avito-shop/internal/domain"
 	"github.com/netscrawler/avito-shop/internal/repository"
 )
@@ -112,8 +116,9 @@ func (t *transaction) GetTransactionsByReceiver(ctx context.Context, receiverNam
 	return transactions, nil
 }
 
-// ExecuteTransfer выполняет перевод монет между пользователями в рамках одной транзакции
-func (t *transaction) ExecuteTransfer(ctx context.Context, fromUsername, toUsername string, amount uint64) error {
+// ExecuteTransfer выполняет перевод монет между пользователями в рамках одной транзакции.
+// Если передан idem, результат запроса сохраняется в той же транзакции.
+func (t *transaction) ExecuteTransfer(ctx context.Context, fromUsername, toUsername string, amount uint64, idem *domain.IdempotencyRecord) error {
 	const op = "TransactionRepository.ExecuteTransfer"
 
 	tx, err := t.db.Begin(ctx)
@@ -130,6 +135,16 @@ func (t *transaction) ExecuteTransfer(ctx context.Context, fromUsername, toUsern
 		}
 	}()
 
+	// Занимаем ключ идемпотентности до изменения балансов
+	if idem != nil {
+		if err := saveIdempotencyRecord(ctx, tx, idem); err != nil {
+			if err == domain.ErrIdempotencyKeyUsed {
+				return err
+			}
+			return fmt.Errorf("%s: сохранение ключа идемпотентности: %w", op, err)
+		}
+	}
+
 	// Получаем баланс отправителя
 	var senderCoins uint64
 	err = tx.QueryRow(ctx,
@@ -230,10 +245,232 @@ func (t *transaction) GetUserTransactions(ctx context.Context, username string)
 	return transactions, nil
 }
 
-// ExecutePurchase выполняет покупку товара в рамках одной транзакции
-func (t *transaction) ExecutePurchase(ctx context.Context, username string, merchName string, price uint64) error {
+// GetUserLedger возвращает операции пользователя, подходящие под фильтр, от новых к старым
+func (t *transaction) GetUserLedger(ctx context.Context, filter domain.LedgerFilter) ([]*domain.LedgerEntry, error) {
+	const op = "TransactionRepository.GetUserLedger"
+
+	query, args := buildLedgerQuery(filter)
+	rows, err := t.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.LedgerEntry
+	for rows.Next() {
+		entry := &domain.LedgerEntry{}
+		if err := rows.Scan(
+			&entry.TransactionId,
+			&entry.Username,
+			&entry.Counterparty,
+			&entry.Direction,
+			&entry.Amount,
+			&entry.Type,
+			&entry.ItemName,
+			&entry.Quantity,
+			&entry.Message,
+			&entry.Timestamp,
+		); err != nil {
+			return nil, fmt.Errorf("%s: сканирование строки: %w", op, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: итерация по результатам: %w", op, err)
+	}
+
+	return entries, nil
+}
+
+// buildLedgerQuery формирует запрос к представлению user_ledger с условиями фильтра
+func buildLedgerQuery(filter domain.LedgerFilter) (string, []interface{}) {
+	var b strings.Builder
+	b.WriteString(`
+		SELECT transaction_id, username, COALESCE(counterparty, ''), direction, amount, transfer_type, COALESCE(item_name, ''), COALESCE(quantity, 0), COALESCE(message, ''), timestamp
+		FROM user_ledger
+		WHERE username = $1`)
+	args := []interface{}{filter.Username}
+
+	where := func(cond string, value interface{}) {
+		args = append(args, value)
+		fmt.Fprintf(&b, "\n\t\tAND "+cond, len(args))
+	}
+
+	if filter.Direction != "" {
+		where("direction = $%d", string(filter.Direction))
+	}
+	if filter.Counterparty != "" {
+		where("counterparty = $%d", filter.Counterparty)
+	}
+	if filter.Type != "" {
+		where("transfer_type = $%d", string(filter.Type))
+	}
+	if filter.MinAmount > 0 {
+		where("amount >= $%d", filter.MinAmount)
+	}
+	if filter.MaxAmount > 0 {
+		where("amount <= $%d", filter.MaxAmount)
+	}
+	if !filter.From.IsZero() {
+		where("timestamp >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("timestamp < $%d", filter.To)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Timestamp, filter.After.TransactionId)
+		fmt.Fprintf(&b, "\n\t\tAND (timestamp, transaction_id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	b.WriteString("\n\t\tORDER BY timestamp DESC, transaction_id DESC")
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		fmt.Fprintf(&b, "\n\t\tLIMIT $%d", len(args))
+	}
+
+	return b.String(), args
+}
+
+const (
+	// statementFetchSize количество строк, читаемых из курсора выписки за один запрос
+	statementFetchSize = 500
+	// statementDefaultTimeout ограничивает выгрузку выписки, если у контекста нет срока
+	statementDefaultTimeout = 10 * time.Minute
+)
+
+// StreamStatement построчно передает в fn операции за период вместе с балансами на начало и конец периода.
+// Строки читаются из серверного курсора порциями, поэтому выписка не загружается в память целиком.
+// Курсор работает на снимке данных, так что балансы согласованы с операциями.
+func (t *transaction) StreamStatement(ctx context.Context, filter domain.StatementFilter, fn func(*domain.StatementRow) error) error {
+	const op = "TransactionRepository.StreamStatement"
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Между порциями транзакция простаивает, пока клиент принимает данные, а открытие курсора
+	// по всей компании может длиться дольше обычного запроса. Таймауты пула для выписки малы,
+	// поэтому на время транзакции они заменяются сроком выгрузки.
+	if err := setStatementTimeouts(ctx, tx); err != nil {
+		return fmt.Errorf("%s: установка таймаутов: %w", op, err)
+	}
+
+	query := `
+		DECLARE statement_cursor NO SCROLL CURSOR FOR
+		SELECT u.username, b.opening, b.closing,
+			l.transaction_id, COALESCE(l.counterparty, ''), COALESCE(l.direction, ''), COALESCE(l.amount, 0),
+			COALESCE(l.transfer_type, ''), COALESCE(l.item_name, ''), COALESCE(l.quantity, 0), l.timestamp
+		FROM users u
+		CROSS JOIN LATERAL (
+			SELECT (u.coins - COALESCE(SUM(CASE WHEN direction = 'CREDIT' THEN amount ELSE -amount END), 0))::BIGINT AS opening,
+				(u.coins - COALESCE(SUM(CASE WHEN direction = 'CREDIT' THEN amount ELSE -amount END) FILTER (WHERE timestamp >= $2), 0))::BIGINT AS closing
+			FROM user_ledger
+			WHERE username = u.username AND timestamp >= $1
+		) b
+		LEFT JOIN user_ledger l ON l.username = u.username AND l.timestamp >= $1 AND l.timestamp < $2
+		WHERE u.role <> 'service'`
+	args := []interface{}{filter.From, filter.To}
+	if filter.Username != "" {
+		query += " AND u.username = $3"
+		args = append(args, filter.Username)
+	}
+	query += "\n\t\tORDER BY u.username, l.timestamp, l.transaction_id"
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("%s: открытие курсора: %w", op, err)
+	}
+
+	for {
+		fetched, err := fetchStatementRows(ctx, tx, fn)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if fetched < statementFetchSize {
+			return nil
+		}
+	}
+}
+
+// setStatementTimeouts задает таймауты запроса и простоя транзакции по оставшемуся сроку контекста
+func setStatementTimeouts(ctx context.Context, tx pgx.Tx) error {
+	timeout := statementDefaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	// Ноль отключает таймаут в PostgreSQL, поэтому истекший срок заменяется минимальным
+	ms := strconv.FormatInt(max(timeout.Milliseconds(), 1), 10)
+
+	_, err := tx.Exec(ctx,
+		"SELECT set_config('statement_timeout', $1, true), set_config('idle_in_transaction_session_timeout', $1, true)",
+		ms,
+	)
+	return err
+}
+
+// fetchStatementRows читает очередную порцию строк курсора и возвращает их количество
+func fetchStatementRows(ctx context.Context, tx pgx.Tx, fn func(*domain.StatementRow) error) (int, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf("FETCH %d FROM statement_cursor", statementFetchSize))
+	if err != nil {
+		return 0, fmt.Errorf("чтение курсора: %w", err)
+	}
+	defer rows.Close()
+
+	var fetched int
+	for rows.Next() {
+		row := &domain.StatementRow{}
+		entry := &domain.LedgerEntry{}
+		var id *int64
+		var timestamp *time.Time
+		if err := rows.Scan(
+			&row.Username,
+			&row.OpeningBalance,
+			&row.ClosingBalance,
+			&id,
+			&entry.Counterparty,
+			&entry.Direction,
+			&entry.Amount,
+			&entry.Type,
+			&entry.ItemName,
+			&entry.Quantity,
+			&timestamp,
+		); err != nil {
+			return fetched, fmt.Errorf("сканирование строки: %w", err)
+		}
+		fetched++
+
+		if id != nil {
+			entry.TransactionId = *id
+			entry.Username = row.Username
+			entry.Timestamp = *timestamp
+			row.Entry = entry
+		}
+		if err := fn(row); err != nil {
+			return fetched, err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fetched, fmt.Errorf("итерация по результатам: %w", err)
+	}
+
+	return fetched, nil
+}
+
+// ExecutePurchase выполняет покупку одной или нескольких единиц товара в рамках одной транзакции.
+// Если передан idem, результат запроса сохраняется в той же транзакции.
+func (t *transaction) ExecutePurchase(ctx context.Context, purchase *domain.Purchase, idem *domain.IdempotencyRecord) error {
 	const op = "TransactionRepository.ExecutePurchase"
 
+	total, err := purchase.Total()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	tx, err := t.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: начало транзакции: %w", op, err)
@@ -248,52 +485,483 @@ func (t *transaction) ExecutePurchase(ctx context.Context, username string, merc
 		}
 	}()
 
+	// Занимаем ключ идемпотентности до изменения балансов
+	if idem != nil {
+		if err := saveIdempotencyRecord(ctx, tx, idem); err != nil {
+			if err == domain.ErrIdempotencyKeyUsed {
+				return err
+			}
+			return fmt.Errorf("%s: сохранение ключа идемпотентности: %w", op, err)
+		}
+	}
+
 	// Блокируем строку пользователя для обновления
 	var coins uint64
 	err = tx.QueryRow(ctx,
 		"SELECT coins FROM users WHERE username = $1 FOR UPDATE",
-		username,
+		purchase.Username,
 	).Scan(&coins)
 	if err != nil {
 		return fmt.Errorf("%s: получение данных пользователя: %w", op, err)
 	}
 
 	// Проверяем достаточность средств
-	if coins < price {
+	if coins < total {
 		return domain.ErrInsufficientFunds
 	}
 
 	// Обновляем баланс
 	_, err = tx.Exec(ctx,
 		"UPDATE users SET coins = coins - $1 WHERE username = $2",
-		price, username,
+		total, purchase.Username,
 	)
 	if err != nil {
 		return fmt.Errorf("%s: обновление баланса: %w", op, err)
 	}
 
+	// Списываем товар со склада. Достаточность остатка проверяется под блокировкой строки,
+	// поэтому при одновременной покупке последних единиц успешной будет только одна транзакция
+	if err := takeMerchStock(ctx, tx, purchase.ItemName, purchase.Quantity); err != nil {
+		if err == domain.ErrOutOfStock {
+			return err
+		}
+		return fmt.Errorf("%s: списание со склада: %w", op, err)
+	}
+
+	// Цена в покупке могла быть взята из кэша, поэтому сверяем ее с ценой товара под блокировкой
+	now := time.Now()
+	if err := lockPurchasableMerch(ctx, tx, purchase.ItemName, purchase.UnitPrice, now); err != nil {
+		if isPurchaseCheckError(err) {
+			return err
+		}
+		return fmt.Errorf("%s: проверка товара: %w", op, err)
+	}
+
+	// Создаем заказ, по которому товар будет выдан покупателю
+	order, err := domain.NewPurchaseOrder(purchase, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertOrder(ctx, tx, order); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Учитываем использование промокода. Лимиты проверяются под блокировкой промокода,
+	// поэтому скидка не будет предоставлена сверх лимита
+	if purchase.PromoCode != "" {
+		if err := redeemPromoCode(ctx, tx, purchase, order.Id, now); err != nil {
+			return fmt.Errorf("%s: применение промокода: %w", op, err)
+		}
+	}
+
 	// Обновляем или создаем запись в инвентаре
-	_, err = tx.Exec(ctx, `
+	if err := addToInventory(ctx, tx, purchase.Username, purchase.ItemName, purchase.Quantity); err != nil {
+		return fmt.Errorf("%s: обновление инвентаря: %w", op, err)
+	}
+
+	// Создаем запись о транзакции
+	if err := insertPurchaseTransaction(ctx, tx, purchase, total, &order.Id, now); err != nil {
+		return fmt.Errorf("%s: создание записи о транзакции: %w", op, err)
+	}
+
+	// Фиксируем транзакцию
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// ExecuteGift выполняет покупку товара в подарок в рамках одной транзакции: списывает стоимость
+// у отправителя, добавляет товар в инвентарь получателя и создает заказ на его выдачу.
+// Существование получателя проверяется в той же транзакции, поэтому монеты не списываются
+// за подарок несуществующему пользователю.
+// Если передан idem, результат запроса сохраняется в той же транзакции.
+func (t *transaction) ExecuteGift(ctx context.Context, gift *domain.Gift, idem *domain.IdempotencyRecord) error {
+	const op = "TransactionRepository.ExecuteGift"
+
+	total, err := gift.Total()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%v, rollback error: %v", err, rollbackErr)
+			}
+		}
+	}()
+
+	// Занимаем ключ идемпотентности до изменения балансов
+	if idem != nil {
+		if err := saveIdempotencyRecord(ctx, tx, idem); err != nil {
+			if err == domain.ErrIdempotencyKeyUsed {
+				return err
+			}
+			return fmt.Errorf("%s: сохранение ключа идемпотентности: %w", op, err)
+		}
+	}
+
+	// Блокируем отправителя и получателя в порядке имен, чтобы встречные подарки не приводили
+	// к взаимной блокировке. Блокировка получателя не дает удалить его до фиксации подарка.
+	coins, err := lockUsers(ctx, tx, gift.Username, gift.Recipient)
+	if err != nil {
+		return fmt.Errorf("%s: получение данных пользователей: %w", op, err)
+	}
+	senderCoins, ok := coins[gift.Username]
+	if !ok {
+		return domain.ErrSenderNotFound
+	}
+	if _, ok := coins[gift.Recipient]; !ok {
+		return domain.ErrRecipientNotFound
+	}
+
+	if senderCoins < total {
+		return domain.ErrInsufficientFunds
+	}
+
+	_, err = tx.Exec(ctx,
+		"UPDATE users SET coins = coins - $1 WHERE username = $2",
+		total, gift.Username,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: обновление баланса: %w", op, err)
+	}
+
+	if err := takeMerchStock(ctx, tx, gift.ItemName, gift.Quantity); err != nil {
+		if err == domain.ErrOutOfStock {
+			return err
+		}
+		return fmt.Errorf("%s: списание со склада: %w", op, err)
+	}
+
+	now := time.Now()
+	if err := lockPurchasableMerch(ctx, tx, gift.ItemName, gift.UnitPrice, now); err != nil {
+		if isPurchaseCheckError(err) {
+			return err
+		}
+		return fmt.Errorf("%s: проверка товара: %w", op, err)
+	}
+
+	// Подарок выдается получателю по заказу, который отправитель может отменить до выдачи
+	order, err := domain.NewGiftOrder(gift, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertOrder(ctx, tx, order); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := addToInventory(ctx, tx, gift.Recipient, gift.ItemName, gift.Quantity); err != nil {
+		return fmt.Errorf("%s: обновление инвентаря: %w", op, err)
+	}
+
+	var message *string
+	if gift.Message != "" {
+		message = &gift.Message
+	}
+	_, err = tx.Exec(ctx,
+		"INSERT INTO transactions (sender_name, receiver_name, amount, transfer_type, item_name, quantity, message, order_id, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		gift.Username, gift.Recipient, total, domain.TransactionTypeGift, gift.ItemName, gift.Quantity, message, order.Id, now,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: создание записи о транзакции: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// ExecuteItemTransfer передает единицы товара из инвентаря одного пользователя другому в рамках одной транзакции.
+// Снятые с продажи и непередаваемые товары не передаются.
+func (t *transaction) ExecuteItemTransfer(ctx context.Context, transfer *domain.ItemTransfer, idem *domain.IdempotencyRecord) error {
+	const op = "TransactionRepository.ExecuteItemTransfer"
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				err = fmt.Errorf("%v, rollback error: %v", err, rollbackErr)
+			}
+		}
+	}()
+
+	if idem != nil {
+		if err := saveIdempotencyRecord(ctx, tx, idem); err != nil {
+			if err == domain.ErrIdempotencyKeyUsed {
+				return err
+			}
+			return fmt.Errorf("%s: сохранение ключа идемпотентности: %w", op, err)
+		}
+	}
+
+	// Пользователи блокируются раньше товара, как и при покупке, чтобы не было взаимной блокировки
+	coins, err := lockUsers(ctx, tx, transfer.From, transfer.To)
+	if err != nil {
+		return fmt.Errorf("%s: получение данных пользователей: %w", op, err)
+	}
+	if _, ok := coins[transfer.From]; !ok {
+		return domain.ErrSenderNotFound
+	}
+	if _, ok := coins[transfer.To]; !ok {
+		return domain.ErrRecipientNotFound
+	}
+
+	if err := checkTransferable(ctx, tx, transfer.ItemName); err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) || errors.Is(err, domain.ErrItemNotTransferable) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := lockInventory(ctx, tx, transfer.ItemName, transfer.From, transfer.To); err != nil {
+		return fmt.Errorf("%s: блокировка инвентаря: %w", op, err)
+	}
+
+	if err := takeFromInventory(ctx, tx, transfer.From, transfer.ItemName, transfer.Quantity); err != nil {
+		if errors.Is(err, domain.ErrInventoryShortage) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := addToInventory(ctx, tx, transfer.To, transfer.ItemName, transfer.Quantity); err != nil {
+		return fmt.Errorf("%s: обновление инвентаря: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO transactions (sender_name, receiver_name, amount, transfer_type, item_name, quantity, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		transfer.From, transfer.To, 0, domain.TransactionTypeItemTransfer, transfer.ItemName, transfer.Quantity, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: создание записи о транзакции: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: фиксация транзакции: %w", op, err)
+	}
+	committed = true
+
+	return nil
+}
+
+// checkTransferable проверяет, что товар существует и его можно передавать.
+// FOR SHARE не дает снять товар с продажи до конца транзакции.
+func checkTransferable(ctx context.Context, tx pgx.Tx, itemName string) error {
+	merch, err := scanMerch(tx.QueryRow(ctx, "SELECT "+merchColumns+" FROM merch WHERE name = $1 FOR SHARE", itemName))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return domain.ErrMerchNotFound
+		}
+		return fmt.Errorf("получение товара: %w", err)
+	}
+	if merch.IsRetired() {
+		return fmt.Errorf("%w: товар снят с продажи", domain.ErrItemNotTransferable)
+	}
+	if !merch.CanBeTransferred() {
+		return fmt.Errorf("%w: передача этого товара запрещена", domain.ErrItemNotTransferable)
+	}
+	return nil
+}
+
+// lockPurchasableMerch блокирует строку товара до конца транзакции и проверяет, что товар продается
+// по цене unitPrice в момент now. Вызывается после списания со склада: строка товара с ограниченным
+// количеством к этому моменту уже заблокирована на обновление, и FOR SHARE не приводит к взаимоблокировке.
+func lockPurchasableMerch(ctx context.Context, tx pgx.Tx, itemName string, unitPrice uint64, now time.Time) error {
+	var price uint64
+	var purchasable bool
+	err := tx.QueryRow(ctx,
+		"SELECT price, available AND retired_at IS NULL FROM merch WHERE name = $1 FOR SHARE",
+		itemName,
+	).Scan(&price, &purchasable)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return domain.ErrMerchNotFound
+		}
+		return fmt.Errorf("получение товара: %w", err)
+	}
+	if !purchasable {
+		return fmt.Errorf("%w: %s", domain.ErrMerchUnavailable, itemName)
+	}
+
+	schedules, err := loadPriceSchedules(ctx, tx, []string{itemName}, now)
+	if err != nil {
+		return fmt.Errorf("расписания цен: %w", err)
+	}
+	if domain.EffectivePrice(price, schedules[itemName], now) != unitPrice {
+		return fmt.Errorf("%w: %s", domain.ErrPriceChanged, itemName)
+	}
+	return nil
+}
+
+// isPurchaseCheckError проверяет, что покупка отклонена из-за состояния товара, а не сбоя базы
+func isPurchaseCheckError(err error) bool {
+	for _, target := range []error{domain.ErrMerchNotFound, domain.ErrMerchUnavailable, domain.ErrPriceChanged} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockInventory блокирует строки инвентаря пользователей с товаром в порядке имен.
+// Строка получателя, у которого еще нет товара, создается при зачислении.
+func lockInventory(ctx context.Context, tx pgx.Tx, itemName string, usernames ...string) error {
+	rows, err := tx.Query(ctx,
+		"SELECT username FROM user_inventory WHERE item_name = $1 AND username = ANY($2) ORDER BY username FOR UPDATE",
+		itemName, usernames,
+	)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+	return rows.Err()
+}
+
+// lockUsers блокирует строки пользователей в порядке имен и возвращает их балансы.
+// Отсутствующих пользователей нет в результате.
+func lockUsers(ctx context.Context, tx pgx.Tx, usernames ...string) (map[string]uint64, error) {
+	rows, err := tx.Query(ctx,
+		"SELECT username, coins FROM users WHERE username = ANY($1) ORDER BY username FOR UPDATE",
+		usernames,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coins := make(map[string]uint64, len(usernames))
+	for rows.Next() {
+		var username string
+		var balance uint64
+		if err := rows.Scan(&username, &balance); err != nil {
+			return nil, fmt.Errorf("сканирование строки: %w", err)
+		}
+		coins[username] = balance
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("итерация по результатам: %w", err)
+	}
+
+	return coins, nil
+}
+
+// addToInventory добавляет единицы товара в инвентарь пользователя
+func addToInventory(ctx context.Context, tx pgx.Tx, username, itemName string, quantity uint64) error {
+	_, err := tx.Exec(ctx, `
 		INSERT INTO user_inventory (username, item_name, quantity)
-		VALUES ($1, $2, 1)
+		VALUES ($1, $2, $3)
 		ON CONFLICT (username, item_name)
-		DO UPDATE SET quantity = user_inventory.quantity + 1`,
-		username, merchName,
+		DO UPDATE SET quantity = user_inventory.quantity + EXCLUDED.quantity`,
+		username, itemName, quantity,
+	)
+	return err
+}
+
+// takeFromInventory уменьшает количество товара в инвентаре пользователя.
+// Возвращает ErrInventoryShortage, если у пользователя меньше quantity единиц.
+func takeFromInventory(ctx context.Context, tx pgx.Tx, username, itemName string, quantity uint64) error {
+	result, err := tx.Exec(ctx,
+		"UPDATE user_inventory SET quantity = quantity - $3 WHERE username = $1 AND item_name = $2 AND quantity >= $3",
+		username, itemName, quantity,
 	)
 	if err != nil {
-		return fmt.Errorf("%s: обновление инвентаря: %w", op, err)
+		return fmt.Errorf("обновление инвентаря: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrInventoryShortage, itemName)
+	}
+	return nil
+}
+
+// insertPurchaseTransaction записывает покупку в историю транзакций. orderId указывается для позиций заказа.
+func insertPurchaseTransaction(ctx context.Context, tx pgx.Tx, purchase *domain.Purchase, total uint64, orderId *int64, now time.Time) error {
+	_, err := tx.Exec(ctx,
+		"INSERT INTO transactions (sender_name, receiver_name, amount, transfer_type, item_name, quantity, order_id, timestamp) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		purchase.Username, domain.ShopAccount, total, domain.TransactionTypePurchase, purchase.ItemName, purchase.Quantity, orderId, now,
+	)
+	return err
+}
+
+// takeMerchStock уменьшает остаток товара с ограниченным количеством на quantity единиц.
+// Для товаров без ограничения строка не блокируется.
+func takeMerchStock(ctx context.Context, tx pgx.Tx, merchName string, quantity uint64) error {
+	result, err := tx.Exec(ctx,
+		"UPDATE merch SET stock = stock - $2 WHERE name = $1 AND stock >= $2",
+		merchName, quantity,
+	)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() > 0 {
+		return nil
+	}
+
+	var limited bool
+	if err := tx.QueryRow(ctx, "SELECT stock IS NOT NULL FROM merch WHERE name = $1", merchName).Scan(&limited); err != nil {
+		return err
+	}
+	if limited {
+		return domain.ErrOutOfStock
+	}
+	return nil
+}
+
+// ExecuteGrant начисляет монеты получателю в рамках одной транзакции.
+// Баланс granter не меняется, поэтому отправителем записывается магазин, а granter сохраняется в granted_by.
+func (t *transaction) ExecuteGrant(ctx context.Context, granter, toUsername string, amount uint64) error {
+	const op = "TransactionRepository.ExecuteGrant"
+
+	tx, err := t.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: начало транзакции: %w", op, err)
+	}
+
+	var committed bool
+	defer func() {
+		if !committed {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Начисляем монеты получателю
+	result, err := tx.Exec(ctx,
+		"UPDATE users SET coins = coins + $1 WHERE username = $2",
+		amount, toUsername,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: обновление баланса получателя: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrRecipientNotFound
 	}
 
 	// Создаем запись о транзакции
 	_, err = tx.Exec(ctx,
-		"INSERT INTO transactions (sender_name, receiver_name, amount, transfer_type, timestamp) VALUES ($1, $2, $3, $4, $5)",
-		username, "SHOP", price, domain.TransactionTypePurchase, time.Now(),
+		"INSERT INTO transactions (sender_name, receiver_name, amount, transfer_type, granted_by, timestamp) VALUES ($1, $2, $3, $4, $5, $6)",
+		domain.ShopAccount, toUsername, amount, domain.TransactionTypeGrant, granter, time.Now(),
 	)
 	if err != nil {
 		return fmt.Errorf("%s: создание записи о транзакции: %w", op, err)
 	}
 
-	// Фиксируем транзакцию
 	if err := tx.Commit(ctx); err != nil {
 		return fmt.Errorf("%s: фиксация транзакции: %w", op, err)
 	}
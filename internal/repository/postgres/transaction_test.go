@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -85,6 +87,228 @@ func TestGetUserTransactions(t *testing.T) {
 	})
 }
 
+func TestGetUserLedger(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTransactionRepository(mock)
+	ctx := context.Background()
+	username := "testuser"
+	now := time.Now()
+	columns := []string{"transaction_id", "username", "counterparty", "direction", "amount", "transfer_type", "item_name", "quantity", "message", "timestamp"}
+
+	t.Run("покупки, подарки и переводы в одной истории", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM user_ledger WHERE username = \\$1 ORDER BY timestamp DESC, transaction_id DESC").
+			WithArgs(username).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(int64(3), username, "colleague", domain.LedgerCredit, uint64(0), domain.TransactionTypeGift, "cup", uint64(1), "С днем рождения!", now).
+				AddRow(int64(2), username, "SHOP", domain.LedgerDebit, uint64(80), domain.TransactionTypePurchase, "t-shirt", uint64(2), "", now).
+				AddRow(int64(1), username, "sender", domain.LedgerCredit, uint64(200), domain.TransactionTypeTransfer, "", uint64(0), "", now))
+
+		entries, err := repo.GetUserLedger(ctx, domain.LedgerFilter{Username: username})
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, domain.TransactionTypeGift, entries[0].Type)
+		assert.Equal(t, "С днем рождения!", entries[0].Message)
+		assert.True(t, entries[1].IsPurchase())
+		assert.Equal(t, "t-shirt", entries[1].ItemName)
+		assert.Equal(t, uint64(2), entries[1].Quantity)
+		assert.Equal(t, domain.LedgerCredit, entries[2].Direction)
+		assert.Equal(t, "sender", entries[2].Counterparty)
+	})
+
+	t.Run("фильтры и курсор", func(t *testing.T) {
+		cursor := &domain.LedgerCursor{Timestamp: now, TransactionId: 10}
+		filter := domain.LedgerFilter{
+			Username:     username,
+			Direction:    domain.LedgerCredit,
+			Counterparty: "sender",
+			Type:         domain.TransactionTypeTransfer,
+			MinAmount:    10,
+			MaxAmount:    500,
+			From:         now.Add(-time.Hour),
+			After:        cursor,
+			Limit:        21,
+		}
+
+		mock.ExpectQuery("FROM user_ledger WHERE username = \\$1 AND direction = \\$2 AND counterparty = \\$3 AND transfer_type = \\$4 "+
+			"AND amount >= \\$5 AND amount <= \\$6 AND timestamp >= \\$7 AND \\(timestamp, transaction_id\\) < \\(\\$8, \\$9\\) "+
+			"ORDER BY timestamp DESC, transaction_id DESC LIMIT \\$10").
+			WithArgs(username, "CREDIT", "sender", "TRANSFER", uint64(10), uint64(500), filter.From, now, int64(10), 21).
+			WillReturnRows(pgxmock.NewRows(columns))
+
+		entries, err := repo.GetUserLedger(ctx, filter)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("ошибка запроса", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM user_ledger").
+			WithArgs(username).
+			WillReturnError(pgx.ErrTxClosed)
+
+		_, err := repo.GetUserLedger(ctx, domain.LedgerFilter{Username: username})
+		assert.Error(t, err)
+	})
+}
+
+func TestStreamStatement(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"username", "opening", "closing", "transaction_id", "counterparty", "direction", "amount", "transfer_type", "item_name", "quantity", "timestamp"}
+
+	t.Run("строки читаются из курсора", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTransactionRepository(mock)
+		id := int64(5)
+		ts := from.Add(time.Hour)
+
+		mock.ExpectBegin()
+		expectStatementTimeouts(mock)
+		mock.ExpectExec("DECLARE statement_cursor NO SCROLL CURSOR FOR (.+) AND u.username = \\$3").
+			WithArgs(from, to, "alice").
+			WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("FETCH 500 FROM statement_cursor").
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow("alice", int64(1000), int64(920), &id, "SHOP", domain.LedgerDebit, uint64(80), domain.TransactionTypePurchase, "t-shirt", uint64(1), &ts))
+		mock.ExpectRollback()
+
+		var rows []*domain.StatementRow
+		err = repo.StreamStatement(context.Background(), domain.StatementFilter{Username: "alice", From: from, To: to}, func(row *domain.StatementRow) error {
+			rows = append(rows, row)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, int64(1000), rows[0].OpeningBalance)
+		require.NotNil(t, rows[0].Entry)
+		assert.Equal(t, int64(5), rows[0].Entry.TransactionId)
+		assert.Equal(t, "t-shirt", rows[0].Entry.ItemName)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("пользователь без операций", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTransactionRepository(mock)
+
+		mock.ExpectBegin()
+		expectStatementTimeouts(mock)
+		mock.ExpectExec("DECLARE statement_cursor").
+			WithArgs(from, to).
+			WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("FETCH 500 FROM statement_cursor").
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow("bob", int64(500), int64(500), nil, "", domain.LedgerDirection(""), uint64(0), domain.TransactionType(""), "", uint64(0), nil))
+		mock.ExpectRollback()
+
+		var rows []*domain.StatementRow
+		err = repo.StreamStatement(context.Background(), domain.StatementFilter{From: from, To: to}, func(row *domain.StatementRow) error {
+			rows = append(rows, row)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Nil(t, rows[0].Entry)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ошибка записи прерывает чтение", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTransactionRepository(mock)
+		writeErr := errors.New("client disconnected")
+
+		mock.ExpectBegin()
+		expectStatementTimeouts(mock)
+		mock.ExpectExec("DECLARE statement_cursor").
+			WithArgs(from, to).
+			WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("FETCH 500 FROM statement_cursor").
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow("bob", int64(500), int64(500), nil, "", domain.LedgerDirection(""), uint64(0), domain.TransactionType(""), "", uint64(0), nil))
+		mock.ExpectRollback()
+
+		err = repo.StreamStatement(context.Background(), domain.StatementFilter{From: from, To: to}, func(row *domain.StatementRow) error {
+			return writeErr
+		})
+
+		require.ErrorIs(t, err, writeErr)
+	})
+
+	t.Run("медленный клиент не обрывает выгрузку", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTransactionRepository(mock)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		full := pgxmock.NewRows(columns)
+		for i := 0; i < statementFetchSize; i++ {
+			full.AddRow("bob", int64(500), int64(500), nil, "", domain.LedgerDirection(""), uint64(0), domain.TransactionType(""), "", uint64(0), nil)
+		}
+
+		mock.ExpectBegin()
+		// Таймауты рассчитываются по сроку выгрузки, а не берутся из настроек пула
+		mock.ExpectExec("SELECT set_config\\('statement_timeout', \\$1, true\\), set_config\\('idle_in_transaction_session_timeout', \\$1, true\\)").
+			WithArgs(statementTimeoutAbove(9 * time.Minute)).
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectExec("DECLARE statement_cursor").
+			WithArgs(from, to).
+			WillReturnResult(pgxmock.NewResult("DECLARE CURSOR", 0))
+		mock.ExpectQuery("FETCH 500 FROM statement_cursor").WillReturnRows(full)
+		mock.ExpectQuery("FETCH 500 FROM statement_cursor").
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow("carol", int64(100), int64(100), nil, "", domain.LedgerDirection(""), uint64(0), domain.TransactionType(""), "", uint64(0), nil))
+		mock.ExpectRollback()
+
+		var count int
+		err = repo.StreamStatement(ctx, domain.StatementFilter{From: from, To: to}, func(row *domain.StatementRow) error {
+			count++
+			if count == statementFetchSize {
+				// Клиент перестает принимать данные между порциями
+				time.Sleep(50 * time.Millisecond)
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, statementFetchSize+1, count)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// expectStatementTimeouts ожидает установку таймаутов транзакции выписки
+func expectStatementTimeouts(mock pgxmock.PgxPoolIface) {
+	mock.ExpectExec("SELECT set_config\\('statement_timeout'").
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+}
+
+// statementTimeoutAbove проверяет, что таймаут в миллисекундах больше min
+type statementTimeoutAbove time.Duration
+
+func (a statementTimeoutAbove) Match(v interface{}) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	return err == nil && time.Duration(ms)*time.Millisecond > time.Duration(a)
+}
+
 func TestExecuteTransfer(t *testing.T) {
 	t.Run("успешный перевод", func(t *testing.T) {
 		// Arrange
@@ -131,7 +355,7 @@ func TestExecuteTransfer(t *testing.T) {
 		mock.ExpectCommit()
 
 		// Act
-		err = repo.ExecuteTransfer(ctx, sender, receiver, amount)
+		err = repo.ExecuteTransfer(ctx, sender, receiver, amount, nil)
 
 		// Assert
 		require.NoError(t, err)
@@ -168,12 +392,51 @@ func TestExecuteTransfer(t *testing.T) {
 		mock.ExpectRollback()
 
 		// Act
-		err = repo.ExecuteTransfer(ctx, sender, receiver, amount)
+		err = repo.ExecuteTransfer(ctx, sender, receiver, amount, nil)
 
 		// Assert
 		require.ErrorIs(t, err, domain.ErrInsufficientFunds)
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("ключ идемпотентности уже использован", func(t *testing.T) {
+		// Arrange
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTransactionRepository(mock)
+		ctx := context.Background()
+		idem := domain.NewIdempotencyRecord("sender", "key-1", "hash", time.Hour)
+
+		// Начало транзакции
+		mock.ExpectBegin()
+
+		// Ключ уже сохранен параллельным запросом
+		mock.ExpectExec("INSERT INTO idempotency_keys").
+			WithArgs(idem.Username, idem.Key, idem.RequestHash, idem.StatusCode, idem.Response, idem.CreatedAt, idem.ExpiresAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+		// Балансы не меняются, транзакция откатывается
+		mock.ExpectRollback()
+
+		// Act
+		err = repo.ExecuteTransfer(ctx, "sender", "receiver", uint64(100), idem)
+
+		// Assert
+		require.ErrorIs(t, err, domain.ErrIdempotencyKeyUsed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// expectPurchasableMerch ожидает проверку цены и доступности товара под блокировкой
+func expectPurchasableMerch(mock pgxmock.PgxPoolIface, name string, price uint64, purchasable bool) {
+	mock.ExpectQuery("SELECT price, available AND retired_at IS NULL FROM merch WHERE name = \\$1 FOR SHARE").
+		WithArgs(name).
+		WillReturnRows(pgxmock.NewRows([]string{"price", "purchasable"}).AddRow(price, purchasable))
+	if purchasable {
+		expectPriceSchedules(mock, []string{name}, pgxmock.AnyArg())
+	}
 }
 
 func TestExecutePurchase(t *testing.T) {
@@ -196,15 +459,23 @@ func TestExecutePurchase(t *testing.T) {
 		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
 			WithArgs(price, username).
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
-		mock.ExpectExec("INSERT INTO user_inventory \\(username, item_name, quantity\\) VALUES \\(\\$1, \\$2, 1\\) ON CONFLICT \\(username, item_name\\) DO UPDATE SET quantity = user_inventory.quantity \\+ 1").
-			WithArgs(username, merchName).
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs(merchName, uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery("SELECT stock IS NOT NULL FROM merch WHERE name = \\$1").
+			WithArgs(merchName).
+			WillReturnRows(pgxmock.NewRows([]string{"limited"}).AddRow(false))
+		expectPurchasableMerch(mock, merchName, price, true)
+		expectInsertOrder(mock, username, price, 0, pgxmock.AnyArg(), 1, &domain.OrderItem{ItemName: merchName, Quantity: 1, UnitPrice: price})
+		mock.ExpectExec("INSERT INTO user_inventory \\(username, item_name, quantity\\) VALUES \\(\\$1, \\$2, \\$3\\) ON CONFLICT \\(username, item_name\\) DO UPDATE SET quantity = user_inventory.quantity \\+ EXCLUDED.quantity").
+			WithArgs(username, merchName, uint64(1)).
 			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 		mock.ExpectExec("INSERT INTO transactions").
-			WithArgs(username, "SHOP", price, domain.TransactionTypePurchase, pgxmock.AnyArg()).
+			WithArgs(username, "SHOP", price, domain.TransactionTypePurchase, merchName, uint64(1), pgxmock.AnyArg(), pgxmock.AnyArg()).
 			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 		mock.ExpectCommit()
 
-		err := repo.ExecutePurchase(ctx, username, merchName, price)
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: username, ItemName: merchName, Quantity: 1, UnitPrice: price}, nil)
 		assert.NoError(t, err)
 	})
 
@@ -222,7 +493,438 @@ func TestExecutePurchase(t *testing.T) {
 			WillReturnError(pgx.ErrTxClosed)
 		mock.ExpectRollback()
 
-		err := repo.ExecutePurchase(ctx, username, merchName, price)
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: username, ItemName: merchName, Quantity: 1, UnitPrice: price}, nil)
 		assert.Error(t, err)
 	})
+
+	t.Run("покупка нескольких единиц", func(t *testing.T) {
+		username := "buyer"
+		merchName := "cup"
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs(username).
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(uint64(60), username).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs(merchName, uint64(3)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectPurchasableMerch(mock, merchName, 20, true)
+		expectInsertOrder(mock, username, 60, 0, pgxmock.AnyArg(), 1, &domain.OrderItem{ItemName: merchName, Quantity: 3, UnitPrice: 20})
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs(username, merchName, uint64(3)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs(username, "SHOP", uint64(60), domain.TransactionTypePurchase, merchName, uint64(3), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: username, ItemName: merchName, Quantity: 3, UnitPrice: 20}, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("недостаточно средств на несколько единиц", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(50)))
+		mock.ExpectRollback()
+
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: "buyer", ItemName: "cup", Quantity: 3, UnitPrice: 20}, nil)
+		assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("покупка товара с ограниченным количеством", func(t *testing.T) {
+		username := "buyer"
+		merchName := "limited"
+		price := uint64(100)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs(username).
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(price, username).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs(merchName, uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectPurchasableMerch(mock, merchName, price, true)
+		expectInsertOrder(mock, username, price, 0, pgxmock.AnyArg(), 1, &domain.OrderItem{ItemName: merchName, Quantity: 1, UnitPrice: price})
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs(username, merchName, uint64(1)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs(username, "SHOP", price, domain.TransactionTypePurchase, merchName, uint64(1), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: username, ItemName: merchName, Quantity: 1, UnitPrice: price}, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("товар закончился", func(t *testing.T) {
+		username := "buyer"
+		merchName := "limited"
+		price := uint64(100)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs(username).
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(price, username).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs(merchName, uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery("SELECT stock IS NOT NULL FROM merch WHERE name = \\$1").
+			WithArgs(merchName).
+			WillReturnRows(pgxmock.NewRows([]string{"limited"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: username, ItemName: merchName, Quantity: 1, UnitPrice: price}, nil)
+		assert.ErrorIs(t, err, domain.ErrOutOfStock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("цена изменилась после кэширования", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(uint64(100), "buyer").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs("cup", uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectPurchasableMerch(mock, "cup", 150, true)
+		mock.ExpectRollback()
+
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: "buyer", ItemName: "cup", Quantity: 1, UnitPrice: 100}, nil)
+		assert.ErrorIs(t, err, domain.ErrPriceChanged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("товар снят с продажи", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(uint64(100), "buyer").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2 WHERE name = \\$1 AND stock >= \\$2").
+			WithArgs("cup", uint64(1)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectPurchasableMerch(mock, "cup", 100, false)
+		mock.ExpectRollback()
+
+		err := repo.ExecutePurchase(ctx, &domain.Purchase{Username: "buyer", ItemName: "cup", Quantity: 1, UnitPrice: 100}, nil)
+		assert.ErrorIs(t, err, domain.ErrMerchUnavailable)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestExecutePurchase_PromoCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTransactionRepository(mock)
+	ctx := context.Background()
+	limit := uint64(1)
+	promo := &domain.PromoCode{Code: "FIRST-10", DiscountType: domain.PromoDiscountFixed, DiscountValue: 10, MaxPerUser: &limit}
+	purchase := func() *domain.Purchase {
+		return &domain.Purchase{Username: "buyer", ItemName: "cup", Quantity: 3, UnitPrice: 20, PromoCode: "FIRST-10", Discount: 10}
+	}
+
+	expectPurchase := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT coins FROM users WHERE username = \\$1 FOR UPDATE").
+			WithArgs("buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"coins"}).AddRow(uint64(1000)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(uint64(50), "buyer").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2").
+			WithArgs("cup", uint64(3)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectPurchasableMerch(mock, "cup", 20, true)
+		expectInsertOrder(mock, "buyer", 50, 10, pgxmock.AnyArg(), 1, &domain.OrderItem{ItemName: "cup", Quantity: 3, UnitPrice: 20})
+		mock.ExpectQuery("SELECT .+ FROM promo_codes WHERE code = \\$1 FOR UPDATE").
+			WithArgs("FIRST-10").
+			WillReturnRows(promoCodeRows(promo))
+	}
+
+	t.Run("покупка со скидкой", func(t *testing.T) {
+		expectPurchase()
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM promo_redemptions WHERE code = \\$1 AND username = \\$2").
+			WithArgs("FIRST-10", "buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(uint64(0)))
+		mock.ExpectExec("UPDATE promo_codes SET redemptions = redemptions \\+ 1 WHERE code = \\$1").
+			WithArgs("FIRST-10").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO promo_redemptions").
+			WithArgs("FIRST-10", "buyer", int64(1), uint64(10), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs("buyer", "cup", uint64(3)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("buyer", "SHOP", uint64(50), domain.TransactionTypePurchase, "cup", uint64(3), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.ExecutePurchase(ctx, purchase(), nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("пользователь уже использовал промокод", func(t *testing.T) {
+		expectPurchase()
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM promo_redemptions").
+			WithArgs("FIRST-10", "buyer").
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(uint64(1)))
+		mock.ExpectRollback()
+
+		err := repo.ExecutePurchase(ctx, purchase(), nil)
+		assert.ErrorIs(t, err, domain.ErrPromoCodeExhausted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestExecuteGift(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTransactionRepository(mock)
+	ctx := context.Background()
+	gift := func(message string) *domain.Gift {
+		return &domain.Gift{
+			Purchase:  domain.Purchase{Username: "alice", ItemName: "cup", Quantity: 2, UnitPrice: 20},
+			Recipient: "bob",
+			Message:   message,
+		}
+	}
+	expectLock := func(rows *pgxmock.Rows) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT username, coins FROM users WHERE username = ANY\\(\\$1\\) ORDER BY username FOR UPDATE").
+			WithArgs([]string{"alice", "bob"}).
+			WillReturnRows(rows)
+	}
+
+	t.Run("подарок с сообщением", func(t *testing.T) {
+		message := "С днем рождения!"
+		expectLock(pgxmock.NewRows([]string{"username", "coins"}).AddRow("alice", uint64(100)).AddRow("bob", uint64(0)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1 WHERE username = \\$2").
+			WithArgs(uint64(40), "alice").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectPurchasableMerch(mock, "cup", 20, true)
+		expectInsertGiftOrder(mock, "alice", "bob", 40, 0, pgxmock.AnyArg(), 3, &domain.OrderItem{ItemName: "cup", Quantity: 2, UnitPrice: 20})
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs("bob", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("alice", "bob", uint64(40), domain.TransactionTypeGift, "cup", uint64(2), &message, int64(3), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := repo.ExecuteGift(ctx, gift(message), nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("цена изменилась после кэширования", func(t *testing.T) {
+		expectLock(pgxmock.NewRows([]string{"username", "coins"}).AddRow("alice", uint64(100)).AddRow("bob", uint64(0)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1").
+			WithArgs(uint64(40), "alice").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		expectPurchasableMerch(mock, "cup", 25, true)
+		mock.ExpectRollback()
+
+		err := repo.ExecuteGift(ctx, gift(""), nil)
+		assert.ErrorIs(t, err, domain.ErrPriceChanged)
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		expectLock(pgxmock.NewRows([]string{"username", "coins"}).AddRow("alice", uint64(100)))
+		mock.ExpectRollback()
+
+		err := repo.ExecuteGift(ctx, gift(""), nil)
+		assert.ErrorIs(t, err, domain.ErrRecipientNotFound)
+	})
+
+	t.Run("недостаточно средств", func(t *testing.T) {
+		expectLock(pgxmock.NewRows([]string{"username", "coins"}).AddRow("alice", uint64(30)).AddRow("bob", uint64(0)))
+		mock.ExpectRollback()
+
+		err := repo.ExecuteGift(ctx, gift(""), nil)
+		assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	})
+
+	t.Run("товар закончился", func(t *testing.T) {
+		expectLock(pgxmock.NewRows([]string{"username", "coins"}).AddRow("alice", uint64(100)).AddRow("bob", uint64(0)))
+		mock.ExpectExec("UPDATE users SET coins = coins - \\$1").
+			WithArgs(uint64(40), "alice").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("UPDATE merch SET stock = stock - \\$2").
+			WithArgs("cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectQuery("SELECT stock IS NOT NULL FROM merch WHERE name = \\$1").
+			WithArgs("cup").
+			WillReturnRows(pgxmock.NewRows([]string{"limited"}).AddRow(true))
+		mock.ExpectRollback()
+
+		err := repo.ExecuteGift(ctx, gift(""), nil)
+		assert.ErrorIs(t, err, domain.ErrOutOfStock)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecuteItemTransfer(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewTransactionRepository(mock)
+	ctx := context.Background()
+	transfer := &domain.ItemTransfer{From: "alice", To: "bob", ItemName: "cup", Quantity: 2}
+	retiredAt := time.Now()
+
+	expectUsers := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT username, coins FROM users WHERE username = ANY\\(\\$1\\) ORDER BY username FOR UPDATE").
+			WithArgs([]string{"alice", "bob"}).
+			WillReturnRows(pgxmock.NewRows([]string{"username", "coins"}).AddRow("alice", uint64(100)).AddRow("bob", uint64(0)))
+	}
+	expectMerch := func(transferable bool, retiredAt *time.Time) {
+		mock.ExpectQuery("SELECT (.+) FROM merch WHERE name = \\$1 FOR SHARE").
+			WithArgs("cup").
+			WillReturnRows(pgxmock.NewRows(merchRowColumns).
+				AddRow("cup", uint64(20), "Кружка", "tableware", true, retiredAt, nil, transferable))
+	}
+	expectInventoryLock := func() {
+		mock.ExpectQuery("SELECT username FROM user_inventory WHERE item_name = \\$1 AND username = ANY\\(\\$2\\) ORDER BY username FOR UPDATE").
+			WithArgs("cup", []string{"alice", "bob"}).
+			WillReturnRows(pgxmock.NewRows([]string{"username"}).AddRow("alice"))
+	}
+
+	t.Run("успешная передача", func(t *testing.T) {
+		expectUsers()
+		expectMerch(true, nil)
+		expectInventoryLock()
+		mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - \\$3 WHERE username = \\$1 AND item_name = \\$2 AND quantity >= \\$3").
+			WithArgs("alice", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec("INSERT INTO user_inventory").
+			WithArgs("bob", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("alice", "bob", 0, domain.TransactionTypeItemTransfer, "cup", uint64(2), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err := repo.ExecuteItemTransfer(ctx, transfer, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("недостаточно товара", func(t *testing.T) {
+		expectUsers()
+		expectMerch(true, nil)
+		expectInventoryLock()
+		mock.ExpectExec("UPDATE user_inventory SET quantity = quantity - \\$3").
+			WithArgs("alice", "cup", uint64(2)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectRollback()
+
+		err := repo.ExecuteItemTransfer(ctx, transfer, nil)
+		assert.ErrorIs(t, err, domain.ErrInventoryShortage)
+	})
+
+	t.Run("непередаваемый товар", func(t *testing.T) {
+		expectUsers()
+		expectMerch(false, nil)
+		mock.ExpectRollback()
+
+		err := repo.ExecuteItemTransfer(ctx, transfer, nil)
+		assert.ErrorIs(t, err, domain.ErrItemNotTransferable)
+	})
+
+	t.Run("товар снят с продажи", func(t *testing.T) {
+		expectUsers()
+		expectMerch(true, &retiredAt)
+		mock.ExpectRollback()
+
+		err := repo.ExecuteItemTransfer(ctx, transfer, nil)
+		assert.ErrorIs(t, err, domain.ErrItemNotTransferable)
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT username, coins FROM users").
+			WithArgs([]string{"alice", "bob"}).
+			WillReturnRows(pgxmock.NewRows([]string{"username", "coins"}).AddRow("alice", uint64(100)))
+		mock.ExpectRollback()
+
+		err := repo.ExecuteItemTransfer(ctx, transfer, nil)
+		assert.ErrorIs(t, err, domain.ErrRecipientNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecuteGrant(t *testing.T) {
+	t.Run("успешное начисление", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTransactionRepository(mock)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users SET coins = coins \\+ \\$1 WHERE username = \\$2").
+			WithArgs(uint64(100), "employee").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		// Отправитель — магазин: у автора начисления не появляется списание в user_ledger
+		mock.ExpectExec("INSERT INTO transactions \\(sender_name, receiver_name, amount, transfer_type, granted_by, timestamp\\) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6\\)").
+			WithArgs(domain.ShopAccount, "employee", uint64(100), domain.TransactionTypeGrant, "hr-bot", pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		err = repo.ExecuteGrant(context.Background(), "hr-bot", "employee", 100)
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewTransactionRepository(mock)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users SET coins = coins \\+ \\$1 WHERE username = \\$2").
+			WithArgs(uint64(100), "unknown").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectRollback()
+
+		err = repo.ExecuteGrant(context.Background(), "hr-bot", "unknown", 100)
+
+		require.ErrorIs(t, err, domain.ErrRecipientNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
 }
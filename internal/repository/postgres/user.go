@@ -54,8 +54,8 @@ func (u *user) CreateUser(ctx context.Context, user *domain.User) error {
 
 	// Создаем пользователя
 	_, err = tx.Exec(ctx,
-		"INSERT INTO users (username, password, coins) VALUES ($1, $2, $3)",
-		user.Username, user.Password, user.Coins,
+		"INSERT INTO users (username, password, coins, role) VALUES ($1, $2, $3, $4)",
+		user.Username, user.Password, user.Coins, string(user.Role),
 	)
 	if err != nil {
 		if pgErr, ok := err.(*pgconn.PgError); ok {
@@ -132,10 +132,11 @@ func (u *user) GetUserByUsername(ctx context.Context, username string) (*domain.
 	const op = "UserRepository.GetUserByUsername"
 
 	user := &domain.User{}
+	var role string
 	err := u.db.QueryRow(ctx,
-		"SELECT username, password, coins FROM users WHERE username = $1",
+		"SELECT username, password, coins, role FROM users WHERE username = $1",
 		username,
-	).Scan(&user.Username, &user.Password, &user.Coins)
+	).Scan(&user.Username, &user.Password, &user.Coins, &role)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -146,6 +147,7 @@ func (u *user) GetUserByUsername(ctx context.Context, username string) (*domain.
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	user.Role = domain.Role(role)
 
 	return user, nil
 }
@@ -203,3 +205,39 @@ func (u *user) UpdateUser(ctx context.Context, user *domain.User) error {
 	}
 	return nil
 }
+
+// UpdateUserRole изменяет роль пользователя
+func (u *user) UpdateUserRole(ctx context.Context, username string, role domain.Role) error {
+	const op = "UserRepository.UpdateUserRole"
+
+	result, err := u.db.Exec(ctx,
+		"UPDATE users SET role = $1 WHERE username = $2",
+		string(role), username,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUserPassword заменяет хэш пароля пользователя
+func (u *user) UpdateUserPassword(ctx context.Context, username string, password []byte) error {
+	const op = "UserRepository.UpdateUserPassword"
+
+	result, err := u.db.Exec(ctx,
+		"UPDATE users SET password = $1 WHERE username = $2",
+		password, username,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
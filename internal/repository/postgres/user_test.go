@@ -29,10 +29,10 @@ func TestGetUserInfo(t *testing.T) {
 		}
 
 		// Настройка ожиданий
-		mock.ExpectQuery("SELECT username, password, coins FROM users WHERE username = \\$1").
+		mock.ExpectQuery("SELECT username, password, coins, role FROM users WHERE username = \\$1").
 			WithArgs(username).
-			WillReturnRows(pgxmock.NewRows([]string{"username", "password", "coins"}).
-				AddRow(username, []byte("hashedpassword"), uint64(1000)))
+			WillReturnRows(pgxmock.NewRows([]string{"username", "password", "coins", "role"}).
+				AddRow(username, []byte("hashedpassword"), uint64(1000), "employee"))
 
 		// Добавляем ожидание для запроса инвентаря
 		mock.ExpectQuery("SELECT item_name, quantity FROM user_inventory WHERE username = \\$1").
@@ -50,6 +50,7 @@ func TestGetUserInfo(t *testing.T) {
 		require.Equal(t, expectedUser.Username, user.Username)
 		require.Equal(t, expectedUser.Password, user.Password)
 		require.Equal(t, expectedUser.Coins, user.Coins)
+		require.Equal(t, domain.RoleEmployee, user.Role)
 		require.Len(t, user.Inventory, 2)
 		require.Equal(t, "item1", user.Inventory[0].Type)
 		require.Equal(t, 1, user.Inventory[0].Quantity)
@@ -68,9 +69,9 @@ func TestGetUserInfo(t *testing.T) {
 		username := "nonexistent"
 
 		// Настройка ожиданий
-		mock.ExpectQuery("SELECT username, password, coins FROM users WHERE username = \\$1").
+		mock.ExpectQuery("SELECT username, password, coins, role FROM users WHERE username = \\$1").
 			WithArgs(username).
-			WillReturnRows(pgxmock.NewRows([]string{"username", "password", "coins"}))
+			WillReturnRows(pgxmock.NewRows([]string{"username", "password", "coins", "role"}))
 
 		// Действие
 		user, err := repo.GetUserInfo(context.Background(), username)
@@ -178,3 +179,39 @@ func TestUpdateUser(t *testing.T) {
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	t.Run("успешное изменение роли", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewUserRepository(mock)
+
+		mock.ExpectExec("UPDATE users SET role = \\$1 WHERE username = \\$2").
+			WithArgs("admin", "testuser").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+		err = repo.UpdateUserRole(context.Background(), "testuser", domain.RoleAdmin)
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("пользователь не найден", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+		defer mock.Close()
+
+		repo := NewUserRepository(mock)
+
+		mock.ExpectExec("UPDATE users SET role = \\$1 WHERE username = \\$2").
+			WithArgs("admin", "nonexistent").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+		err = repo.UpdateUserRole(context.Background(), "nonexistent", domain.RoleAdmin)
+
+		require.ErrorIs(t, err, domain.ErrUserNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
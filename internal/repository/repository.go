@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/netscrawler/avito-shop/internal/domain"
 )
@@ -13,18 +14,117 @@ type UserRepository interface {
 	GetUserInfo(ctx context.Context, username string) (*domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) error
 	UpdateUserInventory(ctx context.Context, user *domain.User, item string, quantity int) error
+	UpdateUserRole(ctx context.Context, username string, role domain.Role) error
+	UpdateUserPassword(ctx context.Context, username string, password []byte) error
 }
 
 // TransactionRepository определяет методы для работы с транзакциями
 type TransactionRepository interface {
 	CreateTransaction(ctx context.Context, transaction *domain.Transaction) error
 	GetUserTransactions(ctx context.Context, username string) ([]*domain.Transaction, error)
-	ExecuteTransfer(ctx context.Context, fromUsername, toUsername string, amount uint64) error
-	ExecutePurchase(ctx context.Context, username string, merchName string, price uint64) error
+	GetUserLedger(ctx context.Context, filter domain.LedgerFilter) ([]*domain.LedgerEntry, error)
+	StreamStatement(ctx context.Context, filter domain.StatementFilter, fn func(*domain.StatementRow) error) error
+	ExecuteTransfer(ctx context.Context, fromUsername, toUsername string, amount uint64, idem *domain.IdempotencyRecord) error
+	ExecutePurchase(ctx context.Context, purchase *domain.Purchase, idem *domain.IdempotencyRecord) error
+	ExecuteGrant(ctx context.Context, granter, toUsername string, amount uint64) error
+	ExecuteGift(ctx context.Context, gift *domain.Gift, idem *domain.IdempotencyRecord) error
+	ExecuteItemTransfer(ctx context.Context, transfer *domain.ItemTransfer, idem *domain.IdempotencyRecord) error
 }
 
 // MerchRepository определяет методы для работы с товарами
 type MerchRepository interface {
 	GetMerchByName(ctx context.Context, name string) (*domain.Merch, error)
 	GetAllMerch(ctx context.Context) ([]*domain.Merch, error)
+	CreateMerch(ctx context.Context, item *domain.Merch) error
+	UpdateMerch(ctx context.Context, name string, update domain.MerchUpdate) (*domain.Merch, error)
+	RetireMerch(ctx context.Context, name string, now time.Time) error
+	RestockMerch(ctx context.Context, name string, quantity uint64) (*domain.Merch, error)
+	CreatePriceSchedule(ctx context.Context, schedule *domain.PriceSchedule) error
+	CancelPriceSchedule(ctx context.Context, name string, id int64, now time.Time) error
+	GetPriceHistory(ctx context.Context, name string) (*domain.PriceHistory, error)
+}
+
+// PromoCodeRepository определяет методы для работы с промокодами
+type PromoCodeRepository interface {
+	CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error
+	GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error)
+	GetPromoCodes(ctx context.Context) ([]*domain.PromoCode, error)
+	DeactivatePromoCode(ctx context.Context, code string, now time.Time) error
+}
+
+// CartRepository определяет методы для работы с корзиной и оформления заказов
+type CartRepository interface {
+	GetCart(ctx context.Context, username string) (*domain.Cart, error)
+	AddCartItem(ctx context.Context, username string, item *domain.CartItem) error
+	RemoveCartItem(ctx context.Context, username, itemName string) error
+	RefreshCartPrices(ctx context.Context, username string, now time.Time) error
+	ExecuteCheckout(ctx context.Context, username string, now time.Time, idem *domain.IdempotencyRecord) (*domain.Order, error)
+}
+
+// OrderRepository определяет методы для работы с заказами и их статусами
+type OrderRepository interface {
+	GetOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error)
+	GetOrder(ctx context.Context, id int64) (*domain.Order, error)
+	UpdateOrderStatus(ctx context.Context, change *domain.OrderStatusChange) error
+}
+
+// TokenRepository определяет методы для работы с refresh-токенами и списком отозванных JWT
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, tokenHash string, next *domain.RefreshToken) (*domain.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, username, tokenHash string) error
+	RevokeUserRefreshTokens(ctx context.Context, username string) error
+	RevokeUserAccessTokens(ctx context.Context, username string, issuedBefore time.Time) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti, username string, issuedAt time.Time) (bool, error)
+	DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error)
+}
+
+// LoginAttemptRepository определяет методы для учета неудачных попыток входа
+type LoginAttemptRepository interface {
+	GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error)
+	RecordLoginFailure(ctx context.Context, key string, now time.Time, resetAfter time.Duration) (*domain.LoginAttempts, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+	DeleteStaleLoginAttempts(ctx context.Context, now time.Time, resetAfter time.Duration) (int64, error)
+}
+
+// PasswordResetRepository определяет методы для работы с токенами сброса пароля
+type PasswordResetRepository interface {
+	CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error
+	ResetPassword(ctx context.Context, tokenHash string, password []byte, now time.Time) (string, error)
+}
+
+// MFARepository определяет методы для работы с TOTP-аутентификаторами и кодами восстановления
+type MFARepository interface {
+	GetMFAFactor(ctx context.Context, username string) (*domain.MFAFactor, error)
+	SaveMFASecret(ctx context.Context, username, secret string) error
+	EnableMFA(ctx context.Context, username string, recoveryCodeHashes []string) error
+	UseMFAStep(ctx context.Context, username string, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, username, codeHash string) (bool, error)
+}
+
+// APIKeyRepository определяет методы для работы с API-ключами сервисных учетных записей
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *domain.APIKey) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, account string) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64, now time.Time) error
+	TouchAPIKey(ctx context.Context, id int64, now time.Time) error
+}
+
+// IdempotencyRepository определяет методы для работы с результатами запросов по ключам идемпотентности
+type IdempotencyRepository interface {
+	GetIdempotencyRecord(ctx context.Context, username, key string) (*domain.IdempotencyRecord, error)
+	SaveIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error
+	DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error)
+}
+
+// MarketRepository определяет методы для работы с лотами маркетплейса
+type MarketRepository interface {
+	CreateListing(ctx context.Context, listing *domain.Listing) error
+	GetListing(ctx context.Context, id int64) (*domain.Listing, error)
+	GetListings(ctx context.Context, filter domain.ListingFilter) ([]*domain.Listing, error)
+	CancelListing(ctx context.Context, id int64, seller string, now time.Time) (*domain.Listing, error)
+	BuyListing(ctx context.Context, id int64, buyer string, feePercent uint64, now time.Time, idem *domain.IdempotencyRecord) (*domain.Listing, error)
 }
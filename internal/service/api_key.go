@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// apiKeyTouchInterval ограничивает частоту обновления времени последнего использования ключа
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService управляет сервисными учетными записями и их API-ключами
+type apiKeyService struct {
+	repo  repository.APIKeyRepository
+	users repository.UserRepository
+	now   func() time.Time
+}
+
+// NewAPIKeyService создает новый экземпляр сервиса API-ключей
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository) APIKeyService {
+	return &apiKeyService{
+		repo:  repo,
+		users: users,
+		now:   time.Now,
+	}
+}
+
+// CreateAPIKey выпускает API-ключ сервисной учетной записи, создавая ее при необходимости.
+// Значение ключа возвращается только один раз, в базе хранится его хэш.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, account, name string, scopes []string, expiresAt *time.Time) (string, *domain.APIKey, error) {
+	const op = "APIKeyService.CreateAPIKey"
+
+	if err := domain.ValidateUsername(account); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := domain.ValidateScopes(scopes); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := s.now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", nil, fmt.Errorf("%s: %w: срок действия уже истек", op, domain.ErrInvalidAPIKey)
+	}
+
+	if err := s.ensureServiceAccount(ctx, account); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rawKey, prefix, err := generateAPIKey()
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: генерация ключа: %w", op, err)
+	}
+
+	key := &domain.APIKey{
+		Account:   account,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: выпущен API-ключ %s для %s с областями %v", op, prefix, account, scopes)
+	return rawKey, key, nil
+}
+
+// ListAPIKeys возвращает ключи сервисной учетной записи или все ключи, если account пуст
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, account string) ([]*domain.APIKey, error) {
+	const op = "APIKeyService.ListAPIKeys"
+
+	keys, err := s.repo.ListAPIKeys(ctx, account)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	const op = "APIKeyService.RevokeAPIKey"
+
+	if err := s.repo.RevokeAPIKey(ctx, id, s.now()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: API-ключ %d отозван", op, id)
+	return nil
+}
+
+// AuthenticateAPIKey проверяет предъявленный ключ и возвращает его описание
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	const op = "APIKeyService.AuthenticateAPIKey"
+
+	prefix, ok := parseAPIKeyPrefix(rawKey)
+	if !ok {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashToken(rawKey)), []byte(key.KeyHash)) != 1 {
+		logrus.Warnf("%s: неверное значение API-ключа %s", op, prefix)
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	now := s.now()
+	if !key.IsActive(now) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repo.TouchAPIKey(ctx, key.Id, now); err != nil {
+			logrus.Errorf("%s: ошибка обновления времени использования ключа %s: %v", op, prefix, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
+
+// ensureServiceAccount создает сервисную учетную запись или проверяет, что имя принадлежит ей
+func (s *apiKeyService) ensureServiceAccount(ctx context.Context, account string) error {
+	user, err := s.users.GetUserByUsername(ctx, account)
+	if err == nil {
+		if user.Role != domain.RoleService {
+			return domain.ErrNotServiceAccount
+		}
+		return nil
+	}
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		return fmt.Errorf("получение учетной записи: %w", err)
+	}
+
+	// Пустой хэш не совпадает ни с одним паролем, поэтому войти по паролю нельзя
+	serviceAccount := domain.NewUser(account, []byte{}, 0)
+	serviceAccount.Role = domain.RoleService
+	if err := s.users.CreateUser(ctx, serviceAccount); err != nil {
+		if errors.Is(err, domain.ErrUserAlreadyExists) {
+			// Имя заняли параллельно: проверяем, кем
+			return s.ensureServiceAccount(ctx, account)
+		}
+		return fmt.Errorf("создание сервисной учетной записи: %w", err)
+	}
+
+	logrus.Infof("APIKeyService: создана сервисная учетная запись %s", account)
+	return nil
+}
+
+// generateAPIKey возвращает ключ вида ak_<prefix>_<secret> и его открытую часть
+func generateAPIKey() (string, string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix := domain.APIKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + secret, prefix, nil
+}
+
+// parseAPIKeyPrefix извлекает открытую часть из значения ключа
+func parseAPIKeyPrefix(rawKey string) (string, bool) {
+	rest, ok := strings.CutPrefix(rawKey, domain.APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return domain.APIKeyPrefix + id, true
+}
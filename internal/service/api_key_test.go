@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *mockAPIKeyRepo) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepo) ListAPIKeys(ctx context.Context, account string) ([]*domain.APIKey, error) {
+	args := m.Called(ctx, account)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.APIKey), args.Error(1)
+}
+
+func (m *mockAPIKeyRepo) RevokeAPIKey(ctx context.Context, id int64, now time.Time) error {
+	args := m.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyRepo) TouchAPIKey(ctx context.Context, id int64, now time.Time) error {
+	args := m.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+var testAPIKeyNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestAPIKeyService(repo *mockAPIKeyRepo, users *mockUserRepo) *apiKeyService {
+	service := NewAPIKeyService(repo, users).(*apiKeyService)
+	service.now = func() time.Time { return testAPIKeyNow }
+	return service
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	t.Run("создание сервисной учетной записи и ключа", func(t *testing.T) {
+		repo := new(mockAPIKeyRepo)
+		users := new(mockUserRepo)
+		service := newTestAPIKeyService(repo, users)
+
+		users.On("GetUserByUsername", mock.Anything, "hr-bot").Return(nil, domain.ErrUserNotFound)
+		users.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return user.Username == "hr-bot" && user.Role == domain.RoleService && user.Coins == 0
+		})).Return(nil)
+		repo.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*domain.APIKey")).Return(nil)
+
+		rawKey, key, err := service.CreateAPIKey(context.Background(), "hr-bot", "начисления", []string{domain.ScopeCoinsGrant}, nil)
+
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(rawKey, key.Prefix+"_"))
+		require.Equal(t, hashToken(rawKey), key.KeyHash)
+		require.NotContains(t, key.KeyHash, rawKey)
+		users.AssertExpectations(t)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("имя занято сотрудником", func(t *testing.T) {
+		repo := new(mockAPIKeyRepo)
+		users := new(mockUserRepo)
+		service := newTestAPIKeyService(repo, users)
+
+		users.On("GetUserByUsername", mock.Anything, "alice").
+			Return(&domain.User{Username: "alice", Role: domain.RoleEmployee}, nil)
+
+		_, _, err := service.CreateAPIKey(context.Background(), "alice", "", []string{domain.ScopeInfoRead}, nil)
+
+		require.ErrorIs(t, err, domain.ErrNotServiceAccount)
+		repo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("неизвестная область доступа", func(t *testing.T) {
+		service := newTestAPIKeyService(new(mockAPIKeyRepo), new(mockUserRepo))
+
+		_, _, err := service.CreateAPIKey(context.Background(), "hr-bot", "", []string{"coins:burn"}, nil)
+
+		require.ErrorIs(t, err, domain.ErrInvalidScope)
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	const rawKey = "ak_0a1b2c3d_secret-value"
+
+	t.Run("действующий ключ", func(t *testing.T) {
+		repo := new(mockAPIKeyRepo)
+		service := newTestAPIKeyService(repo, new(mockUserRepo))
+
+		repo.On("GetAPIKeyByPrefix", mock.Anything, "ak_0a1b2c3d").Return(&domain.APIKey{
+			Id:      7,
+			Account: "hr-bot",
+			KeyHash: hashToken(rawKey),
+			Scopes:  []string{domain.ScopeCoinsGrant},
+		}, nil)
+		repo.On("TouchAPIKey", mock.Anything, int64(7), testAPIKeyNow).Return(nil)
+
+		key, err := service.AuthenticateAPIKey(context.Background(), rawKey)
+
+		require.NoError(t, err)
+		require.Equal(t, "hr-bot", key.Account)
+		require.Equal(t, testAPIKeyNow, *key.LastUsedAt)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("недавно использованный ключ не обновляется", func(t *testing.T) {
+		repo := new(mockAPIKeyRepo)
+		service := newTestAPIKeyService(repo, new(mockUserRepo))
+		lastUsed := testAPIKeyNow.Add(-time.Second)
+
+		repo.On("GetAPIKeyByPrefix", mock.Anything, "ak_0a1b2c3d").Return(&domain.APIKey{
+			Id:         7,
+			KeyHash:    hashToken(rawKey),
+			LastUsedAt: &lastUsed,
+		}, nil)
+
+		_, err := service.AuthenticateAPIKey(context.Background(), rawKey)
+
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("неверный секрет", func(t *testing.T) {
+		repo := new(mockAPIKeyRepo)
+		service := newTestAPIKeyService(repo, new(mockUserRepo))
+
+		repo.On("GetAPIKeyByPrefix", mock.Anything, "ak_0a1b2c3d").Return(&domain.APIKey{
+			Id:      7,
+			KeyHash: hashToken(rawKey),
+		}, nil)
+
+		_, err := service.AuthenticateAPIKey(context.Background(), "ak_0a1b2c3d_wrong")
+
+		require.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+
+	t.Run("отозванный ключ", func(t *testing.T) {
+		repo := new(mockAPIKeyRepo)
+		service := newTestAPIKeyService(repo, new(mockUserRepo))
+		revokedAt := testAPIKeyNow.Add(-time.Hour)
+
+		repo.On("GetAPIKeyByPrefix", mock.Anything, "ak_0a1b2c3d").Return(&domain.APIKey{
+			Id:        7,
+			KeyHash:   hashToken(rawKey),
+			RevokedAt: &revokedAt,
+		}, nil)
+
+		_, err := service.AuthenticateAPIKey(context.Background(), rawKey)
+
+		require.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+
+	t.Run("неизвестный формат", func(t *testing.T) {
+		service := newTestAPIKeyService(new(mockAPIKeyRepo), new(mockUserRepo))
+
+		_, err := service.AuthenticateAPIKey(context.Background(), "not-a-key")
+
+		require.ErrorIs(t, err, domain.ErrInvalidAPIKey)
+	})
+}
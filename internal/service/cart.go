@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// cartService управляет корзиной пользователя и оформлением заказов
+type cartService struct {
+	cartRepo  repository.CartRepository
+	merchRepo repository.MerchRepository
+	cache     MerchCache
+	now       func() time.Time
+}
+
+// NewCartService создает новый экземпляр сервиса корзины
+func NewCartService(cartRepo repository.CartRepository, merchRepo repository.MerchRepository, cache MerchCache) CartService {
+	return &cartService{
+		cartRepo:  cartRepo,
+		merchRepo: merchRepo,
+		cache:     cache,
+		now:       time.Now,
+	}
+}
+
+// GetCart возвращает корзину пользователя
+func (s *cartService) GetCart(ctx context.Context, username string) (*domain.Cart, error) {
+	const op = "CartService.GetCart"
+
+	cart, err := s.cartRepo.GetCart(ctx, username)
+	if err != nil {
+		logrus.Errorf("%s: ошибка при получении корзины пользователя %s: %v", op, username, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cart, nil
+}
+
+// AddItem добавляет quantity единиц товара в корзину по текущей цене и возвращает обновленную корзину
+func (s *cartService) AddItem(ctx context.Context, username, itemName string, quantity uint64) (*domain.Cart, error) {
+	const op = "CartService.AddItem"
+
+	if err := domain.ValidatePurchaseQuantity(quantity); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	merch, err := s.merchRepo.GetMerchByName(ctx, itemName)
+	if err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		logrus.Errorf("%s: ошибка при получении товара %s: %v", op, itemName, err)
+		return nil, fmt.Errorf("%s: получение товара: %w", op, err)
+	}
+	if !merch.CanBePurchased() {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchUnavailable)
+	}
+
+	cart, err := s.GetCart(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if len(cart.Items) >= domain.MaxCartItems && !cartContains(cart, itemName) {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrCartFull)
+	}
+
+	now := s.now()
+	item := &domain.CartItem{
+		ItemName:  merch.Name,
+		Quantity:  quantity,
+		UnitPrice: merch.PriceAt(now),
+		AddedAt:   now,
+	}
+	if err := s.cartRepo.AddCartItem(ctx, username, item); err != nil {
+		if errors.Is(err, domain.ErrInvalidQuantity) || errors.Is(err, domain.ErrMerchNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при добавлении товара %s в корзину: %v", op, itemName, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.GetCart(ctx, username)
+}
+
+// RemoveItem удаляет товар из корзины и возвращает обновленную корзину
+func (s *cartService) RemoveItem(ctx context.Context, username, itemName string) (*domain.Cart, error) {
+	const op = "CartService.RemoveItem"
+
+	if err := s.cartRepo.RemoveCartItem(ctx, username, itemName); err != nil {
+		if errors.Is(err, domain.ErrCartItemNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrCartItemNotFound)
+		}
+		logrus.Errorf("%s: ошибка при удалении товара %s из корзины: %v", op, itemName, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.GetCart(ctx, username)
+}
+
+// Checkout оформляет заказ из всей корзины. Если цены изменились, заказ не оформляется,
+// а цены в корзине обновляются, чтобы пользователь увидел новую стоимость перед повтором.
+func (s *cartService) Checkout(ctx context.Context, username string, idem *domain.IdempotencyRecord) (*domain.Order, error) {
+	const op = "CartService.Checkout"
+
+	order, err := s.cartRepo.ExecuteCheckout(ctx, username, s.now(), idem)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPriceChanged):
+			if refreshErr := s.cartRepo.RefreshCartPrices(ctx, username, s.now()); refreshErr != nil {
+				logrus.Errorf("%s: ошибка при обновлении цен в корзине пользователя %s: %v", op, username, refreshErr)
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, domain.ErrOutOfStock):
+			// Кэшированные остатки товаров корзины могли устареть — сбрасываем их, чтобы каталог показал актуальные значения
+			s.invalidateCartItems(ctx, username)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, domain.ErrCartEmpty),
+			errors.Is(err, domain.ErrInsufficientFunds),
+			errors.Is(err, domain.ErrMerchUnavailable),
+			errors.Is(err, domain.ErrInvalidQuantity),
+			errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при оформлении заказа пользователя %s: %v", op, username, err)
+		return nil, fmt.Errorf("%s: оформление заказа: %w", op, err)
+	}
+
+	// Остатки товаров с ограниченным количеством изменились
+	names := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		names = append(names, item.ItemName)
+	}
+	s.cache.InvalidateMerch(names...)
+
+	logrus.Infof("%s: пользователь %s оформил заказ %d на сумму %d", op, username, order.Id, order.Total)
+	return order, nil
+}
+
+// invalidateCartItems сбрасывает кэш товаров, оставшихся в корзине после неудачного оформления
+func (s *cartService) invalidateCartItems(ctx context.Context, username string) {
+	const op = "CartService.invalidateCartItems"
+
+	cart, err := s.cartRepo.GetCart(ctx, username)
+	if err != nil {
+		logrus.Errorf("%s: ошибка при получении корзины пользователя %s: %v", op, username, err)
+		return
+	}
+
+	names := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		names = append(names, item.ItemName)
+	}
+	s.cache.InvalidateMerch(names...)
+}
+
+func cartContains(cart *domain.Cart, itemName string) bool {
+	for _, item := range cart.Items {
+		if item.ItemName == itemName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockCartRepo struct {
+	mock.Mock
+}
+
+func (m *mockCartRepo) GetCart(ctx context.Context, username string) (*domain.Cart, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Cart), args.Error(1)
+}
+
+func (m *mockCartRepo) AddCartItem(ctx context.Context, username string, item *domain.CartItem) error {
+	args := m.Called(ctx, username, item)
+	return args.Error(0)
+}
+
+func (m *mockCartRepo) RemoveCartItem(ctx context.Context, username, itemName string) error {
+	args := m.Called(ctx, username, itemName)
+	return args.Error(0)
+}
+
+func (m *mockCartRepo) RefreshCartPrices(ctx context.Context, username string, now time.Time) error {
+	args := m.Called(ctx, username, now)
+	return args.Error(0)
+}
+
+func (m *mockCartRepo) ExecuteCheckout(ctx context.Context, username string, now time.Time, idem *domain.IdempotencyRecord) (*domain.Order, error) {
+	args := m.Called(ctx, username, now, idem)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+// recordingMerchCache запоминает товары, кэш которых был сброшен
+type recordingMerchCache struct {
+	invalidated []string
+}
+
+func (c *recordingMerchCache) InvalidateMerch(names ...string) {
+	c.invalidated = append(c.invalidated, names...)
+}
+
+func newTestCartService(cartRepo *mockCartRepo, merchRepo *mockMerchRepo, now time.Time) *cartService {
+	s := NewCartService(cartRepo, merchRepo, new(recordingMerchCache)).(*cartService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestCartAddItem(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cup := &domain.Merch{Name: "cup", Price: 20, Available: true}
+
+	t.Run("товар добавлен по текущей цене", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		merchRepo := new(mockMerchRepo)
+		s := newTestCartService(cartRepo, merchRepo, now)
+
+		updated := &domain.Cart{Username: "buyer", Items: []*domain.CartItem{{ItemName: "cup", Quantity: 2, UnitPrice: 20, AddedAt: now}}}
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(cup, nil)
+		cartRepo.On("GetCart", mock.Anything, "buyer").Return(&domain.Cart{Username: "buyer"}, nil).Once()
+		cartRepo.On("AddCartItem", mock.Anything, "buyer", &domain.CartItem{ItemName: "cup", Quantity: 2, UnitPrice: 20, AddedAt: now}).Return(nil)
+		cartRepo.On("GetCart", mock.Anything, "buyer").Return(updated, nil).Once()
+
+		cart, err := s.AddItem(context.Background(), "buyer", "cup", 2)
+		require.NoError(t, err)
+		assert.Equal(t, updated, cart)
+		cartRepo.AssertExpectations(t)
+	})
+
+	t.Run("товар добавлен по цене распродажи", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		merchRepo := new(mockMerchRepo)
+		s := newTestCartService(cartRepo, merchRepo, now)
+
+		saleEnd := now.Add(time.Hour)
+		onSale := &domain.Merch{Name: "cup", Price: 20, Available: true, PriceSchedules: []*domain.PriceSchedule{
+			{Id: 1, ItemName: "cup", Price: 15, EffectiveFrom: now.Add(-time.Hour), EffectiveTo: &saleEnd},
+		}}
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(onSale, nil)
+		cartRepo.On("GetCart", mock.Anything, "buyer").Return(&domain.Cart{Username: "buyer"}, nil)
+		cartRepo.On("AddCartItem", mock.Anything, "buyer", &domain.CartItem{ItemName: "cup", Quantity: 1, UnitPrice: 15, AddedAt: now}).Return(nil)
+
+		_, err := s.AddItem(context.Background(), "buyer", "cup", 1)
+		require.NoError(t, err)
+		cartRepo.AssertExpectations(t)
+	})
+
+	t.Run("товар недоступен", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		merchRepo := new(mockMerchRepo)
+		s := newTestCartService(cartRepo, merchRepo, now)
+
+		merchRepo.On("GetMerchByName", mock.Anything, "umbrella").Return(&domain.Merch{Name: "umbrella", Price: 200}, nil)
+
+		_, err := s.AddItem(context.Background(), "buyer", "umbrella", 1)
+		assert.ErrorIs(t, err, domain.ErrMerchUnavailable)
+		cartRepo.AssertNotCalled(t, "AddCartItem", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("недопустимое количество", func(t *testing.T) {
+		s := newTestCartService(new(mockCartRepo), new(mockMerchRepo), now)
+
+		_, err := s.AddItem(context.Background(), "buyer", "cup", domain.MaxPurchaseQuantity+1)
+		assert.ErrorIs(t, err, domain.ErrInvalidQuantity)
+	})
+
+	t.Run("корзина заполнена", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		merchRepo := new(mockMerchRepo)
+		s := newTestCartService(cartRepo, merchRepo, now)
+
+		full := &domain.Cart{Username: "buyer"}
+		for i := 0; i < domain.MaxCartItems; i++ {
+			full.Items = append(full.Items, &domain.CartItem{ItemName: fmt.Sprintf("item-%d", i), Quantity: 1, UnitPrice: 1})
+		}
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(cup, nil)
+		cartRepo.On("GetCart", mock.Anything, "buyer").Return(full, nil)
+
+		_, err := s.AddItem(context.Background(), "buyer", "cup", 1)
+		assert.ErrorIs(t, err, domain.ErrCartFull)
+	})
+}
+
+func TestCartCheckout(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("заказ оформлен", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		s := newTestCartService(cartRepo, new(mockMerchRepo), now)
+
+		order := &domain.Order{Id: 7, Username: "buyer", Total: 50, CreatedAt: now, Items: []*domain.OrderItem{
+			{ItemName: "book", Quantity: 1, UnitPrice: 30},
+			{ItemName: "cup", Quantity: 1, UnitPrice: 20},
+		}}
+		cartRepo.On("ExecuteCheckout", mock.Anything, "buyer", now, (*domain.IdempotencyRecord)(nil)).Return(order, nil)
+
+		got, err := s.Checkout(context.Background(), "buyer", nil)
+		require.NoError(t, err)
+		assert.Equal(t, order, got)
+		assert.Equal(t, []string{"book", "cup"}, s.cache.(*recordingMerchCache).invalidated)
+	})
+
+	t.Run("товар закончился", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		s := newTestCartService(cartRepo, new(mockMerchRepo), now)
+
+		cart := &domain.Cart{Username: "buyer", Items: []*domain.CartItem{{ItemName: "cup", Quantity: 3, UnitPrice: 20, AddedAt: now}}}
+		cartRepo.On("ExecuteCheckout", mock.Anything, "buyer", now, (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("%w: cup", domain.ErrOutOfStock))
+		cartRepo.On("GetCart", mock.Anything, "buyer").Return(cart, nil)
+
+		_, err := s.Checkout(context.Background(), "buyer", nil)
+		assert.ErrorIs(t, err, domain.ErrOutOfStock)
+		assert.Equal(t, []string{"cup"}, s.cache.(*recordingMerchCache).invalidated)
+	})
+
+	t.Run("цена изменилась", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		s := newTestCartService(cartRepo, new(mockMerchRepo), now)
+
+		cartRepo.On("ExecuteCheckout", mock.Anything, "buyer", now, (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("%w: cup", domain.ErrPriceChanged))
+		cartRepo.On("RefreshCartPrices", mock.Anything, "buyer", now).Return(nil)
+
+		_, err := s.Checkout(context.Background(), "buyer", nil)
+		assert.ErrorIs(t, err, domain.ErrPriceChanged)
+		cartRepo.AssertExpectations(t)
+	})
+
+	t.Run("пустая корзина", func(t *testing.T) {
+		cartRepo := new(mockCartRepo)
+		s := newTestCartService(cartRepo, new(mockMerchRepo), now)
+
+		cartRepo.On("ExecuteCheckout", mock.Anything, "buyer", now, (*domain.IdempotencyRecord)(nil)).Return(nil, domain.ErrCartEmpty)
+
+		_, err := s.Checkout(context.Background(), "buyer", nil)
+		assert.ErrorIs(t, err, domain.ErrCartEmpty)
+		cartRepo.AssertNotCalled(t, "RefreshCartPrices", mock.Anything, mock.Anything, mock.Anything)
+		assert.Empty(t, s.cache.(*recordingMerchCache).invalidated)
+	})
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// IdempotencyService хранит результаты запросов с заголовком Idempotency-Key
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyService создает новый экземпляр сервиса идемпотентности
+// и запускает фоновое удаление истекших ключей
+func NewIdempotencyService(repo repository.IdempotencyRepository, cfg config.IdempotencyConfig) IdempotencyService {
+	service := &idempotencyService{
+		repo: repo,
+		ttl:  cfg.TTL,
+	}
+
+	if cfg.CleanupInterval > 0 {
+		go service.cleanupExpired(cfg.CleanupInterval)
+	}
+
+	return service
+}
+
+// Lookup возвращает сохраненный результат запроса или nil, если ключ еще не использован.
+// Ключ, использованный с другим запросом, возвращает ErrIdempotencyReused.
+func (s *idempotencyService) Lookup(ctx context.Context, username, key, requestHash string) (*domain.IdempotencyRecord, error) {
+	const op = "IdempotencyService.Lookup"
+
+	record, err := s.repo.GetIdempotencyRecord(ctx, username, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if record == nil {
+		return nil, nil
+	}
+	if !record.Matches(requestHash) {
+		logrus.Warnf("%s: ключ %s пользователя %s повторно использован с другим запросом", op, key, username)
+		return nil, domain.ErrIdempotencyReused
+	}
+
+	return record, nil
+}
+
+// NewRecord создает запись для нового запроса со сроком хранения из конфигурации
+func (s *idempotencyService) NewRecord(username, key, requestHash string) *domain.IdempotencyRecord {
+	return domain.NewIdempotencyRecord(username, key, requestHash, s.ttl)
+}
+
+// SaveResult сохраняет результат запроса, завершившегося без изменения баланса
+func (s *idempotencyService) SaveResult(ctx context.Context, record *domain.IdempotencyRecord) error {
+	const op = "IdempotencyService.SaveResult"
+
+	if err := s.repo.SaveIdempotencyRecord(ctx, record); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *idempotencyService) cleanupExpired(interval time.Duration) {
+	const op = "IdempotencyService.cleanupExpired"
+
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		deleted, err := s.repo.DeleteExpiredIdempotencyRecords(context.Background(), time.Now())
+		if err != nil {
+			logrus.Errorf("%s: ошибка удаления истекших ключей: %v", op, err)
+			continue
+		}
+		if deleted > 0 {
+			logrus.Infof("%s: удалено истекших ключей: %d", op, deleted)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockIdempotencyRepo struct {
+	mock.Mock
+}
+
+func (m *mockIdempotencyRepo) GetIdempotencyRecord(ctx context.Context, username, key string) (*domain.IdempotencyRecord, error) {
+	args := m.Called(ctx, username, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.IdempotencyRecord), args.Error(1)
+}
+
+func (m *mockIdempotencyRepo) SaveIdempotencyRecord(ctx context.Context, record *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *mockIdempotencyRepo) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var testIdempotencyConfig = config.IdempotencyConfig{TTL: 24 * time.Hour}
+
+func TestIdempotencyLookup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ключ не использован", func(t *testing.T) {
+		repo := new(mockIdempotencyRepo)
+		service := NewIdempotencyService(repo, testIdempotencyConfig)
+
+		repo.On("GetIdempotencyRecord", ctx, "user", "key-1").Return(nil, nil)
+
+		record, err := service.Lookup(ctx, "user", "key-1", "hash")
+
+		require.NoError(t, err)
+		require.Nil(t, record)
+	})
+
+	t.Run("повтор того же запроса", func(t *testing.T) {
+		repo := new(mockIdempotencyRepo)
+		service := NewIdempotencyService(repo, testIdempotencyConfig)
+
+		stored := &domain.IdempotencyRecord{RequestHash: "hash", StatusCode: 200}
+		repo.On("GetIdempotencyRecord", ctx, "user", "key-1").Return(stored, nil)
+
+		record, err := service.Lookup(ctx, "user", "key-1", "hash")
+
+		require.NoError(t, err)
+		require.Equal(t, stored, record)
+	})
+
+	t.Run("ключ использован с другим запросом", func(t *testing.T) {
+		repo := new(mockIdempotencyRepo)
+		service := NewIdempotencyService(repo, testIdempotencyConfig)
+
+		repo.On("GetIdempotencyRecord", ctx, "user", "key-1").
+			Return(&domain.IdempotencyRecord{RequestHash: "other"}, nil)
+
+		record, err := service.Lookup(ctx, "user", "key-1", "hash")
+
+		require.ErrorIs(t, err, domain.ErrIdempotencyReused)
+		require.Nil(t, record)
+	})
+
+	t.Run("ошибка репозитория", func(t *testing.T) {
+		repo := new(mockIdempotencyRepo)
+		service := NewIdempotencyService(repo, testIdempotencyConfig)
+
+		repo.On("GetIdempotencyRecord", ctx, "user", "key-1").Return(nil, errors.New("db error"))
+
+		_, err := service.Lookup(ctx, "user", "key-1", "hash")
+
+		require.Error(t, err)
+	})
+}
+
+func TestIdempotencyNewRecord(t *testing.T) {
+	service := NewIdempotencyService(new(mockIdempotencyRepo), testIdempotencyConfig)
+
+	record := service.NewRecord("user", "key-1", "hash")
+
+	require.Equal(t, "user", record.Username)
+	require.Equal(t, "key-1", record.Key)
+	require.Equal(t, "hash", record.RequestHash)
+	require.Equal(t, testIdempotencyConfig.TTL, record.ExpiresAt.Sub(record.CreatedAt))
+}
+
+func TestIdempotencySaveResult(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockIdempotencyRepo)
+	service := NewIdempotencyService(repo, testIdempotencyConfig)
+
+	record := &domain.IdempotencyRecord{Username: "user", Key: "key-1"}
+	repo.On("SaveIdempotencyRecord", ctx, record).Return(domain.ErrIdempotencyKeyUsed)
+
+	err := service.SaveResult(ctx, record)
+
+	require.ErrorIs(t, err, domain.ErrIdempotencyKeyUsed)
+	repo.AssertExpectations(t)
+}
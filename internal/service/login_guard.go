@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	userAttemptsPrefix = "user:"
+	ipAttemptsPrefix   = "ip:"
+)
+
+// LoginGuard ограничивает перебор паролей по имени пользователя и IP-адресу
+type loginGuard struct {
+	repo       repository.LoginAttemptRepository
+	userPolicy domain.LockoutPolicy
+	ipPolicy   domain.LockoutPolicy
+	now        func() time.Time
+}
+
+// NewLoginGuard создает новый экземпляр защиты от перебора паролей.
+// Если задан интервал очистки, устаревшие счетчики периодически удаляются.
+func NewLoginGuard(repo repository.LoginAttemptRepository, cfg config.LockoutConfig) LoginGuard {
+	guard := &loginGuard{
+		repo: repo,
+		userPolicy: domain.LockoutPolicy{
+			FreeAttempts:    cfg.FreeAttempts,
+			MaxFailures:     cfg.MaxFailures,
+			BaseDelay:       cfg.BaseDelay,
+			LockoutDuration: cfg.Duration,
+			ResetAfter:      cfg.ResetAfter,
+		},
+		// С одного адреса допускается больше попыток: за ним может быть NAT
+		ipPolicy: domain.LockoutPolicy{
+			FreeAttempts:    cfg.IPMaxFailures / 2,
+			MaxFailures:     cfg.IPMaxFailures,
+			BaseDelay:       cfg.BaseDelay,
+			LockoutDuration: cfg.Duration,
+			ResetAfter:      cfg.ResetAfter,
+		},
+		now: time.Now,
+	}
+
+	if cfg.CleanupInterval > 0 {
+		go guard.cleanupStale(cfg.CleanupInterval)
+	}
+
+	return guard
+}
+
+// CheckLogin возвращает *domain.LockoutError, если вход по имени или адресу заблокирован
+func (g *loginGuard) CheckLogin(ctx context.Context, username, ip string) error {
+	const op = "LoginGuard.CheckLogin"
+
+	now := g.now()
+	var retryAfter time.Duration
+	for _, key := range g.keys(username, ip) {
+		attempts, err := g.repo.GetLoginAttempts(ctx, key)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if attempts.IsLocked(now) {
+			if wait := attempts.LockedUntil.Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &domain.LockoutError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordLoginFailure учитывает неудачную попытку и при необходимости блокирует вход
+func (g *loginGuard) RecordLoginFailure(ctx context.Context, username, ip string) error {
+	const op = "LoginGuard.RecordLoginFailure"
+
+	if err := g.recordFailure(ctx, userAttemptsPrefix+username, g.userPolicy); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if ip != "" {
+		if err := g.recordFailure(ctx, ipAttemptsPrefix+ip, g.ipPolicy); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// RecordLoginSuccess сбрасывает счетчик пользователя после успешного входа.
+// Счетчик адреса не сбрасывается, чтобы вход в свой аккаунт не открывал перебор чужих.
+func (g *loginGuard) RecordLoginSuccess(ctx context.Context, username string) error {
+	const op = "LoginGuard.RecordLoginSuccess"
+
+	if err := g.repo.ResetLoginAttempts(ctx, userAttemptsPrefix+username); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UnlockUser снимает блокировку входа с пользователя
+func (g *loginGuard) UnlockUser(ctx context.Context, username string) error {
+	const op = "LoginGuard.UnlockUser"
+
+	if err := g.repo.ResetLoginAttempts(ctx, userAttemptsPrefix+username); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: блокировка входа пользователя %s снята", op, username)
+	return nil
+}
+
+func (g *loginGuard) recordFailure(ctx context.Context, key string, policy domain.LockoutPolicy) error {
+	now := g.now()
+	attempts, err := g.repo.RecordLoginFailure(ctx, key, now, policy.ResetAfter)
+	if err != nil {
+		return err
+	}
+
+	delay := policy.LockDuration(attempts.Failures)
+	if delay <= 0 {
+		return nil
+	}
+
+	if attempts.Failures >= policy.MaxFailures {
+		logrus.Warnf("LoginGuard: вход по ключу %s заблокирован на %s после %d неудачных попыток", key, delay, attempts.Failures)
+	}
+	return g.repo.LockLogin(ctx, key, now.Add(delay))
+}
+
+func (g *loginGuard) cleanupStale(interval time.Duration) {
+	const op = "LoginGuard.cleanupStale"
+
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		deleted, err := g.repo.DeleteStaleLoginAttempts(context.Background(), g.now(), g.userPolicy.ResetAfter)
+		if err != nil {
+			logrus.Errorf("%s: ошибка удаления устаревших счетчиков: %v", op, err)
+			continue
+		}
+		if deleted > 0 {
+			logrus.Infof("%s: удалено устаревших счетчиков попыток входа: %d", op, deleted)
+		}
+	}
+}
+
+func (g *loginGuard) keys(username, ip string) []string {
+	keys := []string{userAttemptsPrefix + username}
+	if ip != "" {
+		keys = append(keys, ipAttemptsPrefix+ip)
+	}
+	return keys
+}
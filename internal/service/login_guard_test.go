@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLoginAttemptRepo struct {
+	mock.Mock
+}
+
+func (m *mockLoginAttemptRepo) GetLoginAttempts(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginAttempts), args.Error(1)
+}
+
+func (m *mockLoginAttemptRepo) RecordLoginFailure(ctx context.Context, key string, now time.Time, resetAfter time.Duration) (*domain.LoginAttempts, error) {
+	args := m.Called(ctx, key, now, resetAfter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.LoginAttempts), args.Error(1)
+}
+
+func (m *mockLoginAttemptRepo) LockLogin(ctx context.Context, key string, until time.Time) error {
+	args := m.Called(ctx, key, until)
+	return args.Error(0)
+}
+
+func (m *mockLoginAttemptRepo) ResetLoginAttempts(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *mockLoginAttemptRepo) DeleteStaleLoginAttempts(ctx context.Context, now time.Time, resetAfter time.Duration) (int64, error) {
+	args := m.Called(ctx, now, resetAfter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var testLockoutConfig = config.LockoutConfig{
+	FreeAttempts:  3,
+	MaxFailures:   10,
+	IPMaxFailures: 50,
+	BaseDelay:     time.Second,
+	Duration:      15 * time.Minute,
+	ResetAfter:    time.Hour,
+}
+
+func newTestLoginGuard(repo *mockLoginAttemptRepo, now time.Time) *loginGuard {
+	guard := NewLoginGuard(repo, testLockoutConfig).(*loginGuard)
+	guard.now = func() time.Time { return now }
+	return guard
+}
+
+func TestCheckLogin(t *testing.T) {
+	now := time.Now()
+
+	t.Run("вход разрешен", func(t *testing.T) {
+		repo := new(mockLoginAttemptRepo)
+		guard := newTestLoginGuard(repo, now)
+
+		repo.On("GetLoginAttempts", mock.Anything, "user:testuser").Return(&domain.LoginAttempts{Key: "user:testuser"}, nil)
+		repo.On("GetLoginAttempts", mock.Anything, "ip:10.0.0.1").Return(&domain.LoginAttempts{Key: "ip:10.0.0.1"}, nil)
+
+		err := guard.CheckLogin(context.Background(), "testuser", "10.0.0.1")
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("заблокирован адрес", func(t *testing.T) {
+		repo := new(mockLoginAttemptRepo)
+		guard := newTestLoginGuard(repo, now)
+
+		repo.On("GetLoginAttempts", mock.Anything, "user:testuser").Return(&domain.LoginAttempts{Key: "user:testuser"}, nil)
+		repo.On("GetLoginAttempts", mock.Anything, "ip:10.0.0.1").
+			Return(&domain.LoginAttempts{Key: "ip:10.0.0.1", LockedUntil: now.Add(time.Minute)}, nil)
+
+		err := guard.CheckLogin(context.Background(), "testuser", "10.0.0.1")
+
+		require.ErrorIs(t, err, domain.ErrAccountLocked)
+		var lockout *domain.LockoutError
+		require.True(t, errors.As(err, &lockout))
+		require.Equal(t, time.Minute, lockout.RetryAfter)
+	})
+}
+
+func TestRecordLoginFailure(t *testing.T) {
+	now := time.Now()
+
+	t.Run("бесплатные попытки без задержки", func(t *testing.T) {
+		repo := new(mockLoginAttemptRepo)
+		guard := newTestLoginGuard(repo, now)
+
+		repo.On("RecordLoginFailure", mock.Anything, "user:testuser", now, time.Hour).
+			Return(&domain.LoginAttempts{Failures: 1}, nil)
+		repo.On("RecordLoginFailure", mock.Anything, "ip:10.0.0.1", now, time.Hour).
+			Return(&domain.LoginAttempts{Failures: 1}, nil)
+
+		err := guard.RecordLoginFailure(context.Background(), "testuser", "10.0.0.1")
+
+		require.NoError(t, err)
+		repo.AssertNotCalled(t, "LockLogin", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("экспоненциальная задержка", func(t *testing.T) {
+		repo := new(mockLoginAttemptRepo)
+		guard := newTestLoginGuard(repo, now)
+
+		repo.On("RecordLoginFailure", mock.Anything, "user:testuser", now, time.Hour).
+			Return(&domain.LoginAttempts{Failures: 5}, nil)
+		repo.On("LockLogin", mock.Anything, "user:testuser", now.Add(4*time.Second)).Return(nil)
+		repo.On("RecordLoginFailure", mock.Anything, "ip:10.0.0.1", now, time.Hour).
+			Return(&domain.LoginAttempts{Failures: 5}, nil)
+
+		err := guard.RecordLoginFailure(context.Background(), "testuser", "10.0.0.1")
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("полная блокировка", func(t *testing.T) {
+		repo := new(mockLoginAttemptRepo)
+		guard := newTestLoginGuard(repo, now)
+
+		repo.On("RecordLoginFailure", mock.Anything, "user:testuser", now, time.Hour).
+			Return(&domain.LoginAttempts{Failures: 10}, nil)
+		repo.On("LockLogin", mock.Anything, "user:testuser", now.Add(15*time.Minute)).Return(nil)
+
+		err := guard.RecordLoginFailure(context.Background(), "testuser", "")
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestUnlockUser(t *testing.T) {
+	repo := new(mockLoginAttemptRepo)
+	guard := newTestLoginGuard(repo, time.Now())
+
+	repo.On("ResetLoginAttempts", mock.Anything, "user:testuser").Return(nil)
+
+	err := guard.UnlockUser(context.Background(), "testuser")
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// marketService управляет лотами маркетплейса, на котором сотрудники перепродают товары друг другу
+type marketService struct {
+	marketRepo repository.MarketRepository
+	feePercent uint64
+	now        func() time.Time
+}
+
+// NewMarketService создает новый экземпляр сервиса маркетплейса
+func NewMarketService(marketRepo repository.MarketRepository, cfg config.MarketConfig) MarketService {
+	feePercent := cfg.FeePercent
+	if feePercent > domain.MaxMarketFeePercent {
+		logrus.Warnf("MarketService: комиссия %d%% больше допустимой, используется %d%%", feePercent, domain.MaxMarketFeePercent)
+		feePercent = domain.MaxMarketFeePercent
+	}
+
+	return &marketService{
+		marketRepo: marketRepo,
+		feePercent: feePercent,
+		now:        time.Now,
+	}
+}
+
+// CreateListing выставляет товар из инвентаря продавца на продажу.
+// До продажи или снятия лота товар удерживается и недоступен продавцу.
+func (s *marketService) CreateListing(ctx context.Context, seller, itemName string, quantity, price uint64) (*domain.Listing, error) {
+	const op = "MarketService.CreateListing"
+
+	listing := domain.NewListing(seller, itemName, quantity, price, s.now())
+	if err := listing.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.marketRepo.CreateListing(ctx, listing); err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) || errors.Is(err, domain.ErrItemNotTransferable) || errors.Is(err, domain.ErrInventoryShortage) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при создании лота пользователя %s: %v", op, seller, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: пользователь %s выставил лот %d: %d x %s за %d", op, seller, listing.Id, quantity, itemName, price)
+	return listing, nil
+}
+
+// ListListings возвращает лоты, подходящие под фильтр, начиная с последних
+func (s *marketService) ListListings(ctx context.Context, filter domain.ListingFilter) ([]*domain.Listing, error) {
+	const op = "MarketService.ListListings"
+
+	if filter.Limit == 0 {
+		filter.Limit = domain.DefaultListingPageSize
+	}
+	if filter.Limit > domain.MaxListingPageSize {
+		filter.Limit = domain.MaxListingPageSize
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	listings, err := s.marketRepo.GetListings(ctx, filter)
+	if err != nil {
+		logrus.Errorf("%s: ошибка при получении лотов: %v", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return listings, nil
+}
+
+// GetListing возвращает лот по номеру
+func (s *marketService) GetListing(ctx context.Context, id int64) (*domain.Listing, error) {
+	const op = "MarketService.GetListing"
+
+	listing, err := s.marketRepo.GetListing(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrListingNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrListingNotFound)
+		}
+		logrus.Errorf("%s: ошибка при получении лота %d: %v", op, id, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return listing, nil
+}
+
+// CancelListing снимает активный лот продавца и возвращает товар в его инвентарь
+func (s *marketService) CancelListing(ctx context.Context, seller string, id int64) (*domain.Listing, error) {
+	const op = "MarketService.CancelListing"
+
+	listing, err := s.marketRepo.CancelListing(ctx, id, seller, s.now())
+	if err != nil {
+		if errors.Is(err, domain.ErrListingNotFound) || errors.Is(err, domain.ErrListingClosed) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при снятии лота %d: %v", op, id, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: пользователь %s снял лот %d", op, seller, id)
+	return listing, nil
+}
+
+// BuyListing покупает лот целиком. Монеты переходят от покупателя продавцу, комиссия маркетплейса
+// удерживается из выручки продавца в пользу магазина, а товар зачисляется в инвентарь покупателя.
+func (s *marketService) BuyListing(ctx context.Context, buyer string, id int64, idem *domain.IdempotencyRecord) (*domain.Listing, error) {
+	const op = "MarketService.BuyListing"
+
+	listing, err := s.marketRepo.BuyListing(ctx, id, buyer, s.feePercent, s.now(), idem)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrListingNotFound),
+			errors.Is(err, domain.ErrListingClosed),
+			errors.Is(err, domain.ErrOwnListing),
+			errors.Is(err, domain.ErrInsufficientFunds),
+			errors.Is(err, domain.ErrItemNotTransferable),
+			errors.Is(err, domain.ErrIdempotencyKeyUsed):
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при покупке лота %d пользователем %s: %v", op, id, buyer, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: пользователь %s купил лот %d у %s за %d, комиссия %d", op, buyer, id, listing.Seller, listing.Price, listing.Fee)
+	return listing, nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMarketRepo struct {
+	mock.Mock
+}
+
+func (m *mockMarketRepo) CreateListing(ctx context.Context, listing *domain.Listing) error {
+	args := m.Called(ctx, listing)
+	return args.Error(0)
+}
+
+func (m *mockMarketRepo) GetListing(ctx context.Context, id int64) (*domain.Listing, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Listing), args.Error(1)
+}
+
+func (m *mockMarketRepo) GetListings(ctx context.Context, filter domain.ListingFilter) ([]*domain.Listing, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Listing), args.Error(1)
+}
+
+func (m *mockMarketRepo) CancelListing(ctx context.Context, id int64, seller string, now time.Time) (*domain.Listing, error) {
+	args := m.Called(ctx, id, seller, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Listing), args.Error(1)
+}
+
+func (m *mockMarketRepo) BuyListing(ctx context.Context, id int64, buyer string, feePercent uint64, now time.Time, idem *domain.IdempotencyRecord) (*domain.Listing, error) {
+	args := m.Called(ctx, id, buyer, feePercent, now, idem)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Listing), args.Error(1)
+}
+
+func newTestMarketService(repo *mockMarketRepo, feePercent uint64, now time.Time) *marketService {
+	s := NewMarketService(repo, config.MarketConfig{FeePercent: feePercent}).(*marketService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestNewMarketService_FeeLimit(t *testing.T) {
+	s := NewMarketService(new(mockMarketRepo), config.MarketConfig{FeePercent: 150}).(*marketService)
+	assert.Equal(t, uint64(domain.MaxMarketFeePercent), s.feePercent)
+}
+
+func TestCreateListing(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("лот выставлен", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, now)
+		repo.On("CreateListing", mock.Anything, domain.NewListing("alice", "cup", 2, 100, now)).
+			Run(func(args mock.Arguments) { args.Get(1).(*domain.Listing).Id = 5 }).
+			Return(nil)
+
+		listing, err := s.CreateListing(context.Background(), "alice", "cup", 2, 100)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), listing.Id)
+		assert.Equal(t, domain.ListingStatusActive, listing.Status)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("нулевая цена", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, now)
+
+		_, err := s.CreateListing(context.Background(), "alice", "cup", 2, 0)
+		assert.ErrorIs(t, err, domain.ErrInvalidListing)
+		repo.AssertNotCalled(t, "CreateListing", mock.Anything, mock.Anything)
+	})
+
+	t.Run("недостаточно товара", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, now)
+		repo.On("CreateListing", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("MarketRepository.CreateListing: %w: cup", domain.ErrInventoryShortage))
+
+		_, err := s.CreateListing(context.Background(), "alice", "cup", 2, 100)
+		assert.ErrorIs(t, err, domain.ErrInventoryShortage)
+	})
+}
+
+func TestListListings(t *testing.T) {
+	t.Run("размер страницы по умолчанию", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, time.Now())
+		repo.On("GetListings", mock.Anything, domain.ListingFilter{ItemName: "cup", Limit: domain.DefaultListingPageSize}).Return([]*domain.Listing{}, nil)
+
+		_, err := s.ListListings(context.Background(), domain.ListingFilter{ItemName: "cup"})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("размер страницы ограничен", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, time.Now())
+		repo.On("GetListings", mock.Anything, domain.ListingFilter{Limit: domain.MaxListingPageSize}).Return([]*domain.Listing{}, nil)
+
+		_, err := s.ListListings(context.Background(), domain.ListingFilter{Limit: 1000})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("минимальная цена больше максимальной", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, time.Now())
+
+		_, err := s.ListListings(context.Background(), domain.ListingFilter{MinPrice: 100, MaxPrice: 10})
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		repo.AssertNotCalled(t, "GetListings", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCancelListing(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("лот снят", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, now)
+		repo.On("CancelListing", mock.Anything, int64(5), "alice", now).
+			Return(&domain.Listing{Id: 5, Seller: "alice", Status: domain.ListingStatusCancelled}, nil)
+
+		listing, err := s.CancelListing(context.Background(), "alice", 5)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ListingStatusCancelled, listing.Status)
+	})
+
+	t.Run("лот уже продан", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, now)
+		repo.On("CancelListing", mock.Anything, int64(5), "alice", now).
+			Return(nil, fmt.Errorf("MarketRepository.CancelListing: %w", domain.ErrListingClosed))
+
+		_, err := s.CancelListing(context.Background(), "alice", 5)
+		assert.ErrorIs(t, err, domain.ErrListingClosed)
+	})
+}
+
+func TestBuyListing(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("покупка с комиссией из настроек", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 5, now)
+		idem := domain.NewIdempotencyRecord("bob", "key-1", "hash", time.Hour)
+		repo.On("BuyListing", mock.Anything, int64(5), "bob", uint64(5), now, idem).
+			Return(&domain.Listing{Id: 5, Seller: "alice", Buyer: "bob", Price: 100, Fee: 5, Status: domain.ListingStatusSold}, nil)
+
+		listing, err := s.BuyListing(context.Background(), "bob", 5, idem)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(95), listing.SellerProceeds())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("собственный лот", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, now)
+		repo.On("BuyListing", mock.Anything, int64(5), "alice", uint64(0), now, (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("MarketRepository.BuyListing: %w", domain.ErrOwnListing))
+
+		_, err := s.BuyListing(context.Background(), "alice", 5, nil)
+		assert.ErrorIs(t, err, domain.ErrOwnListing)
+	})
+
+	t.Run("недостаточно средств", func(t *testing.T) {
+		repo := new(mockMarketRepo)
+		s := newTestMarketService(repo, 0, now)
+		repo.On("BuyListing", mock.Anything, int64(5), "bob", uint64(0), now, (*domain.IdempotencyRecord)(nil)).
+			Return(nil, fmt.Errorf("MarketRepository.BuyListing: %w", domain.ErrInsufficientFunds))
+
+		_, err := s.BuyListing(context.Background(), "bob", 5, nil)
+		assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	})
+}
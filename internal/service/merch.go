@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -20,21 +24,32 @@ type merchCache struct {
 	timestamp time.Time
 }
 
+// merchCatalog хранит полный список товаров. ETag не кэшируется,
+// потому что цены по расписаниям меняются без изменения каталога.
+type merchCatalog struct {
+	items     []*domain.Merch
+	timestamp time.Time
+}
+
 // MerchService предоставляет методы для работы с товарами
 type merchService struct {
 	userRepo  repository.UserRepository
 	merchRepo repository.MerchRepository
 	transRepo repository.TransactionRepository
+	promoRepo repository.PromoCodeRepository
 	cache     map[string]merchCache
+	catalog   *merchCatalog
+	cacheGen  uint64 // Увеличивается при каждом изменении каталога
 	cacheMu   sync.RWMutex
 }
 
 // NewMerchService создает новый экземпляр сервиса товаров
-func NewMerchService(userRepo repository.UserRepository, merchRepo repository.MerchRepository, transRepo repository.TransactionRepository) MerchService {
+func NewMerchService(userRepo repository.UserRepository, merchRepo repository.MerchRepository, transRepo repository.TransactionRepository, promoRepo repository.PromoCodeRepository) MerchService {
 	service := &merchService{
 		userRepo:  userRepo,
 		merchRepo: merchRepo,
 		transRepo: transRepo,
+		promoRepo: promoRepo,
 		cache:     make(map[string]merchCache),
 	}
 
@@ -54,6 +69,9 @@ func (s *merchService) cleanCache() {
 				delete(s.cache, name)
 			}
 		}
+		if s.catalog != nil && now.Sub(s.catalog.timestamp) > merchCacheTTL {
+			s.catalog = nil
+		}
 		s.cacheMu.Unlock()
 	}
 }
@@ -70,61 +88,432 @@ func (s *merchService) getCachedMerch(name string) *domain.Merch {
 	return nil
 }
 
-func (s *merchService) cacheMerch(merch *domain.Merch) {
+// cacheGeneration возвращает текущее поколение кэша. Его нужно получить до чтения из базы,
+// чтобы не сохранить в кэш данные, прочитанные до изменения каталога.
+func (s *merchService) cacheGeneration() uint64 {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+
+	return s.cacheGen
+}
+
+func (s *merchService) cacheMerch(merch *domain.Merch, gen uint64) {
 	s.cacheMu.Lock()
 	defer s.cacheMu.Unlock()
 
+	if gen != s.cacheGen {
+		return
+	}
 	s.cache[merch.Name] = merchCache{
 		merch:     merch,
 		timestamp: time.Now(),
 	}
 }
 
-// BuyMerch обрабатывает покупку товара пользователем
-func (s *merchService) BuyMerch(ctx context.Context, username, merchName string) error {
+// BuyMerch обрабатывает покупку quantity единиц товара пользователем.
+// Если указан promoCode, стоимость покупки уменьшается на скидку по промокоду.
+// Если передан idem, результат запроса сохраняется вместе с покупкой.
+func (s *merchService) BuyMerch(ctx context.Context, username, merchName string, quantity uint64, promoCode string, idem *domain.IdempotencyRecord) error {
 	const op = "MerchService.BuyMerch"
 
-	// Проверяем кэш
+	if err := domain.ValidatePurchaseQuantity(quantity); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	merch, err := s.purchasableMerch(ctx, op, merchName)
+	if err != nil {
+		return err
+	}
+
+	purchase := domain.NewPurchase(username, merch, quantity)
+	if _, err := purchase.Total(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if promoCode != "" {
+		if err := s.applyPromo(ctx, purchase, merch, promoCode); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Выполняем покупку в рамках одной транзакции
+	if err := s.transRepo.ExecutePurchase(ctx, purchase, idem); err != nil {
+		if errors.Is(err, domain.ErrIdempotencyKeyUsed) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if errors.Is(err, domain.ErrPromoCodeNotFound) || errors.Is(err, domain.ErrPromoNotApplicable) || errors.Is(err, domain.ErrPromoCodeExhausted) {
+			logrus.Warnf("%s: промокод %s не применен: %v", op, purchase.PromoCode, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if errors.Is(err, domain.ErrOutOfStock) {
+			// Кэшированный остаток мог устареть — сбрасываем его, чтобы каталог показал актуальное значение
+			s.invalidateCache(merchName)
+			logrus.Warnf("%s: товар %s закончился", op, merchName)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if errors.Is(err, domain.ErrPriceChanged) || errors.Is(err, domain.ErrMerchUnavailable) || errors.Is(err, domain.ErrMerchNotFound) {
+			// Цена или доступность товара изменились после кэширования — следующий запрос прочитает их из базы
+			s.invalidateCache(merchName)
+			logrus.Warnf("%s: товар %s изменился до покупки: %v", op, merchName, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при выполнении покупки: %v", op, err)
+		return fmt.Errorf("%s: выполнение покупки: %w", op, err)
+	}
+
+	// Остаток товара с ограниченным количеством изменился
+	if merch.IsLimited() {
+		s.invalidateCache(merchName)
+	}
+
+	if purchase.PromoCode != "" {
+		logrus.Infof("%s: к покупке применен промокод %s, скидка %d", op, purchase.PromoCode, purchase.Discount)
+	}
+	logrus.Infof("%s: пользователь %s успешно купил товар %s в количестве %d", op, username, merchName, quantity)
+	return nil
+}
+
+// GiftMerch обрабатывает покупку quantity единиц товара в подарок: стоимость списывается у отправителя,
+// товар добавляется в инвентарь получателя, а на его выдачу создается заказ.
+// Если передан idem, результат запроса сохраняется вместе с подарком.
+func (s *merchService) GiftMerch(ctx context.Context, sender, recipient, merchName string, quantity uint64, message string, idem *domain.IdempotencyRecord) error {
+	const op = "MerchService.GiftMerch"
+
+	merch, err := s.purchasableMerch(ctx, op, merchName)
+	if err != nil {
+		return err
+	}
+
+	gift := domain.NewGift(sender, recipient, merch, quantity, message)
+	if err := gift.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := gift.Total(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.transRepo.ExecuteGift(ctx, gift, idem); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed), errors.Is(err, domain.ErrInsufficientFunds):
+			return fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			logrus.Warnf("%s: получатель %s не найден", op, recipient)
+			return fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, domain.ErrOutOfStock):
+			s.invalidateCache(merchName)
+			logrus.Warnf("%s: товар %s закончился", op, merchName)
+			return fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, domain.ErrPriceChanged), errors.Is(err, domain.ErrMerchUnavailable), errors.Is(err, domain.ErrMerchNotFound):
+			s.invalidateCache(merchName)
+			logrus.Warnf("%s: товар %s изменился до покупки: %v", op, merchName, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при выполнении подарка: %v", op, err)
+		return fmt.Errorf("%s: выполнение подарка: %w", op, err)
+	}
+
+	if merch.IsLimited() {
+		s.invalidateCache(merchName)
+	}
+
+	logrus.Infof("%s: пользователь %s подарил %s в количестве %d пользователю %s", op, sender, merchName, quantity, recipient)
+	return nil
+}
+
+// purchasableMerch возвращает доступный для покупки товар с ценой, действующей в момент покупки.
+// Товар берется из кэша, если он там есть.
+func (s *merchService) purchasableMerch(ctx context.Context, op, merchName string) (*domain.Merch, error) {
 	merch := s.getCachedMerch(merchName)
-	var err error
 	if merch == nil {
+		gen := s.cacheGeneration()
+		var err error
 		merch, err = s.merchRepo.GetMerchByName(ctx, merchName)
 		if err != nil {
 			if err == domain.ErrMerchNotFound {
 				logrus.Warnf("%s: товар %s не найден", op, merchName)
-				return fmt.Errorf("%s: %w", op, err)
+				return nil, fmt.Errorf("%s: %w", op, err)
 			}
 			logrus.Errorf("%s: ошибка при получении товара %s: %v", op, merchName, err)
-			return fmt.Errorf("%s: получение товара: %w", op, err)
+			return nil, fmt.Errorf("%s: получение товара: %w", op, err)
 		}
-		// Кэшируем товар
-		s.cacheMerch(merch)
+		s.cacheMerch(merch, gen)
 	}
 
-	// Выполняем покупку в рамках одной транзакции
-	if err := s.transRepo.ExecutePurchase(ctx, username, merchName, merch.Price); err != nil {
-		logrus.Errorf("%s: ошибка при выполнении покупки: %v", op, err)
-		return fmt.Errorf("%s: выполнение покупки: %w", op, err)
+	if !merch.CanBePurchased() {
+		logrus.Warnf("%s: товар %s недоступен для покупки", op, merchName)
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchUnavailable)
 	}
 
-	logrus.Infof("%s: пользователь %s успешно купил товар %s", op, username, merchName)
-	return nil
+	return merch.PricedAt(time.Now()), nil
+}
+
+// applyPromo проверяет, что промокод действует на товар, и вычисляет скидку.
+// Лимиты использований проверяются репозиторием в транзакции покупки.
+func (s *merchService) applyPromo(ctx context.Context, purchase *domain.Purchase, merch *domain.Merch, code string) error {
+	promo, err := s.promoRepo.GetPromoCode(ctx, domain.NormalizePromoCode(code))
+	if err != nil {
+		if errors.Is(err, domain.ErrPromoCodeNotFound) {
+			return domain.ErrPromoCodeNotFound
+		}
+		return fmt.Errorf("получение промокода: %w", err)
+	}
+
+	return purchase.ApplyPromo(promo, merch, time.Now())
 }
 
-// GetAllMerch возвращает список всех доступных товаров
+// GetAllMerch возвращает список всех товаров каталога
 func (s *merchService) GetAllMerch(ctx context.Context) ([]*domain.Merch, error) {
-	const op = "MerchService.GetAllMerch"
+	items, _, err := s.GetCatalog(ctx)
+	return items, err
+}
+
+// GetCatalog возвращает список всех товаров по текущим ценам и ETag каталога.
+// Список берется из кэша, пока не истек merchCacheTTL; цены по расписаниям вычисляются при каждом запросе.
+func (s *merchService) GetCatalog(ctx context.Context) ([]*domain.Merch, string, error) {
+	const op = "MerchService.GetCatalog"
 
+	if catalog := s.getCachedCatalog(); catalog != nil {
+		items := pricedAt(catalog.items, time.Now())
+		return items, merchETag(items...), nil
+	}
+
+	gen := s.cacheGeneration()
 	merch, err := s.merchRepo.GetAllMerch(ctx)
 	if err != nil {
 		logrus.Errorf("%s: ошибка при получении списка товаров: %v", op, err)
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Кэшируем все товары
+	// Кэшируем весь каталог и каждый товар отдельно
+	catalog := &merchCatalog{
+		items:     merch,
+		timestamp: time.Now(),
+	}
+	s.cacheMu.Lock()
+	if gen == s.cacheGen {
+		s.catalog = catalog
+	}
+	s.cacheMu.Unlock()
 	for _, m := range merch {
-		s.cacheMerch(m)
+		s.cacheMerch(m, gen)
+	}
+
+	items := pricedAt(merch, time.Now())
+	return items, merchETag(items...), nil
+}
+
+// pricedAt возвращает копии товаров с ценами, действующими в момент at
+func pricedAt(items []*domain.Merch, at time.Time) []*domain.Merch {
+	priced := make([]*domain.Merch, 0, len(items))
+	for _, m := range items {
+		priced = append(priced, m.PricedAt(at))
+	}
+	return priced
+}
+
+// GetMerch возвращает товар по названию с текущей ценой и его ETag
+func (s *merchService) GetMerch(ctx context.Context, name string) (*domain.Merch, string, error) {
+	const op = "MerchService.GetMerch"
+
+	merch := s.getCachedMerch(name)
+	if merch == nil {
+		gen := s.cacheGeneration()
+		var err error
+		merch, err = s.merchRepo.GetMerchByName(ctx, name)
+		if err != nil {
+			if errors.Is(err, domain.ErrMerchNotFound) {
+				return nil, "", fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+			}
+			logrus.Errorf("%s: ошибка при получении товара %s: %v", op, name, err)
+			return nil, "", fmt.Errorf("%s: %w", op, err)
+		}
+		s.cacheMerch(merch, gen)
+	}
+
+	merch = merch.PricedAt(time.Now())
+	return merch, merchETag(merch), nil
+}
+
+// CreateMerch добавляет товар в каталог
+func (s *merchService) CreateMerch(ctx context.Context, item *domain.Merch) error {
+	const op = "MerchService.CreateMerch"
+
+	if item.Category == "" {
+		item.Category = domain.DefaultMerchCategory
+	}
+	if err := item.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.merchRepo.CreateMerch(ctx, item); err != nil {
+		if errors.Is(err, domain.ErrMerchAlreadyExists) {
+			return fmt.Errorf("%s: %w", op, domain.ErrMerchAlreadyExists)
+		}
+		logrus.Errorf("%s: ошибка при создании товара %s: %v", op, item.Name, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCache(item.Name)
+	logrus.Infof("%s: добавлен товар %s по цене %d", op, item.Name, item.Price)
+	return nil
+}
+
+// UpdateMerch изменяет цену, описание, категорию или доступность товара
+func (s *merchService) UpdateMerch(ctx context.Context, name string, update domain.MerchUpdate) (*domain.Merch, error) {
+	const op = "MerchService.UpdateMerch"
+
+	if err := update.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	item, err := s.merchRepo.UpdateMerch(ctx, name, update)
+	if err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		logrus.Errorf("%s: ошибка при изменении товара %s: %v", op, name, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCache(name)
+	logrus.Infof("%s: изменен товар %s", op, name)
+	return item, nil
+}
+
+// RetireMerch снимает товар с продажи
+func (s *merchService) RetireMerch(ctx context.Context, name string) error {
+	const op = "MerchService.RetireMerch"
+
+	if err := s.merchRepo.RetireMerch(ctx, name, time.Now()); err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		logrus.Errorf("%s: ошибка при снятии товара %s с продажи: %v", op, name, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCache(name)
+	logrus.Infof("%s: товар %s снят с продажи", op, name)
+	return nil
+}
+
+// RestockMerch пополняет склад товара с ограниченным количеством
+func (s *merchService) RestockMerch(ctx context.Context, name string, quantity uint64) (*domain.Merch, error) {
+	const op = "MerchService.RestockMerch"
+
+	if err := domain.ValidateRestockQuantity(quantity); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	item, err := s.merchRepo.RestockMerch(ctx, name, quantity)
+	if err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) || errors.Is(err, domain.ErrStockUnlimited) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при пополнении склада товара %s: %v", op, name, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCache(name)
+	logrus.Infof("%s: склад товара %s пополнен на %d, остаток %d", op, name, quantity, *item.Stock)
+	return item, nil
+}
+
+// SchedulePrice планирует цену товара на заданный период
+func (s *merchService) SchedulePrice(ctx context.Context, schedule *domain.PriceSchedule) error {
+	const op = "MerchService.SchedulePrice"
+
+	now := time.Now()
+	if schedule.EffectiveFrom.IsZero() {
+		schedule.EffectiveFrom = now
+	}
+	if err := schedule.Validate(now); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	schedule.CreatedAt = now
+
+	if err := s.merchRepo.CreatePriceSchedule(ctx, schedule); err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		logrus.Errorf("%s: ошибка при создании расписания цены товара %s: %v", op, schedule.ItemName, err)
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	return merch, nil
+	s.invalidateCache(schedule.ItemName)
+	logrus.Infof("%s: для товара %s запланирована цена %d с %s", op, schedule.ItemName, schedule.Price, schedule.EffectiveFrom.Format(time.RFC3339))
+	return nil
+}
+
+// CancelPriceSchedule отменяет расписание цены товара
+func (s *merchService) CancelPriceSchedule(ctx context.Context, name string, id int64) error {
+	const op = "MerchService.CancelPriceSchedule"
+
+	if err := s.merchRepo.CancelPriceSchedule(ctx, name, id, time.Now()); err != nil {
+		if errors.Is(err, domain.ErrPriceScheduleNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrPriceScheduleNotFound)
+		}
+		logrus.Errorf("%s: ошибка при отмене расписания цены %d товара %s: %v", op, id, name, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCache(name)
+	logrus.Infof("%s: расписание цены %d товара %s отменено", op, id, name)
+	return nil
+}
+
+// GetPriceHistory возвращает историю цен товара
+func (s *merchService) GetPriceHistory(ctx context.Context, name string) (*domain.PriceHistory, error) {
+	const op = "MerchService.GetPriceHistory"
+
+	history, err := s.merchRepo.GetPriceHistory(ctx, name)
+	if err != nil {
+		if errors.Is(err, domain.ErrMerchNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrMerchNotFound)
+		}
+		logrus.Errorf("%s: ошибка при получении истории цен товара %s: %v", op, name, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return history, nil
+}
+
+// invalidateCache удаляет товар и весь каталог из кэша, чтобы изменения были видны сразу
+func (s *merchService) invalidateCache(name string) {
+	s.InvalidateMerch(name)
+}
+
+// InvalidateMerch удаляет из кэша товары, остатки которых изменились при оформлении или отмене заказа
+func (s *merchService) InvalidateMerch(names ...string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.cacheGen++
+	for _, name := range names {
+		delete(s.cache, name)
+	}
+	s.catalog = nil
+}
+
+func (s *merchService) getCachedCatalog() *merchCatalog {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+
+	if s.catalog != nil && time.Since(s.catalog.timestamp) < merchCacheTTL {
+		return s.catalog
+	}
+	return nil
+}
+
+// merchETag вычисляет ETag по содержимому товаров
+func merchETag(items ...*domain.Merch) string {
+	h := sha256.New()
+	for _, m := range items {
+		stock := "-"
+		if m.Stock != nil {
+			stock = strconv.FormatUint(*m.Stock, 10)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00%s\x00%s\x00%t\x00%t\x00%s\x00%t\n", m.Name, m.Price, m.RegularPrice, m.Description, m.Category, m.Available, m.IsRetired(), stock, m.Transferable)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
 }
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/netscrawler/avito-shop/internal/domain"
 	"github.com/stretchr/testify/mock"
@@ -46,6 +49,16 @@ func (m *mockUserRepo) UpdateUserInventory(ctx context.Context, user *domain.Use
 	return args.Error(0)
 }
 
+func (m *mockUserRepo) UpdateUserRole(ctx context.Context, username string, role domain.Role) error {
+	args := m.Called(ctx, username, role)
+	return args.Error(0)
+}
+
+func (m *mockUserRepo) UpdateUserPassword(ctx context.Context, username string, password []byte) error {
+	args := m.Called(ctx, username, password)
+	return args.Error(0)
+}
+
 type mockMerchRepo struct {
 	mock.Mock
 }
@@ -63,6 +76,50 @@ func (m *mockMerchRepo) GetAllMerch(ctx context.Context) ([]*domain.Merch, error
 	return args.Get(0).([]*domain.Merch), args.Error(1)
 }
 
+func (m *mockMerchRepo) CreateMerch(ctx context.Context, item *domain.Merch) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *mockMerchRepo) UpdateMerch(ctx context.Context, name string, update domain.MerchUpdate) (*domain.Merch, error) {
+	args := m.Called(ctx, name, update)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Merch), args.Error(1)
+}
+
+func (m *mockMerchRepo) RetireMerch(ctx context.Context, name string, now time.Time) error {
+	args := m.Called(ctx, name, now)
+	return args.Error(0)
+}
+
+func (m *mockMerchRepo) RestockMerch(ctx context.Context, name string, quantity uint64) (*domain.Merch, error) {
+	args := m.Called(ctx, name, quantity)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Merch), args.Error(1)
+}
+
+func (m *mockMerchRepo) CreatePriceSchedule(ctx context.Context, schedule *domain.PriceSchedule) error {
+	args := m.Called(ctx, schedule)
+	return args.Error(0)
+}
+
+func (m *mockMerchRepo) CancelPriceSchedule(ctx context.Context, name string, id int64, now time.Time) error {
+	args := m.Called(ctx, name, id, now)
+	return args.Error(0)
+}
+
+func (m *mockMerchRepo) GetPriceHistory(ctx context.Context, name string) (*domain.PriceHistory, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PriceHistory), args.Error(1)
+}
+
 func (m *mockMerchRepo) GetMerchById(ctx context.Context, id int) (*domain.Merch, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -90,16 +147,48 @@ func (m *mockTransactionRepo) GetTransactionsByReceiver(ctx context.Context, rec
 	return args.Get(0).([]*domain.Transaction), args.Error(1)
 }
 
-func (m *mockTransactionRepo) ExecutePurchase(ctx context.Context, sender, receiver string, amount uint64) error {
-	args := m.Called(ctx, sender, receiver, amount)
+func (m *mockTransactionRepo) ExecutePurchase(ctx context.Context, purchase *domain.Purchase, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, purchase, idem)
+	return args.Error(0)
+}
+
+func (m *mockTransactionRepo) ExecuteGift(ctx context.Context, gift *domain.Gift, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, gift, idem)
 	return args.Error(0)
 }
 
-func (m *mockTransactionRepo) ExecuteTransfer(ctx context.Context, sender, receiver string, amount uint64) error {
-	args := m.Called(ctx, sender, receiver, amount)
+func (m *mockTransactionRepo) ExecuteItemTransfer(ctx context.Context, transfer *domain.ItemTransfer, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, transfer, idem)
 	return args.Error(0)
 }
 
+func (m *mockTransactionRepo) ExecuteGrant(ctx context.Context, granter, receiver string, amount uint64) error {
+	args := m.Called(ctx, granter, receiver, amount)
+	return args.Error(0)
+}
+
+func (m *mockTransactionRepo) ExecuteTransfer(ctx context.Context, sender, receiver string, amount uint64, idem *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, sender, receiver, amount, idem)
+	return args.Error(0)
+}
+
+func (m *mockTransactionRepo) GetUserLedger(ctx context.Context, filter domain.LedgerFilter) ([]*domain.LedgerEntry, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*domain.LedgerEntry), args.Error(1)
+}
+
+func (m *mockTransactionRepo) StreamStatement(ctx context.Context, filter domain.StatementFilter, fn func(*domain.StatementRow) error) error {
+	args := m.Called(ctx, filter)
+	if rows, ok := args.Get(0).([]*domain.StatementRow); ok {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
 func (m *mockTransactionRepo) GetUserTransactions(ctx context.Context, username string) ([]*domain.Transaction, error) {
 	args := m.Called(ctx, username)
 	return args.Get(0).([]*domain.Transaction), args.Error(1)
@@ -110,23 +199,24 @@ func TestBuyMerch_Success(t *testing.T) {
 	userRepo := new(mockUserRepo)
 	merchRepo := new(mockMerchRepo)
 	transRepo := new(mockTransactionRepo)
-	service := NewMerchService(userRepo, merchRepo, transRepo)
+	service := NewMerchService(userRepo, merchRepo, transRepo, new(mockPromoCodeRepo))
 
 	username := "testuser"
 	itemName := "test-item"
 	price := uint64(100)
 
 	merch := &domain.Merch{
-		Name:  itemName,
-		Price: price,
+		Name:      itemName,
+		Price:     price,
+		Available: true,
 	}
 
 	// Настройка ожиданий
 	merchRepo.On("GetMerchByName", mock.Anything, itemName).Return(merch, nil)
-	transRepo.On("ExecutePurchase", mock.Anything, username, itemName, price).Return(nil)
+	transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: username, ItemName: itemName, Quantity: 1, UnitPrice: price}, mock.Anything).Return(nil)
 
 	// Действие
-	err := service.BuyMerch(context.Background(), username, itemName)
+	err := service.BuyMerch(context.Background(), username, itemName, 1, "", nil)
 
 	// Проверка
 	require.NoError(t, err)
@@ -139,23 +229,24 @@ func TestBuyMerch_InsufficientFunds(t *testing.T) {
 	userRepo := new(mockUserRepo)
 	merchRepo := new(mockMerchRepo)
 	transRepo := new(mockTransactionRepo)
-	service := NewMerchService(userRepo, merchRepo, transRepo)
+	service := NewMerchService(userRepo, merchRepo, transRepo, new(mockPromoCodeRepo))
 
 	username := "testuser"
 	itemName := "test-item"
 	price := uint64(1000)
 
 	merch := &domain.Merch{
-		Name:  itemName,
-		Price: price,
+		Name:      itemName,
+		Price:     price,
+		Available: true,
 	}
 
 	// Настройка ожиданий
 	merchRepo.On("GetMerchByName", mock.Anything, itemName).Return(merch, nil)
-	transRepo.On("ExecutePurchase", mock.Anything, username, itemName, price).Return(domain.ErrInsufficientFunds)
+	transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: username, ItemName: itemName, Quantity: 1, UnitPrice: price}, mock.Anything).Return(domain.ErrInsufficientFunds)
 
 	// Действие
-	err := service.BuyMerch(context.Background(), username, itemName)
+	err := service.BuyMerch(context.Background(), username, itemName, 1, "", nil)
 
 	// Проверка
 	require.Error(t, err)
@@ -169,25 +260,26 @@ func TestBuyMerch_FailedTransaction(t *testing.T) {
 	userRepo := new(mockUserRepo)
 	merchRepo := new(mockMerchRepo)
 	transRepo := new(mockTransactionRepo)
-	service := NewMerchService(userRepo, merchRepo, transRepo)
+	service := NewMerchService(userRepo, merchRepo, transRepo, new(mockPromoCodeRepo))
 
 	username := "testuser"
 	itemName := "test-item"
 	price := uint64(100)
 
 	merch := &domain.Merch{
-		Name:  itemName,
-		Price: price,
+		Name:      itemName,
+		Price:     price,
+		Available: true,
 	}
 
 	expectedError := errors.New("ошибка транзакции")
 
 	// Настройка ожиданий
 	merchRepo.On("GetMerchByName", mock.Anything, itemName).Return(merch, nil)
-	transRepo.On("ExecutePurchase", mock.Anything, username, itemName, price).Return(expectedError)
+	transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: username, ItemName: itemName, Quantity: 1, UnitPrice: price}, mock.Anything).Return(expectedError)
 
 	// Действие
-	err := service.BuyMerch(context.Background(), username, itemName)
+	err := service.BuyMerch(context.Background(), username, itemName, 1, "", nil)
 
 	// Проверка
 	require.Error(t, err)
@@ -201,27 +293,28 @@ func TestBuyMerch_CacheHit(t *testing.T) {
 	userRepo := new(mockUserRepo)
 	merchRepo := new(mockMerchRepo)
 	transRepo := new(mockTransactionRepo)
-	service := NewMerchService(userRepo, merchRepo, transRepo)
+	service := NewMerchService(userRepo, merchRepo, transRepo, new(mockPromoCodeRepo))
 
 	username := "testuser"
 	itemName := "test-item"
 	price := uint64(100)
 
 	merch := &domain.Merch{
-		Name:  itemName,
-		Price: price,
+		Name:      itemName,
+		Price:     price,
+		Available: true,
 	}
 
 	// Первый запрос - промах кэша
 	merchRepo.On("GetMerchByName", mock.Anything, itemName).Return(merch, nil).Once()
-	transRepo.On("ExecutePurchase", mock.Anything, username, itemName, price).Return(nil)
+	transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: username, ItemName: itemName, Quantity: 1, UnitPrice: price}, mock.Anything).Return(nil)
 
 	// Первая покупка
-	err := service.BuyMerch(context.Background(), username, itemName)
+	err := service.BuyMerch(context.Background(), username, itemName, 1, "", nil)
 	require.NoError(t, err)
 
 	// Вторая покупка - должна использовать кэш
-	err = service.BuyMerch(context.Background(), username, itemName)
+	err = service.BuyMerch(context.Background(), username, itemName, 1, "", nil)
 	require.NoError(t, err)
 
 	// Проверяем, что GetMerchByName был вызван только один раз
@@ -234,11 +327,11 @@ func TestGetAllMerch_WithCaching(t *testing.T) {
 	userRepo := new(mockUserRepo)
 	merchRepo := new(mockMerchRepo)
 	transRepo := new(mockTransactionRepo)
-	service := NewMerchService(userRepo, merchRepo, transRepo)
+	service := NewMerchService(userRepo, merchRepo, transRepo, new(mockPromoCodeRepo))
 
 	merch := []*domain.Merch{
-		{Name: "item1", Price: 100},
-		{Name: "item2", Price: 200},
+		{Name: "item1", Price: 100, Available: true},
+		{Name: "item2", Price: 200, Available: true},
 	}
 
 	// Настройка ожиданий
@@ -252,8 +345,8 @@ func TestGetAllMerch_WithCaching(t *testing.T) {
 	// После этого запроса товары должны быть в кэше
 	// Проверяем каждый товар через BuyMerch - не должно быть обращений к GetMerchByName
 	for _, item := range items {
-		transRepo.On("ExecutePurchase", mock.Anything, "testuser", item.Name, item.Price).Return(nil).Once()
-		err := service.BuyMerch(context.Background(), "testuser", item.Name)
+		transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: "testuser", ItemName: item.Name, Quantity: 1, UnitPrice: item.Price}, mock.Anything).Return(nil).Once()
+		err := service.BuyMerch(context.Background(), "testuser", item.Name, 1, "", nil)
 		require.NoError(t, err)
 	}
 
@@ -261,3 +354,578 @@ func TestGetAllMerch_WithCaching(t *testing.T) {
 	merchRepo.AssertNotCalled(t, "GetMerchByName")
 	merchRepo.AssertNumberOfCalls(t, "GetAllMerch", 1)
 }
+
+func TestBuyMerch_Unavailable(t *testing.T) {
+	merchRepo := new(mockMerchRepo)
+	transRepo := new(mockTransactionRepo)
+	service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+	merchRepo.On("GetMerchByName", mock.Anything, "umbrella").
+		Return(&domain.Merch{Name: "umbrella", Price: 200, Available: false}, nil)
+
+	err := service.BuyMerch(context.Background(), "testuser", "umbrella", 1, "", nil)
+
+	require.ErrorIs(t, err, domain.ErrMerchUnavailable)
+	transRepo.AssertNotCalled(t, "ExecutePurchase", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetCatalog(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("каталог берется из кэша", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		items := []*domain.Merch{{Name: "cup", Price: 20, Category: "tableware", Available: true}}
+		merchRepo.On("GetAllMerch", mock.Anything).Return(items, nil).Once()
+
+		first, etag, err := service.GetCatalog(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, etag)
+
+		second, cachedETag, err := service.GetCatalog(ctx)
+		require.NoError(t, err)
+		require.Equal(t, first, second)
+		require.Equal(t, etag, cachedETag)
+		merchRepo.AssertNumberOfCalls(t, "GetAllMerch", 1)
+	})
+
+	t.Run("ETag меняется вместе с содержимым", func(t *testing.T) {
+		cup := &domain.Merch{Name: "cup", Price: 20, Available: true}
+		repriced := &domain.Merch{Name: "cup", Price: 25, Available: true}
+
+		require.Equal(t, merchETag(cup), merchETag(&domain.Merch{Name: "cup", Price: 20, Available: true}))
+		require.NotEqual(t, merchETag(cup), merchETag(repriced))
+	})
+}
+
+func TestGetMerch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("товар найден", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		item := &domain.Merch{Name: "pen", Price: 10, Available: true}
+		merchRepo.On("GetMerchByName", mock.Anything, "pen").Return(item, nil).Once()
+
+		got, etag, err := service.GetMerch(ctx, "pen")
+		require.NoError(t, err)
+		require.Equal(t, item.Name, got.Name)
+		require.Equal(t, item.Price, got.Price)
+		require.Equal(t, merchETag(got), etag)
+
+		// Повторный запрос обслуживается из кэша
+		_, _, err = service.GetMerch(ctx, "pen")
+		require.NoError(t, err)
+		merchRepo.AssertNumberOfCalls(t, "GetMerchByName", 1)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "unknown").
+			Return(nil, fmt.Errorf("MerchRepository.GetMerchByName: %w", domain.ErrMerchNotFound))
+
+		_, _, err := service.GetMerch(ctx, "unknown")
+		require.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+}
+
+func TestCreateMerch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("создание сбрасывает кэш каталога", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		cup := &domain.Merch{Name: "cup", Price: 20, Category: "tableware", Available: true}
+		sticker := &domain.Merch{Name: "sticker", Price: 5, Available: true}
+		merchRepo.On("GetAllMerch", mock.Anything).Return([]*domain.Merch{cup}, nil).Once()
+		merchRepo.On("CreateMerch", mock.Anything, sticker).Return(nil).Once()
+		merchRepo.On("GetAllMerch", mock.Anything).Return([]*domain.Merch{cup, sticker}, nil).Once()
+
+		_, etag, err := service.GetCatalog(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, service.CreateMerch(ctx, sticker))
+		require.Equal(t, domain.DefaultMerchCategory, sticker.Category)
+
+		items, newETag, err := service.GetCatalog(ctx)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		require.NotEqual(t, etag, newETag)
+		merchRepo.AssertNumberOfCalls(t, "GetAllMerch", 2)
+	})
+
+	t.Run("недопустимое название", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		err := service.CreateMerch(ctx, &domain.Merch{Name: "Red Pen", Price: 10})
+
+		require.ErrorIs(t, err, domain.ErrInvalidMerch)
+		merchRepo.AssertNotCalled(t, "CreateMerch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("товар уже существует", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("CreateMerch", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("MerchRepository.CreateMerch: %w", domain.ErrMerchAlreadyExists))
+
+		err := service.CreateMerch(ctx, &domain.Merch{Name: "pen", Price: 10})
+		require.ErrorIs(t, err, domain.ErrMerchAlreadyExists)
+	})
+}
+
+func TestUpdateMerch(t *testing.T) {
+	ctx := context.Background()
+	price := uint64(15)
+
+	t.Run("изменение сбрасывает кэш товара", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "pen").
+			Return(&domain.Merch{Name: "pen", Price: 10, Available: true}, nil).Once()
+		merchRepo.On("UpdateMerch", mock.Anything, "pen", domain.MerchUpdate{Price: &price}).
+			Return(&domain.Merch{Name: "pen", Price: 15, Available: true}, nil).Once()
+		merchRepo.On("GetMerchByName", mock.Anything, "pen").
+			Return(&domain.Merch{Name: "pen", Price: 15, Available: true}, nil).Once()
+
+		_, _, err := service.GetMerch(ctx, "pen")
+		require.NoError(t, err)
+
+		updated, err := service.UpdateMerch(ctx, "pen", domain.MerchUpdate{Price: &price})
+		require.NoError(t, err)
+		require.Equal(t, uint64(15), updated.Price)
+
+		got, _, err := service.GetMerch(ctx, "pen")
+		require.NoError(t, err)
+		require.Equal(t, uint64(15), got.Price)
+		merchRepo.AssertNumberOfCalls(t, "GetMerchByName", 2)
+	})
+
+	t.Run("пустое изменение", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		_, err := service.UpdateMerch(ctx, "pen", domain.MerchUpdate{})
+
+		require.ErrorIs(t, err, domain.ErrInvalidMerch)
+		merchRepo.AssertNotCalled(t, "UpdateMerch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("UpdateMerch", mock.Anything, "unknown", mock.Anything).
+			Return(nil, fmt.Errorf("MerchRepository.UpdateMerch: %w", domain.ErrMerchNotFound))
+
+		_, err := service.UpdateMerch(ctx, "unknown", domain.MerchUpdate{Price: &price})
+		require.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+}
+
+func TestRetireMerch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("снятый с продажи товар нельзя купить", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		retiredAt := time.Now()
+		merchRepo.On("GetMerchByName", mock.Anything, "pen").
+			Return(&domain.Merch{Name: "pen", Price: 10, Available: true}, nil).Once()
+		merchRepo.On("RetireMerch", mock.Anything, "pen", mock.AnythingOfType("time.Time")).Return(nil).Once()
+		merchRepo.On("GetMerchByName", mock.Anything, "pen").
+			Return(&domain.Merch{Name: "pen", Price: 10, RetiredAt: &retiredAt}, nil).Once()
+
+		_, _, err := service.GetMerch(ctx, "pen")
+		require.NoError(t, err)
+
+		require.NoError(t, service.RetireMerch(ctx, "pen"))
+
+		err = service.BuyMerch(ctx, "testuser", "pen", 1, "", nil)
+		require.ErrorIs(t, err, domain.ErrMerchUnavailable)
+		transRepo.AssertNotCalled(t, "ExecutePurchase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("товар не найден", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("RetireMerch", mock.Anything, "unknown", mock.Anything).
+			Return(fmt.Errorf("MerchRepository.RetireMerch: %w", domain.ErrMerchNotFound))
+
+		err := service.RetireMerch(ctx, "unknown")
+		require.ErrorIs(t, err, domain.ErrMerchNotFound)
+	})
+}
+
+func TestBuyMerch_Stock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("покупка товара с ограниченным количеством сбрасывает кэш", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		stock := uint64(2)
+		merchRepo.On("GetMerchByName", mock.Anything, "limited").
+			Return(&domain.Merch{Name: "limited", Price: 50, Available: true, Stock: &stock}, nil)
+		transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: "testuser", ItemName: "limited", Quantity: 1, UnitPrice: uint64(50)}, mock.Anything).Return(nil)
+
+		require.NoError(t, service.BuyMerch(ctx, "testuser", "limited", 1, "", nil))
+		require.NoError(t, service.BuyMerch(ctx, "testuser", "limited", 1, "", nil))
+
+		merchRepo.AssertNumberOfCalls(t, "GetMerchByName", 2)
+	})
+
+	t.Run("товар закончился", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "pen").
+			Return(&domain.Merch{Name: "pen", Price: 10, Available: true}, nil)
+		transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: "testuser", ItemName: "pen", Quantity: 1, UnitPrice: uint64(10)}, mock.Anything).
+			Return(domain.ErrOutOfStock)
+
+		err := service.BuyMerch(ctx, "testuser", "pen", 1, "", nil)
+		require.ErrorIs(t, err, domain.ErrOutOfStock)
+
+		// Остаток в кэше мог устареть, поэтому следующий запрос идет в репозиторий
+		_, _, err = service.GetMerch(ctx, "pen")
+		require.NoError(t, err)
+		merchRepo.AssertNumberOfCalls(t, "GetMerchByName", 2)
+	})
+
+	t.Run("цена в кэше устарела", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "pen").
+			Return(&domain.Merch{Name: "pen", Price: 10, Available: true}, nil)
+		transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: "testuser", ItemName: "pen", Quantity: 1, UnitPrice: uint64(10)}, mock.Anything).
+			Return(fmt.Errorf("TransactionRepository.ExecutePurchase: %w: pen", domain.ErrPriceChanged))
+
+		err := service.BuyMerch(ctx, "testuser", "pen", 1, "", nil)
+		require.ErrorIs(t, err, domain.ErrPriceChanged)
+
+		// Следующий запрос получит актуальную цену из репозитория
+		_, _, err = service.GetMerch(ctx, "pen")
+		require.NoError(t, err)
+		merchRepo.AssertNumberOfCalls(t, "GetMerchByName", 2)
+	})
+}
+
+func TestRestockMerch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("пополнение сбрасывает кэш каталога", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		empty, restocked := uint64(0), uint64(10)
+		merchRepo.On("GetAllMerch", mock.Anything).
+			Return([]*domain.Merch{{Name: "cup", Price: 20, Available: true, Stock: &empty}}, nil).Once()
+		merchRepo.On("RestockMerch", mock.Anything, "cup", uint64(10)).
+			Return(&domain.Merch{Name: "cup", Price: 20, Available: true, Stock: &restocked}, nil)
+		merchRepo.On("GetAllMerch", mock.Anything).
+			Return([]*domain.Merch{{Name: "cup", Price: 20, Available: true, Stock: &restocked}}, nil).Once()
+
+		_, etag, err := service.GetCatalog(ctx)
+		require.NoError(t, err)
+
+		item, err := service.RestockMerch(ctx, "cup", 10)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), *item.Stock)
+
+		items, newETag, err := service.GetCatalog(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), *items[0].Stock)
+		require.NotEqual(t, etag, newETag)
+	})
+
+	t.Run("недопустимое количество", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		_, err := service.RestockMerch(ctx, "cup", 0)
+
+		require.ErrorIs(t, err, domain.ErrInvalidMerch)
+		merchRepo.AssertNotCalled(t, "RestockMerch", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestBuyMerch_Quantity(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("покупка нескольких единиц", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").
+			Return(&domain.Merch{Name: "cup", Price: 20, Available: true}, nil)
+		transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: "testuser", ItemName: "cup", Quantity: 3, UnitPrice: 20}, mock.Anything).
+			Return(nil)
+
+		require.NoError(t, service.BuyMerch(ctx, "testuser", "cup", 3, "", nil))
+		transRepo.AssertExpectations(t)
+	})
+
+	t.Run("недопустимое количество", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		err := service.BuyMerch(ctx, "testuser", "cup", 0, "", nil)
+
+		require.ErrorIs(t, err, domain.ErrInvalidQuantity)
+		merchRepo.AssertNotCalled(t, "GetMerchByName", mock.Anything, mock.Anything)
+	})
+
+	t.Run("переполнение стоимости", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "gold").
+			Return(&domain.Merch{Name: "gold", Price: math.MaxUint64 / 2, Available: true}, nil)
+
+		err := service.BuyMerch(ctx, "testuser", "gold", 3, "", nil)
+
+		require.ErrorIs(t, err, domain.ErrInvalidQuantity)
+		transRepo.AssertNotCalled(t, "ExecutePurchase", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestBuyMerch_PromoCode(t *testing.T) {
+	ctx := context.Background()
+	category := "clothes"
+	hoody := &domain.Merch{Name: "hoody", Price: 300, Category: category, Available: true}
+
+	t.Run("скидка по промокоду", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		promoRepo := new(mockPromoCodeRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, promoRepo)
+
+		merchRepo.On("GetMerchByName", mock.Anything, "hoody").Return(hoody, nil)
+		promoRepo.On("GetPromoCode", mock.Anything, "HOODIES20").
+			Return(&domain.PromoCode{Code: "HOODIES20", DiscountType: domain.PromoDiscountPercent, DiscountValue: 20, Category: &category}, nil)
+		transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: "testuser", ItemName: "hoody", Quantity: 2, UnitPrice: 300, PromoCode: "HOODIES20", Discount: 120}, mock.Anything).
+			Return(nil)
+
+		require.NoError(t, service.BuyMerch(ctx, "testuser", "hoody", 2, "hoodies20", nil))
+		transRepo.AssertExpectations(t)
+	})
+
+	t.Run("промокод не действует на товар", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		promoRepo := new(mockPromoCodeRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, promoRepo)
+		other := "tableware"
+
+		merchRepo.On("GetMerchByName", mock.Anything, "hoody").Return(hoody, nil)
+		promoRepo.On("GetPromoCode", mock.Anything, "CUPS").
+			Return(&domain.PromoCode{Code: "CUPS", DiscountType: domain.PromoDiscountFixed, DiscountValue: 5, Category: &other}, nil)
+
+		err := service.BuyMerch(ctx, "testuser", "hoody", 1, "CUPS", nil)
+		require.ErrorIs(t, err, domain.ErrPromoNotApplicable)
+		transRepo.AssertNotCalled(t, "ExecutePurchase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("промокод не найден", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		promoRepo := new(mockPromoCodeRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), promoRepo)
+
+		merchRepo.On("GetMerchByName", mock.Anything, "hoody").Return(hoody, nil)
+		promoRepo.On("GetPromoCode", mock.Anything, "NOPE").
+			Return(nil, fmt.Errorf("PromoCodeRepository.GetPromoCode: %w", domain.ErrPromoCodeNotFound))
+
+		err := service.BuyMerch(ctx, "testuser", "hoody", 1, "nope", nil)
+		require.ErrorIs(t, err, domain.ErrPromoCodeNotFound)
+	})
+
+	t.Run("лимит исчерпан при покупке", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		promoRepo := new(mockPromoCodeRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, promoRepo)
+
+		merchRepo.On("GetMerchByName", mock.Anything, "hoody").Return(hoody, nil)
+		promoRepo.On("GetPromoCode", mock.Anything, "FIRST-10").
+			Return(&domain.PromoCode{Code: "FIRST-10", DiscountType: domain.PromoDiscountFixed, DiscountValue: 10}, nil)
+		transRepo.On("ExecutePurchase", mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("TransactionRepository.ExecutePurchase: применение промокода: %w: вы уже использовали этот промокод", domain.ErrPromoCodeExhausted))
+
+		err := service.BuyMerch(ctx, "testuser", "hoody", 1, "FIRST-10", nil)
+		require.ErrorIs(t, err, domain.ErrPromoCodeExhausted)
+	})
+}
+
+func TestBuyMerch_ScheduledPrice(t *testing.T) {
+	merchRepo := new(mockMerchRepo)
+	transRepo := new(mockTransactionRepo)
+	service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+	saleEnd := time.Now().Add(time.Hour)
+	merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(&domain.Merch{
+		Name:      "cup",
+		Price:     20,
+		Available: true,
+		PriceSchedules: []*domain.PriceSchedule{
+			{Id: 1, ItemName: "cup", Price: 15, EffectiveFrom: time.Now().Add(-time.Hour), EffectiveTo: &saleEnd},
+		},
+	}, nil)
+	transRepo.On("ExecutePurchase", mock.Anything, &domain.Purchase{Username: "testuser", ItemName: "cup", Quantity: 2, UnitPrice: 15}, mock.Anything).Return(nil)
+
+	require.NoError(t, service.BuyMerch(context.Background(), "testuser", "cup", 2, "", nil))
+	transRepo.AssertExpectations(t)
+}
+
+func TestGiftMerch(t *testing.T) {
+	ctx := context.Background()
+	gift := func(message string) *domain.Gift {
+		return &domain.Gift{
+			Purchase:  domain.Purchase{Username: "alice", ItemName: "cup", Quantity: 2, UnitPrice: 20},
+			Recipient: "bob",
+			Message:   message,
+		}
+	}
+
+	t.Run("подарок оплачивает отправитель", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(&domain.Merch{Name: "cup", Price: 20, Available: true}, nil)
+		transRepo.On("ExecuteGift", mock.Anything, gift("Спасибо за помощь"), mock.Anything).Return(nil)
+
+		require.NoError(t, service.GiftMerch(ctx, "alice", "bob", "cup", 2, "Спасибо за помощь", nil))
+		transRepo.AssertExpectations(t)
+	})
+
+	t.Run("подарок самому себе", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(&domain.Merch{Name: "cup", Price: 20, Available: true}, nil)
+
+		err := service.GiftMerch(ctx, "alice", "alice", "cup", 1, "", nil)
+		require.ErrorIs(t, err, domain.ErrInvalidGift)
+		transRepo.AssertNotCalled(t, "ExecuteGift", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		transRepo := new(mockTransactionRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, transRepo, new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(&domain.Merch{Name: "cup", Price: 20, Available: true}, nil)
+		transRepo.On("ExecuteGift", mock.Anything, gift(""), mock.Anything).Return(domain.ErrRecipientNotFound)
+
+		err := service.GiftMerch(ctx, "alice", "bob", "cup", 2, "", nil)
+		require.ErrorIs(t, err, domain.ErrRecipientNotFound)
+	})
+
+	t.Run("товар недоступен", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(&domain.Merch{Name: "cup", Price: 20}, nil)
+
+		err := service.GiftMerch(ctx, "alice", "bob", "cup", 1, "", nil)
+		require.ErrorIs(t, err, domain.ErrMerchUnavailable)
+	})
+}
+
+func TestGetCatalog_ScheduledPrice(t *testing.T) {
+	merchRepo := new(mockMerchRepo)
+	service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+	saleStart := time.Now().Add(-time.Hour)
+	merchRepo.On("GetAllMerch", mock.Anything).Return([]*domain.Merch{{
+		Name:           "cup",
+		Price:          20,
+		Available:      true,
+		PriceSchedules: []*domain.PriceSchedule{{Id: 1, ItemName: "cup", Price: 15, EffectiveFrom: saleStart}},
+	}}, nil)
+
+	items, etag, err := service.GetCatalog(context.Background())
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, uint64(15), items[0].Price)
+	require.Equal(t, uint64(20), items[0].RegularPrice)
+	require.NotEqual(t, merchETag(&domain.Merch{Name: "cup", Price: 20, RegularPrice: 20, Available: true}), etag)
+}
+
+func TestSchedulePrice(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("цена запланирована с текущего момента", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("CreatePriceSchedule", mock.Anything, mock.AnythingOfType("*domain.PriceSchedule")).Return(nil)
+
+		schedule := &domain.PriceSchedule{ItemName: "cup", Price: 15, CreatedBy: "admin"}
+		require.NoError(t, service.SchedulePrice(ctx, schedule))
+		require.False(t, schedule.EffectiveFrom.IsZero())
+		require.Equal(t, schedule.EffectiveFrom, schedule.CreatedAt)
+		merchRepo.AssertExpectations(t)
+	})
+
+	t.Run("начало в прошлом", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		schedule := &domain.PriceSchedule{ItemName: "cup", Price: 15, EffectiveFrom: time.Now().Add(-time.Hour)}
+		err := service.SchedulePrice(ctx, schedule)
+		require.ErrorIs(t, err, domain.ErrInvalidPriceSchedule)
+		merchRepo.AssertNotCalled(t, "CreatePriceSchedule", mock.Anything, mock.Anything)
+	})
+
+	t.Run("новая цена сразу видна в каталоге", func(t *testing.T) {
+		merchRepo := new(mockMerchRepo)
+		service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(&domain.Merch{Name: "cup", Price: 20, Available: true}, nil).Once()
+		merchRepo.On("CreatePriceSchedule", mock.Anything, mock.Anything).Return(nil)
+		merchRepo.On("GetMerchByName", mock.Anything, "cup").Return(&domain.Merch{Name: "cup", Price: 20, Available: true, PriceSchedules: []*domain.PriceSchedule{
+			{Id: 1, ItemName: "cup", Price: 15, EffectiveFrom: time.Now().Add(-time.Minute)},
+		}}, nil).Once()
+
+		_, _, err := service.GetMerch(ctx, "cup")
+		require.NoError(t, err)
+
+		require.NoError(t, service.SchedulePrice(ctx, &domain.PriceSchedule{ItemName: "cup", Price: 15}))
+
+		item, _, err := service.GetMerch(ctx, "cup")
+		require.NoError(t, err)
+		require.Equal(t, uint64(15), item.Price)
+	})
+}
+
+func TestCancelPriceSchedule(t *testing.T) {
+	merchRepo := new(mockMerchRepo)
+	service := NewMerchService(new(mockUserRepo), merchRepo, new(mockTransactionRepo), new(mockPromoCodeRepo))
+
+	merchRepo.On("CancelPriceSchedule", mock.Anything, "cup", int64(3), mock.AnythingOfType("time.Time")).
+		Return(fmt.Errorf("MerchRepository.CancelPriceSchedule: %w", domain.ErrPriceScheduleNotFound))
+
+	err := service.CancelPriceSchedule(context.Background(), "cup", 3)
+	require.ErrorIs(t, err, domain.ErrPriceScheduleNotFound)
+}
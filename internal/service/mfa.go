@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/netscrawler/avito-shop/internal/totp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew допускает расхождение часов клиента на один период
+	totpSkew = 1
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// MFAService управляет двухфакторной аутентификацией по TOTP
+type mfaService struct {
+	repo   repository.MFARepository
+	users  repository.UserRepository
+	tokens TokenService
+	guard  LoginGuard
+	issuer string
+	now    func() time.Time
+}
+
+// NewMFAService создает новый экземпляр сервиса двухфакторной аутентификации
+func NewMFAService(repo repository.MFARepository, users repository.UserRepository, tokens TokenService, guard LoginGuard, cfg config.MFAConfig) MFAService {
+	return &mfaService{
+		repo:   repo,
+		users:  users,
+		tokens: tokens,
+		guard:  guard,
+		issuer: cfg.Issuer,
+		now:    time.Now,
+	}
+}
+
+// Enroll создает новый секрет и возвращает otpauth-ссылку. Второй фактор включается после Confirm.
+func (s *mfaService) Enroll(ctx context.Context, username string) (*domain.MFAEnrollment, error) {
+	const op = "MFAService.Enroll"
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%s: генерация секрета: %w", op, err)
+	}
+
+	if err := s.repo.SaveMFASecret(ctx, username, secret); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &domain.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, username, secret),
+	}, nil
+}
+
+// Confirm проверяет первый код из приложения, включает второй фактор и возвращает коды восстановления
+func (s *mfaService) Confirm(ctx context.Context, username, code string) ([]string, error) {
+	const op = "MFAService.Confirm"
+
+	factor, err := s.repo.GetMFAFactor(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if factor.Enabled {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrMFAAlreadyEnabled)
+	}
+
+	if _, ok := totp.Validate(factor.Secret, code, s.now(), totpSkew); !ok {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrInvalidMFACode)
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("%s: генерация кода восстановления: %w", op, err)
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := s.repo.EnableMFA(ctx, username, hashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: пользователь %s подключил двухфакторную аутентификацию", op, username)
+	return codes, nil
+}
+
+// IsEnabled проверяет, подключен ли у пользователя второй фактор
+func (s *mfaService) IsEnabled(ctx context.Context, username string) (bool, error) {
+	const op = "MFAService.IsEnabled"
+
+	factor, err := s.repo.GetMFAFactor(ctx, username)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFANotEnrolled) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return factor.Enabled, nil
+}
+
+// VerifyLogin завершает вход: проверяет токен ожидания и код TOTP или код восстановления
+func (s *mfaService) VerifyLogin(ctx context.Context, mfaToken, code string) (*domain.TokenPair, error) {
+	const op = "MFAService.VerifyLogin"
+
+	username, err := s.tokens.ParseMFAToken(mfaToken)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Перебор кодов ограничивается так же, как перебор паролей
+	if err := s.guard.CheckLogin(ctx, username, ""); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	factor, err := s.repo.GetMFAFactor(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !factor.Enabled {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrMFANotEnrolled)
+	}
+
+	ok, err := s.verifyCode(ctx, factor, code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		if err := s.guard.RecordLoginFailure(ctx, username, ""); err != nil {
+			logrus.Errorf("%s: ошибка учета неудачной попытки: %v", op, err)
+		}
+		logrus.Warnf("%s: неверный код второго фактора для пользователя %s", op, username)
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	if err := s.guard.RecordLoginSuccess(ctx, username); err != nil {
+		logrus.Errorf("%s: ошибка сброса счетчика попыток: %v", op, err)
+	}
+
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("%s: получение пользователя: %w", op, err)
+	}
+
+	tokens, err := s.tokens.IssueTokens(ctx, user.Username, user.Role, []string{domain.AMRPassword, domain.AMRMFA})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// verifyCode проверяет код TOTP с защитой от повторного использования или погашает код восстановления
+func (s *mfaService) verifyCode(ctx context.Context, factor *domain.MFAFactor, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(factor.Secret, code, s.now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+		return s.repo.UseMFAStep(ctx, factor.Username, step)
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, factor.Username, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return false, err
+	}
+	if used {
+		logrus.Infof("MFAService: пользователь %s вошел по коду восстановления", factor.Username)
+	}
+	return used, nil
+}
+
+// generateRecoveryCode возвращает код вида xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode приводит код к виду, в котором хранится его хэш
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
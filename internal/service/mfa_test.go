@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/totp"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMFARepo struct {
+	mock.Mock
+}
+
+func (m *mockMFARepo) GetMFAFactor(ctx context.Context, username string) (*domain.MFAFactor, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MFAFactor), args.Error(1)
+}
+
+func (m *mockMFARepo) SaveMFASecret(ctx context.Context, username, secret string) error {
+	args := m.Called(ctx, username, secret)
+	return args.Error(0)
+}
+
+func (m *mockMFARepo) EnableMFA(ctx context.Context, username string, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, username, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *mockMFARepo) UseMFAStep(ctx context.Context, username string, step int64) (bool, error) {
+	args := m.Called(ctx, username, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockMFARepo) UseRecoveryCode(ctx context.Context, username, codeHash string) (bool, error) {
+	args := m.Called(ctx, username, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+// newNotEnrolledMFARepo возвращает репозиторий, в котором ни у кого не подключен второй фактор
+func newNotEnrolledMFARepo() *mockMFARepo {
+	repo := new(mockMFARepo)
+	repo.On("GetMFAFactor", mock.Anything, mock.Anything).Return(nil, domain.ErrMFANotEnrolled).Maybe()
+	return repo
+}
+
+const testMFASecret = "JBSWY3DPEHPK3PXP"
+
+var testMFANow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestMFAService(repo *mockMFARepo, userRepo *mockUserRepo, tokenRepo *mockTokenRepo, attempts *mockLoginAttemptRepo) *mfaService {
+	guard := newTestLoginGuard(attempts, testMFANow)
+	service := NewMFAService(repo, userRepo, newTestTokenService(tokenRepo, userRepo), guard, config.MFAConfig{Issuer: "Avito Shop"}).(*mfaService)
+	service.now = func() time.Time { return testMFANow }
+	return service
+}
+
+func TestMFAEnroll(t *testing.T) {
+	repo := new(mockMFARepo)
+	service := newTestMFAService(repo, new(mockUserRepo), new(mockTokenRepo), new(mockLoginAttemptRepo))
+
+	repo.On("SaveMFASecret", mock.Anything, "testuser", mock.AnythingOfType("string")).Return(nil)
+
+	enrollment, err := service.Enroll(context.Background(), "testuser")
+
+	require.NoError(t, err)
+	require.NotEmpty(t, enrollment.Secret)
+	require.Contains(t, enrollment.URI, "otpauth://totp/")
+	require.Contains(t, enrollment.URI, enrollment.Secret)
+	repo.AssertExpectations(t)
+}
+
+func TestMFAConfirm(t *testing.T) {
+	t.Run("верный код включает второй фактор", func(t *testing.T) {
+		repo := new(mockMFARepo)
+		service := newTestMFAService(repo, new(mockUserRepo), new(mockTokenRepo), new(mockLoginAttemptRepo))
+
+		repo.On("GetMFAFactor", mock.Anything, "testuser").
+			Return(&domain.MFAFactor{Username: "testuser", Secret: testMFASecret}, nil)
+		repo.On("EnableMFA", mock.Anything, "testuser", mock.MatchedBy(func(hashes []string) bool {
+			return len(hashes) == recoveryCodeCount
+		})).Return(nil)
+
+		code, err := totp.Code(testMFASecret, totp.Step(testMFANow))
+		require.NoError(t, err)
+
+		codes, err := service.Confirm(context.Background(), "testuser", code)
+
+		require.NoError(t, err)
+		require.Len(t, codes, recoveryCodeCount)
+		require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("неверный код", func(t *testing.T) {
+		repo := new(mockMFARepo)
+		service := newTestMFAService(repo, new(mockUserRepo), new(mockTokenRepo), new(mockLoginAttemptRepo))
+
+		repo.On("GetMFAFactor", mock.Anything, "testuser").
+			Return(&domain.MFAFactor{Username: "testuser", Secret: testMFASecret}, nil)
+
+		_, err := service.Confirm(context.Background(), "testuser", "000000")
+
+		require.ErrorIs(t, err, domain.ErrInvalidMFACode)
+		repo.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("второй фактор уже подключен", func(t *testing.T) {
+		repo := new(mockMFARepo)
+		service := newTestMFAService(repo, new(mockUserRepo), new(mockTokenRepo), new(mockLoginAttemptRepo))
+
+		repo.On("GetMFAFactor", mock.Anything, "testuser").
+			Return(&domain.MFAFactor{Username: "testuser", Secret: testMFASecret, Enabled: true}, nil)
+
+		_, err := service.Confirm(context.Background(), "testuser", "123456")
+
+		require.ErrorIs(t, err, domain.ErrMFAAlreadyEnabled)
+	})
+}
+
+func TestMFAVerifyLogin(t *testing.T) {
+	enabledFactor := &domain.MFAFactor{Username: "testuser", Secret: testMFASecret, Enabled: true}
+
+	setup := func(t *testing.T) (*mfaService, *mockMFARepo, *mockUserRepo, *mockTokenRepo, *mockLoginAttemptRepo, string) {
+		repo := new(mockMFARepo)
+		userRepo := new(mockUserRepo)
+		tokenRepo := new(mockTokenRepo)
+		attempts := new(mockLoginAttemptRepo)
+		service := newTestMFAService(repo, userRepo, tokenRepo, attempts)
+
+		mfaToken, err := service.tokens.IssueMFAToken("testuser")
+		require.NoError(t, err)
+
+		attempts.On("GetLoginAttempts", mock.Anything, "user:testuser").Return(&domain.LoginAttempts{}, nil)
+		repo.On("GetMFAFactor", mock.Anything, "testuser").Return(enabledFactor, nil)
+		return service, repo, userRepo, tokenRepo, attempts, mfaToken
+	}
+
+	t.Run("верный код TOTP", func(t *testing.T) {
+		service, repo, userRepo, tokenRepo, attempts, mfaToken := setup(t)
+
+		step := totp.Step(testMFANow)
+		code, err := totp.Code(testMFASecret, step)
+		require.NoError(t, err)
+
+		repo.On("UseMFAStep", mock.Anything, "testuser", step).Return(true, nil)
+		attempts.On("ResetLoginAttempts", mock.Anything, "user:testuser").Return(nil)
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").
+			Return(&domain.User{Username: "testuser", Role: domain.RoleEmployee}, nil)
+		tokenRepo.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *domain.RefreshToken) bool {
+			return domain.HasAMR(token.Amr, domain.AMRMFA)
+		})).Return(nil)
+
+		tokens, err := service.VerifyLogin(context.Background(), mfaToken, code)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, tokens.AccessToken)
+		repo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+		attempts.AssertExpectations(t)
+	})
+
+	t.Run("повторное использование кода", func(t *testing.T) {
+		service, repo, _, _, attempts, mfaToken := setup(t)
+
+		step := totp.Step(testMFANow)
+		code, err := totp.Code(testMFASecret, step)
+		require.NoError(t, err)
+
+		repo.On("UseMFAStep", mock.Anything, "testuser", step).Return(false, nil)
+		attempts.On("RecordLoginFailure", mock.Anything, "user:testuser", testMFANow, testLockoutConfig.ResetAfter).
+			Return(&domain.LoginAttempts{Failures: 1}, nil)
+
+		_, err = service.VerifyLogin(context.Background(), mfaToken, code)
+
+		require.ErrorIs(t, err, domain.ErrInvalidMFACode)
+		attempts.AssertExpectations(t)
+	})
+
+	t.Run("код восстановления", func(t *testing.T) {
+		service, repo, userRepo, tokenRepo, attempts, mfaToken := setup(t)
+
+		repo.On("UseRecoveryCode", mock.Anything, "testuser", hashToken("abcdefghij")).Return(true, nil)
+		attempts.On("ResetLoginAttempts", mock.Anything, "user:testuser").Return(nil)
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").
+			Return(&domain.User{Username: "testuser", Role: domain.RoleEmployee}, nil)
+		tokenRepo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+
+		tokens, err := service.VerifyLogin(context.Background(), mfaToken, "ABCDE-FGHIJ")
+
+		require.NoError(t, err)
+		require.NotEmpty(t, tokens.RefreshToken)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("access-токен вместо токена ожидания", func(t *testing.T) {
+		service, _, _, tokenRepo, _, _ := setup(t)
+
+		tokenRepo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+		pair, err := service.tokens.IssueTokens(context.Background(), "testuser", domain.RoleEmployee, []string{domain.AMRPassword})
+		require.NoError(t, err)
+
+		_, err = service.VerifyLogin(context.Background(), pair.AccessToken, "123456")
+
+		require.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// orderService управляет заказами и их выдачей
+type orderService struct {
+	orderRepo repository.OrderRepository
+	cache     MerchCache
+	now       func() time.Time
+}
+
+// NewOrderService создает новый экземпляр сервиса заказов
+func NewOrderService(orderRepo repository.OrderRepository, cache MerchCache) OrderService {
+	return &orderService{
+		orderRepo: orderRepo,
+		cache:     cache,
+		now:       time.Now,
+	}
+}
+
+// ListOrders возвращает заказы, подходящие под фильтр, начиная с последних
+func (s *orderService) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	const op = "OrderService.ListOrders"
+
+	if filter.Limit == 0 {
+		filter.Limit = domain.DefaultOrderPageSize
+	}
+	if filter.Limit > domain.MaxOrderPageSize {
+		filter.Limit = domain.MaxOrderPageSize
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	orders, err := s.orderRepo.GetOrders(ctx, filter)
+	if err != nil {
+		logrus.Errorf("%s: ошибка при получении заказов: %v", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+// GetOrder возвращает заказ с журналом изменений статуса
+func (s *orderService) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	const op = "OrderService.GetOrder"
+
+	order, err := s.orderRepo.GetOrder(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrOrderNotFound)
+		}
+		logrus.Errorf("%s: ошибка при получении заказа %d: %v", op, id, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return order, nil
+}
+
+// GetUserOrder возвращает заказ пользователя. Чужой заказ считается ненайденным.
+func (s *orderService) GetUserOrder(ctx context.Context, username string, id int64) (*domain.Order, error) {
+	const op = "OrderService.GetUserOrder"
+
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Username != username {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrOrderNotFound)
+	}
+
+	return order, nil
+}
+
+// ChangeOrderStatus переводит заказ в новый статус и возвращает обновленный заказ
+func (s *orderService) ChangeOrderStatus(ctx context.Context, id int64, status domain.OrderStatus, changedBy, comment string) (*domain.Order, error) {
+	const op = "OrderService.ChangeOrderStatus"
+
+	change := &domain.OrderStatusChange{
+		OrderId:   id,
+		To:        status,
+		ChangedBy: changedBy,
+		Comment:   comment,
+		ChangedAt: s.now(),
+	}
+	if err := s.orderRepo.UpdateOrderStatus(ctx, change); err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) || errors.Is(err, domain.ErrOrderTransition) || errors.Is(err, domain.ErrInventoryShortage) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при изменении статуса заказа %d: %v", op, id, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: %s перевел заказ %d из %s в %s", op, changedBy, id, change.From, change.To)
+
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// При отмене товар вернулся на склад — кэшированные остатки устарели
+	if change.To == domain.OrderStatusCancelled {
+		names := make([]string, 0, len(order.Items))
+		for _, item := range order.Items {
+			names = append(names, item.ItemName)
+		}
+		s.cache.InvalidateMerch(names...)
+	}
+
+	return order, nil
+}
+
+// CancelUserOrder отменяет невыданный заказ по просьбе покупателя.
+// Монеты возвращаются на баланс, а товар списывается из инвентаря и возвращается на склад.
+func (s *orderService) CancelUserOrder(ctx context.Context, username string, id int64) (*domain.Order, error) {
+	if _, err := s.GetUserOrder(ctx, username, id); err != nil {
+		return nil, err
+	}
+
+	return s.ChangeOrderStatus(ctx, id, domain.OrderStatusCancelled, username, "")
+}
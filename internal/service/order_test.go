@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockOrderRepo struct {
+	mock.Mock
+}
+
+func (m *mockOrderRepo) GetOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderRepo) GetOrder(ctx context.Context, id int64) (*domain.Order, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Order), args.Error(1)
+}
+
+func (m *mockOrderRepo) UpdateOrderStatus(ctx context.Context, change *domain.OrderStatusChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+func newTestOrderService(repo *mockOrderRepo, now time.Time) *orderService {
+	s := NewOrderService(repo, new(recordingMerchCache)).(*orderService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestListOrders(t *testing.T) {
+	t.Run("размер страницы по умолчанию", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, time.Now())
+		repo.On("GetOrders", mock.Anything, domain.OrderFilter{Username: "buyer", Limit: domain.DefaultOrderPageSize}).Return([]*domain.Order{}, nil)
+
+		_, err := s.ListOrders(context.Background(), domain.OrderFilter{Username: "buyer"})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("размер страницы ограничен", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, time.Now())
+		repo.On("GetOrders", mock.Anything, domain.OrderFilter{Limit: domain.MaxOrderPageSize}).Return([]*domain.Order{}, nil)
+
+		_, err := s.ListOrders(context.Background(), domain.OrderFilter{Limit: 1000})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("неизвестный статус", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, time.Now())
+
+		_, err := s.ListOrders(context.Background(), domain.OrderFilter{Status: "lost"})
+		assert.ErrorIs(t, err, domain.ErrInvalidFilter)
+		repo.AssertNotCalled(t, "GetOrders", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetUserOrder(t *testing.T) {
+	repo := new(mockOrderRepo)
+	s := newTestOrderService(repo, time.Now())
+	repo.On("GetOrder", mock.Anything, int64(7)).Return(&domain.Order{Id: 7, Username: "buyer"}, nil)
+
+	t.Run("свой заказ", func(t *testing.T) {
+		order, err := s.GetUserOrder(context.Background(), "buyer", 7)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), order.Id)
+	})
+
+	t.Run("чужой заказ", func(t *testing.T) {
+		_, err := s.GetUserOrder(context.Background(), "other", 7)
+		assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+	})
+}
+
+func TestChangeOrderStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+
+	t.Run("статус изменен", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, now)
+
+		change := &domain.OrderStatusChange{OrderId: 7, To: domain.OrderStatusReadyForPickup, ChangedBy: "manager", Comment: "стойка 3", ChangedAt: now}
+		repo.On("UpdateOrderStatus", mock.Anything, change).Return(nil)
+		repo.On("GetOrder", mock.Anything, int64(7)).Return(&domain.Order{Id: 7, Status: domain.OrderStatusReadyForPickup}, nil)
+
+		order, err := s.ChangeOrderStatus(context.Background(), 7, domain.OrderStatusReadyForPickup, "manager", "стойка 3")
+		require.NoError(t, err)
+		assert.Equal(t, domain.OrderStatusReadyForPickup, order.Status)
+		assert.Empty(t, s.cache.(*recordingMerchCache).invalidated)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("недопустимый переход", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, now)
+
+		repo.On("UpdateOrderStatus", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("OrderRepository.UpdateOrderStatus: %w: из delivered в cancelled", domain.ErrOrderTransition))
+
+		_, err := s.ChangeOrderStatus(context.Background(), 7, domain.OrderStatusCancelled, "manager", "")
+		assert.ErrorIs(t, err, domain.ErrOrderTransition)
+		repo.AssertNotCalled(t, "GetOrder", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCancelUserOrder(t *testing.T) {
+	now := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+
+	t.Run("заказ отменен покупателем", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, now)
+
+		change := &domain.OrderStatusChange{OrderId: 7, To: domain.OrderStatusCancelled, ChangedBy: "buyer", ChangedAt: now}
+		repo.On("GetOrder", mock.Anything, int64(7)).Return(&domain.Order{Id: 7, Username: "buyer", Status: domain.OrderStatusPlaced}, nil).Once()
+		repo.On("UpdateOrderStatus", mock.Anything, change).Return(nil)
+		repo.On("GetOrder", mock.Anything, int64(7)).Return(&domain.Order{Id: 7, Username: "buyer", Status: domain.OrderStatusCancelled, Items: []*domain.OrderItem{
+			{ItemName: "cup", Quantity: 2, UnitPrice: 20},
+		}}, nil).Once()
+
+		order, err := s.CancelUserOrder(context.Background(), "buyer", 7)
+		require.NoError(t, err)
+		assert.Equal(t, domain.OrderStatusCancelled, order.Status)
+		assert.Equal(t, []string{"cup"}, s.cache.(*recordingMerchCache).invalidated)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("чужой заказ", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, now)
+		repo.On("GetOrder", mock.Anything, int64(7)).Return(&domain.Order{Id: 7, Username: "buyer"}, nil)
+
+		_, err := s.CancelUserOrder(context.Background(), "other", 7)
+		assert.ErrorIs(t, err, domain.ErrOrderNotFound)
+		repo.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("товар уже передан", func(t *testing.T) {
+		repo := new(mockOrderRepo)
+		s := newTestOrderService(repo, now)
+		repo.On("GetOrder", mock.Anything, int64(7)).Return(&domain.Order{Id: 7, Username: "buyer"}, nil)
+		repo.On("UpdateOrderStatus", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("OrderRepository.UpdateOrderStatus: %w: cup", domain.ErrInventoryShortage))
+
+		_, err := s.CancelUserOrder(context.Background(), "buyer", 7)
+		assert.ErrorIs(t, err, domain.ErrInventoryShortage)
+	})
+}
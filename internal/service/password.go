@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/hasher"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// PasswordService управляет сменой и сбросом паролей
+type passwordService struct {
+	users          repository.UserRepository
+	resets         repository.PasswordResetRepository
+	tokens         TokenService
+	hasher         hasher.PasswordHasher
+	passwordPolicy domain.PasswordPolicy
+	resetTTL       time.Duration
+}
+
+// NewPasswordService создает новый экземпляр сервиса паролей
+func NewPasswordService(users repository.UserRepository, resets repository.PasswordResetRepository, tokens TokenService, hasher hasher.PasswordHasher, cfg config.AuthConfig) PasswordService {
+	return &passwordService{
+		users:          users,
+		resets:         resets,
+		tokens:         tokens,
+		hasher:         hasher,
+		passwordPolicy: domain.NewPasswordPolicy(cfg.PasswordMinLength),
+		resetTTL:       cfg.PasswordResetTTL,
+	}
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего и отзывает его refresh-токены
+func (s *passwordService) ChangePassword(ctx context.Context, username, oldPassword, newPassword string) error {
+	const op = "PasswordService.ChangePassword"
+
+	user, err := s.users.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("%s: получение пользователя: %w", op, err)
+	}
+
+	ok, err := s.hasher.Verify(user.Password, oldPassword)
+	if err != nil || !ok {
+		logrus.Warnf("%s: неверный текущий пароль пользователя %s", op, username)
+		return domain.ErrInvalidCredentials
+	}
+
+	if oldPassword == newPassword {
+		return fmt.Errorf("%s: %w: новый пароль совпадает с текущим", op, domain.ErrWeakPassword)
+	}
+
+	hashedPassword, err := s.hashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.users.UpdateUserPassword(ctx, username, hashedPassword); err != nil {
+		return fmt.Errorf("%s: обновление пароля: %w", op, err)
+	}
+
+	if err := s.tokens.RevokeAllTokens(ctx, username); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: пароль пользователя %s изменен", op, username)
+	return nil
+}
+
+// IssueResetToken выпускает одноразовый токен сброса пароля и возвращает его вместе со временем истечения
+func (s *passwordService) IssueResetToken(ctx context.Context, username string) (string, time.Time, error) {
+	const op = "PasswordService.IssueResetToken"
+
+	if _, err := s.users.GetUserByUsername(ctx, username); err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: получение пользователя: %w", op, err)
+	}
+
+	resetToken, err := generateRandomToken(32)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: генерация токена: %w", op, err)
+	}
+
+	record := domain.NewPasswordResetToken(hashToken(resetToken), username, time.Now().Add(s.resetTTL))
+	if err := s.resets.CreatePasswordResetToken(ctx, record); err != nil {
+		logrus.Errorf("%s: ошибка сохранения токена сброса: %v", op, err)
+		return "", time.Time{}, fmt.Errorf("%s: сохранение токена: %w", op, err)
+	}
+
+	logrus.Infof("%s: выпущен токен сброса пароля для пользователя %s", op, username)
+	return resetToken, record.ExpiresAt, nil
+}
+
+// ResetPassword устанавливает новый пароль по одноразовому токену сброса и отзывает токены пользователя
+func (s *passwordService) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	const op = "PasswordService.ResetPassword"
+
+	// Проверяем пароль до погашения токена, чтобы слабый пароль не сжигал токен
+	hashedPassword, err := s.hashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	username, err := s.resets.ResetPassword(ctx, hashToken(resetToken), hashedPassword, time.Now())
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			logrus.Warnf("%s: предъявлен недействительный токен сброса пароля", op)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.tokens.RevokeAllTokens(ctx, username); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: пароль пользователя %s сброшен", op, username)
+	return nil
+}
+
+func (s *passwordService) hashPassword(password string) ([]byte, error) {
+	if err := s.passwordPolicy.Validate(password); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, fmt.Errorf("хеширование пароля: %w", err)
+	}
+	return hashedPassword, nil
+}
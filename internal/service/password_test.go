@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type mockPasswordResetRepo struct {
+	mock.Mock
+}
+
+func (m *mockPasswordResetRepo) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockPasswordResetRepo) ResetPassword(ctx context.Context, tokenHash string, password []byte, now time.Time) (string, error) {
+	args := m.Called(ctx, tokenHash, password, now)
+	return args.String(0), args.Error(1)
+}
+
+func newTestPasswordService(userRepo *mockUserRepo, resetRepo *mockPasswordResetRepo, tokenRepo *mockTokenRepo) PasswordService {
+	cfg := testAuthConfig
+	cfg.PasswordResetTTL = time.Hour
+	return NewPasswordService(userRepo, resetRepo, newTestTokenService(tokenRepo, userRepo), testHasher, cfg)
+}
+
+func TestChangePassword(t *testing.T) {
+	hashedPassword, _ := testHasher.Hash("old-password1")
+
+	t.Run("успешная смена пароля", func(t *testing.T) {
+		userRepo := new(mockUserRepo)
+		tokenRepo := new(mockTokenRepo)
+		service := newTestPasswordService(userRepo, new(mockPasswordResetRepo), tokenRepo)
+
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").
+			Return(&domain.User{Username: "testuser", Password: hashedPassword}, nil)
+		userRepo.On("UpdateUserPassword", mock.Anything, "testuser", mock.MatchedBy(func(hash []byte) bool {
+			return bcrypt.CompareHashAndPassword(hash, []byte("new-password1")) == nil
+		})).Return(nil)
+		tokenRepo.On("RevokeUserAccessTokens", mock.Anything, "testuser", mock.Anything).Return(nil)
+		tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, "testuser").Return(nil)
+
+		err := service.ChangePassword(context.Background(), "testuser", "old-password1", "new-password1")
+
+		require.NoError(t, err)
+		userRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("неверный текущий пароль", func(t *testing.T) {
+		userRepo := new(mockUserRepo)
+		service := newTestPasswordService(userRepo, new(mockPasswordResetRepo), new(mockTokenRepo))
+
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").
+			Return(&domain.User{Username: "testuser", Password: hashedPassword}, nil)
+
+		err := service.ChangePassword(context.Background(), "testuser", "wrong-password1", "new-password1")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		userRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("слабый новый пароль", func(t *testing.T) {
+		userRepo := new(mockUserRepo)
+		service := newTestPasswordService(userRepo, new(mockPasswordResetRepo), new(mockTokenRepo))
+
+		userRepo.On("GetUserByUsername", mock.Anything, "testuser").
+			Return(&domain.User{Username: "testuser", Password: hashedPassword}, nil)
+
+		err := service.ChangePassword(context.Background(), "testuser", "old-password1", "short")
+
+		assert.ErrorIs(t, err, domain.ErrWeakPassword)
+		userRepo.AssertNotCalled(t, "UpdateUserPassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestIssueResetToken(t *testing.T) {
+	userRepo := new(mockUserRepo)
+	resetRepo := new(mockPasswordResetRepo)
+	service := newTestPasswordService(userRepo, resetRepo, new(mockTokenRepo))
+
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(&domain.User{Username: "testuser"}, nil)
+	resetRepo.On("CreatePasswordResetToken", mock.Anything, mock.MatchedBy(func(token *domain.PasswordResetToken) bool {
+		return token.Username == "testuser" && token.TokenHash != ""
+	})).Return(nil)
+
+	resetToken, expiresAt, err := service.IssueResetToken(context.Background(), "testuser")
+
+	require.NoError(t, err)
+	require.NotEmpty(t, resetToken)
+	require.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+	resetRepo.AssertExpectations(t)
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Run("успешный сброс", func(t *testing.T) {
+		userRepo := new(mockUserRepo)
+		resetRepo := new(mockPasswordResetRepo)
+		tokenRepo := new(mockTokenRepo)
+		service := newTestPasswordService(userRepo, resetRepo, tokenRepo)
+
+		resetRepo.On("ResetPassword", mock.Anything, hashToken("reset-token"), mock.Anything, mock.Anything).
+			Return("testuser", nil)
+		tokenRepo.On("RevokeUserAccessTokens", mock.Anything, "testuser", mock.Anything).Return(nil)
+		tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, "testuser").Return(nil)
+
+		err := service.ResetPassword(context.Background(), "reset-token", "new-password1")
+
+		require.NoError(t, err)
+		resetRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("слабый пароль не погашает токен", func(t *testing.T) {
+		resetRepo := new(mockPasswordResetRepo)
+		service := newTestPasswordService(new(mockUserRepo), resetRepo, new(mockTokenRepo))
+
+		err := service.ResetPassword(context.Background(), "reset-token", "short")
+
+		assert.ErrorIs(t, err, domain.ErrWeakPassword)
+		resetRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("недействительный токен", func(t *testing.T) {
+		resetRepo := new(mockPasswordResetRepo)
+		service := newTestPasswordService(new(mockUserRepo), resetRepo, new(mockTokenRepo))
+
+		resetRepo.On("ResetPassword", mock.Anything, hashToken("used-token"), mock.Anything, mock.Anything).
+			Return("", domain.ErrInvalidToken)
+
+		err := service.ResetPassword(context.Background(), "used-token", "new-password1")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// promoCodeService управляет промокодами на скидку
+type promoCodeService struct {
+	promoRepo repository.PromoCodeRepository
+	now       func() time.Time
+}
+
+// NewPromoCodeService создает новый экземпляр сервиса промокодов
+func NewPromoCodeService(promoRepo repository.PromoCodeRepository) PromoCodeService {
+	return &promoCodeService{
+		promoRepo: promoRepo,
+		now:       time.Now,
+	}
+}
+
+// CreatePromoCode проверяет и сохраняет новый промокод
+func (s *promoCodeService) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	const op = "PromoCodeService.CreatePromoCode"
+
+	promo.Code = domain.NormalizePromoCode(promo.Code)
+	promo.Redemptions = 0
+	promo.CreatedAt = s.now()
+	if err := promo.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.promoRepo.CreatePromoCode(ctx, promo); err != nil {
+		if errors.Is(err, domain.ErrPromoCodeExists) || errors.Is(err, domain.ErrMerchNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при создании промокода %s: %v", op, promo.Code, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: создан промокод %s (%s, %d)", op, promo.Code, promo.DiscountType, promo.DiscountValue)
+	return nil
+}
+
+// ListPromoCodes возвращает все промокоды вместе со счетчиками использований
+func (s *promoCodeService) ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	const op = "PromoCodeService.ListPromoCodes"
+
+	promos, err := s.promoRepo.GetPromoCodes(ctx)
+	if err != nil {
+		logrus.Errorf("%s: ошибка при получении промокодов: %v", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return promos, nil
+}
+
+// DeactivatePromoCode досрочно завершает действие промокода
+func (s *promoCodeService) DeactivatePromoCode(ctx context.Context, code string) error {
+	const op = "PromoCodeService.DeactivatePromoCode"
+
+	code = domain.NormalizePromoCode(code)
+	if err := s.promoRepo.DeactivatePromoCode(ctx, code, s.now()); err != nil {
+		if errors.Is(err, domain.ErrPromoCodeNotFound) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при отключении промокода %s: %v", op, code, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: промокод %s отключен", op, code)
+	return nil
+}
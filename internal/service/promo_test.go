@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPromoCodeRepo struct {
+	mock.Mock
+}
+
+func (m *mockPromoCodeRepo) CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error {
+	args := m.Called(ctx, promo)
+	return args.Error(0)
+}
+
+func (m *mockPromoCodeRepo) GetPromoCode(ctx context.Context, code string) (*domain.PromoCode, error) {
+	args := m.Called(ctx, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PromoCode), args.Error(1)
+}
+
+func (m *mockPromoCodeRepo) GetPromoCodes(ctx context.Context) ([]*domain.PromoCode, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PromoCode), args.Error(1)
+}
+
+func (m *mockPromoCodeRepo) DeactivatePromoCode(ctx context.Context, code string, now time.Time) error {
+	args := m.Called(ctx, code, now)
+	return args.Error(0)
+}
+
+func newTestPromoCodeService(repo *mockPromoCodeRepo, now time.Time) *promoCodeService {
+	s := NewPromoCodeService(repo).(*promoCodeService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestCreatePromoCode(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("код приводится к верхнему регистру", func(t *testing.T) {
+		repo := new(mockPromoCodeRepo)
+		s := newTestPromoCodeService(repo, now)
+		repo.On("CreatePromoCode", mock.Anything, mock.Anything).Return(nil)
+
+		promo := &domain.PromoCode{Code: " first-10 ", DiscountType: domain.PromoDiscountFixed, DiscountValue: 10, Redemptions: 5}
+		require.NoError(t, s.CreatePromoCode(context.Background(), promo))
+		assert.Equal(t, "FIRST-10", promo.Code)
+		assert.Zero(t, promo.Redemptions)
+		assert.Equal(t, now, promo.CreatedAt)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("некорректная скидка", func(t *testing.T) {
+		repo := new(mockPromoCodeRepo)
+		s := newTestPromoCodeService(repo, now)
+
+		err := s.CreatePromoCode(context.Background(), &domain.PromoCode{Code: "ALL", DiscountType: domain.PromoDiscountPercent, DiscountValue: 150})
+		assert.ErrorIs(t, err, domain.ErrInvalidPromoCode)
+		repo.AssertNotCalled(t, "CreatePromoCode", mock.Anything, mock.Anything)
+	})
+
+	t.Run("код уже существует", func(t *testing.T) {
+		repo := new(mockPromoCodeRepo)
+		s := newTestPromoCodeService(repo, now)
+		repo.On("CreatePromoCode", mock.Anything, mock.Anything).
+			Return(fmt.Errorf("PromoCodeRepository.CreatePromoCode: %w", domain.ErrPromoCodeExists))
+
+		err := s.CreatePromoCode(context.Background(), &domain.PromoCode{Code: "FIRST-10", DiscountType: domain.PromoDiscountFixed, DiscountValue: 10})
+		assert.ErrorIs(t, err, domain.ErrPromoCodeExists)
+	})
+}
+
+func TestDeactivatePromoCode(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := new(mockPromoCodeRepo)
+	s := newTestPromoCodeService(repo, now)
+	repo.On("DeactivatePromoCode", mock.Anything, "FIRST-10", now).Return(nil)
+	repo.On("DeactivatePromoCode", mock.Anything, "NOPE", now).
+		Return(fmt.Errorf("PromoCodeRepository.DeactivatePromoCode: %w", domain.ErrPromoCodeNotFound))
+
+	require.NoError(t, s.DeactivatePromoCode(context.Background(), "first-10"))
+	assert.ErrorIs(t, s.DeactivatePromoCode(context.Background(), "nope"), domain.ErrPromoCodeNotFound)
+}
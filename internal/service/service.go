@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/netscrawler/avito-shop/internal/domain"
 	"github.com/netscrawler/avito-shop/internal/model"
@@ -13,17 +15,114 @@ const (
 )
 
 type UserService interface {
+	SignUp(ctx context.Context, username, password string) error
 	RegisterUser(ctx context.Context, username, password string) error
-	AuthenticateUser(ctx context.Context, username, password string) (string, error)
+	AuthenticateUser(ctx context.Context, username, password string) (*domain.TokenPair, error)
 	GetUserInfo(ctx context.Context, username string) (*domain.User, error)
+	SetUserRole(ctx context.Context, username string, role domain.Role) error
 }
 
 type TransferService interface {
-	SendCoins(ctx context.Context, sender, receiver string, amount uint64) error
-	GetTransactionHistory(ctx context.Context, username string) (model.CoinHistory, error)
+	SendCoins(ctx context.Context, sender, receiver string, amount uint64, idem *domain.IdempotencyRecord) error
+	GetTransactionHistory(ctx context.Context, username string, limit int) (model.CoinHistory, error)
+	ListTransactions(ctx context.Context, filter domain.LedgerFilter) (model.TransactionPage, error)
+	GrantCoins(ctx context.Context, granter, receiver string, amount uint64) error
+	TransferItem(ctx context.Context, from, to, itemName string, quantity uint64, idem *domain.IdempotencyRecord) error
+}
+
+type CartService interface {
+	GetCart(ctx context.Context, username string) (*domain.Cart, error)
+	AddItem(ctx context.Context, username, itemName string, quantity uint64) (*domain.Cart, error)
+	RemoveItem(ctx context.Context, username, itemName string) (*domain.Cart, error)
+	Checkout(ctx context.Context, username string, idem *domain.IdempotencyRecord) (*domain.Order, error)
+}
+
+type OrderService interface {
+	ListOrders(ctx context.Context, filter domain.OrderFilter) ([]*domain.Order, error)
+	GetOrder(ctx context.Context, id int64) (*domain.Order, error)
+	GetUserOrder(ctx context.Context, username string, id int64) (*domain.Order, error)
+	ChangeOrderStatus(ctx context.Context, id int64, status domain.OrderStatus, changedBy, comment string) (*domain.Order, error)
+	CancelUserOrder(ctx context.Context, username string, id int64) (*domain.Order, error)
+}
+
+type StatementService interface {
+	ExportStatement(ctx context.Context, filter domain.StatementFilter, format domain.StatementFormat, w io.Writer) error
 }
 
 type MerchService interface {
-	BuyMerch(ctx context.Context, username, merchName string) error
+	MerchCache
+	BuyMerch(ctx context.Context, username, merchName string, quantity uint64, promoCode string, idem *domain.IdempotencyRecord) error
+	GiftMerch(ctx context.Context, sender, recipient, merchName string, quantity uint64, message string, idem *domain.IdempotencyRecord) error
 	GetAllMerch(ctx context.Context) ([]*domain.Merch, error)
+	GetCatalog(ctx context.Context) ([]*domain.Merch, string, error)
+	GetMerch(ctx context.Context, name string) (*domain.Merch, string, error)
+	CreateMerch(ctx context.Context, item *domain.Merch) error
+	UpdateMerch(ctx context.Context, name string, update domain.MerchUpdate) (*domain.Merch, error)
+	RetireMerch(ctx context.Context, name string) error
+	RestockMerch(ctx context.Context, name string, quantity uint64) (*domain.Merch, error)
+	SchedulePrice(ctx context.Context, schedule *domain.PriceSchedule) error
+	CancelPriceSchedule(ctx context.Context, name string, id int64) error
+	GetPriceHistory(ctx context.Context, name string) (*domain.PriceHistory, error)
+}
+
+// MerchCache сбрасывает кэш товаров, остатки которых изменились вне MerchService
+type MerchCache interface {
+	InvalidateMerch(names ...string)
+}
+
+type PromoCodeService interface {
+	CreatePromoCode(ctx context.Context, promo *domain.PromoCode) error
+	ListPromoCodes(ctx context.Context) ([]*domain.PromoCode, error)
+	DeactivatePromoCode(ctx context.Context, code string) error
+}
+
+type TokenService interface {
+	IssueTokens(ctx context.Context, username string, role domain.Role, amr []string) (*domain.TokenPair, error)
+	IssueMFAToken(username string) (string, error)
+	ParseMFAToken(mfaToken string) (string, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	RevokeTokens(ctx context.Context, username, refreshToken, jti string, expiresAt time.Time) error
+	RevokeAllTokens(ctx context.Context, username string) error
+	IsTokenRevoked(ctx context.Context, username, jti string, issuedAt time.Time) (bool, error)
+}
+
+type PasswordService interface {
+	ChangePassword(ctx context.Context, username, oldPassword, newPassword string) error
+	IssueResetToken(ctx context.Context, username string) (string, time.Time, error)
+	ResetPassword(ctx context.Context, resetToken, newPassword string) error
+}
+
+type MFAService interface {
+	Enroll(ctx context.Context, username string) (*domain.MFAEnrollment, error)
+	Confirm(ctx context.Context, username, code string) ([]string, error)
+	IsEnabled(ctx context.Context, username string) (bool, error)
+	VerifyLogin(ctx context.Context, mfaToken, code string) (*domain.TokenPair, error)
+}
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, account, name string, scopes []string, expiresAt *time.Time) (string, *domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, account string) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*domain.APIKey, error)
+}
+
+type IdempotencyService interface {
+	Lookup(ctx context.Context, username, key, requestHash string) (*domain.IdempotencyRecord, error)
+	NewRecord(username, key, requestHash string) *domain.IdempotencyRecord
+	SaveResult(ctx context.Context, record *domain.IdempotencyRecord) error
+}
+
+type LoginGuard interface {
+	CheckLogin(ctx context.Context, username, ip string) error
+	RecordLoginFailure(ctx context.Context, username, ip string) error
+	RecordLoginSuccess(ctx context.Context, username string) error
+	UnlockUser(ctx context.Context, username string) error
+}
+
+type MarketService interface {
+	CreateListing(ctx context.Context, seller, itemName string, quantity, price uint64) (*domain.Listing, error)
+	ListListings(ctx context.Context, filter domain.ListingFilter) ([]*domain.Listing, error)
+	GetListing(ctx context.Context, id int64) (*domain.Listing, error)
+	CancelListing(ctx context.Context, seller string, id int64) (*domain.Listing, error)
+	BuyListing(ctx context.Context, buyer string, id int64, idem *domain.IdempotencyRecord) (*domain.Listing, error)
 }
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// StatementService формирует выписки по операциям с монетами
+type statementService struct {
+	transRepo repository.TransactionRepository
+	now       func() time.Time
+}
+
+// NewStatementService создает новый экземпляр сервиса выписок
+func NewStatementService(transRepo repository.TransactionRepository) StatementService {
+	return &statementService{
+		transRepo: transRepo,
+		now:       time.Now,
+	}
+}
+
+// ExportStatement записывает выписку за период в w по мере чтения строк из базы.
+// Если период не задан, выписка формируется с начала текущего месяца до текущего момента.
+func (s *statementService) ExportStatement(ctx context.Context, filter domain.StatementFilter, format domain.StatementFormat, w io.Writer) error {
+	const op = "StatementService.ExportStatement"
+
+	now := s.now()
+	if filter.From.IsZero() {
+		filter.From = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	if filter.To.IsZero() {
+		filter.To = now
+	}
+	if err := filter.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	encoder, err := newStatementEncoder(format, w)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var last *domain.StatementRow
+	var balance int64
+	err = s.transRepo.StreamStatement(ctx, filter, func(row *domain.StatementRow) error {
+		if last == nil || last.Username != row.Username {
+			if last != nil {
+				if err := encoder.Encode(closingLine(last, filter.To)); err != nil {
+					return err
+				}
+			}
+			balance = row.OpeningBalance
+			if err := encoder.Encode(model.StatementLine{
+				Username:  row.Username,
+				Record:    model.StatementRecordOpening,
+				Timestamp: filter.From,
+				Balance:   balance,
+			}); err != nil {
+				return err
+			}
+		}
+		last = row
+
+		if row.Entry == nil {
+			return nil
+		}
+		balance += row.Entry.SignedAmount()
+		return encoder.Encode(model.StatementLine{
+			Username:     row.Username,
+			Record:       model.StatementRecordTransaction,
+			Id:           row.Entry.TransactionId,
+			Timestamp:    row.Entry.Timestamp,
+			Type:         string(row.Entry.Type),
+			Direction:    string(row.Entry.Direction),
+			Counterparty: row.Entry.Counterparty,
+			Amount:       row.Entry.Amount,
+			Item:         row.Entry.ItemName,
+			Quantity:     row.Entry.Quantity,
+			Balance:      balance,
+		})
+	})
+	if err != nil {
+		logrus.Errorf("%s: ошибка выгрузки выписки: %v", op, err)
+		return fmt.Errorf("%s: выгрузка операций: %w", op, err)
+	}
+
+	if last != nil {
+		if err := encoder.Encode(closingLine(last, filter.To)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Последняя строка позволяет клиенту отличить полную выписку от оборванной
+	if err := encoder.Encode(model.StatementLine{Record: model.StatementRecordEnd, Timestamp: filter.To}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// closingLine возвращает строку с балансом пользователя на конец периода
+func closingLine(row *domain.StatementRow, to time.Time) model.StatementLine {
+	return model.StatementLine{
+		Username:  row.Username,
+		Record:    model.StatementRecordClosing,
+		Timestamp: to,
+		Balance:   row.ClosingBalance,
+	}
+}
+
+// statementEncoder записывает строки выписки в выбранном формате
+type statementEncoder interface {
+	Encode(line model.StatementLine) error
+	Flush() error
+}
+
+func newStatementEncoder(format domain.StatementFormat, w io.Writer) (statementEncoder, error) {
+	switch format {
+	case domain.StatementFormatCSV:
+		return &csvStatementEncoder{w: csv.NewWriter(w)}, nil
+	case domain.StatementFormatJSONL:
+		return &jsonlStatementEncoder{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, domain.ErrInvalidFilter
+	}
+}
+
+var statementCSVHeader = []string{"username", "record", "id", "timestamp", "type", "direction", "counterparty", "amount", "item", "quantity", "balance"}
+
+// csvStatementEncoder записывает выписку в CSV с заголовком
+type csvStatementEncoder struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvStatementEncoder) Encode(line model.StatementLine) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	var id, amount, quantity, balance string
+	if line.Record != model.StatementRecordEnd {
+		balance = strconv.FormatInt(line.Balance, 10)
+	}
+	if line.Record == model.StatementRecordTransaction {
+		id = strconv.FormatInt(line.Id, 10)
+		amount = strconv.FormatUint(line.Amount, 10)
+	}
+	if line.Quantity > 0 {
+		quantity = strconv.FormatUint(line.Quantity, 10)
+	}
+
+	return e.w.Write([]string{
+		line.Username,
+		line.Record,
+		id,
+		line.Timestamp.Format(time.RFC3339),
+		line.Type,
+		line.Direction,
+		line.Counterparty,
+		amount,
+		line.Item,
+		quantity,
+		balance,
+	})
+}
+
+// Flush дописывает буфер. Заголовок записывается, даже если строк не было.
+func (e *csvStatementEncoder) Flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvStatementEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.w.Write(statementCSVHeader)
+}
+
+// jsonlStatementEncoder записывает каждую строку выписки отдельным JSON-объектом
+type jsonlStatementEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *jsonlStatementEncoder) Encode(line model.StatementLine) error {
+	return e.enc.Encode(line)
+}
+
+func (e *jsonlStatementEncoder) Flush() error {
+	return nil
+}
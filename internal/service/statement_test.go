@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStatementService(repo *mockTransactionRepo, now time.Time) *statementService {
+	service := NewStatementService(repo).(*statementService)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestExportStatement(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	filter := domain.StatementFilter{From: from, To: to}
+
+	rows := []*domain.StatementRow{
+		{
+			Username:       "alice",
+			OpeningBalance: 1000,
+			ClosingBalance: 1120,
+			Entry: &domain.LedgerEntry{
+				TransactionId: 1,
+				Counterparty:  "SHOP",
+				Direction:     domain.LedgerDebit,
+				Amount:        80,
+				Type:          domain.TransactionTypePurchase,
+				ItemName:      "t-shirt",
+				Quantity:      2,
+				Timestamp:     from.Add(time.Hour),
+			},
+		},
+		{
+			Username:       "alice",
+			OpeningBalance: 1000,
+			ClosingBalance: 1120,
+			Entry: &domain.LedgerEntry{
+				TransactionId: 2,
+				Counterparty:  "bob",
+				Direction:     domain.LedgerCredit,
+				Amount:        200,
+				Type:          domain.TransactionTypeTransfer,
+				Timestamp:     from.Add(2 * time.Hour),
+			},
+		},
+		{Username: "bob", OpeningBalance: 500, ClosingBalance: 500},
+	}
+
+	t.Run("выписка в CSV", func(t *testing.T) {
+		repo := new(mockTransactionRepo)
+		service := newTestStatementService(repo, to)
+		repo.On("StreamStatement", ctx, filter).Return(rows, nil)
+
+		var buf bytes.Buffer
+		err := service.ExportStatement(ctx, filter, domain.StatementFormatCSV, &buf)
+
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, []string{
+			"username,record,id,timestamp,type,direction,counterparty,amount,item,quantity,balance",
+			"alice,opening_balance,,2025-01-01T00:00:00Z,,,,,,,1000",
+			"alice,transaction,1,2025-01-01T01:00:00Z,PURCHASE,DEBIT,SHOP,80,t-shirt,2,920",
+			"alice,transaction,2,2025-01-01T02:00:00Z,TRANSFER,CREDIT,bob,200,,,1120",
+			"alice,closing_balance,,2025-02-01T00:00:00Z,,,,,,,1120",
+			"bob,opening_balance,,2025-01-01T00:00:00Z,,,,,,,500",
+			"bob,closing_balance,,2025-02-01T00:00:00Z,,,,,,,500",
+			",end_of_statement,,2025-02-01T00:00:00Z,,,,,,,",
+		}, lines)
+	})
+
+	t.Run("выписка в JSON Lines", func(t *testing.T) {
+		repo := new(mockTransactionRepo)
+		service := newTestStatementService(repo, to)
+		repo.On("StreamStatement", ctx, filter).Return(rows[:1], nil)
+
+		var buf bytes.Buffer
+		err := service.ExportStatement(ctx, filter, domain.StatementFormatJSONL, &buf)
+
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 4)
+
+		var line model.StatementLine
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+		assert.Equal(t, model.StatementRecordTransaction, line.Record)
+		assert.Equal(t, "t-shirt", line.Item)
+		assert.Equal(t, int64(920), line.Balance)
+
+		var end model.StatementLine
+		require.NoError(t, json.Unmarshal([]byte(lines[3]), &end))
+		assert.Equal(t, model.StatementRecordEnd, end.Record)
+	})
+
+	t.Run("период по умолчанию - текущий месяц", func(t *testing.T) {
+		repo := new(mockTransactionRepo)
+		now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+		service := newTestStatementService(repo, now)
+		expected := domain.StatementFilter{
+			Username: "alice",
+			From:     time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			To:       now,
+		}
+		repo.On("StreamStatement", ctx, expected).Return([]*domain.StatementRow(nil), nil)
+
+		var buf bytes.Buffer
+		err := service.ExportStatement(ctx, domain.StatementFilter{Username: "alice"}, domain.StatementFormatCSV, &buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "username,record,id,timestamp,type,direction,counterparty,amount,item,quantity,balance\n"+
+			",end_of_statement,,2025-03-15T12:00:00Z,,,,,,,\n", buf.String())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("начало периода позже конца", func(t *testing.T) {
+		service := newTestStatementService(new(mockTransactionRepo), to)
+
+		err := service.ExportStatement(ctx, domain.StatementFilter{From: to, To: from}, domain.StatementFormatCSV, &bytes.Buffer{})
+
+		require.ErrorIs(t, err, domain.ErrInvalidFilter)
+	})
+
+	t.Run("ошибка чтения операций", func(t *testing.T) {
+		repo := new(mockTransactionRepo)
+		service := newTestStatementService(repo, to)
+		repo.On("StreamStatement", ctx, filter).Return([]*domain.StatementRow(nil), errors.New("db error"))
+
+		err := service.ExportStatement(ctx, filter, domain.StatementFormatCSV, &bytes.Buffer{})
+
+		require.Error(t, err)
+	})
+
+	t.Run("оборванная выгрузка не содержит завершающей строки", func(t *testing.T) {
+		repo := new(mockTransactionRepo)
+		service := newTestStatementService(repo, to)
+		repo.On("StreamStatement", ctx, filter).Return(rows[:1], errors.New("db error"))
+
+		var buf bytes.Buffer
+		err := service.ExportStatement(ctx, filter, domain.StatementFormatJSONL, &buf)
+
+		require.Error(t, err)
+		assert.Contains(t, buf.String(), model.StatementRecordOpening)
+		assert.NotContains(t, buf.String(), model.StatementRecordEnd)
+	})
+}
+
+func TestStatementEncoderUnknownFormat(t *testing.T) {
+	_, err := newStatementEncoder("xml", &bytes.Buffer{})
+	require.ErrorIs(t, err, domain.ErrInvalidFilter)
+}
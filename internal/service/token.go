@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/repository"
+	"github.com/netscrawler/avito-shop/internal/signer"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// revocationCacheTTL ограничивает время хранения в памяти отозванного jti, найденного в базе.
+	// Кэшируются только отозванные токены: отзыв необратим, а отрицательный результат
+	// мог бы скрыть отзыв, выполненный другим экземпляром сервиса.
+	revocationCacheTTL = 30 * time.Second
+	// mfaTokenTTL ограничивает время между вводом пароля и кода второго фактора
+	mfaTokenTTL = 5 * time.Minute
+)
+
+// TokenService выпускает, ротирует и отзывает токены
+type tokenService struct {
+	repo       repository.TokenRepository
+	users      repository.UserRepository
+	signer     signer.Signer
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	cache      map[string]time.Time // jti отозванного токена -> время, до которого хранится запись
+	cacheMu    sync.RWMutex
+}
+
+// NewTokenService создает новый экземпляр сервиса токенов.
+// Если cleanupInterval больше нуля, истекшие токены периодически удаляются из базы.
+func NewTokenService(repo repository.TokenRepository, users repository.UserRepository, signer signer.Signer, accessTTL, refreshTTL, cleanupInterval time.Duration) TokenService {
+	service := &tokenService{
+		repo:       repo,
+		users:      users,
+		signer:     signer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		cache:      make(map[string]time.Time),
+	}
+
+	// Запускаем очистку кэша отозванных токенов
+	go service.cleanCache()
+
+	if cleanupInterval > 0 {
+		go service.cleanupExpired(cleanupInterval)
+	}
+
+	return service
+}
+
+func (s *tokenService) cleanCache() {
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		s.cacheMu.Lock()
+		now := time.Now()
+		for jti, expiresAt := range s.cache {
+			if now.After(expiresAt) {
+				delete(s.cache, jti)
+			}
+		}
+		s.cacheMu.Unlock()
+	}
+}
+
+func (s *tokenService) cleanupExpired(interval time.Duration) {
+	const op = "TokenService.cleanupExpired"
+
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		deleted, err := s.repo.DeleteExpiredTokens(context.Background(), time.Now())
+		if err != nil {
+			logrus.Errorf("%s: ошибка удаления истекших токенов: %v", op, err)
+			continue
+		}
+		if deleted > 0 {
+			logrus.Infof("%s: удалено истекших токенов: %d", op, deleted)
+		}
+	}
+}
+
+func (s *tokenService) isCachedRevoked(jti string) bool {
+	s.cacheMu.RLock()
+	defer s.cacheMu.RUnlock()
+
+	expiresAt, exists := s.cache[jti]
+	return exists && time.Now().Before(expiresAt)
+}
+
+func (s *tokenService) cacheRevoked(jti string, ttl time.Duration) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	s.cache[jti] = time.Now().Add(ttl)
+}
+
+// IssueTokens выпускает новую пару токенов, открывая новое семейство refresh-токенов
+func (s *tokenService) IssueTokens(ctx context.Context, username string, role domain.Role, amr []string) (*domain.TokenPair, error) {
+	const op = "TokenService.IssueTokens"
+
+	familyId, err := generateRandomToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("%s: генерация семейства: %w", op, err)
+	}
+
+	refreshToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("%s: генерация refresh-токена: %w", op, err)
+	}
+
+	record := domain.NewRefreshToken(hashToken(refreshToken), username, familyId, time.Now().Add(s.refreshTTL))
+	record.Amr = amr
+	if err := s.repo.CreateRefreshToken(ctx, record); err != nil {
+		logrus.Errorf("%s: ошибка сохранения refresh-токена: %v", op, err)
+		return nil, fmt.Errorf("%s: сохранение refresh-токена: %w", op, err)
+	}
+
+	accessToken, err := s.issueAccessToken(username, role, amr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &domain.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// RefreshTokens обменивает refresh-токен на новую пару токенов
+func (s *tokenService) RefreshTokens(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	const op = "TokenService.RefreshTokens"
+
+	nextToken, err := generateRandomToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("%s: генерация refresh-токена: %w", op, err)
+	}
+
+	next := domain.NewRefreshToken(hashToken(nextToken), "", "", time.Now().Add(s.refreshTTL))
+	rotated, err := s.repo.RotateRefreshToken(ctx, hashToken(refreshToken), next)
+	if err != nil {
+		if errors.Is(err, domain.ErrTokenReused) {
+			logrus.Warnf("%s: обнаружено повторное использование refresh-токена, семейство отозвано", op)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Роль берем из базы, чтобы ее изменение вступало в силу при обновлении токена
+	user, err := s.users.GetUserByUsername(ctx, rotated.Username)
+	if err != nil {
+		return nil, fmt.Errorf("%s: получение пользователя: %w", op, err)
+	}
+
+	accessToken, err := s.issueAccessToken(user.Username, user.Role, rotated.Amr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &domain.TokenPair{AccessToken: accessToken, RefreshToken: nextToken}, nil
+}
+
+// RevokeTokens отзывает access-токен и, если передан, семейство refresh-токена
+func (s *tokenService) RevokeTokens(ctx context.Context, username, refreshToken, jti string, expiresAt time.Time) error {
+	const op = "TokenService.RevokeTokens"
+
+	if jti != "" {
+		if err := s.repo.RevokeAccessToken(ctx, jti, expiresAt); err != nil {
+			logrus.Errorf("%s: ошибка отзыва access-токена: %v", op, err)
+			return fmt.Errorf("%s: отзыв access-токена: %w", op, err)
+		}
+		s.cacheRevoked(jti, time.Until(expiresAt))
+	}
+
+	if refreshToken != "" {
+		if err := s.repo.RevokeRefreshTokenFamily(ctx, username, hashToken(refreshToken)); err != nil {
+			logrus.Errorf("%s: ошибка отзыва refresh-токена: %v", op, err)
+			return fmt.Errorf("%s: отзыв refresh-токена: %w", op, err)
+		}
+	}
+
+	logrus.Infof("%s: токены пользователя %s отозваны", op, username)
+	return nil
+}
+
+// RevokeAllTokens отзывает все access- и refresh-токены пользователя.
+// Время выпуска в JWT хранится с точностью до секунды, поэтому граница отзыва округляется вниз:
+// токен, выпущенный сразу после отзыва, остается действительным.
+func (s *tokenService) RevokeAllTokens(ctx context.Context, username string) error {
+	const op = "TokenService.RevokeAllTokens"
+
+	if err := s.repo.RevokeUserAccessTokens(ctx, username, time.Now().Truncate(time.Second)); err != nil {
+		logrus.Errorf("%s: ошибка отзыва access-токенов: %v", op, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.RevokeUserRefreshTokens(ctx, username); err != nil {
+		logrus.Errorf("%s: ошибка отзыва refresh-токенов: %v", op, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: все токены пользователя %s отозваны", op, username)
+	return nil
+}
+
+// IsTokenRevoked проверяет, отозван ли access-токен пользователя с указанным идентификатором и временем выпуска
+func (s *tokenService) IsTokenRevoked(ctx context.Context, username, jti string, issuedAt time.Time) (bool, error) {
+	const op = "TokenService.IsTokenRevoked"
+
+	if s.isCachedRevoked(jti) {
+		return true, nil
+	}
+
+	revoked, err := s.repo.IsAccessTokenRevoked(ctx, jti, username, issuedAt)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if revoked {
+		s.cacheRevoked(jti, revocationCacheTTL)
+	}
+	return revoked, nil
+}
+
+// IssueMFAToken выпускает короткоживущий токен, подтверждающий ввод пароля до проверки второго фактора
+func (s *tokenService) IssueMFAToken(username string) (string, error) {
+	const op = "TokenService.IssueMFAToken"
+
+	jti, err := generateRandomToken(16)
+	if err != nil {
+		return "", fmt.Errorf("%s: генерация идентификатора токена: %w", op, err)
+	}
+
+	now := time.Now()
+	tokenString, err := s.signer.Sign(jwt.MapClaims{
+		"username": username,
+		"typ":      domain.MFAPendingTokenType,
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      now.Add(mfaTokenTTL).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s: подпись токена: %w", op, err)
+	}
+
+	return tokenString, nil
+}
+
+// ParseMFAToken проверяет токен ожидания второго фактора и возвращает имя пользователя
+func (s *tokenService) ParseMFAToken(mfaToken string) (string, error) {
+	const op = "TokenService.ParseMFAToken"
+
+	claims, err := s.signer.Verify(mfaToken)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %v", op, domain.ErrInvalidToken, err)
+	}
+
+	username, _ := claims["username"].(string)
+	if typ, _ := claims["typ"].(string); typ != domain.MFAPendingTokenType || username == "" {
+		return "", fmt.Errorf("%s: %w", op, domain.ErrInvalidToken)
+	}
+
+	return username, nil
+}
+
+func (s *tokenService) issueAccessToken(username string, role domain.Role, amr []string) (string, error) {
+	jti, err := generateRandomToken(16)
+	if err != nil {
+		return "", fmt.Errorf("генерация идентификатора токена: %w", err)
+	}
+
+	now := time.Now()
+	tokenString, err := s.signer.Sign(jwt.MapClaims{
+		"username": username,
+		"role":     string(role),
+		"amr":      amr,
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      now.Add(s.accessTTL).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("подпись токена: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// generateRandomToken возвращает криптостойкую случайную строку из size байт
+func generateRandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken возвращает SHA-256 хэш токена для хранения в базе данных
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
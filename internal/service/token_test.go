@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/signer"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockTokenRepo struct {
+	mock.Mock
+}
+
+func (m *mockTokenRepo) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *mockTokenRepo) RotateRefreshToken(ctx context.Context, tokenHash string, next *domain.RefreshToken) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash, next)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RefreshToken), args.Error(1)
+}
+
+func (m *mockTokenRepo) RevokeRefreshTokenFamily(ctx context.Context, username, tokenHash string) error {
+	args := m.Called(ctx, username, tokenHash)
+	return args.Error(0)
+}
+
+func (m *mockTokenRepo) RevokeUserRefreshTokens(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
+
+func (m *mockTokenRepo) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *mockTokenRepo) RevokeUserAccessTokens(ctx context.Context, username string, issuedBefore time.Time) error {
+	args := m.Called(ctx, username, issuedBefore)
+	return args.Error(0)
+}
+
+func (m *mockTokenRepo) IsAccessTokenRevoked(ctx context.Context, jti, username string, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, jti, username, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockTokenRepo) DeleteExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+const testTokenSecret = "service-test-secret-of-32-bytes!"
+
+func newTestTokenService(tokenRepo *mockTokenRepo, userRepo *mockUserRepo) TokenService {
+	key, _ := signer.NewHMACKey("test", []byte(testTokenSecret))
+	keys, _ := signer.New(key)
+	return NewTokenService(tokenRepo, userRepo, keys, 15*time.Minute, time.Hour, 0)
+}
+
+func TestIssueTokens_Success(t *testing.T) {
+	// Подготовка
+	tokenRepo := new(mockTokenRepo)
+	service := newTestTokenService(tokenRepo, new(mockUserRepo))
+
+	tokenRepo.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *domain.RefreshToken) bool {
+		return token.Username == "testuser" && token.FamilyId != "" && token.TokenHash != ""
+	})).Return(nil)
+
+	// Действие
+	tokens, err := service.IssueTokens(context.Background(), "testuser", domain.RoleAdmin, []string{domain.AMRPassword, domain.AMRMFA})
+
+	// Проверка
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.RefreshToken)
+
+	parsed, err := jwt.Parse(tokens.AccessToken, func(token *jwt.Token) (interface{}, error) {
+		return []byte(testTokenSecret), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "test", parsed.Header["kid"])
+	claims := parsed.Claims.(jwt.MapClaims)
+	require.Equal(t, "testuser", claims["username"])
+	require.Equal(t, "admin", claims["role"])
+	require.Equal(t, []interface{}{"pwd", "mfa"}, claims["amr"])
+	require.NotEmpty(t, claims["jti"])
+	require.Equal(t, float64(15*60), claims["exp"].(float64)-claims["iat"].(float64))
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestRefreshTokens_Rotation(t *testing.T) {
+	// Подготовка
+	tokenRepo := new(mockTokenRepo)
+	userRepo := new(mockUserRepo)
+	service := newTestTokenService(tokenRepo, userRepo)
+
+	tokenRepo.On("RotateRefreshToken", mock.Anything, hashToken("old-refresh"), mock.AnythingOfType("*domain.RefreshToken")).
+		Return(&domain.RefreshToken{Username: "testuser", FamilyId: "family"}, nil)
+	userRepo.On("GetUserByUsername", mock.Anything, "testuser").
+		Return(&domain.User{Username: "testuser", Role: domain.RoleAdmin}, nil)
+
+	// Действие
+	tokens, err := service.RefreshTokens(context.Background(), "old-refresh")
+
+	// Проверка
+	require.NoError(t, err)
+	require.NotEqual(t, "old-refresh", tokens.RefreshToken)
+	require.NotEmpty(t, tokens.AccessToken)
+
+	// Проверка: роль взята из актуальных данных пользователя
+	parsed, err := jwt.Parse(tokens.AccessToken, func(token *jwt.Token) (interface{}, error) {
+		return []byte(testTokenSecret), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "admin", parsed.Claims.(jwt.MapClaims)["role"])
+	tokenRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+}
+
+func TestRefreshTokens_Reused(t *testing.T) {
+	// Подготовка
+	tokenRepo := new(mockTokenRepo)
+	service := newTestTokenService(tokenRepo, new(mockUserRepo))
+
+	tokenRepo.On("RotateRefreshToken", mock.Anything, hashToken("used-refresh"), mock.AnythingOfType("*domain.RefreshToken")).
+		Return(nil, domain.ErrTokenReused)
+
+	// Действие
+	tokens, err := service.RefreshTokens(context.Background(), "used-refresh")
+
+	// Проверка
+	require.ErrorIs(t, err, domain.ErrTokenReused)
+	require.Nil(t, tokens)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestIsTokenRevoked_UsesCache(t *testing.T) {
+	// Подготовка
+	tokenRepo := new(mockTokenRepo)
+	service := newTestTokenService(tokenRepo, new(mockUserRepo))
+	expiresAt := time.Now().Add(time.Minute)
+	issuedAt := time.Now().Truncate(time.Second)
+
+	tokenRepo.On("RevokeAccessToken", mock.Anything, "jti-1", expiresAt).Return(nil)
+	tokenRepo.On("IsAccessTokenRevoked", mock.Anything, "jti-2", "testuser", issuedAt).Return(false, nil)
+	tokenRepo.On("IsAccessTokenRevoked", mock.Anything, "jti-3", "testuser", issuedAt).Return(true, nil).Once()
+
+	// Действие
+	err := service.RevokeTokens(context.Background(), "testuser", "", "jti-1", expiresAt)
+	require.NoError(t, err)
+
+	revoked, err := service.IsTokenRevoked(context.Background(), "testuser", "jti-1", issuedAt)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	for i := 0; i < 2; i++ {
+		revoked, err = service.IsTokenRevoked(context.Background(), "testuser", "jti-2", issuedAt)
+		require.NoError(t, err)
+		require.False(t, revoked)
+
+		revoked, err = service.IsTokenRevoked(context.Background(), "testuser", "jti-3", issuedAt)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	}
+
+	// Проверка: отозванные токены берутся из кэша, а неотозванный каждый раз проверяется в базе,
+	// чтобы отзыв на другом экземпляре сервиса вступал в силу сразу
+	tokenRepo.AssertNotCalled(t, "IsAccessTokenRevoked", mock.Anything, "jti-1", mock.Anything, mock.Anything)
+	tokenRepo.AssertNumberOfCalls(t, "IsAccessTokenRevoked", 3)
+}
+
+func TestRevokeAllTokens(t *testing.T) {
+	// Подготовка
+	tokenRepo := new(mockTokenRepo)
+	service := newTestTokenService(tokenRepo, new(mockUserRepo))
+	before := time.Now().Truncate(time.Second)
+
+	tokenRepo.On("RevokeUserAccessTokens", mock.Anything, "testuser", mock.MatchedBy(func(issuedBefore time.Time) bool {
+		return !issuedBefore.Before(before) && issuedBefore.Equal(issuedBefore.Truncate(time.Second))
+	})).Return(nil)
+	tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, "testuser").Return(nil)
+
+	// Действие
+	err := service.RevokeAllTokens(context.Background(), "testuser")
+
+	// Проверка: отзываются и access-токены, и refresh-токены
+	require.NoError(t, err)
+	tokenRepo.AssertExpectations(t)
+}
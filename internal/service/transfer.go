@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -41,8 +42,9 @@ func (s *transferService) getUserLock(username string) *sync.Mutex {
 	return lock
 }
 
-// SendCoins выполняет перевод монет между пользователями
-func (s *transferService) SendCoins(ctx context.Context, from, to string, amount uint64) error {
+// SendCoins выполняет перевод монет между пользователями.
+// Если передан idem, результат запроса сохраняется вместе с переводом.
+func (s *transferService) SendCoins(ctx context.Context, from, to string, amount uint64, idem *domain.IdempotencyRecord) error {
 	const op = "TransferService.SendCoins"
 
 	if from == to {
@@ -84,8 +86,11 @@ func (s *transferService) SendCoins(ctx context.Context, from, to string, amount
 	}
 
 	// Выполняем перевод в рамках транзакции
-	err := s.transRepo.ExecuteTransfer(ctx, from, to, amount)
+	err := s.transRepo.ExecuteTransfer(ctx, from, to, amount, idem)
 	if err != nil {
+		if errors.Is(err, domain.ErrIdempotencyKeyUsed) {
+			return fmt.Errorf("%s: %w", op, err)
+		}
 		logrus.Errorf("%s: ошибка при выполнении перевода: %v", op, err)
 		return fmt.Errorf("%s: выполнение перевода: %w", op, err)
 	}
@@ -94,36 +99,151 @@ func (s *transferService) SendCoins(ctx context.Context, from, to string, amount
 	return nil
 }
 
-// GetTransactionHistory возвращает историю транзакций пользователя
-func (s *transferService) GetTransactionHistory(ctx context.Context, username string) (model.CoinHistory, error) {
+// GrantCoins начисляет монеты сотруднику по запросу сервисной учетной записи или администратора
+func (s *transferService) GrantCoins(ctx context.Context, granter, receiver string, amount uint64) error {
+	const op = "TransferService.GrantCoins"
+
+	if amount == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrInvalidAmount)
+	}
+
+	// Блокировка получателя упорядочивает начисление с его переводами
+	lock := s.getUserLock(receiver)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.transRepo.ExecuteGrant(ctx, granter, receiver, amount); err != nil {
+		if errors.Is(err, domain.ErrRecipientNotFound) {
+			logrus.Warnf("%s: получатель %s не найден", op, receiver)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при начислении монет: %v", op, err)
+		return fmt.Errorf("%s: начисление монет: %w", op, err)
+	}
+
+	logrus.Infof("%s: %s начислил %d монет пользователю %s", op, granter, amount, receiver)
+	return nil
+}
+
+// TransferItem передает единицы купленного товара из инвентаря одного пользователя другому.
+// Если передан idem, результат запроса сохраняется вместе с передачей.
+func (s *transferService) TransferItem(ctx context.Context, from, to, itemName string, quantity uint64, idem *domain.IdempotencyRecord) error {
+	const op = "TransferService.TransferItem"
+
+	transfer := domain.NewItemTransfer(from, to, itemName, quantity)
+	if err := transfer.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Строки пользователей и инвентаря блокируются в репозитории
+	if err := s.transRepo.ExecuteItemTransfer(ctx, transfer, idem); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyUsed), errors.Is(err, domain.ErrInventoryShortage):
+			return fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, domain.ErrRecipientNotFound):
+			logrus.Warnf("%s: получатель %s не найден", op, to)
+			return fmt.Errorf("%s: %w", op, err)
+		case errors.Is(err, domain.ErrMerchNotFound), errors.Is(err, domain.ErrItemNotTransferable):
+			logrus.Warnf("%s: товар %s нельзя передать: %v", op, itemName, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		logrus.Errorf("%s: ошибка при передаче товара: %v", op, err)
+		return fmt.Errorf("%s: передача товара: %w", op, err)
+	}
+
+	logrus.Infof("%s: %s передал %s в количестве %d пользователю %s", op, from, itemName, quantity, to)
+	return nil
+}
+
+// GetTransactionHistory возвращает историю транзакций пользователя.
+// Переводы разделяются на отправленные и полученные, покупки выводятся отдельно.
+// Если limit больше нуля, учитываются только limit последних операций.
+func (s *transferService) GetTransactionHistory(ctx context.Context, username string, limit int) (model.CoinHistory, error) {
 	const op = "TransferService.GetTransactionHistory"
 
-	// Получаем все транзакции пользователя
-	transactions, err := s.transRepo.GetUserTransactions(ctx, username)
+	// Получаем последние операции пользователя
+	entries, err := s.transRepo.GetUserLedger(ctx, domain.LedgerFilter{Username: username, Limit: limit})
 	if err != nil {
 		return model.CoinHistory{}, fmt.Errorf("%s: получение транзакций: %w", op, err)
 	}
 
-	// Разделяем транзакции на отправленные и полученные
 	var sent []model.SentTransaction
 	var received []model.ReceivedTransaction
-
-	for _, t := range transactions {
-		if t.SenderName == username {
+	var purchases []model.PurchaseTransaction
+
+	for _, e := range entries {
+		switch {
+		case e.IsPurchase():
+			purchases = append(purchases, model.PurchaseTransaction{
+				Item:      e.ItemName,
+				Price:     e.Amount,
+				Quantity:  e.Quantity,
+				Timestamp: e.Timestamp,
+			})
+		case e.Type != domain.TransactionTypeTransfer:
+			continue
+		case e.Direction == domain.LedgerDebit:
 			sent = append(sent, model.SentTransaction{
-				ToUser: t.ReceiverName,
-				Amount: t.Amount,
+				ToUser: e.Counterparty,
+				Amount: e.Amount,
 			})
-		} else if t.ReceiverName == username {
+		case e.Direction == domain.LedgerCredit:
 			received = append(received, model.ReceivedTransaction{
-				FromUser: t.SenderName,
-				Amount:   t.Amount,
+				FromUser: e.Counterparty,
+				Amount:   e.Amount,
 			})
 		}
 	}
 
 	return model.CoinHistory{
-		Sent:     sent,
-		Received: received,
+		Sent:      sent,
+		Received:  received,
+		Purchases: purchases,
 	}, nil
 }
+
+// ListTransactions возвращает страницу истории операций пользователя по фильтру
+func (s *transferService) ListTransactions(ctx context.Context, filter domain.LedgerFilter) (model.TransactionPage, error) {
+	const op = "TransferService.ListTransactions"
+
+	if filter.Limit == 0 {
+		filter.Limit = domain.DefaultLedgerPageSize
+	}
+	if filter.Limit > domain.MaxLedgerPageSize {
+		filter.Limit = domain.MaxLedgerPageSize
+	}
+	if err := filter.Validate(); err != nil {
+		return model.TransactionPage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+
+	entries, err := s.transRepo.GetUserLedger(ctx, filter)
+	if err != nil {
+		return model.TransactionPage{}, fmt.Errorf("%s: получение транзакций: %w", op, err)
+	}
+
+	page := model.TransactionPage{Transactions: make([]model.TransactionEntry, 0, len(entries))}
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		page.NextCursor = entries[pageSize-1].Cursor().Encode()
+	}
+
+	for _, e := range entries {
+		page.Transactions = append(page.Transactions, model.TransactionEntry{
+			Id:           e.TransactionId,
+			Type:         string(e.Type),
+			Direction:    string(e.Direction),
+			Counterparty: e.Counterparty,
+			Amount:       e.Amount,
+			Item:         e.ItemName,
+			Quantity:     e.Quantity,
+			Message:      e.Message,
+			Timestamp:    e.Timestamp,
+		})
+	}
+
+	return page, nil
+}
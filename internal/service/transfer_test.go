@@ -3,10 +3,12 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -35,10 +37,10 @@ func TestSendCoins_Success(t *testing.T) {
 	// Настройка ожиданий
 	userRepo.On("GetUserByUsername", mock.Anything, sender).Return(senderUser, nil)
 	userRepo.On("GetUserByUsername", mock.Anything, receiver).Return(receiverUser, nil)
-	transRepo.On("ExecuteTransfer", mock.Anything, sender, receiver, amount).Return(nil)
+	transRepo.On("ExecuteTransfer", mock.Anything, sender, receiver, amount, mock.Anything).Return(nil)
 
 	// Действие
-	err := service.SendCoins(context.Background(), sender, receiver, amount)
+	err := service.SendCoins(context.Background(), sender, receiver, amount, nil)
 
 	// Проверка
 	require.NoError(t, err)
@@ -71,10 +73,10 @@ func TestSendCoins_InsufficientFunds(t *testing.T) {
 	// Настраиваем ожидания
 	userRepo.On("GetUserByUsername", ctx, "sender").Return(sender, nil)
 	userRepo.On("GetUserByUsername", ctx, "receiver").Return(receiver, nil)
-	transRepo.On("ExecuteTransfer", ctx, "sender", "receiver", amount).Return(domain.ErrInsufficientFunds)
+	transRepo.On("ExecuteTransfer", ctx, "sender", "receiver", amount, mock.Anything).Return(domain.ErrInsufficientFunds)
 
 	// Act
-	err := service.SendCoins(ctx, "sender", "receiver", amount)
+	err := service.SendCoins(ctx, "sender", "receiver", amount, nil)
 
 	// Assert
 	assert.Error(t, err)
@@ -96,35 +98,61 @@ func TestGetTransactionHistory_Success(t *testing.T) {
 	username := "testuser"
 	now := time.Now()
 
-	transactions := []*domain.Transaction{
+	entries := []*domain.LedgerEntry{
 		{
-			SenderName:   username,
-			ReceiverName: "user1",
-			Amount:       100,
-			Type:         domain.TransactionTypeTransfer,
-			Timestamp:    now,
+			TransactionId: 3,
+			Username:      username,
+			Counterparty:  "SHOP",
+			Direction:     domain.LedgerDebit,
+			Amount:        80,
+			Type:          domain.TransactionTypePurchase,
+			ItemName:      "t-shirt",
+			Timestamp:     now,
 		},
 		{
-			SenderName:   "user2",
-			ReceiverName: username,
-			Amount:       200,
-			Type:         domain.TransactionTypeTransfer,
-			Timestamp:    now,
+			TransactionId: 2,
+			Username:      username,
+			Counterparty:  "user1",
+			Direction:     domain.LedgerDebit,
+			Amount:        100,
+			Type:          domain.TransactionTypeTransfer,
+			Timestamp:     now,
+		},
+		{
+			TransactionId: 1,
+			Username:      username,
+			Counterparty:  "user2",
+			Direction:     domain.LedgerCredit,
+			Amount:        200,
+			Type:          domain.TransactionTypeTransfer,
+			Timestamp:     now,
+		},
+		{
+			TransactionId: 0,
+			Username:      username,
+			Counterparty:  "hr-bot",
+			Direction:     domain.LedgerCredit,
+			Amount:        500,
+			Type:          domain.TransactionTypeGrant,
+			Timestamp:     now,
 		},
 	}
 
 	// Настраиваем ожидания
-	transRepo.On("GetUserTransactions", ctx, username).Return(transactions, nil)
+	transRepo.On("GetUserLedger", ctx, domain.LedgerFilter{Username: username}).Return(entries, nil)
 
 	// Act
-	history, err := service.GetTransactionHistory(ctx, username)
+	history, err := service.GetTransactionHistory(ctx, username, 0)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.Len(t, history.Sent, 1)
 	assert.Len(t, history.Received, 1)
+	assert.Equal(t, "user1", history.Sent[0].ToUser)
 	assert.Equal(t, uint64(100), history.Sent[0].Amount)
+	assert.Equal(t, "user2", history.Received[0].FromUser)
 	assert.Equal(t, uint64(200), history.Received[0].Amount)
+	assert.Equal(t, []model.PurchaseTransaction{{Item: "t-shirt", Price: 80, Timestamp: now}}, history.Purchases)
 	transRepo.AssertExpectations(t)
 }
 
@@ -153,10 +181,10 @@ func TestSendCoins_TransactionError(t *testing.T) {
 	// Настройка ожиданий
 	userRepo.On("GetUserByUsername", mock.Anything, sender).Return(senderUser, nil)
 	userRepo.On("GetUserByUsername", mock.Anything, receiver).Return(receiverUser, nil)
-	transRepo.On("ExecuteTransfer", mock.Anything, sender, receiver, amount).Return(expectedError)
+	transRepo.On("ExecuteTransfer", mock.Anything, sender, receiver, amount, mock.Anything).Return(expectedError)
 
 	// Действие
-	err := service.SendCoins(context.Background(), sender, receiver, amount)
+	err := service.SendCoins(context.Background(), sender, receiver, amount, nil)
 
 	// Проверка
 	require.Error(t, err)
@@ -177,7 +205,7 @@ func TestSendCoins_SelfTransfer(t *testing.T) {
 	amount := uint64(100)
 
 	// Act
-	err := service.SendCoins(ctx, username, username, amount)
+	err := service.SendCoins(ctx, username, username, amount, nil)
 
 	// Assert
 	assert.NoError(t, err)
@@ -211,10 +239,10 @@ func TestSendCoins_ReceiverUpdateError(t *testing.T) {
 	// Настраиваем ожидания
 	userRepo.On("GetUserByUsername", ctx, "sender").Return(sender, nil)
 	userRepo.On("GetUserByUsername", ctx, "receiver").Return(receiver, nil)
-	transRepo.On("ExecuteTransfer", ctx, "sender", "receiver", amount).Return(expectedError)
+	transRepo.On("ExecuteTransfer", ctx, "sender", "receiver", amount, mock.Anything).Return(expectedError)
 
 	// Act
-	err := service.SendCoins(ctx, "sender", "receiver", amount)
+	err := service.SendCoins(ctx, "sender", "receiver", amount, nil)
 
 	// Assert
 	assert.Error(t, err)
@@ -234,7 +262,7 @@ func TestSendCoins_GetUserError(t *testing.T) {
 	userRepo.On("GetUserByUsername", ctx, "sender").Return(nil, errors.New("пользователь не найден"))
 
 	// Act
-	err := service.SendCoins(ctx, "sender", "receiver", uint64(100))
+	err := service.SendCoins(ctx, "sender", "receiver", uint64(100), nil)
 
 	// Assert
 	assert.Error(t, err)
@@ -256,7 +284,7 @@ func TestSendCoins_GetUserError(t *testing.T) {
 	userRepo.On("GetUserByUsername", ctx, "receiver").Return(nil, errors.New("пользователь не найден"))
 
 	// Act
-	err = service.SendCoins(ctx, "sender", "receiver", uint64(100))
+	err = service.SendCoins(ctx, "sender", "receiver", uint64(100), nil)
 
 	// Assert
 	assert.Error(t, err)
@@ -276,9 +304,9 @@ func TestGetTransactionHistory_Error(t *testing.T) {
 
 	// Тест ошибки получения транзакций
 	t.Run("ошибка получения транзакций", func(t *testing.T) {
-		transRepo.On("GetUserTransactions", ctx, username).Return([]*domain.Transaction(nil), expectedError)
+		transRepo.On("GetUserLedger", ctx, domain.LedgerFilter{Username: username}).Return([]*domain.LedgerEntry(nil), expectedError)
 
-		history, err := service.GetTransactionHistory(ctx, username)
+		history, err := service.GetTransactionHistory(ctx, username, 0)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "получение транзакций")
@@ -286,3 +314,163 @@ func TestGetTransactionHistory_Error(t *testing.T) {
 		transRepo.AssertExpectations(t)
 	})
 }
+
+func TestListTransactions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	newEntries := func(n int) []*domain.LedgerEntry {
+		entries := make([]*domain.LedgerEntry, 0, n)
+		for i := 0; i < n; i++ {
+			entries = append(entries, &domain.LedgerEntry{
+				TransactionId: int64(n - i),
+				Username:      "testuser",
+				Counterparty:  "user1",
+				Direction:     domain.LedgerDebit,
+				Amount:        10,
+				Type:          domain.TransactionTypeTransfer,
+				Timestamp:     now.Add(-time.Duration(i) * time.Minute),
+			})
+		}
+		return entries
+	}
+
+	t.Run("есть следующая страница", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		entries := newEntries(3)
+		transRepo.On("GetUserLedger", ctx, domain.LedgerFilter{Username: "testuser", Limit: 3}).Return(entries, nil)
+
+		page, err := service.ListTransactions(ctx, domain.LedgerFilter{Username: "testuser", Limit: 2})
+
+		require.NoError(t, err)
+		require.Len(t, page.Transactions, 2)
+		assert.Equal(t, int64(3), page.Transactions[0].Id)
+		assert.Equal(t, "DEBIT", page.Transactions[0].Direction)
+
+		cursor, err := domain.ParseLedgerCursor(page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), cursor.TransactionId)
+		assert.True(t, cursor.Timestamp.Equal(entries[1].Timestamp))
+	})
+
+	t.Run("последняя страница", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		transRepo.On("GetUserLedger", ctx, domain.LedgerFilter{Username: "testuser", Limit: domain.DefaultLedgerPageSize + 1}).
+			Return(newEntries(2), nil)
+
+		page, err := service.ListTransactions(ctx, domain.LedgerFilter{Username: "testuser"})
+
+		require.NoError(t, err)
+		assert.Len(t, page.Transactions, 2)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("размер страницы ограничен", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		transRepo.On("GetUserLedger", ctx, domain.LedgerFilter{Username: "testuser", Limit: domain.MaxLedgerPageSize + 1}).
+			Return([]*domain.LedgerEntry{}, nil)
+
+		page, err := service.ListTransactions(ctx, domain.LedgerFilter{Username: "testuser", Limit: 1000})
+
+		require.NoError(t, err)
+		assert.Empty(t, page.Transactions)
+		transRepo.AssertExpectations(t)
+	})
+
+	t.Run("недопустимый фильтр", func(t *testing.T) {
+		service := NewTransferService(new(mockTransactionRepo), new(mockUserRepo))
+
+		_, err := service.ListTransactions(ctx, domain.LedgerFilter{Username: "testuser", MinAmount: 100, MaxAmount: 10})
+
+		require.ErrorIs(t, err, domain.ErrInvalidFilter)
+	})
+}
+
+func TestGrantCoins(t *testing.T) {
+	t.Run("успешное начисление", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		transRepo.On("ExecuteGrant", mock.Anything, "hr-bot", "employee", uint64(100)).Return(nil)
+
+		err := service.GrantCoins(context.Background(), "hr-bot", "employee", 100)
+
+		require.NoError(t, err)
+		transRepo.AssertExpectations(t)
+	})
+
+	t.Run("нулевая сумма", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		err := service.GrantCoins(context.Background(), "hr-bot", "employee", 0)
+
+		require.ErrorIs(t, err, domain.ErrInvalidAmount)
+		transRepo.AssertNotCalled(t, "ExecuteGrant", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("получатель не найден", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		transRepo.On("ExecuteGrant", mock.Anything, "hr-bot", "unknown", uint64(100)).Return(domain.ErrRecipientNotFound)
+
+		err := service.GrantCoins(context.Background(), "hr-bot", "unknown", 100)
+
+		require.ErrorIs(t, err, domain.ErrRecipientNotFound)
+	})
+}
+
+func TestTransferItem(t *testing.T) {
+	t.Run("успешная передача", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		transRepo.On("ExecuteItemTransfer", mock.Anything, &domain.ItemTransfer{From: "alice", To: "bob", ItemName: "cup", Quantity: 2}, mock.Anything).Return(nil)
+
+		err := service.TransferItem(context.Background(), "alice", "bob", "cup", 2, nil)
+
+		require.NoError(t, err)
+		transRepo.AssertExpectations(t)
+	})
+
+	t.Run("передача самому себе", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		err := service.TransferItem(context.Background(), "alice", "alice", "cup", 1, nil)
+
+		require.ErrorIs(t, err, domain.ErrInvalidItemTransfer)
+		transRepo.AssertNotCalled(t, "ExecuteItemTransfer", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("товар нельзя передать", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		transRepo.On("ExecuteItemTransfer", mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("%w: товар снят с продажи", domain.ErrItemNotTransferable))
+
+		err := service.TransferItem(context.Background(), "alice", "bob", "badge", 1, nil)
+
+		require.ErrorIs(t, err, domain.ErrItemNotTransferable)
+	})
+
+	t.Run("недостаточно товара", func(t *testing.T) {
+		transRepo := new(mockTransactionRepo)
+		service := NewTransferService(transRepo, new(mockUserRepo))
+
+		transRepo.On("ExecuteItemTransfer", mock.Anything, mock.Anything, mock.Anything).
+			Return(fmt.Errorf("%w: cup", domain.ErrInventoryShortage))
+
+		err := service.TransferItem(context.Background(), "alice", "bob", "cup", 5, nil)
+
+		require.ErrorIs(t, err, domain.ErrInventoryShortage)
+	})
+}
@@ -2,32 +2,46 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/netscrawler/avito-shop/internal/config"
 	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/hasher"
 	"github.com/netscrawler/avito-shop/internal/repository"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
-)
-
-const (
-	defaultInitialCoins = uint64(1000)
-	defaultTokenTTL     = 72 * time.Hour
 )
 
 // UserService предоставляет методы для работы с пользователями
 type userService struct {
-	repo      repository.UserRepository
-	jwtSecret string
+	repo           repository.UserRepository
+	mfa            repository.MFARepository
+	tokens         TokenService
+	hasher         hasher.PasswordHasher
+	passwordPolicy domain.PasswordPolicy
+	autoRegister   bool
+	initialCoins   uint64
+	// dummyHash используется для проверки при входе несуществующего пользователя,
+	// чтобы время ответа не раскрывало, зарегистрировано ли имя
+	dummyHash []byte
 }
 
 // NewUserService создает новый экземпляр сервиса пользователей
-func NewUserService(repo repository.UserRepository, jwtSecret string) UserService {
+func NewUserService(repo repository.UserRepository, mfa repository.MFARepository, tokens TokenService, hasher hasher.PasswordHasher, cfg config.AuthConfig) UserService {
+	dummyHash, err := hasher.Hash("dummy-password")
+	if err != nil {
+		logrus.Errorf("UserService: ошибка подготовки фиктивного хэша: %v", err)
+	}
+
 	return &userService{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:           repo,
+		mfa:            mfa,
+		tokens:         tokens,
+		hasher:         hasher,
+		passwordPolicy: domain.NewPasswordPolicy(cfg.PasswordMinLength),
+		autoRegister:   cfg.AutoRegister,
+		initialCoins:   cfg.InitialCoins,
+		dummyHash:      dummyHash,
 	}
 }
 
@@ -43,16 +57,39 @@ func (s *userService) GetUserByUsername(ctx context.Context, username string) (*
 	return user, nil
 }
 
-// RegisterUser регистрирует нового пользователя
+// SignUp регистрирует нового пользователя по явному запросу, проверяя формат имени и политику паролей
+func (s *userService) SignUp(ctx context.Context, username, password string) error {
+	const op = "UserService.SignUp"
+
+	if err := domain.ValidateUsername(username); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.passwordPolicy.Validate(password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.RegisterUser(ctx, username, password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RegisterUser регистрирует нового пользователя без проверки политики паролей.
+// Используется авторегистрацией при входе, чтобы прежние учетные данные продолжали работать.
 func (s *userService) RegisterUser(ctx context.Context, username, password string) error {
 	const op = "UserService.RegisterUser"
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := domain.CheckReservedUsername(username); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return fmt.Errorf("%s: хеширование пароля: %w", op, err)
 	}
 
-	user := domain.NewUser(username, hashedPassword, defaultInitialCoins)
+	user := domain.NewUser(username, hashedPassword, s.initialCoins)
 	if err := s.repo.CreateUser(ctx, user); err != nil {
 		return fmt.Errorf("%s: создание пользователя: %w", op, err)
 	}
@@ -60,63 +97,126 @@ func (s *userService) RegisterUser(ctx context.Context, username, password strin
 	return nil
 }
 
-// AuthenticateUser аутентифицирует пользователя и возвращает JWT токен
-func (s *userService) AuthenticateUser(ctx context.Context, username, password string) (string, error) {
+// AuthenticateUser аутентифицирует пользователя и возвращает пару токенов
+func (s *userService) AuthenticateUser(ctx context.Context, username, password string) (*domain.TokenPair, error) {
 	const op = "UserService.AuthenticateUser"
 
 	// Пытаемся получить пользователя
 	user, err := s.repo.GetUserByUsername(ctx, username)
 	if err != nil {
-		if err == domain.ErrUserNotFound {
-			// Пытаемся зарегистрировать пользователя
-			logrus.Infof("%s: пользователь не найден, выполняем регистрацию", op)
-			if regErr := s.RegisterUser(ctx, username, password); regErr != nil {
-				if regErr == domain.ErrUserAlreadyExists {
-					// Если во время регистрации обнаружили, что пользователь уже существует,
-					// значит он был создан параллельным запросом
-					user, err = s.repo.GetUserByUsername(ctx, username)
-					if err != nil {
-						logrus.Errorf("%s: ошибка получения существующего пользователя: %v", op, err)
-						return "", fmt.Errorf("%s: получение существующего пользователя: %w", op, err)
-					}
-				} else {
-					logrus.Errorf("%s: ошибка регистрации пользователя: %v", op, regErr)
-					return "", fmt.Errorf("%s: регистрация нового пользователя: %w", op, regErr)
-				}
-			} else {
-				// Регистрация прошла успешно, получаем созданного пользователя
-				user, err = s.repo.GetUserByUsername(ctx, username)
-				if err != nil {
-					logrus.Errorf("%s: ошибка получения пользователя после регистрации: %v", op, err)
-					return "", fmt.Errorf("%s: получение пользователя после регистрации: %w", op, err)
-				}
-			}
-		} else {
+		if !errors.Is(err, domain.ErrUserNotFound) {
 			logrus.Errorf("%s: ошибка получения пользователя: %v", op, err)
-			return "", fmt.Errorf("%s: получение пользователя: %w", op, err)
+			return nil, fmt.Errorf("%s: получение пользователя: %w", op, err)
+		}
+
+		if !s.autoRegister {
+			_, _ = s.hasher.Verify(s.dummyHash, password)
+			logrus.Warnf("%s: попытка входа несуществующего пользователя %s", op, username)
+			return nil, domain.ErrInvalidCredentials
+		}
+
+		user, err = s.registerOnLogin(ctx, username, password)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 	}
 
+	// Сервисные учетные записи работают только по API-ключам
+	if user.Role == domain.RoleService {
+		_, _ = s.hasher.Verify(s.dummyHash, password)
+		logrus.Warnf("%s: попытка входа по паролю в сервисную учетную запись %s", op, username)
+		return nil, domain.ErrInvalidCredentials
+	}
+
 	// Проверяем пароль
-	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(password)); err != nil {
+	ok, err := s.hasher.Verify(user.Password, password)
+	if err != nil {
+		logrus.Errorf("%s: ошибка проверки хэша пароля пользователя %s: %v", op, username, err)
+		return nil, domain.ErrInvalidCredentials
+	}
+	if !ok {
 		logrus.Errorf("%s: неверный пароль для пользователя %s", op, username)
-		return "", domain.ErrInvalidCredentials
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	// Хэш получен устаревшим алгоритмом или параметрами: пересчитываем, пока известен пароль
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, username, password)
+	}
+
+	// При подключенном втором факторе выдаем только токен ожидания кода
+	mfaEnabled, err := s.isMFAEnabled(ctx, user.Username)
+	if err != nil {
+		logrus.Errorf("%s: ошибка проверки второго фактора: %v", op, err)
+		return nil, fmt.Errorf("%s: проверка второго фактора: %w", op, err)
+	}
+	if mfaEnabled {
+		mfaToken, err := s.tokens.IssueMFAToken(user.Username)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return &domain.TokenPair{MFAToken: mfaToken}, nil
 	}
 
-	// Генерируем JWT токен
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"username": user.Username,
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
-	})
+	// Выпускаем access и refresh токены
+	tokens, err := s.tokens.IssueTokens(ctx, user.Username, user.Role, []string{domain.AMRPassword})
+	if err != nil {
+		logrus.Errorf("%s: ошибка выпуска токенов: %v", op, err)
+		return nil, fmt.Errorf("%s: выпуск токенов: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+func (s *userService) isMFAEnabled(ctx context.Context, username string) (bool, error) {
+	factor, err := s.mfa.GetMFAFactor(ctx, username)
+	if err != nil {
+		if errors.Is(err, domain.ErrMFANotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return factor.Enabled, nil
+}
+
+// rehashPassword обновляет хэш пароля. Ошибка не прерывает вход: хэш обновится при следующем входе.
+func (s *userService) rehashPassword(ctx context.Context, username, password string) {
+	const op = "UserService.rehashPassword"
 
-	// Подписываем токен
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
-		logrus.Errorf("%s: ошибка подписи токена: %v", op, err)
-		return "", fmt.Errorf("%s: подпись токена: %w", op, err)
+		logrus.Errorf("%s: ошибка хеширования пароля: %v", op, err)
+		return
+	}
+
+	if err := s.repo.UpdateUserPassword(ctx, username, hashedPassword); err != nil {
+		logrus.Errorf("%s: ошибка сохранения нового хэша пароля: %v", op, err)
+		return
 	}
 
-	return tokenString, nil
+	logrus.Infof("%s: хэш пароля пользователя %s обновлен", op, username)
+}
+
+// registerOnLogin регистрирует пользователя при первом входе, если включена авторегистрация
+func (s *userService) registerOnLogin(ctx context.Context, username, password string) (*domain.User, error) {
+	const op = "UserService.registerOnLogin"
+
+	logrus.Infof("%s: пользователь не найден, выполняем регистрацию", op)
+	if err := s.RegisterUser(ctx, username, password); err != nil {
+		if !errors.Is(err, domain.ErrUserAlreadyExists) {
+			logrus.Errorf("%s: ошибка регистрации пользователя: %v", op, err)
+			return nil, fmt.Errorf("%s: регистрация нового пользователя: %w", op, err)
+		}
+		// Пользователь был создан параллельным запросом
+	}
+
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if err != nil {
+		logrus.Errorf("%s: ошибка получения пользователя после регистрации: %v", op, err)
+		return nil, fmt.Errorf("%s: получение пользователя после регистрации: %w", op, err)
+	}
+
+	return user, nil
 }
 
 // GetUserInfo возвращает информацию о пользователе
@@ -130,3 +230,19 @@ func (s *userService) GetUserInfo(ctx context.Context, username string) (*domain
 
 	return user, nil
 }
+
+// SetUserRole назначает пользователю роль
+func (s *userService) SetUserRole(ctx context.Context, username string, role domain.Role) error {
+	const op = "UserService.SetUserRole"
+
+	if _, err := domain.ParseRole(string(role)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.UpdateUserRole(ctx, username, role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	logrus.Infof("%s: пользователю %s назначена роль %s", op, username, role)
+	return nil
+}
@@ -4,22 +4,35 @@ import (
 	"context"
 	"testing"
 
+	"github.com/netscrawler/avito-shop/internal/config"
 	"github.com/netscrawler/avito-shop/internal/domain"
+	"github.com/netscrawler/avito-shop/internal/hasher"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var testHasher = hasher.New(hasher.NewBcrypt(bcrypt.MinCost))
+
+var testAuthConfig = config.AuthConfig{
+	AutoRegister:      true,
+	InitialCoins:      1000,
+	PasswordMinLength: 8,
+}
+
 func TestRegisterUser_Success(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	userRepo := new(mockUserRepo)
-	service := NewUserService(userRepo, "test-secret")
+	tokenRepo := new(mockTokenRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(tokenRepo, userRepo), testHasher, testAuthConfig)
 
 	username := "testuser"
 	password := "password123"
 
-	userRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+	userRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+		return user.Username == username && user.Coins == testAuthConfig.InitialCoins
+	})).Return(nil)
 
 	// Act
 	err := service.RegisterUser(ctx, username, password)
@@ -29,11 +42,78 @@ func TestRegisterUser_Success(t *testing.T) {
 	userRepo.AssertExpectations(t)
 }
 
+func TestSignUp_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantErr  error
+	}{
+		{name: "недопустимое имя", username: "bad name", password: "password123", wantErr: domain.ErrInvalidUsername},
+		{name: "слабый пароль", username: "testuser", password: "short", wantErr: domain.ErrWeakPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := new(mockUserRepo)
+			service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(new(mockTokenRepo), userRepo), testHasher, testAuthConfig)
+
+			err := service.SignUp(context.Background(), tt.username, tt.password)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			userRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRegisterUser_SkipsPasswordPolicy(t *testing.T) {
+	userRepo := new(mockUserRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(new(mockTokenRepo), userRepo), testHasher, testAuthConfig)
+
+	userRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+
+	// Авторегистрация при входе принимает учетные данные, допустимые до появления политики паролей
+	err := service.RegisterUser(context.Background(), "user 42", "testpass")
+
+	assert.NoError(t, err)
+	userRepo.AssertExpectations(t)
+}
+
+func TestRegisterUser_ReservedName(t *testing.T) {
+	userRepo := new(mockUserRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(new(mockTokenRepo), userRepo), testHasher, testAuthConfig)
+
+	err := service.RegisterUser(context.Background(), "Shop", "testpass")
+
+	assert.ErrorIs(t, err, domain.ErrInvalidUsername)
+	userRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticateUser_StrictLoginUnknownUser(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	userRepo := new(mockUserRepo)
+	strictConfig := testAuthConfig
+	strictConfig.AutoRegister = false
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(new(mockTokenRepo), userRepo), testHasher, strictConfig)
+
+	userRepo.On("GetUserByUsername", mock.Anything, "typo-user").Return(nil, domain.ErrUserNotFound)
+
+	// Act
+	tokens, err := service.AuthenticateUser(ctx, "typo-user", "password123")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Nil(t, tokens)
+	userRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
 func TestAuthenticateUser_Success(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	userRepo := new(mockUserRepo)
-	service := NewUserService(userRepo, "test-secret")
+	tokenRepo := new(mockTokenRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(tokenRepo, userRepo), testHasher, testAuthConfig)
 
 	username := "testuser"
 	password := "password123"
@@ -41,20 +121,54 @@ func TestAuthenticateUser_Success(t *testing.T) {
 	// Настраиваем мок для автоматической регистрации
 	userRepo.On("GetUserByUsername", mock.Anything, username).Return(nil, domain.ErrUserNotFound).Once()
 	userRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*domain.User")).Return(nil)
+	tokenRepo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
 
 	// Настраиваем мок для возврата пользователя после регистрации
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, _ := testHasher.Hash(password)
 	userRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{
 		Username: username,
 		Password: hashedPassword,
 	}, nil)
 
 	// Act
-	token, err := service.AuthenticateUser(ctx, username, password)
+	tokens, err := service.AuthenticateUser(ctx, username, password)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	userRepo.AssertExpectations(t)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestAuthenticateUser_RehashOutdatedHash(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	userRepo := new(mockUserRepo)
+	tokenRepo := new(mockTokenRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(tokenRepo, userRepo), testHasher, testAuthConfig)
+
+	username := "testuser"
+	password := "password123"
+
+	// Хэш с устаревшей стоимостью bcrypt
+	outdatedHash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost+1)
+	userRepo.On("GetUserByUsername", mock.Anything, username).Return(&domain.User{
+		Username: username,
+		Password: outdatedHash,
+	}, nil)
+	userRepo.On("UpdateUserPassword", mock.Anything, username, mock.MatchedBy(func(hash []byte) bool {
+		cost, err := bcrypt.Cost(hash)
+		return err == nil && cost == bcrypt.MinCost && bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+	})).Return(nil)
+	tokenRepo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+
+	// Act
+	tokens, err := service.AuthenticateUser(ctx, username, password)
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotNil(t, tokens)
 	userRepo.AssertExpectations(t)
 }
 
@@ -62,7 +176,8 @@ func TestAuthenticateUser_InvalidCredentials(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	userRepo := new(mockUserRepo)
-	service := NewUserService(userRepo, "test-secret")
+	tokenRepo := new(mockTokenRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(tokenRepo, userRepo), testHasher, testAuthConfig)
 
 	username := "testuser"
 	wrongPassword := "wrongpassword"
@@ -75,11 +190,11 @@ func TestAuthenticateUser_InvalidCredentials(t *testing.T) {
 	userRepo.On("GetUserByUsername", mock.Anything, username).Return(user, nil)
 
 	// Act
-	token, err := service.AuthenticateUser(ctx, username, wrongPassword)
+	tokens, err := service.AuthenticateUser(ctx, username, wrongPassword)
 
 	// Assert
 	assert.Error(t, err)
-	assert.Empty(t, token)
+	assert.Nil(t, tokens)
 	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
 	userRepo.AssertExpectations(t)
 }
@@ -88,7 +203,8 @@ func TestGetUserInfo_Success(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	userRepo := new(mockUserRepo)
-	service := NewUserService(userRepo, "test-secret")
+	tokenRepo := new(mockTokenRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(tokenRepo, userRepo), testHasher, testAuthConfig)
 
 	username := "testuser"
 	expectedUser := &domain.User{
@@ -115,7 +231,8 @@ func TestGetUserInfo_UserNotFound(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
 	userRepo := new(mockUserRepo)
-	service := NewUserService(userRepo, "test-secret")
+	tokenRepo := new(mockTokenRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(tokenRepo, userRepo), testHasher, testAuthConfig)
 
 	username := "nonexistent"
 
@@ -130,3 +247,40 @@ func TestGetUserInfo_UserNotFound(t *testing.T) {
 	assert.ErrorIs(t, err, domain.ErrUserNotFound)
 	userRepo.AssertExpectations(t)
 }
+
+func TestSetUserRole(t *testing.T) {
+	t.Run("назначение роли администратора", func(t *testing.T) {
+		userRepo := new(mockUserRepo)
+		service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(new(mockTokenRepo), userRepo), testHasher, testAuthConfig)
+
+		userRepo.On("UpdateUserRole", mock.Anything, "testuser", domain.RoleAdmin).Return(nil)
+
+		err := service.SetUserRole(context.Background(), "testuser", domain.RoleAdmin)
+
+		assert.NoError(t, err)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("неизвестная роль", func(t *testing.T) {
+		userRepo := new(mockUserRepo)
+		service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(new(mockTokenRepo), userRepo), testHasher, testAuthConfig)
+
+		err := service.SetUserRole(context.Background(), "testuser", domain.Role("root"))
+
+		assert.ErrorIs(t, err, domain.ErrInvalidRole)
+		userRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthenticateUser_ServiceAccount(t *testing.T) {
+	userRepo := new(mockUserRepo)
+	service := NewUserService(userRepo, newNotEnrolledMFARepo(), newTestTokenService(new(mockTokenRepo), userRepo), testHasher, testAuthConfig)
+
+	userRepo.On("GetUserByUsername", mock.Anything, "hr-bot").
+		Return(&domain.User{Username: "hr-bot", Password: []byte{}, Role: domain.RoleService}, nil)
+
+	tokens, err := service.AuthenticateUser(context.Background(), "hr-bot", "password123")
+
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Nil(t, tokens)
+}
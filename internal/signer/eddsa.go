@@ -0,0 +1,52 @@
+package signer
+
+import (
+	"crypto/ed25519"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA реализует алгоритм EdDSA (Ed25519), отсутствующий в jwt-go v3
+type SigningMethodEdDSA struct{}
+
+// SigningMethodEd25519 — экземпляр алгоритма EdDSA для использования в ключах
+var SigningMethodEd25519 = &SigningMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEd25519.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEd25519
+	})
+}
+
+// Alg возвращает идентификатор алгоритма
+func (m *SigningMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify проверяет подпись открытым ключом ed25519.PublicKey
+func (m *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Sign подписывает строку закрытым ключом ed25519.PrivateKey
+func (m *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
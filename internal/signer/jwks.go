@@ -0,0 +1,48 @@
+package signer
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWKSet представляет набор открытых ключей (RFC 7517)
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK представляет открытый ключ в формате JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+func toJWK(key Key) (JWK, bool) {
+	jwk := JWK{
+		Kid: key.Id,
+		Use: "sig",
+		Alg: key.Method.Alg(),
+	}
+
+	switch public := key.VerifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
@@ -0,0 +1,158 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/netscrawler/avito-shop/internal/config"
+)
+
+const (
+	secretKeyExt  = ".secret"
+	privateKeyExt = ".pem"
+)
+
+// NewFromConfig создает Signer из конфигурации JWT.
+// Если каталог ключей не задан, используется единственный ключ HS256 из JWT_SECRET.
+// Если в каталоге несколько ключей, ключ для подписи задается явно через JWT_ACTIVE_KEY_ID.
+func NewFromConfig(cfg config.JWTConfig) (Signer, error) {
+	if cfg.KeysDir == "" {
+		key, err := NewHMACKey("default", []byte(cfg.Secret))
+		if err != nil {
+			return nil, err
+		}
+		return New(key)
+	}
+
+	keys, err := LoadKeys(cfg.KeysDir)
+	if err != nil {
+		return nil, err
+	}
+
+	active, previous, err := selectActiveKey(keys, cfg.ActiveKeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(active, previous...)
+}
+
+// selectActiveKey отделяет ключ для подписи от ключей, используемых только для проверки.
+// Без явного kid активным может быть только единственный ключ.
+func selectActiveKey(keys []Key, activeId string) (Key, []Key, error) {
+	if activeId == "" {
+		if len(keys) == 1 {
+			return keys[0], nil, nil
+		}
+		return Key{}, nil, ErrNoActiveKey
+	}
+
+	for i, key := range keys {
+		if key.Id == activeId {
+			previous := make([]Key, 0, len(keys)-1)
+			previous = append(previous, keys[:i]...)
+			previous = append(previous, keys[i+1:]...)
+			return key, previous, nil
+		}
+	}
+
+	return Key{}, nil, fmt.Errorf("%w: активный ключ %q не найден", ErrUnknownKey, activeId)
+}
+
+// LoadKeys загружает ключи из каталога. Имя файла без расширения используется как kid:
+// *.secret — общий секрет HS256, *.pem — закрытый ключ RSA (RS256) или Ed25519 (EdDSA).
+func LoadKeys(dir string) ([]Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("чтение каталога ключей: %w", err)
+	}
+
+	var keys []Key
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(entry.Name())
+		if ext != secretKeyExt && ext != privateKeyExt {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("чтение ключа %s: %w", entry.Name(), err)
+		}
+
+		kid := strings.TrimSuffix(entry.Name(), ext)
+		if ext == secretKeyExt {
+			key, err := NewHMACKey(kid, bytes.TrimSpace(data))
+			if err != nil {
+				return nil, fmt.Errorf("разбор ключа %s: %w", entry.Name(), err)
+			}
+			keys = append(keys, key)
+			continue
+		}
+
+		key, err := ParsePrivateKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("разбор ключа %s: %w", entry.Name(), err)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	return keys, nil
+}
+
+// ParsePrivateKey разбирает закрытый ключ в формате PEM (PKCS#1 или PKCS#8)
+// и определяет алгоритм подписи по типу ключа
+func ParsePrivateKey(kid string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("ключ не в формате PEM")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("неподдерживаемый тип PEM-блока %q", block.Type)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		return Key{
+			Id:         kid,
+			Method:     jwt.SigningMethodRS256,
+			SigningKey: private,
+			VerifyKey:  &private.PublicKey,
+		}, nil
+	case ed25519.PrivateKey:
+		return Key{
+			Id:         kid,
+			Method:     SigningMethodEd25519,
+			SigningKey: private,
+			VerifyKey:  private.Public(),
+		}, nil
+	default:
+		return Key{}, fmt.Errorf("неподдерживаемый тип ключа %T", parsed)
+	}
+}
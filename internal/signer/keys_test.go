@@ -0,0 +1,50 @@
+package signer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKeysRejectsWeakSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{name: "пустой файл", secret: ""},
+		{name: "только пробелы", secret: " \n\t\n"},
+		{name: "короткий секрет", secret: "short-secret\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "key-1.secret"), []byte(tt.secret), 0o600))
+
+			_, err := LoadKeys(dir)
+			assert.ErrorIs(t, err, ErrWeakSecret)
+		})
+	}
+
+	t.Run("секрет минимальной длины", func(t *testing.T) {
+		dir := t.TempDir()
+		secret := make([]byte, MinHMACSecretLength)
+		for i := range secret {
+			secret[i] = 'a'
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "key-1.secret"), secret, 0o600))
+
+		keys, err := LoadKeys(dir)
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+	})
+}
+
+func TestNewFromConfigRejectsWeakSecret(t *testing.T) {
+	_, err := NewFromConfig(config.JWTConfig{Secret: ""})
+	assert.ErrorIs(t, err, ErrWeakSecret)
+}
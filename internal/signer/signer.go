@@ -0,0 +1,133 @@
+// Package signer подписывает и проверяет JWT с поддержкой нескольких ключей и их ротации
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrNoKeys        = errors.New("не задано ни одного ключа подписи")
+	ErrNoActiveKey   = errors.New("не указан активный ключ подписи")
+	ErrUnknownKey    = errors.New("неизвестный идентификатор ключа")
+	ErrInvalidClaims = errors.New("некорректные claims токена")
+	ErrWeakSecret    = errors.New("слишком короткий секрет HMAC")
+)
+
+// MinHMACSecretLength — минимальная длина секрета HS256 в байтах,
+// соответствующая размеру выхода SHA-256
+const MinHMACSecretLength = 32
+
+// Key описывает ключ подписи JWT
+type Key struct {
+	Id         string            // Идентификатор ключа (kid)
+	Method     jwt.SigningMethod // Алгоритм подписи
+	SigningKey interface{}       // Ключ для подписи: секрет HMAC или закрытый ключ
+	VerifyKey  interface{}       // Ключ для проверки: секрет HMAC или открытый ключ
+}
+
+// Signer подписывает и проверяет JWT
+type Signer interface {
+	Sign(claims jwt.MapClaims) (string, error)
+	Verify(tokenString string) (jwt.MapClaims, error)
+	JWKS() JWKSet
+}
+
+// keySet реализует Signer поверх набора ключей.
+// Подпись выполняется активным ключом, проверка — любым из загруженных.
+type keySet struct {
+	keys    map[string]Key
+	current Key
+	ordered []Key
+}
+
+// New создает Signer, подписывающий токены ключом active.
+// Ключи previous используются только для проверки ранее выпущенных токенов.
+func New(active Key, previous ...Key) (Signer, error) {
+	ordered := make([]Key, 0, len(previous)+1)
+	ordered = append(ordered, active)
+	ordered = append(ordered, previous...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Id < ordered[j].Id })
+
+	set := &keySet{
+		keys:    make(map[string]Key, len(ordered)),
+		current: active,
+		ordered: ordered,
+	}
+	for _, key := range ordered {
+		if _, exists := set.keys[key.Id]; exists {
+			return nil, fmt.Errorf("повторяющийся идентификатор ключа %q", key.Id)
+		}
+		set.keys[key.Id] = key
+	}
+
+	return set, nil
+}
+
+// NewHMACKey создает ключ HS256 из общего секрета.
+// Секрет короче MinHMACSecretLength отклоняется: его можно подобрать перебором.
+func NewHMACKey(id string, secret []byte) (Key, error) {
+	if len(secret) < MinHMACSecretLength {
+		return Key{}, fmt.Errorf("%w: ключ %q длиной %d байт, требуется не менее %d",
+			ErrWeakSecret, id, len(secret), MinHMACSecretLength)
+	}
+
+	return Key{
+		Id:         id,
+		Method:     jwt.SigningMethodHS256,
+		SigningKey: secret,
+		VerifyKey:  secret,
+	}, nil
+}
+
+// Sign подписывает claims текущим ключом и проставляет kid в заголовок
+func (s *keySet) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(s.current.Method, claims)
+	token.Header["kid"] = s.current.Id
+
+	return token.SignedString(s.current.SigningKey)
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает его claims
+func (s *keySet) Verify(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		key := s.current
+		if kid, ok := token.Header["kid"].(string); ok {
+			found, exists := s.keys[kid]
+			if !exists {
+				return nil, ErrUnknownKey
+			}
+			key = found
+		}
+
+		// Алгоритм токена обязан совпадать с алгоритмом ключа
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.NewValidationError("unexpected signing method", jwt.ValidationErrorSignatureInvalid)
+		}
+		return key.VerifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidClaims
+	}
+
+	return claims, nil
+}
+
+// JWKS возвращает открытые ключи в формате JWK Set. Секреты HMAC не публикуются.
+func (s *keySet) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(s.ordered))}
+	for _, key := range s.ordered {
+		if jwk, ok := toJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
@@ -0,0 +1,195 @@
+package signer
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"username": "testuser",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// mustHMACKey создает ключ HS256 с секретом допустимой длины
+func mustHMACKey(t *testing.T, id, secret string) Key {
+	t.Helper()
+	key, err := NewHMACKey(id, []byte(secret))
+	require.NoError(t, err)
+	return key
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  Key
+		alg  string
+	}{
+		{
+			name: "HS256",
+			key:  mustHMACKey(t, "hmac", "sign-and-verify-secret-of-32-byte"),
+			alg:  "HS256",
+		},
+		{
+			name: "RS256",
+			key:  Key{Id: "rsa", Method: jwt.SigningMethodRS256, SigningKey: rsaKey, VerifyKey: &rsaKey.PublicKey},
+			alg:  "RS256",
+		},
+		{
+			name: "EdDSA",
+			key:  Key{Id: "ed", Method: SigningMethodEd25519, SigningKey: edKey, VerifyKey: edKey.Public()},
+			alg:  "EdDSA",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.key)
+			require.NoError(t, err)
+
+			tokenString, err := s.Sign(testClaims())
+			require.NoError(t, err)
+
+			token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, token.Header["alg"])
+			assert.Equal(t, tt.key.Id, token.Header["kid"])
+
+			claims, err := s.Verify(tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, "testuser", claims["username"])
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := mustHMACKey(t, "2024-01", "old-secret-old-secret-old-secret")
+	newKey := mustHMACKey(t, "2024-06", "new-secret-new-secret-new-secret")
+
+	oldSigner, err := New(oldKey)
+	require.NoError(t, err)
+	oldToken, err := oldSigner.Sign(testClaims())
+	require.NoError(t, err)
+
+	rotated, err := New(newKey, oldKey)
+	require.NoError(t, err)
+
+	t.Run("токен старого ключа остается валидным", func(t *testing.T) {
+		_, err := rotated.Verify(oldToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("подпись выполняется активным ключом", func(t *testing.T) {
+		tokenString, err := rotated.Sign(testClaims())
+		require.NoError(t, err)
+
+		token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "2024-06", token.Header["kid"])
+	})
+
+	t.Run("токен удаленного ключа отклоняется", func(t *testing.T) {
+		onlyNew, err := New(newKey)
+		require.NoError(t, err)
+
+		_, err = onlyNew.Verify(oldToken)
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifyRejectsAlgorithmMismatch(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	s, err := New(Key{Id: "rsa", Method: jwt.SigningMethodRS256, SigningKey: rsaKey, VerifyKey: &rsaKey.PublicKey})
+	require.NoError(t, err)
+
+	// Токен HS256 с тем же kid не должен приниматься
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	token.Header["kid"] = "rsa"
+	tokenString, err := token.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = s.Verify(tokenString)
+	assert.Error(t, err)
+}
+
+func TestLoadKeysAndJWKS(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024-06.pem"), pemData, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01.secret"), []byte("old-secret-old-secret-old-secret\n"), 0o600))
+
+	keys, err := LoadKeys(dir)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	s, err := New(keys[0], keys[1:]...)
+	require.NoError(t, err)
+
+	jwks := s.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "2024-06", jwks.Keys[0].Kid)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+}
+
+func TestNewFromConfig(t *testing.T) {
+	// Лексикографически "key-9" больше "key-10", поэтому активный ключ задается явно
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key-9.secret"), []byte("old-secret-old-secret-old-secret"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "key-10.secret"), []byte("new-secret-new-secret-new-secret"), 0o600))
+
+	t.Run("подпись активным ключом из конфигурации", func(t *testing.T) {
+		s, err := NewFromConfig(config.JWTConfig{KeysDir: dir, ActiveKeyId: "key-10"})
+		require.NoError(t, err)
+
+		tokenString, err := s.Sign(testClaims())
+		require.NoError(t, err)
+
+		token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "key-10", token.Header["kid"])
+	})
+
+	t.Run("активный ключ не указан", func(t *testing.T) {
+		_, err := NewFromConfig(config.JWTConfig{KeysDir: dir})
+		assert.ErrorIs(t, err, ErrNoActiveKey)
+	})
+
+	t.Run("активный ключ не найден", func(t *testing.T) {
+		_, err := NewFromConfig(config.JWTConfig{KeysDir: dir, ActiveKeyId: "key-11"})
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("единственный ключ активен по умолчанию", func(t *testing.T) {
+		single := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(single, "key-1.secret"), []byte("single-secret-single-secret-32b!"), 0o600))
+
+		_, err := NewFromConfig(config.JWTConfig{KeysDir: single})
+		assert.NoError(t, err)
+	})
+}
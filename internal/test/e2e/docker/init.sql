@@ -51,3 +51,348 @@ INSERT INTO merch (name, price) VALUES
   ('wallet', 50),
   ('pink-hoody', 500);
 
+
+CREATE TABLE refresh_tokens (
+  id SERIAL PRIMARY KEY,
+  token_hash VARCHAR(64) UNIQUE NOT NULL,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  family_id VARCHAR(64) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP,
+  replaced_by INT REFERENCES refresh_tokens(id)
+);
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_username ON refresh_tokens(username);
+
+CREATE TABLE revoked_tokens (
+  jti VARCHAR(64) PRIMARY KEY,
+  expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE login_attempts (
+  key VARCHAR(300) PRIMARY KEY,
+  failures INT NOT NULL DEFAULT 0,
+  last_failure TIMESTAMP NOT NULL,
+  locked_until TIMESTAMP
+);
+
+ALTER TABLE users
+  ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'employee'
+  CHECK (role IN ('employee', 'admin'));
+
+CREATE TABLE password_reset_tokens (
+  token_hash VARCHAR(64) PRIMARY KEY,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX idx_password_reset_tokens_username ON password_reset_tokens(username);
+
+CREATE TABLE mfa_factors (
+  username VARCHAR(255) PRIMARY KEY REFERENCES users(username) ON DELETE CASCADE,
+  secret VARCHAR(64) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  last_used_step BIGINT,
+  created_at TIMESTAMP NOT NULL,
+  confirmed_at TIMESTAMP
+);
+
+CREATE TABLE mfa_recovery_codes (
+  id SERIAL PRIMARY KEY,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX idx_mfa_recovery_codes_username ON mfa_recovery_codes(username);
+
+ALTER TABLE refresh_tokens ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{pwd}';
+
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+  CHECK (role IN ('employee', 'admin', 'service'));
+
+CREATE TABLE api_keys (
+  id SERIAL PRIMARY KEY,
+  account VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  prefix VARCHAR(32) UNIQUE NOT NULL,
+  key_hash VARCHAR(64) NOT NULL,
+  scopes TEXT[] NOT NULL,
+  expires_at TIMESTAMP,
+  last_used_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_account ON api_keys(account);
+
+CREATE TABLE idempotency_keys (
+  username VARCHAR(255) NOT NULL,
+  key VARCHAR(255) NOT NULL,
+  request_hash VARCHAR(64) NOT NULL,
+  status_code INT NOT NULL,
+  response BYTEA NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (username, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- Название купленного товара для записей о покупках.
+ALTER TABLE transactions ADD COLUMN item_name VARCHAR(255);
+
+CREATE INDEX idx_transactions_receiver ON transactions(receiver_name);
+
+-- Единая история операций пользователя: каждая транзакция видна отправителю как списание,
+-- а получателю-пользователю как зачисление. У покупок получатель - магазин, зачисления нет.
+CREATE VIEW user_ledger AS
+  SELECT id AS transaction_id, sender_name AS username, receiver_name AS counterparty,
+         'DEBIT' AS direction, amount, transfer_type, item_name, timestamp
+  FROM transactions
+  UNION ALL
+  SELECT id AS transaction_id, receiver_name AS username, sender_name AS counterparty,
+         'CREDIT' AS direction, amount, transfer_type, item_name, timestamp
+  FROM transactions
+  WHERE receiver_name IS NOT NULL AND transfer_type <> 'PURCHASE';
+
+-- Индексы для постраничной выборки истории по (timestamp, id)
+CREATE INDEX idx_transactions_sender_timestamp ON transactions(sender_name, timestamp DESC, id DESC);
+CREATE INDEX idx_transactions_receiver_timestamp ON transactions(receiver_name, timestamp DESC, id DESC);
+
+-- Описание, категория и доступность товаров для каталога
+ALTER TABLE merch
+  ADD COLUMN description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN category VARCHAR(64) NOT NULL DEFAULT 'other',
+  ADD COLUMN available BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE merch SET category = 'clothing', description = 'Футболка с логотипом Авито' WHERE name = 't-shirt';
+UPDATE merch SET category = 'tableware', description = 'Кружка с логотипом Авито' WHERE name = 'cup';
+UPDATE merch SET category = 'stationery', description = 'Книга из библиотеки Авито' WHERE name = 'book';
+UPDATE merch SET category = 'stationery', description = 'Ручка с логотипом Авито' WHERE name = 'pen';
+UPDATE merch SET category = 'electronics', description = 'Внешний аккумулятор' WHERE name = 'powerbank';
+UPDATE merch SET category = 'clothing', description = 'Худи с логотипом Авито' WHERE name = 'hoody';
+UPDATE merch SET category = 'accessories', description = 'Зонт с логотипом Авито' WHERE name = 'umbrella';
+UPDATE merch SET category = 'clothing', description = 'Носки с логотипом Авито' WHERE name = 'socks';
+UPDATE merch SET category = 'accessories', description = 'Кошелек с логотипом Авито' WHERE name = 'wallet';
+UPDATE merch SET category = 'clothing', description = 'Розовое худи с логотипом Авито' WHERE name = 'pink-hoody';
+
+-- Снятые с продажи товары не удаляются, чтобы история покупок и инвентарь ссылались на существующие записи
+ALTER TABLE merch ADD COLUMN retired_at TIMESTAMP;
+
+-- Остаток товара на складе; NULL означает, что количество не ограничено
+ALTER TABLE merch ADD COLUMN stock INT CHECK (stock >= 0);
+
+-- Количество купленных единиц товара; заполняется только для покупок
+ALTER TABLE transactions ADD COLUMN quantity INT CHECK (quantity > 0);
+UPDATE transactions SET quantity = 1 WHERE transfer_type = 'PURCHASE';
+
+-- Новая колонка добавляется в конец представления, как того требует CREATE OR REPLACE VIEW
+CREATE OR REPLACE VIEW user_ledger AS
+  SELECT id AS transaction_id, sender_name AS username, receiver_name AS counterparty,
+         'DEBIT' AS direction, amount, transfer_type, item_name, timestamp, quantity
+  FROM transactions
+  UNION ALL
+  SELECT id AS transaction_id, receiver_name AS username, sender_name AS counterparty,
+         'CREDIT' AS direction, amount, transfer_type, item_name, timestamp, quantity
+  FROM transactions
+  WHERE receiver_name IS NOT NULL AND transfer_type <> 'PURCHASE';
+
+-- Корзина пользователя. Цена фиксируется при добавлении, чтобы при оформлении
+-- заказа пользователь не заплатил больше, чем видел.
+CREATE TABLE cart_items (
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_price INT NOT NULL CHECK (unit_price >= 0),
+  added_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (username, item_name)
+);
+
+-- Заказ, оформленный из корзины, и его позиции
+CREATE TABLE orders (
+  id BIGSERIAL PRIMARY KEY,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  total BIGINT NOT NULL CHECK (total >= 0),
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_orders_username_created_at ON orders(username, created_at DESC);
+
+CREATE TABLE order_items (
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  item_name VARCHAR(255) NOT NULL,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_price INT NOT NULL CHECK (unit_price >= 0),
+  PRIMARY KEY (order_id, item_name)
+);
+
+-- Каждая позиция заказа записывается отдельной покупкой со ссылкой на заказ
+ALTER TABLE transactions ADD COLUMN order_id BIGINT REFERENCES orders(id);
+
+-- Статус выдачи заказа: placed -> ready_for_pickup -> delivered, до выдачи заказ можно отменить.
+-- Покупки, сделанные до появления заказов, заказов не получают.
+ALTER TABLE orders
+  ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'placed'
+  CHECK (status IN ('placed', 'ready_for_pickup', 'delivered', 'cancelled'));
+ALTER TABLE orders ADD COLUMN updated_at TIMESTAMP;
+UPDATE orders SET updated_at = created_at;
+ALTER TABLE orders ALTER COLUMN updated_at SET NOT NULL;
+
+-- Очередь заказов для выдачи
+CREATE INDEX idx_orders_status_created_at ON orders(status, created_at DESC);
+
+-- Журнал изменений статуса. from_status пуст у записи о создании заказа.
+CREATE TABLE order_status_history (
+  id BIGSERIAL PRIMARY KEY,
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  from_status VARCHAR(32),
+  to_status VARCHAR(32) NOT NULL,
+  changed_by VARCHAR(255) NOT NULL,
+  comment TEXT NOT NULL DEFAULT '',
+  changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, changed_at);
+
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, changed_at)
+SELECT id, NULL, 'placed', username, created_at FROM orders;
+
+-- Системная учетная запись магазина: получатель оплаты покупок и отправитель возвратов
+-- по отмененным заказам. Она должна существовать из-за внешнего ключа transactions.sender_name.
+-- Пустой хэш пароля не совпадает ни с одним паролем, а имя shop зарезервировано при регистрации.
+-- До резервирования имени мог зарегистрироваться обычный пользователь SHOP. Миграция в этом случае
+-- останавливается, чтобы не превратить его в учетную запись магазина вместе с его балансом.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE username = 'SHOP' AND role <> 'service') THEN
+        RAISE EXCEPTION 'пользователь SHOP уже существует: переименуйте его перед созданием учетной записи магазина';
+    END IF;
+END $$;
+
+INSERT INTO users (username, password, coins, role) VALUES ('SHOP', '', 0, 'service')
+ON CONFLICT (username) DO NOTHING;
+
+-- Промокоды на скидку при покупке товара. Код действует на любой товар,
+-- если не указаны ни item_name, ни category.
+CREATE TABLE promo_codes (
+  code VARCHAR(32) PRIMARY KEY,
+  discount_type VARCHAR(16) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+  discount_value BIGINT NOT NULL CHECK (discount_value > 0),
+  item_name VARCHAR(255) REFERENCES merch(name),
+  category VARCHAR(64),
+  valid_from TIMESTAMP,
+  valid_until TIMESTAMP,
+  max_redemptions INT CHECK (max_redemptions > 0),
+  max_per_user INT CHECK (max_per_user > 0),
+  redemptions INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL,
+  CHECK (discount_type <> 'percent' OR discount_value <= 100),
+  CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_until > valid_from),
+  CHECK (max_redemptions IS NULL OR redemptions <= max_redemptions)
+);
+
+-- Использования промокодов; по ним считается лимит на пользователя
+CREATE TABLE promo_redemptions (
+  id BIGSERIAL PRIMARY KEY,
+  code VARCHAR(32) NOT NULL REFERENCES promo_codes(code),
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  order_id BIGINT NOT NULL REFERENCES orders(id),
+  discount BIGINT NOT NULL CHECK (discount >= 0),
+  redeemed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_promo_redemptions_code_username ON promo_redemptions(code, username);
+
+-- Скидка по заказу; total уже хранит сумму со скидкой
+ALTER TABLE orders ADD COLUMN discount BIGINT NOT NULL DEFAULT 0 CHECK (discount >= 0);
+
+-- Запланированные цены товаров. Пока расписание действует, оно заменяет merch.price;
+-- при пересечении расписаний применяется расписание с большим приоритетом.
+CREATE TABLE merch_price_schedules (
+  id BIGSERIAL PRIMARY KEY,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  price INT NOT NULL CHECK (price > 0),
+  effective_from TIMESTAMP NOT NULL,
+  effective_to TIMESTAMP,
+  priority INT NOT NULL DEFAULT 0,
+  created_by VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  cancelled_at TIMESTAMP,
+  CHECK (effective_to IS NULL OR effective_to > effective_from)
+);
+
+CREATE INDEX idx_merch_price_schedules_item ON merch_price_schedules(item_name, effective_from);
+
+-- История базовых цен товаров; вместе с расписаниями позволяет восстановить цену на момент покупки
+CREATE TABLE merch_price_history (
+  id BIGSERIAL PRIMARY KEY,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  price INT NOT NULL CHECK (price >= 0),
+  changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_merch_price_history_item ON merch_price_history(item_name, changed_at);
+
+-- Цены до появления истории неизвестны, поэтому история начинается с момента миграции
+INSERT INTO merch_price_history (item_name, price, changed_at)
+SELECT name, price, LOCALTIMESTAMP FROM merch;
+
+-- Сообщение к подарку; заполняется только для транзакций GIFT
+ALTER TABLE transactions ADD COLUMN message VARCHAR(500);
+
+-- Подарок оплачивает отправитель, а получатель получает товар, а не монеты,
+-- поэтому зачисление по подарку отражается в истории получателя с нулевой суммой.
+CREATE OR REPLACE VIEW user_ledger AS
+  SELECT id AS transaction_id, sender_name AS username, receiver_name AS counterparty,
+         'DEBIT' AS direction, amount, transfer_type, item_name, timestamp, quantity, message
+  FROM transactions
+  UNION ALL
+  SELECT id AS transaction_id, receiver_name AS username, sender_name AS counterparty,
+         'CREDIT' AS direction, CASE WHEN transfer_type = 'GIFT' THEN 0 ELSE amount END, transfer_type, item_name, timestamp, quantity, message
+  FROM transactions
+  WHERE receiver_name IS NOT NULL AND transfer_type <> 'PURCHASE';
+
+-- Признак того, что купленный товар можно передать другому пользователю.
+-- Передача записывается в transactions с типом ITEM_TRANSFER и нулевой суммой.
+ALTER TABLE merch ADD COLUMN transferable BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Лоты маркетплейса. Пока лот активен, его товар списан из инвентаря продавца,
+-- поэтому одну и ту же единицу нельзя продать или передать дважды.
+CREATE TABLE market_listings (
+  id BIGSERIAL PRIMARY KEY,
+  seller VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  quantity INT NOT NULL CHECK (quantity > 0),
+  price BIGINT NOT NULL CHECK (price > 0),
+  status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'sold', 'cancelled')),
+  buyer VARCHAR(255) REFERENCES users(username) ON DELETE SET NULL,
+  fee BIGINT NOT NULL DEFAULT 0 CHECK (fee >= 0 AND fee <= price),
+  created_at TIMESTAMP NOT NULL,
+  closed_at TIMESTAMP
+);
+
+-- Просмотр активных лотов по товару и цене, а также лотов продавца
+CREATE INDEX idx_market_listings_active ON market_listings(item_name, price) WHERE status = 'active';
+CREATE INDEX idx_market_listings_seller ON market_listings(seller, created_at DESC);
+
+-- Лот, по которому проведена оплата или удержана комиссия
+ALTER TABLE transactions ADD COLUMN listing_id BIGINT REFERENCES market_listings(id);
+
+-- Подарок оформляется заказом: оплачивает отправитель (username), товар выдается получателю.
+-- У обычных заказов получатель не указан.
+ALTER TABLE orders ADD COLUMN recipient VARCHAR(255) REFERENCES users(username) ON DELETE CASCADE;
+
+-- Access-токены пользователя, выпущенные раньше этого момента, считаются отозванными.
+-- Заполняется при смене и сбросе пароля; пусто — ограничения нет.
+ALTER TABLE users ADD COLUMN tokens_valid_after TIMESTAMP;
+
+-- Начисление не списывает монеты с того, кто его выполнил, поэтому отправителем GRANT
+-- записывается магазин, а фактический автор начисления сохраняется отдельно для аудита.
+ALTER TABLE transactions ADD COLUMN granted_by VARCHAR(255);
+UPDATE transactions SET granted_by = sender_name, sender_name = 'SHOP' WHERE transfer_type = 'GRANT';
@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/netscrawler/avito-shop/internal/config"
+	"github.com/netscrawler/avito-shop/internal/hasher"
 	"github.com/netscrawler/avito-shop/internal/repository/postgres"
 	"github.com/netscrawler/avito-shop/internal/service"
+	"github.com/netscrawler/avito-shop/internal/signer"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type IntegrationTestSuite struct {
@@ -50,10 +54,18 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	merchRepo := postgres.NewMerchRepository(s.db)
 	userRepo := postgres.NewUserRepository(s.db)
 	transactionRepo := postgres.NewTransactionRepository(s.db)
+	tokenRepo := postgres.NewTokenRepository(s.db)
 
 	// Инициализация сервисов
-	s.userService = service.NewUserService(userRepo, "your-secret-key")
-	s.merchService = service.NewMerchService(userRepo, merchRepo, transactionRepo)
+	key, err := signer.NewHMACKey("test", []byte("integration-test-secret-32-bytes"))
+	require.NoError(s.T(), err)
+	keys, err := signer.New(key)
+	require.NoError(s.T(), err)
+	tokenService := service.NewTokenService(tokenRepo, userRepo, keys, time.Minute, time.Hour, 0)
+	authConfig := config.AuthConfig{AutoRegister: true, InitialCoins: 1000, PasswordMinLength: 8}
+	passwords := hasher.New(hasher.NewBcrypt(bcrypt.DefaultCost))
+	s.userService = service.NewUserService(userRepo, postgres.NewMFARepository(s.db), tokenService, passwords, authConfig)
+	s.merchService = service.NewMerchService(userRepo, merchRepo, transactionRepo, postgres.NewPromoCodeRepository(s.db))
 	s.transferService = service.NewTransferService(transactionRepo, userRepo)
 }
 
@@ -73,9 +85,10 @@ func (s *IntegrationTestSuite) TestUserOperations() {
 	s.Require().NoError(err)
 
 	// Аутентификация пользователя
-	token, err := s.userService.AuthenticateUser(s.ctx, username, password)
+	tokens, err := s.userService.AuthenticateUser(s.ctx, username, password)
 	s.Require().NoError(err)
-	s.Require().NotEmpty(token)
+	s.Require().NotEmpty(tokens.AccessToken)
+	s.Require().NotEmpty(tokens.RefreshToken)
 
 	// Получение информации о пользователе
 	user, err := s.userService.GetUserInfo(s.ctx, username)
@@ -100,7 +113,7 @@ func (s *IntegrationTestSuite) TestMerchOperations() {
 
 	if len(merch) > 0 {
 		// Покупка первого доступного товара
-		err = s.merchService.BuyMerch(s.ctx, username, merch[0].Name)
+		err = s.merchService.BuyMerch(s.ctx, username, merch[0].Name, 1, "", nil)
 		s.Require().NoError(err)
 	}
 }
@@ -119,11 +132,11 @@ func (s *IntegrationTestSuite) TestTransferOperations() {
 	s.Require().NoError(err)
 
 	// Перевод средств
-	err = s.transferService.SendCoins(s.ctx, sender, receiver, amount)
+	err = s.transferService.SendCoins(s.ctx, sender, receiver, amount, nil)
 	s.Require().NoError(err)
 
 	// Проверка истории транзакций
-	history, err := s.transferService.GetTransactionHistory(s.ctx, sender)
+	history, err := s.transferService.GetTransactionHistory(s.ctx, sender, 0)
 	s.Require().NoError(err)
 	s.Require().NotEmpty(history)
 }
@@ -0,0 +1,98 @@
+// Package totp реализует одноразовые пароли на основе времени (RFC 6238)
+// с параметрами, которые поддерживают распространенные приложения-аутентификаторы:
+// HMAC-SHA1, 6 цифр, период 30 секунд
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	Digits = 6                // Количество цифр в коде
+	Period = 30 * time.Second // Период смены кода
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret возвращает новый секрет в кодировке base32
+func GenerateSecret() (string, error) {
+	buf := make([]byte, secretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(buf), nil
+}
+
+// Step возвращает номер временного шага для момента t
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code вычисляет код для секрета и временного шага
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("декодирование секрета: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение (RFC 4226, раздел 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod), nil
+}
+
+// Validate проверяет код в окне ±skew шагов от момента t и возвращает шаг, которому он соответствует
+func Validate(secret, code string, t time.Time, skew int) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := Step(t)
+	for i := -skew; i <= skew; i++ {
+		step := current + int64(i)
+		expected, err := Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// URI возвращает otpauth-ссылку для добавления секрета в приложение-аутентификатор
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
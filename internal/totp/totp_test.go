@@ -0,0 +1,68 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret — секрет "12345678901234567890" из тестовых векторов RFC 6238
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// Тестовые векторы RFC 6238 для SHA1, последние 6 цифр
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tt.want, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	t.Run("код текущего шага", func(t *testing.T) {
+		step, ok := Validate(rfcSecret, "081804", now, 1)
+		require.True(t, ok)
+		require.Equal(t, Step(now), step)
+	})
+
+	t.Run("код предыдущего шага в пределах окна", func(t *testing.T) {
+		_, ok := Validate(rfcSecret, "081804", now.Add(Period), 1)
+		require.True(t, ok)
+	})
+
+	t.Run("код вне окна", func(t *testing.T) {
+		_, ok := Validate(rfcSecret, "081804", now.Add(3*Period), 1)
+		require.False(t, ok)
+	})
+
+	t.Run("неверный код", func(t *testing.T) {
+		_, ok := Validate(rfcSecret, "000000", now, 1)
+		require.False(t, ok)
+	})
+}
+
+func TestURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	uri := URI("Avito Shop", "testuser", secret)
+
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/Avito%20Shop:testuser?"))
+	require.Contains(t, uri, "secret="+secret)
+	require.Contains(t, uri, "issuer=Avito+Shop")
+}
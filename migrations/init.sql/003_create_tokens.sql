@@ -0,0 +1,20 @@
+-- Refresh-токены, выпущенные пользователям. Хранится только хэш значения токена.
+CREATE TABLE refresh_tokens (
+  id SERIAL PRIMARY KEY,
+  token_hash VARCHAR(64) UNIQUE NOT NULL,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  family_id VARCHAR(64) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP,
+  replaced_by INT REFERENCES refresh_tokens(id)
+);
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_username ON refresh_tokens(username);
+
+-- Отозванные access-токены (denylist по jti)
+CREATE TABLE revoked_tokens (
+  jti VARCHAR(64) PRIMARY KEY,
+  expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,7 @@
+-- Счетчики неудачных попыток входа по имени пользователя и IP-адресу
+CREATE TABLE login_attempts (
+  key VARCHAR(300) PRIMARY KEY,
+  failures INT NOT NULL DEFAULT 0,
+  last_failure TIMESTAMP NOT NULL,
+  locked_until TIMESTAMP
+);
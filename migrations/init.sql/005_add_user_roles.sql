@@ -0,0 +1,5 @@
+-- Роль пользователя. Первого администратора назначают вручную:
+-- UPDATE users SET role = 'admin' WHERE username = '...';
+ALTER TABLE users
+  ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'employee'
+  CHECK (role IN ('employee', 'admin'));
@@ -0,0 +1,10 @@
+-- Одноразовые токены сброса пароля. Хранится только хэш значения токена.
+CREATE TABLE password_reset_tokens (
+  token_hash VARCHAR(64) PRIMARY KEY,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX idx_password_reset_tokens_username ON password_reset_tokens(username);
@@ -0,0 +1,22 @@
+-- TOTP-аутентификаторы пользователей
+CREATE TABLE mfa_factors (
+  username VARCHAR(255) PRIMARY KEY REFERENCES users(username) ON DELETE CASCADE,
+  secret VARCHAR(64) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  last_used_step BIGINT,
+  created_at TIMESTAMP NOT NULL,
+  confirmed_at TIMESTAMP
+);
+
+-- Одноразовые коды восстановления. Хранится только хэш кода.
+CREATE TABLE mfa_recovery_codes (
+  id SERIAL PRIMARY KEY,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at TIMESTAMP
+);
+
+CREATE INDEX idx_mfa_recovery_codes_username ON mfa_recovery_codes(username);
+
+-- Методы аутентификации, которыми открыто семейство refresh-токенов
+ALTER TABLE refresh_tokens ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{pwd}';
@@ -0,0 +1,20 @@
+-- Сервисные учетные записи входят только по API-ключу
+ALTER TABLE users DROP CONSTRAINT users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+  CHECK (role IN ('employee', 'admin', 'service'));
+
+-- API-ключи сервисных учетных записей. Хранится только хэш значения ключа.
+CREATE TABLE api_keys (
+  id SERIAL PRIMARY KEY,
+  account VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  prefix VARCHAR(32) UNIQUE NOT NULL,
+  key_hash VARCHAR(64) NOT NULL,
+  scopes TEXT[] NOT NULL,
+  expires_at TIMESTAMP,
+  last_used_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_account ON api_keys(account);
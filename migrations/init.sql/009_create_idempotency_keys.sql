@@ -0,0 +1,14 @@
+-- Результаты запросов с заголовком Idempotency-Key.
+-- Запись создается в той же транзакции, что и изменение баланса.
+CREATE TABLE idempotency_keys (
+  username VARCHAR(255) NOT NULL,
+  key VARCHAR(255) NOT NULL,
+  request_hash VARCHAR(64) NOT NULL,
+  status_code INT NOT NULL,
+  response BYTEA NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (username, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
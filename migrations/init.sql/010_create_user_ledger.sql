@@ -0,0 +1,17 @@
+-- Название купленного товара для записей о покупках.
+-- Для покупок, совершенных до миграции, значение остается пустым.
+ALTER TABLE transactions ADD COLUMN item_name VARCHAR(255);
+
+CREATE INDEX idx_transactions_receiver ON transactions(receiver_name);
+
+-- Единая история операций пользователя: каждая транзакция видна отправителю как списание,
+-- а получателю-пользователю как зачисление. У покупок получатель - магазин, зачисления нет.
+CREATE VIEW user_ledger AS
+  SELECT id AS transaction_id, sender_name AS username, receiver_name AS counterparty,
+         'DEBIT' AS direction, amount, transfer_type, item_name, timestamp
+  FROM transactions
+  UNION ALL
+  SELECT id AS transaction_id, receiver_name AS username, sender_name AS counterparty,
+         'CREDIT' AS direction, amount, transfer_type, item_name, timestamp
+  FROM transactions
+  WHERE receiver_name IS NOT NULL AND transfer_type <> 'PURCHASE';
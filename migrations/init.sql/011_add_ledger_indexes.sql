@@ -0,0 +1,3 @@
+-- Индексы для постраничной выборки истории по (timestamp, id)
+CREATE INDEX idx_transactions_sender_timestamp ON transactions(sender_name, timestamp DESC, id DESC);
+CREATE INDEX idx_transactions_receiver_timestamp ON transactions(receiver_name, timestamp DESC, id DESC);
@@ -0,0 +1,16 @@
+-- Описание, категория и доступность товаров для каталога
+ALTER TABLE merch
+  ADD COLUMN description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN category VARCHAR(64) NOT NULL DEFAULT 'other',
+  ADD COLUMN available BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE merch SET category = 'clothing', description = 'Футболка с логотипом Авито' WHERE name = 't-shirt';
+UPDATE merch SET category = 'tableware', description = 'Кружка с логотипом Авито' WHERE name = 'cup';
+UPDATE merch SET category = 'stationery', description = 'Книга из библиотеки Авито' WHERE name = 'book';
+UPDATE merch SET category = 'stationery', description = 'Ручка с логотипом Авито' WHERE name = 'pen';
+UPDATE merch SET category = 'electronics', description = 'Внешний аккумулятор' WHERE name = 'powerbank';
+UPDATE merch SET category = 'clothing', description = 'Худи с логотипом Авито' WHERE name = 'hoody';
+UPDATE merch SET category = 'accessories', description = 'Зонт с логотипом Авито' WHERE name = 'umbrella';
+UPDATE merch SET category = 'clothing', description = 'Носки с логотипом Авито' WHERE name = 'socks';
+UPDATE merch SET category = 'accessories', description = 'Кошелек с логотипом Авито' WHERE name = 'wallet';
+UPDATE merch SET category = 'clothing', description = 'Розовое худи с логотипом Авито' WHERE name = 'pink-hoody';
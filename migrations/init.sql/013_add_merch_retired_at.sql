@@ -0,0 +1,2 @@
+-- Снятые с продажи товары не удаляются, чтобы история покупок и инвентарь ссылались на существующие записи
+ALTER TABLE merch ADD COLUMN retired_at TIMESTAMP;
@@ -0,0 +1,2 @@
+-- Остаток товара на складе; NULL означает, что количество не ограничено
+ALTER TABLE merch ADD COLUMN stock INT CHECK (stock >= 0);
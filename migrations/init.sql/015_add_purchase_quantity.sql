@@ -0,0 +1,14 @@
+-- Количество купленных единиц товара; заполняется только для покупок
+ALTER TABLE transactions ADD COLUMN quantity INT CHECK (quantity > 0);
+UPDATE transactions SET quantity = 1 WHERE transfer_type = 'PURCHASE';
+
+-- Новая колонка добавляется в конец представления, как того требует CREATE OR REPLACE VIEW
+CREATE OR REPLACE VIEW user_ledger AS
+  SELECT id AS transaction_id, sender_name AS username, receiver_name AS counterparty,
+         'DEBIT' AS direction, amount, transfer_type, item_name, timestamp, quantity
+  FROM transactions
+  UNION ALL
+  SELECT id AS transaction_id, receiver_name AS username, sender_name AS counterparty,
+         'CREDIT' AS direction, amount, transfer_type, item_name, timestamp, quantity
+  FROM transactions
+  WHERE receiver_name IS NOT NULL AND transfer_type <> 'PURCHASE';
@@ -0,0 +1,31 @@
+-- Корзина пользователя. Цена фиксируется при добавлении, чтобы при оформлении
+-- заказа пользователь не заплатил больше, чем видел.
+CREATE TABLE cart_items (
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_price INT NOT NULL CHECK (unit_price >= 0),
+  added_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (username, item_name)
+);
+
+-- Заказ, оформленный из корзины, и его позиции
+CREATE TABLE orders (
+  id BIGSERIAL PRIMARY KEY,
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  total BIGINT NOT NULL CHECK (total >= 0),
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_orders_username_created_at ON orders(username, created_at DESC);
+
+CREATE TABLE order_items (
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  item_name VARCHAR(255) NOT NULL,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_price INT NOT NULL CHECK (unit_price >= 0),
+  PRIMARY KEY (order_id, item_name)
+);
+
+-- Каждая позиция заказа записывается отдельной покупкой со ссылкой на заказ
+ALTER TABLE transactions ADD COLUMN order_id BIGINT REFERENCES orders(id);
@@ -0,0 +1,27 @@
+-- Статус выдачи заказа: placed -> ready_for_pickup -> delivered, до выдачи заказ можно отменить.
+-- Покупки, сделанные до появления заказов, заказов не получают.
+ALTER TABLE orders
+  ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'placed'
+  CHECK (status IN ('placed', 'ready_for_pickup', 'delivered', 'cancelled'));
+ALTER TABLE orders ADD COLUMN updated_at TIMESTAMP;
+UPDATE orders SET updated_at = created_at;
+ALTER TABLE orders ALTER COLUMN updated_at SET NOT NULL;
+
+-- Очередь заказов для выдачи
+CREATE INDEX idx_orders_status_created_at ON orders(status, created_at DESC);
+
+-- Журнал изменений статуса. from_status пуст у записи о создании заказа.
+CREATE TABLE order_status_history (
+  id BIGSERIAL PRIMARY KEY,
+  order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  from_status VARCHAR(32),
+  to_status VARCHAR(32) NOT NULL,
+  changed_by VARCHAR(255) NOT NULL,
+  comment TEXT NOT NULL DEFAULT '',
+  changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history(order_id, changed_at);
+
+INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, changed_at)
+SELECT id, NULL, 'placed', username, created_at FROM orders;
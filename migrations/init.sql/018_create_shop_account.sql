@@ -0,0 +1,14 @@
+-- Системная учетная запись магазина: получатель оплаты покупок и отправитель возвратов
+-- по отмененным заказам. Она должна существовать из-за внешнего ключа transactions.sender_name.
+-- Пустой хэш пароля не совпадает ни с одним паролем, а имя shop зарезервировано при регистрации.
+-- До резервирования имени мог зарегистрироваться обычный пользователь SHOP. Миграция в этом случае
+-- останавливается, чтобы не превратить его в учетную запись магазина вместе с его балансом.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users WHERE username = 'SHOP' AND role <> 'service') THEN
+        RAISE EXCEPTION 'пользователь SHOP уже существует: переименуйте его перед созданием учетной записи магазина';
+    END IF;
+END $$;
+
+INSERT INTO users (username, password, coins, role) VALUES ('SHOP', '', 0, 'service')
+ON CONFLICT (username) DO NOTHING;
@@ -0,0 +1,33 @@
+-- Промокоды на скидку при покупке товара. Код действует на любой товар,
+-- если не указаны ни item_name, ни category.
+CREATE TABLE promo_codes (
+  code VARCHAR(32) PRIMARY KEY,
+  discount_type VARCHAR(16) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+  discount_value BIGINT NOT NULL CHECK (discount_value > 0),
+  item_name VARCHAR(255) REFERENCES merch(name),
+  category VARCHAR(64),
+  valid_from TIMESTAMP,
+  valid_until TIMESTAMP,
+  max_redemptions INT CHECK (max_redemptions > 0),
+  max_per_user INT CHECK (max_per_user > 0),
+  redemptions INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL,
+  CHECK (discount_type <> 'percent' OR discount_value <= 100),
+  CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_until > valid_from),
+  CHECK (max_redemptions IS NULL OR redemptions <= max_redemptions)
+);
+
+-- Использования промокодов; по ним считается лимит на пользователя
+CREATE TABLE promo_redemptions (
+  id BIGSERIAL PRIMARY KEY,
+  code VARCHAR(32) NOT NULL REFERENCES promo_codes(code),
+  username VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  order_id BIGINT NOT NULL REFERENCES orders(id),
+  discount BIGINT NOT NULL CHECK (discount >= 0),
+  redeemed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_promo_redemptions_code_username ON promo_redemptions(code, username);
+
+-- Скидка по заказу; total уже хранит сумму со скидкой
+ALTER TABLE orders ADD COLUMN discount BIGINT NOT NULL DEFAULT 0 CHECK (discount >= 0);
@@ -0,0 +1,30 @@
+-- Запланированные цены товаров. Пока расписание действует, оно заменяет merch.price;
+-- при пересечении расписаний применяется расписание с большим приоритетом.
+CREATE TABLE merch_price_schedules (
+  id BIGSERIAL PRIMARY KEY,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  price INT NOT NULL CHECK (price > 0),
+  effective_from TIMESTAMP NOT NULL,
+  effective_to TIMESTAMP,
+  priority INT NOT NULL DEFAULT 0,
+  created_by VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  cancelled_at TIMESTAMP,
+  CHECK (effective_to IS NULL OR effective_to > effective_from)
+);
+
+CREATE INDEX idx_merch_price_schedules_item ON merch_price_schedules(item_name, effective_from);
+
+-- История базовых цен товаров; вместе с расписаниями позволяет восстановить цену на момент покупки
+CREATE TABLE merch_price_history (
+  id BIGSERIAL PRIMARY KEY,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  price INT NOT NULL CHECK (price >= 0),
+  changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_merch_price_history_item ON merch_price_history(item_name, changed_at);
+
+-- Цены до появления истории неизвестны, поэтому история начинается с момента миграции
+INSERT INTO merch_price_history (item_name, price, changed_at)
+SELECT name, price, LOCALTIMESTAMP FROM merch;
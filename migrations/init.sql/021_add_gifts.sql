@@ -0,0 +1,14 @@
+-- Сообщение к подарку; заполняется только для транзакций GIFT
+ALTER TABLE transactions ADD COLUMN message VARCHAR(500);
+
+-- Подарок оплачивает отправитель, а получатель получает товар, а не монеты,
+-- поэтому зачисление по подарку отражается в истории получателя с нулевой суммой.
+CREATE OR REPLACE VIEW user_ledger AS
+  SELECT id AS transaction_id, sender_name AS username, receiver_name AS counterparty,
+         'DEBIT' AS direction, amount, transfer_type, item_name, timestamp, quantity, message
+  FROM transactions
+  UNION ALL
+  SELECT id AS transaction_id, receiver_name AS username, sender_name AS counterparty,
+         'CREDIT' AS direction, CASE WHEN transfer_type = 'GIFT' THEN 0 ELSE amount END, transfer_type, item_name, timestamp, quantity, message
+  FROM transactions
+  WHERE receiver_name IS NOT NULL AND transfer_type <> 'PURCHASE';
@@ -0,0 +1,3 @@
+-- Признак того, что купленный товар можно передать другому пользователю.
+-- Передача записывается в transactions с типом ITEM_TRANSFER и нулевой суммой.
+ALTER TABLE merch ADD COLUMN transferable BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,21 @@
+-- Лоты маркетплейса. Пока лот активен, его товар списан из инвентаря продавца,
+-- поэтому одну и ту же единицу нельзя продать или передать дважды.
+CREATE TABLE market_listings (
+  id BIGSERIAL PRIMARY KEY,
+  seller VARCHAR(255) NOT NULL REFERENCES users(username) ON DELETE CASCADE,
+  item_name VARCHAR(255) NOT NULL REFERENCES merch(name),
+  quantity INT NOT NULL CHECK (quantity > 0),
+  price BIGINT NOT NULL CHECK (price > 0),
+  status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'sold', 'cancelled')),
+  buyer VARCHAR(255) REFERENCES users(username) ON DELETE SET NULL,
+  fee BIGINT NOT NULL DEFAULT 0 CHECK (fee >= 0 AND fee <= price),
+  created_at TIMESTAMP NOT NULL,
+  closed_at TIMESTAMP
+);
+
+-- Просмотр активных лотов по товару и цене, а также лотов продавца
+CREATE INDEX idx_market_listings_active ON market_listings(item_name, price) WHERE status = 'active';
+CREATE INDEX idx_market_listings_seller ON market_listings(seller, created_at DESC);
+
+-- Лот, по которому проведена оплата или удержана комиссия
+ALTER TABLE transactions ADD COLUMN listing_id BIGINT REFERENCES market_listings(id);
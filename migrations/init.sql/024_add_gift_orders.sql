@@ -0,0 +1,3 @@
+-- Подарок оформляется заказом: оплачивает отправитель (username), товар выдается получателю.
+-- У обычных заказов получатель не указан.
+ALTER TABLE orders ADD COLUMN recipient VARCHAR(255) REFERENCES users(username) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+-- Access-токены пользователя, выпущенные раньше этого момента, считаются отозванными.
+-- Заполняется при смене и сбросе пароля; пусто — ограничения нет.
+ALTER TABLE users ADD COLUMN tokens_valid_after TIMESTAMP;
@@ -0,0 +1,4 @@
+-- Начисление не списывает монеты с того, кто его выполнил, поэтому отправителем GRANT
+-- записывается магазин, а фактический автор начисления сохраняется отдельно для аудита.
+ALTER TABLE transactions ADD COLUMN granted_by VARCHAR(255);
+UPDATE transactions SET granted_by = sender_name, sender_name = 'SHOP' WHERE transfer_type = 'GRANT';
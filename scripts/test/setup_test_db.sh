@@ -25,8 +25,9 @@ PGPASSWORD=postgres psql -h localhost -U postgres -c "DROP DATABASE IF EXISTS av
 PGPASSWORD=postgres psql -h localhost -U postgres -c "CREATE DATABASE avito_shop_test;"
 
 # Применение миграций к тестовой базе данных
-PGPASSWORD=postgres psql -h localhost -U postgres -d avito_shop_test -f migrations/init.sql/001_create_tables.sql
-PGPASSWORD=postgres psql -h localhost -U postgres -d avito_shop_test -f migrations/init.sql/002_add_foreign_keys.sql
+for migration in migrations/init.sql/*.sql; do
+    PGPASSWORD=postgres psql -h localhost -U postgres -d avito_shop_test -f "$migration"
+done
 
 # Добавление тестовых данных
 PGPASSWORD=postgres psql -h localhost -U postgres -d avito_shop_test << EOF